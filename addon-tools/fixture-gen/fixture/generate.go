@@ -0,0 +1,289 @@
+package fixture
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/openshift/kube-compare/pkg/compare"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	conformingDir    = "conforming"
+	driftedDir       = "drifted"
+	driftFileName    = "drift.yaml"
+	driftedSuffix    = "-drifted"
+	sampleNameSuffix = "-sample"
+)
+
+// Fields that are never mutated, changing them changes the correlation of the CR instead of creating a diff
+var notMutatedFields = []string{"apiVersion", "kind", "metadata", "status"}
+
+func NewCmd() *cobra.Command {
+	options := Options{}
+	cmd := &cobra.Command{
+		Use:   "fixture-gen -r <REFERENCE_PATH> -o <OUTPUT_DIRECTORY> [-v <VALUES_PATH>] [--seed <SEED>] [--max-drifts <COUNT>]",
+		Short: "Generate conforming and drifted CR sets from a kube-compare reference.",
+		Long: `The 'fixture-gen' command renders the templates of a kube-compare reference into a synthetic set of CRs that
+conforms to the reference, and a second set of the same CRs with a single controlled mutation in each drifted CR.
+The conforming set is written to <OUTPUT_DIRECTORY>/conforming, the drifted set to <OUTPUT_DIRECTORY>/drifted and the
+injected mutations are listed in <OUTPUT_DIRECTORY>/drift.yaml.
+Reference CI can compare both sets with 'kubectl cluster-compare -f' and assert that the conforming set has no diffs and
+that the diffs of the drifted set are exactly the injected ones.
+Templates are rendered with the values given for their path in the values file (-v), or with no values at all. Fields
+compared by inline diff functions are set to the values given for them in the values file.
+Only fields whose rendered value doesn't depend on the mutated CR, and that aren't omitted or compared by an inline
+diff function, are mutated, so every injected mutation is expected to be reported as a diff.`,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.refPath == "" {
+				return fmt.Errorf("path to reference config file is required, pass by -r/--reference")
+			}
+			return generate(&options)
+		},
+	}
+	cmd.Flags().StringVarP(&options.refPath, "reference", "r", "", "Path to reference config file.")
+	cmd.Flags().StringVarP(&options.outputDir, "output-dir", "o", "fixtures", "Path of the directory to save the generated CRs in")
+	cmd.Flags().StringVarP(&options.valuesPath, "values", "v", "", "Path to a yaml file with the values to render each template with, keyed by the template path")
+	cmd.Flags().Int64Var(&options.seed, "seed", 1, "Seed used to select the mutated fields, the same seed generates the same drift")
+	cmd.Flags().IntVar(&options.maxDrifts, "max-drifts", 0, "Maximum number of drifted CRs, 0 drifts every CR that has a field that can be mutated")
+	return cmd
+}
+
+type Options struct {
+	refPath    string
+	outputDir  string
+	valuesPath string
+	seed       int64
+	maxDrifts  int
+}
+
+// Drift is a mutation injected into a generated CR.
+type Drift struct {
+	Template string `json:"template"`
+	CR       string `json:"cr"`
+	File     string `json:"file"`
+	Path     string `json:"path"`
+	Original any    `json:"original"`
+	Drifted  any    `json:"drifted"`
+}
+
+type DriftManifest struct {
+	Drifts []Drift `json:"drifts"`
+}
+
+func generate(o *Options) error {
+	cfs, err := compare.GetRefFS(o.refPath)
+	if err != nil {
+		return fmt.Errorf("failed to get filesystem of cluster-compare reference %w", err)
+	}
+	ref, err := compare.GetReference(cfs, filepath.Base(o.refPath))
+	if err != nil {
+		return fmt.Errorf("failed to get cluster-compare reference %w", err)
+	}
+	templates, err := compare.ParseTemplates(ref, cfs)
+	if err != nil {
+		return fmt.Errorf("failed to parse cluster-compare reference templates %w", err)
+	}
+
+	values := make(map[string]map[string]any)
+	if o.valuesPath != "" {
+		content, err := os.ReadFile(o.valuesPath)
+		if err != nil {
+			return fmt.Errorf("values file passed to command does not exist: %w", err)
+		}
+		err = yaml.Unmarshal(content, &values)
+		if err != nil {
+			return fmt.Errorf("values file passed to command is not valid YAML: %w", err)
+		}
+	}
+
+	for _, dir := range []string{conformingDir, driftedDir} {
+		err = os.MkdirAll(path.Join(o.outputDir, dir), os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(o.seed)) // nolint:gosec
+	manifest := DriftManifest{Drifts: []Drift{}}
+	for _, t := range templates {
+		params := values[t.GetPath()]
+		if params == nil {
+			params = map[string]any{}
+		}
+		cr, err := t.Exec(params)
+		if err != nil {
+			return fmt.Errorf("failed to render template %s: %w", t.GetIdentifier(), err)
+		}
+		setInlineDiffFields(t, cr, params)
+		if cr.GetName() == "" {
+			cr.SetName(strings.ToLower(cr.GetKind()) + sampleNameSuffix)
+		}
+		fileName := fixtureFileName(t.GetIdentifier())
+		err = writeCR(path.Join(o.outputDir, conformingDir, fileName), cr)
+		if err != nil {
+			return err
+		}
+
+		drifted := cr
+		if o.maxDrifts == 0 || len(manifest.Drifts) < o.maxDrifts {
+			var drift *Drift
+			drifted, drift = mutate(t, ref, cr, rng)
+			if drift != nil {
+				drift.File = fileName
+				manifest.Drifts = append(manifest.Drifts, *drift)
+			}
+		}
+		err = writeCR(path.Join(o.outputDir, driftedDir, fileName), drifted)
+		if err != nil {
+			return err
+		}
+	}
+
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the injected drift: %w", err)
+	}
+	err = os.WriteFile(path.Join(o.outputDir, driftFileName), content, 0o644) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to write the injected drift: %w", err)
+	}
+	return nil
+}
+
+// setInlineDiffFields sets the fields compared by inline diff functions to the values given for them in the params, the
+// rendered values of these fields are patterns that the CR is expected to match rather than conforming values.
+func setInlineDiffFields(t compare.ReferenceTemplate, cr *unstructured.Unstructured, params map[string]any) {
+	paths := lo.Keys(t.GetConfig().GetInlineDiffFuncs())
+	sort.Strings(paths)
+	for _, p := range paths {
+		fields := strings.Split(p, ".")
+		value, found, _ := unstructured.NestedFieldCopy(params, fields...)
+		if !found {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: %s of template %s is compared by an inline diff function, set a conforming value for it in the values file\n", p, t.GetIdentifier())
+			continue
+		}
+		_ = unstructured.SetNestedField(cr.Object, value, fields...)
+	}
+}
+
+// mutate returns a copy of the CR with one of its fields changed in a way the template doesn't allow, the field is
+// selected randomly out of the fields that can be mutated. The CR itself is returned if no field can be mutated.
+func mutate(t compare.ReferenceTemplate, ref compare.Reference, cr *unstructured.Unstructured, rng *rand.Rand) (*unstructured.Unstructured, *Drift) {
+	candidates := mutableFields(t, ref, cr.Object, nil)
+	rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	for _, fields := range candidates {
+		original, _, _ := unstructured.NestedFieldNoCopy(cr.Object, fields...)
+		value, ok := mutateValue(original)
+		if !ok {
+			continue
+		}
+		drifted := cr.DeepCopy()
+		if err := unstructured.SetNestedField(drifted.Object, value, fields...); err != nil {
+			continue
+		}
+		// The mutation is only detectable if the template renders the original value for the drifted CR
+		rendered, err := t.Exec(drifted.Object)
+		if err != nil {
+			continue
+		}
+		renderedValue, found, _ := unstructured.NestedFieldNoCopy(rendered.Object, fields...)
+		if !found || !reflect.DeepEqual(renderedValue, original) {
+			continue
+		}
+		return drifted, &Drift{
+			Template: t.GetIdentifier(),
+			CR:       crName(cr),
+			Path:     strings.Join(fields, "."),
+			Original: original,
+			Drifted:  value,
+		}
+	}
+	return cr, nil
+}
+
+// mutableFields returns the paths of the scalar fields of the CR that can be mutated, sorted to make the selection
+// reproducible. Lists are not descended into.
+func mutableFields(t compare.ReferenceTemplate, ref compare.Reference, obj map[string]any, prefix []string) [][]string {
+	var res [][]string
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields := append(append([]string{}, prefix...), k)
+		if len(prefix) == 0 && lo.Contains(notMutatedFields, k) {
+			continue
+		}
+		if isOmitted(t, ref, strings.Join(fields, ".")) {
+			continue
+		}
+		switch v := obj[k].(type) {
+		case map[string]any:
+			res = append(res, mutableFields(t, ref, v, fields)...)
+		case string, bool, int64, float64:
+			res = append(res, fields)
+		}
+	}
+	return res
+}
+
+// isOmitted checks if the field is omitted from the comparison or compared by an inline diff function.
+func isOmitted(t compare.ReferenceTemplate, ref compare.Reference, fieldPath string) bool {
+	for _, p := range t.GetFieldsToOmit(ref.GetFieldsToOmit()) {
+		if fieldPath == p.PathToKey || strings.HasPrefix(fieldPath, p.PathToKey+".") || (p.IsPrefix && strings.HasPrefix(fieldPath, p.PathToKey)) {
+			return true
+		}
+	}
+	_, ok := t.GetConfig().GetInlineDiffFuncs()[fieldPath]
+	return ok
+}
+
+func mutateValue(value any) (any, bool) {
+	switch v := value.(type) {
+	case string:
+		return v + driftedSuffix, true
+	case bool:
+		return !v, true
+	case int64:
+		return v + 1, true
+	case float64:
+		return v + 1, true
+	}
+	return nil, false
+}
+
+func writeCR(filePath string, cr *unstructured.Unstructured) error {
+	content, err := yaml.Marshal(cr.Object)
+	if err != nil {
+		return fmt.Errorf("failed to marshal CR %s: %w", crName(cr), err)
+	}
+	err = os.WriteFile(filePath, content, 0o644) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to write CR %s: %w", crName(cr), err)
+	}
+	return nil
+}
+
+// fixtureFileName returns the file name of the CR generated from a template, the path of the template is flattened.
+func fixtureFileName(templateIdentifier string) string {
+	return strings.NewReplacer(".yaml", "", ".yml", "", "/", "_", "#", "_").Replace(templateIdentifier) + ".yaml"
+}
+
+// crName returns the name of the CR in the format used by the diff output of kube-compare.
+func crName(cr *unstructured.Unstructured) string {
+	return strings.Join(lo.Compact([]string{cr.GetAPIVersion(), cr.GetKind(), cr.GetNamespace(), cr.GetName()}), compare.FieldSeparator)
+}
@@ -0,0 +1,135 @@
+package fixture
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/openshift/kube-compare/pkg/compare"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+var update = flag.Bool("update", false, "update .golden files")
+
+var testDirs = "testdata"
+var refYamlLocation = "reference/metadata.yaml"
+var valuesFile = "values.yaml"
+var resultDirName = "result"
+
+type Test struct {
+	name           string
+	passValuesFile bool
+	maxDrifts      string
+}
+
+func (test *Test) getTestPath() string {
+	return path.Join(testDirs, strings.ReplaceAll(test.name, " ", ""))
+}
+
+func (test *Test) getRefPath() string {
+	return path.Join(test.getTestPath(), refYamlLocation)
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []Test{
+		{
+			name:           "Reference With Values",
+			passValuesFile: true,
+		},
+		{
+			name:           "Reference With Values",
+			passValuesFile: true,
+			maxDrifts:      "1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name+test.maxDrifts, func(t *testing.T) {
+			cmd := NewCmd()
+			outputDir, err := os.MkdirTemp("", strings.ReplaceAll(test.name, " ", ""))
+			require.NoError(t, err)
+			defer os.RemoveAll(outputDir)
+
+			require.NoError(t, cmd.Flags().Set("reference", test.getRefPath()))
+			require.NoError(t, cmd.Flags().Set("output-dir", outputDir))
+			if test.passValuesFile {
+				require.NoError(t, cmd.Flags().Set("values", path.Join(test.getTestPath(), valuesFile)))
+			}
+			if test.maxDrifts != "" {
+				require.NoError(t, cmd.Flags().Set("max-drifts", test.maxDrifts))
+			}
+			require.NoError(t, cmd.RunE(cmd, []string{}))
+
+			resultDir := path.Join(test.getTestPath(), resultDirName+test.maxDrifts)
+			if *update {
+				require.NoError(t, os.RemoveAll(resultDir))
+				require.NoError(t, exec.Command("cp", "-r", outputDir, resultDir).Run())
+			}
+			require.NoError(t, diffDirs(outputDir, resultDir))
+
+			conforming := runCompare(t, test.getRefPath(), path.Join(outputDir, conformingDir))
+			require.Equal(t, 0, conforming.Summary.NumDiffCRs)
+			require.Equal(t, 0, conforming.Summary.NumMissing)
+
+			content, err := os.ReadFile(path.Join(outputDir, driftFileName))
+			require.NoError(t, err)
+			var manifest DriftManifest
+			require.NoError(t, yaml.Unmarshal(content, &manifest))
+			var expected []string
+			for _, drift := range manifest.Drifts {
+				expected = append(expected, drift.CR)
+			}
+			drifted := runCompare(t, test.getRefPath(), path.Join(outputDir, driftedDir))
+			var reported []string
+			for _, diff := range *drifted.Diffs {
+				if diff.DiffOutput != "" {
+					reported = append(reported, diff.CRName)
+				}
+			}
+			require.ElementsMatch(t, expected, reported)
+		})
+	}
+}
+
+// runCompare compares the CRs in the directory to the reference and returns the json output of the comparison.
+func runCompare(t *testing.T, refPath, crsDir string) compare.Output {
+	tf := cmdtesting.NewTestFactory()
+	defer tf.Cleanup()
+	streams, _, out, _ := genericiooptions.NewTestIOStreams()
+	cmd := compare.NewCmd(tf, streams)
+	require.NoError(t, cmd.Flags().Set("reference", refPath))
+	require.NoError(t, cmd.Flags().Set("filename", crsDir))
+	require.NoError(t, cmd.Flags().Set("output", "json"))
+	cmdutil.BehaviorOnFatal(func(str string, code int) {})
+	defer cmdutil.DefaultBehaviorOnFatal()
+	cmd.Run(cmd, []string{})
+
+	var output compare.Output
+	require.NoError(t, json.Unmarshal(out.Bytes(), &output))
+	return output
+}
+
+func diffDirs(dir1, dir2 string) error {
+	cmd := exec.Command("diff", "-r", dir1, dir2)
+	output, err := cmd.CombinedOutput()
+	var exitError *exec.ExitError
+	if err != nil {
+		if errors.As(err, &exitError) {
+			if exitError.ExitCode() == 1 {
+				// Directories are different
+				return fmt.Errorf("directories differ\n%s", output)
+			}
+		}
+		return fmt.Errorf("failed to execute diff command: %w", err)
+	}
+	return nil
+}
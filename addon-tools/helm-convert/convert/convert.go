@@ -44,6 +44,8 @@ The tool helps automate the creation of values.yaml and supports default values
 	cmd.Flags().StringVarP(&options.valuesPath, "values", "v", "", "Path to existing values.yaml file")
 	cmd.Flags().StringVar(&options.chartDescription, "description", "This Helm Chart was generated from a kube-compare reference", "Description for generated Helm Chart")
 	cmd.Flags().StringVar(&options.chartVersion, "helm-version", "1", "Version of generated Helm Chart")
+	cmd.Flags().BoolVar(&options.subCharts, "sub-charts", false,
+		"Generate an umbrella chart with a sub-chart for each part of the reference, that can be enabled or disabled in the values")
 	return cmd
 }
 
@@ -54,11 +56,42 @@ type Options struct {
 	valuesPath       string
 	chartDescription string
 	chartVersion     string
+	subCharts        bool
+}
+
+// chartContent is the content of a chart, the chart of the reference or of one of its parts
+type chartContent struct {
+	templates  map[string]string
+	values     map[string]any
+	components map[string]any
+	converted  []compare.ReferenceTemplate
+}
+
+func newChartContent(helperFuncs string) *chartContent {
+	return &chartContent{
+		templates:  map[string]string{helpersFileName: helperFuncs},
+		values:     make(map[string]any),
+		components: make(map[string]any),
+	}
+}
+
+// mergedValues returns the values of the chart, merged with the values passed by the user
+func (c *chartContent) mergedValues(preValues map[string]any) (map[string]any, error) {
+	values := c.values
+	if len(c.components) != 0 {
+		values[componentsValuesKey] = c.components
+	}
+	if preValues == nil {
+		return values, nil
+	}
+	merged, err := compare.MergeManifests(&unstructured.Unstructured{Object: preValues}, &unstructured.Unstructured{Object: values})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge given values with generated values %w", err)
+	}
+	return merged.Object, nil
 }
 
 func convertToHelm(o *Options) error {
-	helmTemplates := make(map[string]string)
-	helmValues := make(map[string]any)
 	var preValues map[string]any
 	crsWithDefaults := make(map[string]map[string]interface{})
 
@@ -71,7 +104,6 @@ func convertToHelm(o *Options) error {
 	if err != nil {
 		return err
 	}
-	helmTemplates[helpersFileName] = helperFuncs
 
 	if o.defaultPath != "" {
 		crsWithDefaults, err = loadYAMLFiles(o.defaultPath)
@@ -87,18 +119,51 @@ func convertToHelm(o *Options) error {
 		}
 	}
 
+	// With sub-charts the templates are split by part, and the values passed by the user are the values of the
+	// umbrella chart, with the values of each sub-chart under the name of its part
+	charts := make(map[string]*chartContent)
+	var partNames []string
+	if !o.subCharts {
+		charts[""] = newChartContent(helperFuncs)
+	}
+	converted := make(map[string]bool)
 	for _, t := range templates {
+		// Templates rendering multiple yaml documents share the same file, it's converted once
+		if converted[t.GetPath()] {
+			continue
+		}
+		converted[t.GetPath()] = true
+
+		condition, render := componentCondition(t)
+		if !render {
+			continue
+		}
+
+		chartName, chartValues := "", preValues
+		if o.subCharts {
+			part, _ := t.GetPartAndComponent()
+			chartName = getSubChartName(part)
+			chartValues, _ = preValues[chartName].(map[string]any)
+		}
+		content, ok := charts[chartName]
+		if !ok {
+			content = newChartContent(helperFuncs)
+			charts[chartName] = content
+			partNames = append(partNames, chartName)
+		}
+		content.converted = append(content.converted, t)
+		addComponentValues(content.components, t)
 
 		visitor := ExpectedValuesFinder{}
 		Inspect(t.GetTemplateTree().Root, visitor.Visit())
 
-		helmTemplate, err := convertToHelmTemplate(cfs, t, preValues)
+		helmTemplate, err := convertToHelmTemplate(cfs, t, chartValues, condition)
 		if err != nil {
 			return err
 		}
-		helmTemplates[t.GetIdentifier()] = helmTemplate
+		content.templates[t.GetPath()] = helmTemplate
 
-		val, err := getValuesFromJson(crsWithDefaults[path.Base(t.GetIdentifier())], visitor.expected)
+		val, err := getValuesFromJson(crsWithDefaults[path.Base(t.GetPath())], visitor.expected)
 		if err != nil {
 			return err
 		}
@@ -109,21 +174,80 @@ func convertToHelm(o *Options) error {
 		if err != nil {
 			return err
 		}
+		addTemplateDefaults(tempValues, t.GetConfig().GetDefaults())
 
 		if len(tempValues) != 0 {
-			helmValues[getCompName(t.GetIdentifier())] = append(compValues, tempValues)
+			content.values[getCompName(t.GetPath())] = append(compValues, tempValues)
 		}
 	}
 
-	if preValues != nil {
-		merged, err := compare.MergeManifests(&unstructured.Unstructured{Object: preValues}, &unstructured.Unstructured{Object: helmValues})
+	if !o.subCharts {
+		content := charts[""]
+		helmValues, err := content.mergedValues(preValues)
 		if err != nil {
-			return fmt.Errorf("failed to merge given values with generated values %w", err)
+			return err
 		}
-		helmValues = merged.Object
+		ch, err := newChart(path.Base(o.outputDir), content.templates, helmValues, perFieldComments(content.converted), o.chartDescription, o.chartVersion)
+		if err != nil {
+			return err
+		}
+		return saveChart(ch, path.Dir(o.outputDir))
 	}
+	return createUmbrellaChart(o, charts, partNames, preValues)
+}
 
-	return createChart(helmTemplates, helmValues, o.outputDir, o.chartDescription, o.chartVersion)
+// getSubChartName returns the name of the sub-chart of a part, it's also the key of its values in the umbrella chart
+func getSubChartName(part string) string {
+	return invalidValueKeyChars.ReplaceAllString(part, "_")
+}
+
+// createUmbrellaChart creates a chart with a sub-chart for each part of the reference, each sub-chart is rendered when
+// its part is enabled in the values of the umbrella chart.
+func createUmbrellaChart(o *Options, charts map[string]*chartContent, partNames []string, preValues map[string]any) error {
+	umbrellaValues := make(map[string]any)
+	var dependencies []*chart.Dependency
+	var subCharts []*chart.Chart
+	for _, name := range partNames {
+		content := charts[name]
+		partValues, _ := preValues[name].(map[string]any)
+		values, err := content.mergedValues(partValues)
+		if err != nil {
+			return err
+		}
+		sub, err := newChart(name, content.templates, values, perFieldComments(content.converted),
+			fmt.Sprintf("Part %s of the reference", name), o.chartVersion)
+		if err != nil {
+			return err
+		}
+		sub.Metadata.APIVersion = chart.APIVersionV2
+		subCharts = append(subCharts, sub)
+
+		dependencies = append(dependencies, &chart.Dependency{
+			Name:       name,
+			Version:    o.chartVersion,
+			Repository: "file://./" + path.Join(chartutil.ChartsDir, name),
+			Condition:  name + ".enabled",
+		})
+		umbrellaValues[name] = map[string]any{"enabled": true}
+	}
+
+	umbrella, err := newChart(path.Base(o.outputDir), nil, umbrellaValues, "", o.chartDescription, o.chartVersion)
+	if err != nil {
+		return err
+	}
+	umbrella.Metadata.APIVersion = chart.APIVersionV2
+	umbrella.Metadata.Dependencies = dependencies
+	if err := saveChart(umbrella, path.Dir(o.outputDir)); err != nil {
+		return err
+	}
+	// The sub-charts are saved as directories in the charts directory of the umbrella chart, like the dependencies
+	// vendored by helm, without being packaged
+	for _, sub := range subCharts {
+		if err := saveChart(sub, path.Join(o.outputDir, chartutil.ChartsDir)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func getTemplates(cfs fs.FS, referenceFileName string) ([]compare.ReferenceTemplate, string, error) {
@@ -200,7 +324,9 @@ func removeCgDefaults(compName string, helmValues map[string]any) {
 	}
 }
 
-func convertToHelmTemplate(cfs fs.FS, t compare.ReferenceTemplate, helmValues map[string]any) (string, error) {
+// convertToHelmTemplate converts the reference template to a chart template rendering a CR for each of its values, the
+// chart template is only rendered when the condition (if any) is met.
+func convertToHelmTemplate(cfs fs.FS, t compare.ReferenceTemplate, helmValues map[string]any, condition string) (string, error) {
 	var templateStructure = `{{- $values := list (dict)}}
 {{- if .Values.%v}}
 {{- $values = .Values.%v }}
@@ -210,12 +336,12 @@ func convertToHelmTemplate(cfs fs.FS, t compare.ReferenceTemplate, helmValues ma
 %v 
 {{ end -}}
 `
-	data, err := fs.ReadFile(cfs, t.GetIdentifier())
+	data, err := fs.ReadFile(cfs, t.GetPath())
 	if err != nil {
-		return "", fmt.Errorf("failed to read template named: %s %w", t.GetIdentifier(), err)
+		return "", fmt.Errorf("failed to read template named: %s %w", t.GetPath(), err)
 	}
 
-	compName := getCompName(t.GetIdentifier())
+	compName := getCompName(t.GetPath())
 
 	content := string(data)
 
@@ -246,10 +372,29 @@ func convertToHelmTemplate(cfs fs.FS, t compare.ReferenceTemplate, helmValues ma
 	}
 
 	helmTemplate := fmt.Sprintf(templateStructure, compName, compName, content)
+	if condition != "" {
+		helmTemplate = fmt.Sprintf("{{- if %s }}\n%s{{- end }}\n", condition, helmTemplate)
+	}
 
 	return helmTemplate, nil
 }
 
+// addTemplateDefaults sets the values of the defaults of the template (config.defaults in the reference), that are used
+// by the template as .Defaults, so the chart renders the same CRs as the reference unless they are overridden.
+func addTemplateDefaults(values, defaults map[string]any) {
+	if len(defaults) == 0 {
+		return
+	}
+	templateDefaults, ok := values[compare.DefaultsKey].(map[string]any)
+	if !ok {
+		templateDefaults = make(map[string]any)
+		values[compare.DefaultsKey] = templateDefaults
+	}
+	for k, v := range defaults {
+		templateDefaults[k] = v
+	}
+}
+
 func getCompName(templateName string) string {
 	compName := strings.TrimSuffix(templateName, ".yaml")
 	compName = strings.TrimSuffix(compName, ".yml")
@@ -272,28 +417,31 @@ func loadValues(path string) (map[string]interface{}, error) {
 	return values, nil
 }
 
-func createChart(temps map[string]string, values map[string]any, dir, description, version string) error {
+func newChart(name string, temps map[string]string, values map[string]any, valuesComments, description, version string) (*chart.Chart, error) {
 	var files []*chart.File
 	var valuesF []*chart.File
 	y, err := chartutil.Values(values).YAML()
 	if err != nil {
-		return fmt.Errorf("failed to convert chart values to YAML: %w", err)
+		return nil, fmt.Errorf("failed to convert chart values to YAML: %w", err)
 	}
-	valuesF = append(valuesF, &chart.File{Name: valuesFileName, Data: []byte(y)})
+	valuesF = append(valuesF, &chart.File{Name: valuesFileName, Data: []byte(valuesComments + y)})
 	for name, content := range temps {
 		files = append(files, &chart.File{Name: path.Join(helmTemplatesDir, name), Data: []byte(content)})
 	}
-	ch := &chart.Chart{
+	return &chart.Chart{
 		Metadata: &chart.Metadata{
-			Name:        path.Base(dir),
+			Name:        name,
 			Description: description,
 			Version:     version,
 		},
 		Templates: files,
 		Values:    values,
 		Raw:       valuesF,
-	}
-	err = chartutil.SaveDir(ch, path.Dir(dir))
+	}, nil
+}
+
+func saveChart(ch *chart.Chart, dir string) error {
+	err := chartutil.SaveDir(ch, dir)
 	if err != nil {
 		return fmt.Errorf("failed to save helm chart in dir: %w", err)
 	}
@@ -30,6 +30,7 @@ type Test struct {
 	passValuesFile bool
 	helmVersion    string
 	description    string
+	subCharts      bool
 }
 
 func (test *Test) getRefPath() string {
@@ -85,6 +86,17 @@ func TestConvert(t *testing.T) {
 			name:           "Capturegroup Defaults",
 			passValuesFile: true,
 		},
+		{
+			name: "Reference V2 Groups",
+		},
+		{
+			name: "Template Defaults",
+		},
+		{
+			name:           "Sub Charts",
+			subCharts:      true,
+			passValuesFile: true,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -105,6 +117,9 @@ func TestConvert(t *testing.T) {
 			if test.helmVersion != "" {
 				require.NoError(t, cmd.Flags().Set("helm-version", test.helmVersion))
 			}
+			if test.subCharts {
+				require.NoError(t, cmd.Flags().Set("sub-charts", "true"))
+			}
 			if test.description != "" {
 				require.NoError(t, cmd.Flags().Set("description", test.description))
 			}
@@ -0,0 +1,126 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openshift/kube-compare/pkg/compare"
+)
+
+// componentsValuesKey is the key of the values that select the templates rendered for each component of a V2 reference
+const componentsValuesKey = "referenceComponents"
+
+const (
+	allOfGroup       = "allOf"
+	allOrNoneOfGroup = "allOrNoneOf"
+	anyOfGroup       = "anyOf"
+	oneOfGroup       = "oneOf"
+	anyOneOfGroup    = "anyOneOf"
+	noneOfGroup      = "noneOf"
+)
+
+// groupedTemplate is implemented by the templates of V2 references, that belong to a group of templates of a component
+type groupedTemplate interface {
+	GetComponentGroup() string
+}
+
+var invalidValueKeyChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func getComponentValuesName(part, component string) string {
+	return invalidValueKeyChars.ReplaceAllString(part+"_"+component, "_")
+}
+
+// componentCondition returns the condition of the chart template rendering the reference template, based on the group
+// of templates of its component:
+//   - allOf and allOrNoneOf templates are rendered when their component is enabled
+//   - oneOf and anyOneOf templates are rendered when they are the selected template of their component
+//   - anyOf templates are rendered when they are in the list of selected templates of their component
+//
+// It returns false for noneOf templates, as the CRs of the group aren't expected in the cluster, and an empty
+// condition for the templates of V1 references that are always rendered.
+func componentCondition(t compare.ReferenceTemplate) (condition string, render bool) {
+	grouped, ok := t.(groupedTemplate)
+	if !ok {
+		return "", true
+	}
+	part, component := t.GetPartAndComponent()
+	values := fmt.Sprintf(".Values.%s.%s", componentsValuesKey, getComponentValuesName(part, component))
+	name := getCompName(t.GetPath())
+	switch grouped.GetComponentGroup() {
+	case allOfGroup, allOrNoneOfGroup:
+		return values + ".enabled", true
+	case oneOfGroup, anyOneOfGroup:
+		return fmt.Sprintf("eq %s.selected %q", values, name), true
+	case anyOfGroup:
+		return fmt.Sprintf("has %q %s.selected", name, values), true
+	case noneOfGroup:
+		return "", false
+	}
+	return "", true
+}
+
+// addComponentValues adds the default values of the conditions of the template to the values of the components: the
+// components are enabled, the first template of oneOf groups and all the templates of anyOf groups are selected.
+func addComponentValues(components map[string]any, t compare.ReferenceTemplate) {
+	grouped, ok := t.(groupedTemplate)
+	if !ok {
+		return
+	}
+	key := getComponentValuesName(t.GetPartAndComponent())
+	name := getCompName(t.GetPath())
+	switch grouped.GetComponentGroup() {
+	case allOfGroup, allOrNoneOfGroup:
+		components[key] = map[string]any{"enabled": true}
+	case oneOfGroup, anyOneOfGroup:
+		if _, ok := components[key]; !ok {
+			components[key] = map[string]any{"selected": name}
+		}
+	case anyOfGroup:
+		values, ok := components[key].(map[string]any)
+		if !ok {
+			values = map[string]any{"selected": []any{}}
+			components[key] = values
+		}
+		values["selected"] = append(values["selected"].([]any), name)
+	}
+}
+
+// perFieldComments documents the fields of the templates compared with inline diff functions, the values of these
+// fields in the chart are matched against the patterns of the functions by cluster-compare.
+func perFieldComments(templates []compare.ReferenceTemplate) string {
+	fields := make(map[string]map[string]string)
+	for _, t := range templates {
+		for path, inlineDiffFunc := range t.GetConfig().GetInlineDiffFuncs() {
+			name := getCompName(t.GetPath())
+			if fields[name] == nil {
+				fields[name] = make(map[string]string)
+			}
+			fields[name][path] = fmt.Sprintf("%s", inlineDiffFunc)
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("# Fields compared by cluster-compare with inline diff functions:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "# %s:\n", name)
+		paths := make([]string, 0, len(fields[name]))
+		for path := range fields[name] {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			fmt.Fprintf(&b, "#   %s: %s\n", path, fields[name][path])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,3 @@
+{{- define "ns" -}}
+openshift-{{ . }}
+{{- end -}}
@@ -3,26 +3,29 @@ package report
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/openshift/kube-compare/addon-tools/report-creator/junit"
 	"github.com/openshift/kube-compare/pkg/compare"
+	"github.com/openshift/kube-compare/pkg/junit"
 	"github.com/spf13/cobra"
 	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
 )
 
 var (
 	longDesc = templates.LongDesc(`
 report-creator is a CLI tool that allows creating a JUnit test report from the output of the 'kubectl
-cluster-compare' plugin. The command uses the JSON format output of the 'kubectl cluster-compare'
-plugin. This tol can be handy in automatic test environments.
+cluster-compare' plugin. The command uses the JSON or the YAML format output of the 'kubectl cluster-compare'
+plugin, read from a file or from stdin (-j -). This tol can be handy in automatic test environments.
 
-The tool divides the result of the cluster compare into 3 test suites:
+The tool divides the result of the cluster compare into 3 kinds of test suites:
 
-1. Diff test suite - Each test in the suite represents a CR that is matched and diffed to a reference CR. The test will
+1. Diff test suites - A suite per part of the reference, each test in the suite represents a CR that is matched and
+diffed to a reference CR of the part, the component of the reference CR is the class name of the test. The test will
 be reported as failed if there are differences between the cluster cr and the expected CR.
 The full diff will be included in the test case failure message. In case there are no differences
 for the CR, the test will be marked as successful.
@@ -39,47 +42,84 @@ this suite will include one successful test case representing that there are no
 `)
 )
 
-// createDiffsSuite generates a JUnit test suite representing all differences found between cluster resources
-// and expected reference CRs.
-// The suite includes individual test cases for each cluster resource (CR) that exhibits differences.
+// createDiffsSuites generates a JUnit test suite per part of the reference, representing the differences found
+// between the cluster resources and the expected reference CRs of the part. The components of the part are the class
+// names of the test cases, so CI dashboards show which functional areas of the reference are drifting.
+// The suites include individual test cases for each cluster resource (CR) that was compared to the part.
 // If differences are detected in a CR, a failure message is included in the test case including the full diff output.
-func createDiffsSuite(output compare.Output) junit.TestSuite {
-	diffSuite := junit.TestSuite{
-		Name:      "Detected Differences Between Cluster CRs and Expected CRs",
-		Timestamp: time.Now().Format(time.RFC3339),
-		Time:      time.Now().Format(time.RFC3339),
-		Tests:     len(*output.Diffs),
-		Failures:  output.Summary.NumDiffCRs,
-	}
-
+// The CRs compared to templates that don't belong to a part are in a suite of their own, with the template as their
+// class name.
+func createDiffsSuites(output compare.Output) []*junit.TestSuite {
+	timestamp := time.Now().Format(time.RFC3339)
+	suites := make(map[string]*junit.TestSuite)
+	var partNames []string
 	for _, diff := range *output.Diffs {
+		suite, ok := suites[diff.Part]
+		if !ok {
+			suite = &junit.TestSuite{Name: diffsSuiteName(diff.Part), Timestamp: timestamp}
+			suites[diff.Part] = suite
+			partNames = append(partNames, diff.Part)
+		}
 		testCase := junit.TestCase{
 			Name:      fmt.Sprintf("CR: %s", diff.CRName),
-			Classname: fmt.Sprintf("Matching Reference CR: %s", diff.CorrelatedTemplate),
+			Classname: diff.Component,
+		}
+		if diff.Component == "" {
+			testCase.Classname = fmt.Sprintf("Matching Reference CR: %s", diff.CorrelatedTemplate)
 		}
 
 		if diff.DiffOutput != "" {
 			testCase.Failure = &junit.Failure{
 				Type:     "Difference",
-				Message:  fmt.Sprintf("Differences found in CR: %s, Compared To Reference CR: %s", diff.CRName, diff.CorrelatedTemplate),
+				Message:  fmt.Sprintf("Differences found in CR: %s, Compared To Reference CR: %s%s", diff.CRName, diff.CorrelatedTemplate, links(diff)),
 				Contents: diff.DiffOutput,
 			}
 		}
 
-		diffSuite.TestCases = append(diffSuite.TestCases, testCase)
+		suite.AddTestCase(testCase)
 	}
 
-	return diffSuite
+	// Without diffs, the report has an empty suite of differences
+	if len(partNames) == 0 {
+		return []*junit.TestSuite{{Name: diffsSuiteName(""), Timestamp: timestamp}}
+	}
+	sort.Strings(partNames)
+	res := make([]*junit.TestSuite, 0, len(partNames))
+	for _, partName := range partNames {
+		res = append(res, suites[partName])
+	}
+	return res
+}
+
+// diffsSuiteName returns the name of the suite of the differences of the part.
+func diffsSuiteName(partName string) string {
+	name := "Detected Differences Between Cluster CRs and Expected CRs"
+	if partName == "" {
+		return name
+	}
+	return fmt.Sprintf("%s, Part: %s", name, partName)
+}
+
+// links returns the documentation and remediation links of the template the CR was compared to, to append to the
+// failure message of its test case.
+func links(diff compare.DiffSum) string {
+	var res string
+	if diff.DocsURL != "" {
+		res += fmt.Sprintf(", Docs: %s", diff.DocsURL)
+	}
+	if diff.RemediationURL != "" {
+		res += fmt.Sprintf(", Remediation: %s", diff.RemediationURL)
+	}
+	return res
 }
 
 // createMissingCRsSuite generates a JUnit test suite that ensures that all the expected CRs appear in the cluster.
 // The suite includes test cases for each missing CR, categorized by their respective components and namespaces.
 // If no CRs are missing, a single test case indicating that all expected CRs exist in the cluster is included.
-func createMissingCRsSuite(summary compare.Summary) junit.TestSuite {
-	suite := junit.TestSuite{
+func createMissingCRsSuite(summary compare.Summary) *junit.TestSuite {
+	suite := &junit.TestSuite{
 		Name:      "Missing Cluster Resources",
 		Timestamp: time.Now().Format(time.RFC3339),
-		Time:      time.Now().Format(time.RFC3339),
 	}
 
 	// Iterate over parts and components to add missing CRs as test cases
@@ -116,11 +156,10 @@ func createMissingCRsSuite(summary compare.Summary) junit.TestSuite {
 // createUnmatchedSuite generates a JUnit test suite for representing unmatched cluster resources.
 // The suite includes individual test cases for each unmatched CR.
 // If no CRs are unmatched, a single test case indicating that all CRs are matched is included.
-func createUnmatchedSuite(summary compare.Summary) junit.TestSuite {
-	unmatchedSuite := junit.TestSuite{
+func createUnmatchedSuite(summary compare.Summary) *junit.TestSuite {
+	unmatchedSuite := &junit.TestSuite{
 		Name:      "Unmatched Cluster Resources",
 		Timestamp: time.Now().Format(time.RFC3339),
-		Time:      time.Now().Format(time.RFC3339),
 	}
 
 	// Iterate over unmatched CRs to add them as test cases
@@ -148,18 +187,22 @@ func createUnmatchedSuite(summary compare.Summary) junit.TestSuite {
 	return unmatchedSuite
 }
 
-func createReport(output compare.Output) *junit.TestSuites {
-	suites := junit.TestSuites{Name: "Comparison results of known valid reference configuration and a set of specific cluster CRs", Time: time.Now().Format(time.RFC3339), Suites: []junit.TestSuite{
-		createDiffsSuite(output), createMissingCRsSuite(*output.Summary), createUnmatchedSuite(*output.Summary)}}
-	for _, suite := range suites.Suites {
-		suites.Tests += suite.Tests
-		suites.Failures += suite.Failures
-	}
-	return &suites
+func createReport(output compare.Output) junit.TestSuites {
+	suites := createDiffsSuites(output)
+	suites = append(suites, createMissingCRsSuite(*output.Summary), createUnmatchedSuite(*output.Summary))
+	return junit.TestSuites{Name: "Comparison results of known valid reference configuration and a set of specific cluster CRs", Suites: suites}
 }
 
+// getParsed parses the output of the compare command, in the JSON (-o json) or in the YAML (-o yaml) format.
 func getParsed(raw string) (compare.Output, error) {
 	output := compare.Output{}
+	if !strings.HasPrefix(strings.TrimSpace(raw), "{") {
+		err := yaml.Unmarshal([]byte(raw), &output)
+		if err != nil {
+			return output, fmt.Errorf("failed to unmarshal yaml: %w", err)
+		}
+		return output, nil
+	}
 	err := json.Unmarshal([]byte(raw), &output)
 	if err != nil {
 		return output, fmt.Errorf("failed to unmarshal json: %w", err)
@@ -167,9 +210,59 @@ func getParsed(raw string) (compare.Output, error) {
 	return output, nil
 }
 
+// readInput reads the output of the compare command from the file at path, or from stdin when the path is -.
+func readInput(cmd *cobra.Command, path string) ([]byte, error) {
+	if path == stdinPath {
+		input, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read comparison from stdin: %w", err)
+		}
+		return input, nil
+	}
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comparison file: %w", err)
+	}
+	return input, nil
+}
+
+const (
+	stdinPath = "-"
+
+	JUnit    = "junit"
+	HTML     = "html"
+	Markdown = "markdown"
+)
+
+// Formats are the formats of the reports, with the default path of the report of each format
+var Formats = map[string]string{
+	JUnit:    "report.xml",
+	HTML:     "report.html",
+	Markdown: "report.md",
+}
+
 type Options struct {
 	compareOutputPath string
 	outputFile        string
+	format            string
+	trendDir          string
+}
+
+// writeReport writes the report of the compare output in the requested format.
+func writeReport(w io.Writer, output compare.Output, format string) error {
+	var err error
+	switch format {
+	case HTML:
+		err = writeHTML(w, output)
+	case Markdown:
+		err = writeMarkdown(w, output)
+	default:
+		err = junit.Write(w, createReport(output))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s report: %w", format, err)
+	}
+	return nil
 }
 
 func NewCmd() *cobra.Command {
@@ -180,11 +273,31 @@ func NewCmd() *cobra.Command {
 		Long:  longDesc,
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			jsonInput, err := os.ReadFile(options.compareOutputPath)
+			if options.trendDir != "" && !cmd.Flags().Changed("format") {
+				options.format = Markdown
+			}
+			if _, ok := Formats[options.format]; !ok {
+				return fmt.Errorf("unknown --format value %q, supported values: %s, %s, %s", options.format, JUnit, HTML, Markdown)
+			}
+			if options.trendDir != "" && options.format == JUnit {
+				return fmt.Errorf("trend reports support the %s and %s formats", HTML, Markdown)
+			}
+			if !cmd.Flags().Changed("output") {
+				options.outputFile = Formats[options.format]
+			}
+			if options.trendDir != "" {
+				f, err := os.Create(options.outputFile)
+				if err != nil {
+					return fmt.Errorf("failed to create output file: %w", err)
+				}
+				defer f.Close()
+				return writeTrend(f, options.trendDir, options.format)
+			}
+			input, err := readInput(cmd, options.compareOutputPath)
 			if err != nil {
-				return fmt.Errorf("failed to read comparison file: %w", err)
+				return err
 			}
-			compareOutput, err := getParsed(string(jsonInput))
+			compareOutput, err := getParsed(string(input))
 			if err != nil {
 				return err
 			}
@@ -194,14 +307,15 @@ func NewCmd() *cobra.Command {
 
 			}
 			defer f.Close()
-			err = junit.Write(f, *createReport(compareOutput))
-			if err != nil {
-				return fmt.Errorf("failed to write junit report: %w", err)
-			}
-			return nil
+			return writeReport(f, compareOutput, options.format)
 		},
 	}
-	cmd.Flags().StringVarP(&options.compareOutputPath, "json", "j", "", "Path to the file including the json output of the cluster-compare command")
-	cmd.Flags().StringVarP(&options.outputFile, "output", "o", "report.xml", "Path to save the report")
+	cmd.Flags().StringVarP(&options.compareOutputPath, "json", "j", "", "Path to the file including the json or yaml output of the cluster-compare command, - to read it from stdin")
+	cmd.Flags().StringVarP(&options.outputFile, "output", "o", Formats[JUnit],
+		"Path to save the report, defaults to report.html and report.md for the html and markdown formats")
+	cmd.Flags().StringVar(&options.format, "format", JUnit, fmt.Sprintf("Format of the report. One of: (%s, %s, %s)", JUnit, HTML, Markdown))
+	cmd.Flags().StringVar(&options.trendDir, "trend", "",
+		"Path to a directory of timestamped outputs of the cluster-compare command. Creates a trend report of the diffs "+
+			"that appeared, were resolved or persisted across the runs (ordered by file name), in markdown by default")
 	return cmd
 }
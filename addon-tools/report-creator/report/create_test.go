@@ -25,6 +25,9 @@ var compareTestRefsDir = "../../../pkg/compare/testdata"
 type Test struct {
 	name         string
 	referenceDir string
+	outputFormat string
+	stdin        bool
+	format       string
 }
 
 func (test *Test) getJSONPath() string {
@@ -49,6 +52,45 @@ func TestCompareRun(t *testing.T) {
 			name:         "Missing CRs test suite creation when CRS are Missing",
 			referenceDir: "OnlyRequiredResourcesOfRequiredComponentAreReportedMissing(OptionalResourcesNotReported)",
 		},
+		{
+			name:         "Diff Test Suite Creation From YAML Output",
+			referenceDir: "RefWithTemplateFunctionsRendersAsExpected",
+			outputFormat: compare.Yaml,
+		},
+		{
+			name:         "Diff Test Suite Creation From Stdin",
+			referenceDir: "RefWithTemplateFunctionsRendersAsExpected",
+			outputFormat: compare.Yaml,
+			stdin:        true,
+		},
+		{
+			name:         "Markdown Report With Diffs By Component",
+			referenceDir: "MultiDocumentTemplates",
+			format:       Markdown,
+		},
+		{
+			name:         "HTML Report With Diffs By Component",
+			referenceDir: "MultiDocumentTemplates",
+			format:       HTML,
+		},
+		{
+			name:         "Diff Test Suite Creation With Template Links",
+			referenceDir: "TemplateLinks",
+		},
+		{
+			name:         "Diff Test Suites By Part",
+			referenceDir: "GroupBy",
+		},
+		{
+			name:         "Markdown Report With Template Links",
+			referenceDir: "TemplateLinks",
+			format:       Markdown,
+		},
+		{
+			name:         "HTML Report With Template Links",
+			referenceDir: "TemplateLinks",
+			format:       HTML,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -59,8 +101,19 @@ func TestCompareRun(t *testing.T) {
 			require.NoError(t, err)
 			outputPath := path.Join(dirName, test.name)
 			require.NoError(t, cmd.Flags().Set("output", outputPath))
+			if test.format != "" {
+				require.NoError(t, cmd.Flags().Set("format", test.format))
+			}
 
-			require.NoError(t, cmd.Flags().Set("json", test.getJSONPath()))
+			if test.stdin {
+				input, err := os.Open(test.getJSONPath())
+				require.NoError(t, err)
+				defer input.Close()
+				cmd.SetIn(input)
+				require.NoError(t, cmd.Flags().Set("json", "-"))
+			} else {
+				require.NoError(t, cmd.Flags().Set("json", test.getJSONPath()))
+			}
 
 			err = cmd.RunE(cmd, []string{})
 			if err != nil {
@@ -90,7 +143,11 @@ func checkCompatibilityWithCompareOutput(t *testing.T, test Test, update bool) {
 	require.NoError(t, cmpCmd.Flags().Set("reference", path.Join(compareTestRefsDir, test.referenceDir, "reference/metadata.yaml")))
 	require.NoError(t, cmpCmd.Flags().Set("filename", path.Join(compareTestRefsDir, test.referenceDir, "resources")))
 	require.NoError(t, cmpCmd.Flags().Set("recursive", "true"))
-	require.NoError(t, cmpCmd.Flags().Set("output", compare.Json))
+	outputFormat := compare.Json
+	if test.outputFormat != "" {
+		outputFormat = test.outputFormat
+	}
+	require.NoError(t, cmpCmd.Flags().Set("output", outputFormat))
 	cmpCmd.Run(cmpCmd, []string{})
 	result := testutils.GetFile(t, test.getJSONPath(), testutils.RemoveInconsistentInfo(t, out.String(), testutils.FixupOptions{}), update)
 	require.Equal(t, result, testutils.RemoveInconsistentInfo(t, out.String(), testutils.FixupOptions{}))
@@ -100,3 +157,26 @@ func removeInconsistentInfoFromReport(text []byte) string {
 	re := regexp.MustCompile("(?:time|timestamp)=\"(\\S*)\"")
 	return string(re.ReplaceAll(text, []byte("TIME")))
 }
+
+func TestTrendReport(t *testing.T) {
+	for _, format := range []string{Markdown, HTML} {
+		t.Run(format, func(t *testing.T) {
+			cmd := NewCmd()
+			outputPath := path.Join(t.TempDir(), "trend")
+			require.NoError(t, cmd.Flags().Set("output", outputPath))
+			require.NoError(t, cmd.Flags().Set("trend", path.Join(TestDirs, "Trend")))
+			require.NoError(t, cmd.Flags().Set("format", format))
+			require.NoError(t, cmd.RunE(cmd, []string{}))
+
+			actualOutput, err := os.ReadFile(outputPath)
+			require.NoError(t, err)
+			value := testutils.GetFile(t, path.Join(TestDirs, fmt.Sprintf("Trend%s.golden", format)), string(actualOutput), *update)
+			require.Equal(t, string(actualOutput), value)
+		})
+	}
+
+	cmd := NewCmd()
+	require.NoError(t, cmd.Flags().Set("trend", path.Join(TestDirs, "Trend")))
+	require.NoError(t, cmd.Flags().Set("format", JUnit))
+	require.Error(t, cmd.RunE(cmd, []string{}))
+}
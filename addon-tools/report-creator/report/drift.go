@@ -0,0 +1,221 @@
+package report
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/openshift/kube-compare/pkg/compare"
+)
+
+const noComponent = "Other"
+
+// componentDrift is the drift of the cluster CRs matched to the templates of a component of the reference.
+type componentDrift struct {
+	Part      string
+	Component string
+	Diffs     []compare.DiffSum
+	NumDiffs  int
+}
+
+// driftReport is the data of the human-readable (markdown and HTML) drift reports.
+type driftReport struct {
+	Summary    compare.Summary
+	Components []componentDrift
+}
+
+// newDriftReport groups the diffs of the compare output by the part and component of their templates. The diffs of
+// templates that don't belong to a component (or of outputs of older versions of the compare command) are grouped
+// under the Other component.
+func newDriftReport(output compare.Output) driftReport {
+	components := make(map[string]*componentDrift)
+	var diffs []compare.DiffSum
+	if output.Diffs != nil {
+		diffs = *output.Diffs
+	}
+	for _, diff := range diffs {
+		part, component := diff.Part, diff.Component
+		if component == "" {
+			component = noComponent
+		}
+		key := part + "/" + component
+		c, ok := components[key]
+		if !ok {
+			c = &componentDrift{Part: part, Component: component}
+			components[key] = c
+		}
+		c.Diffs = append(c.Diffs, diff)
+		if diff.HasDiff() {
+			c.NumDiffs++
+		}
+	}
+
+	report := driftReport{}
+	if output.Summary != nil {
+		report.Summary = *output.Summary
+	}
+	for _, c := range components {
+		sort.Slice(c.Diffs, func(i, j int) bool {
+			return c.Diffs[i].CRName < c.Diffs[j].CRName
+		})
+		report.Components = append(report.Components, *c)
+	}
+	sort.Slice(report.Components, func(i, j int) bool {
+		a, b := report.Components[i], report.Components[j]
+		if a.Part != b.Part {
+			return a.Part < b.Part
+		}
+		return a.Component < b.Component
+	})
+	return report
+}
+
+var markdownReportTemplate = template.Must(template.New("markdown").Funcs(template.FuncMap{
+	"trimSuffix": strings.TrimSuffix,
+	// cell escapes the pipes that would end a cell of a markdown table
+	"cell": func(s string) string { return strings.ReplaceAll(s, "|", "\\|") },
+}).Parse(`# Cluster Compare Drift Report
+
+| | |
+| --- | --- |
+{{- with .Summary }}
+| CRs with diffs | {{ .NumDiffCRs }}/{{ .TotalCRs }} |
+| CRs in reference missing from the cluster | {{ .NumMissing }} |
+| Cluster CRs unmatched to reference CRs | {{ len .UnmatchedCRS }} |
+| Cluster CRs with patches applied | {{ .PatchedCRs }} |
+| Metadata Hash | ` + "`{{ .MetadataHash }}`" + ` |
+{{- end }}
+{{- range .Components }}
+
+## {{ with .Part }}{{ . }} / {{ end }}{{ .Component }}
+
+{{ .NumDiffs }}/{{ len .Diffs }} CRs with diffs
+
+| Cluster CR | Reference Template | Status |
+| --- | --- | --- |
+{{- range .Diffs }}
+| ` + "`{{ .CRName }}`" + ` | ` + "`{{ .CorrelatedTemplate }}`" + ` | {{ if .HasDiff }}Diff{{ with .Severity }} ({{ . }}){{ end }}{{ else }}Matches{{ end }} |
+{{- end }}
+{{- range .Diffs }}
+{{- if .HasDiff }}
+
+<details>
+<summary><code>{{ .CRName }}</code> compared to <code>{{ .CorrelatedTemplate }}</code></summary>
+{{- if or .DocsURL .RemediationURL }}
+{{ with .DocsURL }}
+- [Docs]({{ . }})
+{{- end }}
+{{- with .RemediationURL }}
+- [Remediation]({{ . }})
+{{- end }}
+{{- end }}
+
+` + "```diff" + `
+{{ trimSuffix .DiffOutput "\n" }}
+` + "```" + `
+
+</details>
+{{- end }}
+{{- end }}
+{{- end }}
+{{- with .Summary.ValidationIssues }}
+
+## Validation Issues
+
+| Part | Component | Issue | CRs |
+| --- | --- | --- | --- |
+{{- range $partname, $part := . }}
+{{- range $compname, $issue := $part }}
+| {{ cell $partname }} | {{ cell $compname }} | {{ cell $issue.Msg }} | {{ range $i, $cr := $issue.CRs }}{{ if $i }}<br>{{ end }}` + "`{{ $cr }}`" + `{{ end }} |
+{{- end }}
+{{- end }}
+{{- end }}
+{{- with .Summary.UnmatchedCRS }}
+
+## Unmatched Cluster CRs
+{{ range . }}
+- ` + "`{{ . }}`" + `
+{{- end }}
+{{- end }}
+`))
+
+var htmlReportTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cluster Compare Drift Report</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+pre { background: #f6f8fa; padding: 8px; }
+</style>
+</head>
+<body>
+<h1>Cluster Compare Drift Report</h1>
+{{- with .Summary }}
+<table>
+<tr><td>CRs with diffs</td><td>{{ .NumDiffCRs }}/{{ .TotalCRs }}</td></tr>
+<tr><td>CRs in reference missing from the cluster</td><td>{{ .NumMissing }}</td></tr>
+<tr><td>Cluster CRs unmatched to reference CRs</td><td>{{ len .UnmatchedCRS }}</td></tr>
+<tr><td>Cluster CRs with patches applied</td><td>{{ .PatchedCRs }}</td></tr>
+<tr><td>Metadata Hash</td><td><code>{{ .MetadataHash }}</code></td></tr>
+</table>
+{{- end }}
+{{- range .Components }}
+<h2>{{ with .Part }}{{ . }} / {{ end }}{{ .Component }}</h2>
+<p>{{ .NumDiffs }}/{{ len .Diffs }} CRs with diffs</p>
+<table>
+<tr><th>Cluster CR</th><th>Reference Template</th><th>Status</th></tr>
+{{- range .Diffs }}
+<tr><td><code>{{ .CRName }}</code></td><td><code>{{ .CorrelatedTemplate }}</code></td><td>{{ if .HasDiff }}Diff{{ with .Severity }} ({{ . }}){{ end }}{{ else }}Matches{{ end }}</td></tr>
+{{- end }}
+</table>
+{{- range .Diffs }}
+{{- if .HasDiff }}
+<details>
+<summary><code>{{ .CRName }}</code> compared to <code>{{ .CorrelatedTemplate }}</code></summary>
+{{- with .DocsURL }}
+<p><a href="{{ . }}">Docs</a></p>
+{{- end }}
+{{- with .RemediationURL }}
+<p><a href="{{ . }}">Remediation</a></p>
+{{- end }}
+<pre>{{ .DiffOutput }}</pre>
+</details>
+{{- end }}
+{{- end }}
+{{- end }}
+{{- with .Summary.ValidationIssues }}
+<h2>Validation Issues</h2>
+<table>
+<tr><th>Part</th><th>Component</th><th>Issue</th><th>CRs</th></tr>
+{{- range $partname, $part := . }}
+{{- range $compname, $issue := $part }}
+<tr><td>{{ $partname }}</td><td>{{ $compname }}</td><td>{{ $issue.Msg }}</td><td>{{ range $i, $cr := $issue.CRs }}{{ if $i }}<br>{{ end }}<code>{{ $cr }}</code>{{ end }}</td></tr>
+{{- end }}
+{{- end }}
+</table>
+{{- end }}
+{{- with .Summary.UnmatchedCRS }}
+<h2>Unmatched Cluster CRs</h2>
+<ul>
+{{- range . }}
+<li><code>{{ . }}</code></li>
+{{- end }}
+</ul>
+{{- end }}
+</body>
+</html>
+`))
+
+// writeMarkdown writes the drift report of the compare output in markdown, e.g. for attaching to change tickets.
+func writeMarkdown(w io.Writer, output compare.Output) error {
+	return markdownReportTemplate.Execute(w, newDriftReport(output)) // nolint:wrapcheck
+}
+
+// writeHTML writes the drift report of the compare output as an HTML page.
+func writeHTML(w io.Writer, output compare.Output) error {
+	return htmlReportTemplate.Execute(w, newDriftReport(output)) // nolint:wrapcheck
+}
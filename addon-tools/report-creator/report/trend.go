@@ -0,0 +1,226 @@
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"github.com/openshift/kube-compare/pkg/compare"
+)
+
+// trendRun is a compare output of the trend, with the diffs that appeared and disappeared since the previous run.
+type trendRun struct {
+	Name     string
+	NumDiffs int
+	New      int
+	Resolved int
+}
+
+// templateTrend are the cluster CRs matched to a template whose diffs appeared (New), disappeared (Resolved) or
+// remained (Persistent) between the first and the last run.
+type templateTrend struct {
+	Template   string
+	New        []string
+	Resolved   []string
+	Persistent []string
+}
+
+// trendReport is the data of the trend report of a series of compare outputs.
+type trendReport struct {
+	First     string
+	Last      string
+	Runs      []trendRun
+	Templates []templateTrend
+}
+
+type namedOutput struct {
+	name   string
+	output compare.Output
+}
+
+// diffKey identifies the diff of a cluster CR compared to a template across runs.
+type diffKey struct {
+	template string
+	crName   string
+}
+
+// loadTrendOutputs reads the compare outputs (JSON or YAML) in the directory. The runs are ordered by the names of the
+// files, that are expected to be timestamped.
+func loadTrendOutputs(dir string) ([]namedOutput, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the trend directory: %w", err)
+	}
+	var outputs []namedOutput
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read comparison file: %w", err)
+		}
+		output, err := getParsed(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse comparison file %s: %w", entry.Name(), err)
+		}
+		outputs = append(outputs, namedOutput{name: entry.Name(), output: output})
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no comparison files found in %s", dir)
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].name < outputs[j].name
+	})
+	return outputs, nil
+}
+
+// diffsOf returns the diffs of the cluster CRs that differ from their templates.
+func diffsOf(output compare.Output) map[diffKey]bool {
+	diffs := make(map[diffKey]bool)
+	if output.Diffs == nil {
+		return diffs
+	}
+	for _, d := range *output.Diffs {
+		if d.HasDiff() {
+			diffs[diffKey{template: d.CorrelatedTemplate, crName: d.CRName}] = true
+		}
+	}
+	return diffs
+}
+
+func newTrendReport(outputs []namedOutput) trendReport {
+	report := trendReport{First: outputs[0].name, Last: outputs[len(outputs)-1].name}
+	var previous map[diffKey]bool
+	for i, o := range outputs {
+		diffs := diffsOf(o.output)
+		run := trendRun{Name: o.name, NumDiffs: len(diffs)}
+		if i > 0 {
+			for k := range diffs {
+				if !previous[k] {
+					run.New++
+				}
+			}
+			for k := range previous {
+				if !diffs[k] {
+					run.Resolved++
+				}
+			}
+		}
+		report.Runs = append(report.Runs, run)
+		previous = diffs
+	}
+
+	first, last := diffsOf(outputs[0].output), previous
+	templates := make(map[string]*templateTrend)
+	get := func(name string) *templateTrend {
+		t, ok := templates[name]
+		if !ok {
+			t = &templateTrend{Template: name}
+			templates[name] = t
+		}
+		return t
+	}
+	for k := range last {
+		t := get(k.template)
+		if first[k] {
+			t.Persistent = append(t.Persistent, k.crName)
+		} else {
+			t.New = append(t.New, k.crName)
+		}
+	}
+	for k := range first {
+		if !last[k] {
+			t := get(k.template)
+			t.Resolved = append(t.Resolved, k.crName)
+		}
+	}
+	for _, t := range templates {
+		sort.Strings(t.New)
+		sort.Strings(t.Resolved)
+		sort.Strings(t.Persistent)
+		report.Templates = append(report.Templates, *t)
+	}
+	sort.Slice(report.Templates, func(i, j int) bool {
+		return report.Templates[i].Template < report.Templates[j].Template
+	})
+	return report
+}
+
+var markdownTrendTemplate = template.Must(template.New("markdown").Parse(`# Cluster Compare Trend Report
+
+Changes of the diffs between {{ .First }} and {{ .Last }}.
+
+## Runs
+
+| Run | CRs with diffs | New diffs | Resolved diffs |
+| --- | --- | --- | --- |
+{{- range .Runs }}
+| {{ .Name }} | {{ .NumDiffs }} | {{ .New }} | {{ .Resolved }} |
+{{- end }}
+{{- if .Templates }}
+
+## Diffs by template
+
+| Template | New | Resolved | Persistent |
+| --- | --- | --- | --- |
+{{- range .Templates }}
+| ` + "`{{ .Template }}`" + ` | {{ range $i, $cr := .New }}{{ if $i }}<br>{{ end }}` + "`{{ $cr }}`" + `{{ end }} | {{ range $i, $cr := .Resolved }}{{ if $i }}<br>{{ end }}` + "`{{ $cr }}`" + `{{ end }} | {{ range $i, $cr := .Persistent }}{{ if $i }}<br>{{ end }}` + "`{{ $cr }}`" + `{{ end }} |
+{{- end }}
+{{- end }}
+`))
+
+var htmlTrendTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cluster Compare Trend Report</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>Cluster Compare Trend Report</h1>
+<p>Changes of the diffs between {{ .First }} and {{ .Last }}.</p>
+<h2>Runs</h2>
+<table>
+<tr><th>Run</th><th>CRs with diffs</th><th>New diffs</th><th>Resolved diffs</th></tr>
+{{- range .Runs }}
+<tr><td>{{ .Name }}</td><td>{{ .NumDiffs }}</td><td>{{ .New }}</td><td>{{ .Resolved }}</td></tr>
+{{- end }}
+</table>
+{{- if .Templates }}
+<h2>Diffs by template</h2>
+<table>
+<tr><th>Template</th><th>New</th><th>Resolved</th><th>Persistent</th></tr>
+{{- range .Templates }}
+<tr><td><code>{{ .Template }}</code></td><td>{{ range $i, $cr := .New }}{{ if $i }}<br>{{ end }}<code>{{ $cr }}</code>{{ end }}</td><td>{{ range $i, $cr := .Resolved }}{{ if $i }}<br>{{ end }}<code>{{ $cr }}</code>{{ end }}</td><td>{{ range $i, $cr := .Persistent }}{{ if $i }}<br>{{ end }}<code>{{ $cr }}</code>{{ end }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
+
+// writeTrend writes the trend report of the compare outputs in the directory, in markdown or as an HTML page.
+func writeTrend(w io.Writer, dir, format string) error {
+	outputs, err := loadTrendOutputs(dir)
+	if err != nil {
+		return err
+	}
+	report := newTrendReport(outputs)
+	if format == HTML {
+		err = htmlTrendTemplate.Execute(w, report)
+	} else {
+		err = markdownTrendTemplate.Execute(w, report)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s trend report: %w", format, err)
+	}
+	return nil
+}
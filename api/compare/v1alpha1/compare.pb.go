@@ -0,0 +1,984 @@
+// SPDX-License-Identifier:Apache-2.0
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v0.0.0
+// source: api/compare/v1alpha1/compare.proto
+
+package comparev1alpha1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LoadReferenceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Path on the server or URL of the metadata.yaml of the reference. Loading the same reference with the same
+	// configs again reloads it and returns the same id.
+	Reference string `protobuf:"bytes,1,opt,name=reference,proto3" json:"reference,omitempty"`
+	// Optional diff config (the content of the file passed by -c).
+	DiffConfig []byte `protobuf:"bytes,2,opt,name=diff_config,json=diffConfig,proto3" json:"diff_config,omitempty"`
+	// Optional user overrides (the content of the file passed by -p).
+	UserOverrides []byte `protobuf:"bytes,3,opt,name=user_overrides,json=userOverrides,proto3" json:"user_overrides,omitempty"`
+}
+
+func (x *LoadReferenceRequest) Reset() {
+	*x = LoadReferenceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoadReferenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadReferenceRequest) ProtoMessage() {}
+
+func (x *LoadReferenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadReferenceRequest.ProtoReflect.Descriptor instead.
+func (*LoadReferenceRequest) Descriptor() ([]byte, []int) {
+	return file_api_compare_v1alpha1_compare_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LoadReferenceRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *LoadReferenceRequest) GetDiffConfig() []byte {
+	if x != nil {
+		return x.DiffConfig
+	}
+	return nil
+}
+
+func (x *LoadReferenceRequest) GetUserOverrides() []byte {
+	if x != nil {
+		return x.UserOverrides
+	}
+	return nil
+}
+
+type LoadReferenceResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReferenceId  string `protobuf:"bytes,1,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	MetadataHash string `protobuf:"bytes,2,opt,name=metadata_hash,json=metadataHash,proto3" json:"metadata_hash,omitempty"`
+	// Number of templates in the reference, templates rendering multiple documents count once per document.
+	NumTemplates int32 `protobuf:"varint,3,opt,name=num_templates,json=numTemplates,proto3" json:"num_templates,omitempty"`
+}
+
+func (x *LoadReferenceResponse) Reset() {
+	*x = LoadReferenceResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoadReferenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadReferenceResponse) ProtoMessage() {}
+
+func (x *LoadReferenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadReferenceResponse.ProtoReflect.Descriptor instead.
+func (*LoadReferenceResponse) Descriptor() ([]byte, []int) {
+	return file_api_compare_v1alpha1_compare_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LoadReferenceResponse) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+func (x *LoadReferenceResponse) GetMetadataHash() string {
+	if x != nil {
+		return x.MetadataHash
+	}
+	return ""
+}
+
+func (x *LoadReferenceResponse) GetNumTemplates() int32 {
+	if x != nil {
+		return x.NumTemplates
+	}
+	return 0
+}
+
+type UnloadReferenceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReferenceId string `protobuf:"bytes,1,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+}
+
+func (x *UnloadReferenceRequest) Reset() {
+	*x = UnloadReferenceRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UnloadReferenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnloadReferenceRequest) ProtoMessage() {}
+
+func (x *UnloadReferenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnloadReferenceRequest.ProtoReflect.Descriptor instead.
+func (*UnloadReferenceRequest) Descriptor() ([]byte, []int) {
+	return file_api_compare_v1alpha1_compare_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UnloadReferenceRequest) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+type UnloadReferenceResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *UnloadReferenceResponse) Reset() {
+	*x = UnloadReferenceResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UnloadReferenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnloadReferenceResponse) ProtoMessage() {}
+
+func (x *UnloadReferenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnloadReferenceResponse.ProtoReflect.Descriptor instead.
+func (*UnloadReferenceResponse) Descriptor() ([]byte, []int) {
+	return file_api_compare_v1alpha1_compare_proto_rawDescGZIP(), []int{3}
+}
+
+type CompareObjectsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReferenceId string `protobuf:"bytes,1,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	// CRs to compare, each one a yaml or json document.
+	Objects [][]byte `protobuf:"bytes,2,rep,name=objects,proto3" json:"objects,omitempty"`
+	// Correlates and reports missing and unmatched CRs without generating diffs.
+	OnlyValidation bool `protobuf:"varint,3,opt,name=only_validation,json=onlyValidation,proto3" json:"only_validation,omitempty"`
+	// Reports unmatched CRs of all types, not only types the reference has templates for.
+	AllResources bool `protobuf:"varint,4,opt,name=all_resources,json=allResources,proto3" json:"all_resources,omitempty"`
+}
+
+func (x *CompareObjectsRequest) Reset() {
+	*x = CompareObjectsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompareObjectsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompareObjectsRequest) ProtoMessage() {}
+
+func (x *CompareObjectsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompareObjectsRequest.ProtoReflect.Descriptor instead.
+func (*CompareObjectsRequest) Descriptor() ([]byte, []int) {
+	return file_api_compare_v1alpha1_compare_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CompareObjectsRequest) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+func (x *CompareObjectsRequest) GetObjects() [][]byte {
+	if x != nil {
+		return x.Objects
+	}
+	return nil
+}
+
+func (x *CompareObjectsRequest) GetOnlyValidation() bool {
+	if x != nil {
+		return x.OnlyValidation
+	}
+	return false
+}
+
+func (x *CompareObjectsRequest) GetAllResources() bool {
+	if x != nil {
+		return x.AllResources
+	}
+	return false
+}
+
+type CompareObjectsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Summary *Summary `protobuf:"bytes,1,opt,name=summary,proto3" json:"summary,omitempty"`
+	Diffs   []*Diff  `protobuf:"bytes,2,rep,name=diffs,proto3" json:"diffs,omitempty"`
+}
+
+func (x *CompareObjectsResponse) Reset() {
+	*x = CompareObjectsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompareObjectsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompareObjectsResponse) ProtoMessage() {}
+
+func (x *CompareObjectsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompareObjectsResponse.ProtoReflect.Descriptor instead.
+func (*CompareObjectsResponse) Descriptor() ([]byte, []int) {
+	return file_api_compare_v1alpha1_compare_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CompareObjectsResponse) GetSummary() *Summary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *CompareObjectsResponse) GetDiffs() []*Diff {
+	if x != nil {
+		return x.Diffs
+	}
+	return nil
+}
+
+type CompareResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//	*CompareResult_Diff
+	//	*CompareResult_Summary
+	Result isCompareResult_Result `protobuf_oneof:"result"`
+}
+
+func (x *CompareResult) Reset() {
+	*x = CompareResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CompareResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompareResult) ProtoMessage() {}
+
+func (x *CompareResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompareResult.ProtoReflect.Descriptor instead.
+func (*CompareResult) Descriptor() ([]byte, []int) {
+	return file_api_compare_v1alpha1_compare_proto_rawDescGZIP(), []int{6}
+}
+
+func (m *CompareResult) GetResult() isCompareResult_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *CompareResult) GetDiff() *Diff {
+	if x, ok := x.GetResult().(*CompareResult_Diff); ok {
+		return x.Diff
+	}
+	return nil
+}
+
+func (x *CompareResult) GetSummary() *Summary {
+	if x, ok := x.GetResult().(*CompareResult_Summary); ok {
+		return x.Summary
+	}
+	return nil
+}
+
+type isCompareResult_Result interface {
+	isCompareResult_Result()
+}
+
+type CompareResult_Diff struct {
+	Diff *Diff `protobuf:"bytes,1,opt,name=diff,proto3,oneof"`
+}
+
+type CompareResult_Summary struct {
+	Summary *Summary `protobuf:"bytes,2,opt,name=summary,proto3,oneof"`
+}
+
+func (*CompareResult_Diff) isCompareResult_Result() {}
+
+func (*CompareResult_Summary) isCompareResult_Result() {}
+
+// Diff mirrors DiffSum of the json output.
+type Diff struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CrName             string   `protobuf:"bytes,1,opt,name=cr_name,json=crName,proto3" json:"cr_name,omitempty"`
+	CorrelatedTemplate string   `protobuf:"bytes,2,opt,name=correlated_template,json=correlatedTemplate,proto3" json:"correlated_template,omitempty"`
+	DiffOutput         string   `protobuf:"bytes,3,opt,name=diff_output,json=diffOutput,proto3" json:"diff_output,omitempty"`
+	Patched            string   `protobuf:"bytes,4,opt,name=patched,proto3" json:"patched,omitempty"`
+	OverrideReasons    []string `protobuf:"bytes,5,rep,name=override_reasons,json=overrideReasons,proto3" json:"override_reasons,omitempty"`
+	Description        string   `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	Severity           string   `protobuf:"bytes,7,opt,name=severity,proto3" json:"severity,omitempty"`
+	Acknowledgements   []string `protobuf:"bytes,8,rep,name=acknowledgements,proto3" json:"acknowledgements,omitempty"`
+	// The part and the component of the reference the template belongs to.
+	Part      string `protobuf:"bytes,9,opt,name=part,proto3" json:"part,omitempty"`
+	Component string `protobuf:"bytes,10,opt,name=component,proto3" json:"component,omitempty"`
+}
+
+func (x *Diff) Reset() {
+	*x = Diff{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Diff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Diff) ProtoMessage() {}
+
+func (x *Diff) ProtoReflect() protoreflect.Message {
+	mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Diff.ProtoReflect.Descriptor instead.
+func (*Diff) Descriptor() ([]byte, []int) {
+	return file_api_compare_v1alpha1_compare_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Diff) GetCrName() string {
+	if x != nil {
+		return x.CrName
+	}
+	return ""
+}
+
+func (x *Diff) GetCorrelatedTemplate() string {
+	if x != nil {
+		return x.CorrelatedTemplate
+	}
+	return ""
+}
+
+func (x *Diff) GetDiffOutput() string {
+	if x != nil {
+		return x.DiffOutput
+	}
+	return ""
+}
+
+func (x *Diff) GetPatched() string {
+	if x != nil {
+		return x.Patched
+	}
+	return ""
+}
+
+func (x *Diff) GetOverrideReasons() []string {
+	if x != nil {
+		return x.OverrideReasons
+	}
+	return nil
+}
+
+func (x *Diff) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Diff) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Diff) GetAcknowledgements() []string {
+	if x != nil {
+		return x.Acknowledgements
+	}
+	return nil
+}
+
+func (x *Diff) GetPart() string {
+	if x != nil {
+		return x.Part
+	}
+	return ""
+}
+
+func (x *Diff) GetComponent() string {
+	if x != nil {
+		return x.Component
+	}
+	return ""
+}
+
+// Summary mirrors Summary of the json output.
+type Summary struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	NumDiffCrs   int32    `protobuf:"varint,1,opt,name=num_diff_crs,json=numDiffCrs,proto3" json:"num_diff_crs,omitempty"`
+	TotalCrs     int32    `protobuf:"varint,2,opt,name=total_crs,json=totalCrs,proto3" json:"total_crs,omitempty"`
+	NumMissing   int32    `protobuf:"varint,3,opt,name=num_missing,json=numMissing,proto3" json:"num_missing,omitempty"`
+	UnmatchedCrs []string `protobuf:"bytes,4,rep,name=unmatched_crs,json=unmatchedCrs,proto3" json:"unmatched_crs,omitempty"`
+	MetadataHash string   `protobuf:"bytes,5,opt,name=metadata_hash,json=metadataHash,proto3" json:"metadata_hash,omitempty"`
+	PatchedCrs   int32    `protobuf:"varint,6,opt,name=patched_crs,json=patchedCrs,proto3" json:"patched_crs,omitempty"`
+	// The validation issues, serialized as in the json output.
+	ValidationIssues []byte `protobuf:"bytes,7,opt,name=validation_issues,json=validationIssues,proto3" json:"validation_issues,omitempty"`
+}
+
+func (x *Summary) Reset() {
+	*x = Summary{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Summary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Summary) ProtoMessage() {}
+
+func (x *Summary) ProtoReflect() protoreflect.Message {
+	mi := &file_api_compare_v1alpha1_compare_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Summary.ProtoReflect.Descriptor instead.
+func (*Summary) Descriptor() ([]byte, []int) {
+	return file_api_compare_v1alpha1_compare_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Summary) GetNumDiffCrs() int32 {
+	if x != nil {
+		return x.NumDiffCrs
+	}
+	return 0
+}
+
+func (x *Summary) GetTotalCrs() int32 {
+	if x != nil {
+		return x.TotalCrs
+	}
+	return 0
+}
+
+func (x *Summary) GetNumMissing() int32 {
+	if x != nil {
+		return x.NumMissing
+	}
+	return 0
+}
+
+func (x *Summary) GetUnmatchedCrs() []string {
+	if x != nil {
+		return x.UnmatchedCrs
+	}
+	return nil
+}
+
+func (x *Summary) GetMetadataHash() string {
+	if x != nil {
+		return x.MetadataHash
+	}
+	return ""
+}
+
+func (x *Summary) GetPatchedCrs() int32 {
+	if x != nil {
+		return x.PatchedCrs
+	}
+	return 0
+}
+
+func (x *Summary) GetValidationIssues() []byte {
+	if x != nil {
+		return x.ValidationIssues
+	}
+	return nil
+}
+
+var File_api_compare_v1alpha1_compare_proto protoreflect.FileDescriptor
+
+var file_api_compare_v1alpha1_compare_proto_rawDesc = []byte{
+	0x0a, 0x22, 0x61, 0x70, 0x69, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2f, 0x76, 0x31,
+	0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1c, 0x6b, 0x75, 0x62, 0x65, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72,
+	0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68,
+	0x61, 0x31, 0x22, 0x7c, 0x0a, 0x14, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65,
+	0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65,
+	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72,
+	0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x69, 0x66, 0x66,
+	0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x64,
+	0x69, 0x66, 0x66, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x75, 0x73, 0x65,
+	0x72, 0x5f, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0d, 0x75, 0x73, 0x65, 0x72, 0x4f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x73,
+	0x22, 0x84, 0x01, 0x0a, 0x15, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e,
+	0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65,
+	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x23, 0x0a,
+	0x0d, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x48, 0x61,
+	0x73, 0x68, 0x12, 0x23, 0x0a, 0x0d, 0x6e, 0x75, 0x6d, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61,
+	0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x6e, 0x75, 0x6d, 0x54, 0x65,
+	0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x22, 0x3b, 0x0a, 0x16, 0x55, 0x6e, 0x6c, 0x6f, 0x61,
+	0x64, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e,
+	0x63, 0x65, 0x49, 0x64, 0x22, 0x19, 0x0a, 0x17, 0x55, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65,
+	0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0xa2, 0x01, 0x0a, 0x15, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x4f, 0x62, 0x6a, 0x65, 0x63,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x66,
+	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07,
+	0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x07, 0x6f,
+	0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x6f, 0x6e, 0x6c, 0x79, 0x5f, 0x76,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0e, 0x6f, 0x6e, 0x6c, 0x79, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x23, 0x0a, 0x0d, 0x61, 0x6c, 0x6c, 0x5f, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x61, 0x6c, 0x6c, 0x52, 0x65, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x73, 0x22, 0x93, 0x01, 0x0a, 0x16, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65,
+	0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x3f, 0x0a, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x25, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x63,
+	0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e,
+	0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79,
+	0x12, 0x38, 0x0a, 0x05, 0x64, 0x69, 0x66, 0x66, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x22, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f,
+	0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x44,
+	0x69, 0x66, 0x66, 0x52, 0x05, 0x64, 0x69, 0x66, 0x66, 0x73, 0x22, 0x96, 0x01, 0x0a, 0x0d, 0x43,
+	0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x38, 0x0a, 0x04,
+	0x64, 0x69, 0x66, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x6b, 0x75, 0x62,
+	0x65, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65,
+	0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x44, 0x69, 0x66, 0x66, 0x48, 0x00,
+	0x52, 0x04, 0x64, 0x69, 0x66, 0x66, 0x12, 0x41, 0x0a, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72,
+	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x25, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x63, 0x6f,
+	0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x76, 0x31,
+	0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x48, 0x00,
+	0x52, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x22, 0xd2, 0x02, 0x0a, 0x04, 0x44, 0x69, 0x66, 0x66, 0x12, 0x17, 0x0a, 0x07,
+	0x63, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63,
+	0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2f, 0x0a, 0x13, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x6c, 0x61,
+	0x74, 0x65, 0x64, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x12, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x65, 0x64, 0x54, 0x65,
+	0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x69, 0x66, 0x66, 0x5f, 0x6f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x64, 0x69, 0x66,
+	0x66, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x74, 0x63, 0x68,
+	0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x74, 0x63, 0x68, 0x65,
+	0x64, 0x12, 0x29, 0x0a, 0x10, 0x6f, 0x76, 0x65, 0x72, 0x72, 0x69, 0x64, 0x65, 0x5f, 0x72, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x6f, 0x76, 0x65,
+	0x72, 0x72, 0x69, 0x64, 0x65, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x73, 0x12, 0x20, 0x0a, 0x0b,
+	0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a,
+	0x0a, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x2a, 0x0a, 0x10, 0x61, 0x63,
+	0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x08,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x10, 0x61, 0x63, 0x6b, 0x6e, 0x6f, 0x77, 0x6c, 0x65, 0x64, 0x67,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x72, 0x74, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x72, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f,
+	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x22, 0x81, 0x02, 0x0a, 0x07, 0x53, 0x75, 0x6d,
+	0x6d, 0x61, 0x72, 0x79, 0x12, 0x20, 0x0a, 0x0c, 0x6e, 0x75, 0x6d, 0x5f, 0x64, 0x69, 0x66, 0x66,
+	0x5f, 0x63, 0x72, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x6e, 0x75, 0x6d, 0x44,
+	0x69, 0x66, 0x66, 0x43, 0x72, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f,
+	0x63, 0x72, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x43, 0x72, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x6e, 0x75, 0x6d, 0x5f, 0x6d, 0x69, 0x73, 0x73, 0x69,
+	0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x6e, 0x75, 0x6d, 0x4d, 0x69, 0x73,
+	0x73, 0x69, 0x6e, 0x67, 0x12, 0x23, 0x0a, 0x0d, 0x75, 0x6e, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65,
+	0x64, 0x5f, 0x63, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x75, 0x6e, 0x6d,
+	0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x43, 0x72, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x6d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0c, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1f,
+	0x0a, 0x0b, 0x70, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x5f, 0x63, 0x72, 0x73, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0a, 0x70, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x43, 0x72, 0x73, 0x12,
+	0x2b, 0x0a, 0x11, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x73,
+	0x73, 0x75, 0x65, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x10, 0x76, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x73, 0x73, 0x75, 0x65, 0x73, 0x32, 0xf5, 0x03, 0x0a,
+	0x07, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x12, 0x78, 0x0a, 0x0d, 0x4c, 0x6f, 0x61, 0x64,
+	0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x32, 0x2e, 0x6b, 0x75, 0x62, 0x65,
+	0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e,
+	0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x66,
+	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x33, 0x2e,
+	0x6b, 0x75, 0x62, 0x65, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70,
+	0x61, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x4c, 0x6f, 0x61,
+	0x64, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x7e, 0x0a, 0x0f, 0x55, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x66, 0x65,
+	0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x34, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x63, 0x6f, 0x6d, 0x70,
+	0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x61, 0x6c,
+	0x70, 0x68, 0x61, 0x31, 0x2e, 0x55, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x66, 0x65, 0x72,
+	0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x35, 0x2e, 0x6b, 0x75,
+	0x62, 0x65, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72,
+	0x65, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x55, 0x6e, 0x6c, 0x6f, 0x61,
+	0x64, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x7b, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x4f, 0x62, 0x6a,
+	0x65, 0x63, 0x74, 0x73, 0x12, 0x33, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x63, 0x6f, 0x6d, 0x70, 0x61,
+	0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70,
+	0x68, 0x61, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x4f, 0x62, 0x6a, 0x65, 0x63,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x34, 0x2e, 0x6b, 0x75, 0x62, 0x65,
+	0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e,
+	0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65,
+	0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x73, 0x0a, 0x0d, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73,
+	0x12, 0x33, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x63,
+	0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x2e,
+	0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2b, 0x2e, 0x6b, 0x75, 0x62, 0x65, 0x63, 0x6f, 0x6d, 0x70,
+	0x61, 0x72, 0x65, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x61, 0x6c,
+	0x70, 0x68, 0x61, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x30, 0x01, 0x42, 0x48, 0x5a, 0x46, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x73, 0x68, 0x69, 0x66, 0x74, 0x2f, 0x6b, 0x75, 0x62,
+	0x65, 0x2d, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x63, 0x6f,
+	0x6d, 0x70, 0x61, 0x72, 0x65, 0x2f, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x3b, 0x63,
+	0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x76, 0x31, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x31, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_api_compare_v1alpha1_compare_proto_rawDescOnce sync.Once
+	file_api_compare_v1alpha1_compare_proto_rawDescData = file_api_compare_v1alpha1_compare_proto_rawDesc
+)
+
+func file_api_compare_v1alpha1_compare_proto_rawDescGZIP() []byte {
+	file_api_compare_v1alpha1_compare_proto_rawDescOnce.Do(func() {
+		file_api_compare_v1alpha1_compare_proto_rawDescData = protoimpl.X.CompressGZIP(file_api_compare_v1alpha1_compare_proto_rawDescData)
+	})
+	return file_api_compare_v1alpha1_compare_proto_rawDescData
+}
+
+var file_api_compare_v1alpha1_compare_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_api_compare_v1alpha1_compare_proto_goTypes = []any{
+	(*LoadReferenceRequest)(nil),    // 0: kubecompare.compare.v1alpha1.LoadReferenceRequest
+	(*LoadReferenceResponse)(nil),   // 1: kubecompare.compare.v1alpha1.LoadReferenceResponse
+	(*UnloadReferenceRequest)(nil),  // 2: kubecompare.compare.v1alpha1.UnloadReferenceRequest
+	(*UnloadReferenceResponse)(nil), // 3: kubecompare.compare.v1alpha1.UnloadReferenceResponse
+	(*CompareObjectsRequest)(nil),   // 4: kubecompare.compare.v1alpha1.CompareObjectsRequest
+	(*CompareObjectsResponse)(nil),  // 5: kubecompare.compare.v1alpha1.CompareObjectsResponse
+	(*CompareResult)(nil),           // 6: kubecompare.compare.v1alpha1.CompareResult
+	(*Diff)(nil),                    // 7: kubecompare.compare.v1alpha1.Diff
+	(*Summary)(nil),                 // 8: kubecompare.compare.v1alpha1.Summary
+}
+var file_api_compare_v1alpha1_compare_proto_depIdxs = []int32{
+	8, // 0: kubecompare.compare.v1alpha1.CompareObjectsResponse.summary:type_name -> kubecompare.compare.v1alpha1.Summary
+	7, // 1: kubecompare.compare.v1alpha1.CompareObjectsResponse.diffs:type_name -> kubecompare.compare.v1alpha1.Diff
+	7, // 2: kubecompare.compare.v1alpha1.CompareResult.diff:type_name -> kubecompare.compare.v1alpha1.Diff
+	8, // 3: kubecompare.compare.v1alpha1.CompareResult.summary:type_name -> kubecompare.compare.v1alpha1.Summary
+	0, // 4: kubecompare.compare.v1alpha1.Compare.LoadReference:input_type -> kubecompare.compare.v1alpha1.LoadReferenceRequest
+	2, // 5: kubecompare.compare.v1alpha1.Compare.UnloadReference:input_type -> kubecompare.compare.v1alpha1.UnloadReferenceRequest
+	4, // 6: kubecompare.compare.v1alpha1.Compare.CompareObjects:input_type -> kubecompare.compare.v1alpha1.CompareObjectsRequest
+	4, // 7: kubecompare.compare.v1alpha1.Compare.StreamResults:input_type -> kubecompare.compare.v1alpha1.CompareObjectsRequest
+	1, // 8: kubecompare.compare.v1alpha1.Compare.LoadReference:output_type -> kubecompare.compare.v1alpha1.LoadReferenceResponse
+	3, // 9: kubecompare.compare.v1alpha1.Compare.UnloadReference:output_type -> kubecompare.compare.v1alpha1.UnloadReferenceResponse
+	5, // 10: kubecompare.compare.v1alpha1.Compare.CompareObjects:output_type -> kubecompare.compare.v1alpha1.CompareObjectsResponse
+	6, // 11: kubecompare.compare.v1alpha1.Compare.StreamResults:output_type -> kubecompare.compare.v1alpha1.CompareResult
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_api_compare_v1alpha1_compare_proto_init() }
+func file_api_compare_v1alpha1_compare_proto_init() {
+	if File_api_compare_v1alpha1_compare_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_api_compare_v1alpha1_compare_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*LoadReferenceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_compare_v1alpha1_compare_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*LoadReferenceResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_compare_v1alpha1_compare_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*UnloadReferenceRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_compare_v1alpha1_compare_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*UnloadReferenceResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_compare_v1alpha1_compare_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*CompareObjectsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_compare_v1alpha1_compare_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*CompareObjectsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_compare_v1alpha1_compare_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*CompareResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_compare_v1alpha1_compare_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*Diff); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_compare_v1alpha1_compare_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*Summary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_api_compare_v1alpha1_compare_proto_msgTypes[6].OneofWrappers = []any{
+		(*CompareResult_Diff)(nil),
+		(*CompareResult_Summary)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_api_compare_v1alpha1_compare_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_compare_v1alpha1_compare_proto_goTypes,
+		DependencyIndexes: file_api_compare_v1alpha1_compare_proto_depIdxs,
+		MessageInfos:      file_api_compare_v1alpha1_compare_proto_msgTypes,
+	}.Build()
+	File_api_compare_v1alpha1_compare_proto = out.File
+	file_api_compare_v1alpha1_compare_proto_rawDesc = nil
+	file_api_compare_v1alpha1_compare_proto_goTypes = nil
+	file_api_compare_v1alpha1_compare_proto_depIdxs = nil
+}
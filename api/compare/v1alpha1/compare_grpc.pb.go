@@ -0,0 +1,259 @@
+// SPDX-License-Identifier:Apache-2.0
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v0.0.0
+// source: api/compare/v1alpha1/compare.proto
+
+package comparev1alpha1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Compare_LoadReference_FullMethodName   = "/kubecompare.compare.v1alpha1.Compare/LoadReference"
+	Compare_UnloadReference_FullMethodName = "/kubecompare.compare.v1alpha1.Compare/UnloadReference"
+	Compare_CompareObjects_FullMethodName  = "/kubecompare.compare.v1alpha1.Compare/CompareObjects"
+	Compare_StreamResults_FullMethodName   = "/kubecompare.compare.v1alpha1.Compare/StreamResults"
+)
+
+// CompareClient is the client API for Compare service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Compare exposes the correlation and diff engine of kube-compare. A client loads a reference once and then compares
+// any number of CR sets against it.
+type CompareClient interface {
+	// LoadReference loads and parses a reference, the returned id is used by the other calls. The server only loads the
+	// references it was started with (--reference).
+	LoadReference(ctx context.Context, in *LoadReferenceRequest, opts ...grpc.CallOption) (*LoadReferenceResponse, error)
+	// UnloadReference releases a loaded reference, its id can't be used anymore.
+	UnloadReference(ctx context.Context, in *UnloadReferenceRequest, opts ...grpc.CallOption) (*UnloadReferenceResponse, error)
+	// CompareObjects compares a set of CRs with a loaded reference and returns the complete result.
+	CompareObjects(ctx context.Context, in *CompareObjectsRequest, opts ...grpc.CallOption) (*CompareObjectsResponse, error)
+	// StreamResults compares a set of CRs with a loaded reference like CompareObjects, streaming the diff of each CR as
+	// soon as it's compared and the summary last. The diffs are sent in the order the CRs are compared.
+	StreamResults(ctx context.Context, in *CompareObjectsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompareResult], error)
+}
+
+type compareClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCompareClient(cc grpc.ClientConnInterface) CompareClient {
+	return &compareClient{cc}
+}
+
+func (c *compareClient) LoadReference(ctx context.Context, in *LoadReferenceRequest, opts ...grpc.CallOption) (*LoadReferenceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoadReferenceResponse)
+	err := c.cc.Invoke(ctx, Compare_LoadReference_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *compareClient) UnloadReference(ctx context.Context, in *UnloadReferenceRequest, opts ...grpc.CallOption) (*UnloadReferenceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnloadReferenceResponse)
+	err := c.cc.Invoke(ctx, Compare_UnloadReference_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *compareClient) CompareObjects(ctx context.Context, in *CompareObjectsRequest, opts ...grpc.CallOption) (*CompareObjectsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompareObjectsResponse)
+	err := c.cc.Invoke(ctx, Compare_CompareObjects_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *compareClient) StreamResults(ctx context.Context, in *CompareObjectsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompareResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Compare_ServiceDesc.Streams[0], Compare_StreamResults_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CompareObjectsRequest, CompareResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Compare_StreamResultsClient = grpc.ServerStreamingClient[CompareResult]
+
+// CompareServer is the server API for Compare service.
+// All implementations must embed UnimplementedCompareServer
+// for forward compatibility.
+//
+// Compare exposes the correlation and diff engine of kube-compare. A client loads a reference once and then compares
+// any number of CR sets against it.
+type CompareServer interface {
+	// LoadReference loads and parses a reference, the returned id is used by the other calls. The server only loads the
+	// references it was started with (--reference).
+	LoadReference(context.Context, *LoadReferenceRequest) (*LoadReferenceResponse, error)
+	// UnloadReference releases a loaded reference, its id can't be used anymore.
+	UnloadReference(context.Context, *UnloadReferenceRequest) (*UnloadReferenceResponse, error)
+	// CompareObjects compares a set of CRs with a loaded reference and returns the complete result.
+	CompareObjects(context.Context, *CompareObjectsRequest) (*CompareObjectsResponse, error)
+	// StreamResults compares a set of CRs with a loaded reference like CompareObjects, streaming the diff of each CR as
+	// soon as it's compared and the summary last. The diffs are sent in the order the CRs are compared.
+	StreamResults(*CompareObjectsRequest, grpc.ServerStreamingServer[CompareResult]) error
+	mustEmbedUnimplementedCompareServer()
+}
+
+// UnimplementedCompareServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCompareServer struct{}
+
+func (UnimplementedCompareServer) LoadReference(context.Context, *LoadReferenceRequest) (*LoadReferenceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadReference not implemented")
+}
+func (UnimplementedCompareServer) UnloadReference(context.Context, *UnloadReferenceRequest) (*UnloadReferenceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnloadReference not implemented")
+}
+func (UnimplementedCompareServer) CompareObjects(context.Context, *CompareObjectsRequest) (*CompareObjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareObjects not implemented")
+}
+func (UnimplementedCompareServer) StreamResults(*CompareObjectsRequest, grpc.ServerStreamingServer[CompareResult]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamResults not implemented")
+}
+func (UnimplementedCompareServer) mustEmbedUnimplementedCompareServer() {}
+func (UnimplementedCompareServer) testEmbeddedByValue()                 {}
+
+// UnsafeCompareServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CompareServer will
+// result in compilation errors.
+type UnsafeCompareServer interface {
+	mustEmbedUnimplementedCompareServer()
+}
+
+func RegisterCompareServer(s grpc.ServiceRegistrar, srv CompareServer) {
+	// If the following call pancis, it indicates UnimplementedCompareServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Compare_ServiceDesc, srv)
+}
+
+func _Compare_LoadReference_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadReferenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompareServer).LoadReference(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Compare_LoadReference_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompareServer).LoadReference(ctx, req.(*LoadReferenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Compare_UnloadReference_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnloadReferenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompareServer).UnloadReference(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Compare_UnloadReference_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompareServer).UnloadReference(ctx, req.(*UnloadReferenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Compare_CompareObjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareObjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompareServer).CompareObjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Compare_CompareObjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompareServer).CompareObjects(ctx, req.(*CompareObjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Compare_StreamResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CompareObjectsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CompareServer).StreamResults(m, &grpc.GenericServerStream[CompareObjectsRequest, CompareResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Compare_StreamResultsServer = grpc.ServerStreamingServer[CompareResult]
+
+// Compare_ServiceDesc is the grpc.ServiceDesc for Compare service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Compare_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kubecompare.compare.v1alpha1.Compare",
+	HandlerType: (*CompareServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "LoadReference",
+			Handler:    _Compare_LoadReference_Handler,
+		},
+		{
+			MethodName: "UnloadReference",
+			Handler:    _Compare_UnloadReference_Handler,
+		},
+		{
+			MethodName: "CompareObjects",
+			Handler:    _Compare_CompareObjects_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamResults",
+			Handler:       _Compare_StreamResults_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/compare/v1alpha1/compare.proto",
+}
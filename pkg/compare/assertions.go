@@ -0,0 +1,163 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	lookupCRsFunc = "lookupCRs"
+
+	RelationshipAssertionsGroup = "Failed relationship assertions"
+	assertionNotMetMsg          = "The assertion isn't met"
+)
+
+// AssertionV2 is a relationship assertion of the reference: a go template expression evaluated once all the cluster
+// CRs are compared, with the lookupCRs function to get the compared CRs, that has to render to true or false. It's
+// meant for the checks that involve several CRs, e.g. the selector of a MachineConfigPool has to select at least one
+// MachineConfig, which can't be expressed by the templates of single CRs.
+type AssertionV2 struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	// Message is reported when the expression renders to false, the messages of failCompare are reported as they are
+	Message  string `json:"message,omitempty"`
+	template *template.Template
+}
+
+// parseAssertions parses the expressions of the assertions of the reference.
+func (r *ReferenceV2) parseAssertions() error {
+	var errs []error
+	names := make(map[string]bool)
+	for i, a := range r.Assertions {
+		if a.Name == "" {
+			errs = append(errs, fmt.Errorf("reference contains assertion %d without name", i))
+			continue
+		}
+		if names[a.Name] {
+			errs = append(errs, fmt.Errorf("reference contains more than one assertion named %s", a.Name))
+			continue
+		}
+		names[a.Name] = true
+		funcs := FuncMap()
+		funcs[lookupCRsFunc] = (*clusterCRIndex)(nil).lookup
+		t, err := template.New(a.Name).Funcs(funcs).Parse(a.Expression)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reference contains assertion %s that can't be parsed: %w", a.Name, err))
+			continue
+		}
+		a.template = t
+	}
+	return errors.Join(errs...)
+}
+
+// evaluate returns why the assertion isn't met by the compared cluster CRs, or an empty string when it's met.
+func (a *AssertionV2) evaluate(crs *clusterCRIndex, funcs template.FuncMap) string {
+	t, err := a.template.Clone()
+	if err != nil {
+		return err.Error()
+	}
+	t.Funcs(funcs).Funcs(template.FuncMap{lookupCRsFunc: crs.lookup})
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, map[string]any{}); err != nil {
+		var assertionErr TemplateAssertionError
+		if errors.As(err, &assertionErr) {
+			return assertionErr.Msg
+		}
+		return fmt.Sprintf("failed to execute the assertion: %s", err)
+	}
+	res, err := strconv.ParseBool(strings.TrimSpace(buf.String()))
+	if err != nil {
+		return fmt.Sprintf("the assertion rendered %q instead of true or false", strings.TrimSpace(buf.String()))
+	}
+	if res {
+		return ""
+	}
+	if a.Message != "" {
+		return a.Message
+	}
+	return assertionNotMetMsg
+}
+
+// clusterCRIndex keeps copies of the compared cluster CRs for the relationship assertions of the reference, the CRs
+// are modified when they are diffed.
+type clusterCRIndex struct {
+	lock sync.Mutex
+	crs  []*unstructured.Unstructured
+}
+
+// newClusterCRIndex returns an index when the reference has relationship assertions, nil otherwise.
+func newClusterCRIndex(ref Reference) *clusterCRIndex {
+	if len(ref.GetAssertions()) == 0 {
+		return nil
+	}
+	return &clusterCRIndex{}
+}
+
+func (i *clusterCRIndex) set(index int, clusterCR *unstructured.Unstructured) {
+	if i == nil {
+		return
+	}
+	clusterCR = clusterCR.DeepCopy()
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if index >= len(i.crs) {
+		i.crs = append(i.crs, make([]*unstructured.Unstructured, index+1-len(i.crs))...)
+	}
+	i.crs[index] = clusterCR
+}
+
+// lookup returns the compared cluster CRs of the kind in the namespace, or in all the namespaces when it's empty. Only
+// the kinds of the templates of the reference are compared, the other kinds of a live cluster can be looked up with
+// lookupLive.
+//
+// This is designed to be called from an assertion.
+func (i *clusterCRIndex) lookup(apiVersion, kind, namespace string) []any {
+	res := []any{}
+	if i == nil {
+		return res
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	for _, cr := range i.crs {
+		if cr != nil && cr.GetAPIVersion() == apiVersion && cr.GetKind() == kind && (namespace == "" || cr.GetNamespace() == namespace) {
+			res = append(res, cr.Object)
+		}
+	}
+	return res
+}
+
+// addRelationshipIssues reports the assertions of the reference that aren't met by the compared cluster CRs as
+// validation issues, keyed by the name of the assertion.
+func (s *Summary) addRelationshipIssues(assertions []*AssertionV2, crs *clusterCRIndex, funcs template.FuncMap) {
+	issues := make(map[string]ValidationIssue)
+	for _, a := range assertions {
+		if msg := a.evaluate(crs, funcs); msg != "" {
+			issues[a.Name] = ValidationIssue{Msg: msg}
+		}
+	}
+	if len(issues) == 0 {
+		return
+	}
+	if s.ValidationIssues == nil {
+		s.ValidationIssues = make(map[string]map[string]ValidationIssue)
+	}
+	s.ValidationIssues[RelationshipAssertionsGroup] = issues
+}
+
+// assertionFuncs returns the functions of the assertions that depend on the comparison, lookupLive looks up the CRs of
+// the live cluster when comparing one.
+func (o *Options) assertionFuncs() template.FuncMap {
+	if o.liveLookup == nil {
+		return template.FuncMap{}
+	}
+	return template.FuncMap{lookupLiveFunc: o.liveLookup.lookup}
+}
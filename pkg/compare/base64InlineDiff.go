@@ -0,0 +1,40 @@
+package compare
+
+import (
+	"encoding/base64"
+)
+
+const (
+	base64Decoded      inlineDiffType = "base64Decoded"
+	base64DecodedRegex inlineDiffType = "base64DecodedRegex"
+)
+
+// Base64DecodedInlineDiff compares the plain text value of the template to the base64 encoded value of the cluster CR,
+// as in the data of Secrets. The decoded value has to be equal to the template or, if set, match it with the Inner
+// inline diff.
+type Base64DecodedInlineDiff struct {
+	Inner InlineDiff
+}
+
+func (id Base64DecodedInlineDiff) Diff(templateValue, crValue string, sharedCapturedValues CapturedValues) (string, CapturedValues) {
+	decoded, err := base64.StdEncoding.DecodeString(crValue)
+	if err != nil {
+		return templateValue, sharedCapturedValues
+	}
+	result := templateValue
+	if id.Inner != nil {
+		result, sharedCapturedValues = id.Inner.Diff(templateValue, string(decoded), sharedCapturedValues)
+	}
+	if result == string(decoded) {
+		return crValue, sharedCapturedValues
+	}
+	// The template is shown in plain text, the differences are easier to read than between encoded values
+	return result, sharedCapturedValues
+}
+
+func (id Base64DecodedInlineDiff) Validate(templateValue string) error {
+	if id.Inner != nil {
+		return id.Inner.Validate(templateValue) // nolint:wrapcheck
+	}
+	return nil
+}
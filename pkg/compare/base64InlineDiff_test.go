@@ -0,0 +1,46 @@
+package compare
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineBase64DecodedDiff(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name       string
+		inner      InlineDiff
+		template   string
+		input      string
+		expected   string
+		expectedCg CapturedValues
+	}{
+		{name: "equal", template: "user: admin", input: encode("user: admin"), expected: encode("user: admin")},
+		{name: "different", template: "user: admin", input: encode("user: root"), expected: "user: admin"},
+		{name: "not encoded", template: "user: admin", input: "user: admin", expected: "user: admin"},
+		{
+			name:       "regex match",
+			inner:      RegexInlineDiff{},
+			template:   "user: (?<user>[a-z]+)",
+			input:      encode("user: root"),
+			expected:   encode("user: root"),
+			expectedCg: CapturedValues{caps: map[string][]string{"user": {"root"}}},
+		},
+		{name: "regex mismatch", inner: RegexInlineDiff{}, template: "user: [a-z]+", input: encode("user: 123"), expected: "user: [a-z]+"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := Base64DecodedInlineDiff{Inner: test.inner}
+			assert.NoError(t, diff.Validate(test.template))
+			result, cg := diff.Diff(test.template, test.input, CapturedValues{})
+			assert.Equal(t, test.expected, result)
+			assert.Equal(t, test.expectedCg, cg)
+		})
+	}
+}
+
+func TestInlineBase64DecodedValidate(t *testing.T) {
+	assert.Error(t, Base64DecodedInlineDiff{Inner: RegexInlineDiff{}}.Validate("user: ("))
+}
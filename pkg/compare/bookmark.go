@@ -0,0 +1,175 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	bookmarkNotInLive  = "Bookmarks (--bookmark, --since) can only be used when comparing live clusters"
+	bookmarkIsStale    = "Ignoring bookmark %s: %s, all the CRs will be compared"
+	bookmarkRefChanged = "the reference changed since the bookmark was recorded"
+	bookmarkValidation = "the bookmark was recorded with --only-validation and contains no diffs"
+	bookmarkOptions    = "the options of the comparison (-c, -p, --severity-rules...) changed since the bookmark was recorded"
+)
+
+// Bookmark records the resourceVersion of each cluster CR compared in a live run together with its result. A later run
+// that is passed the bookmark (--since) still lists all the cluster CRs, but it reuses the results of the CRs whose
+// resourceVersion didn't change instead of rendering and diffing them again: only the CRs that changed since the
+// bookmark are compared.
+type Bookmark struct {
+	MetadataHash string `json:"metadataHash"`
+	// OptionsHash is the hash of the options the results were compared with, see comparisonOptionsHash
+	OptionsHash    string                   `json:"optionsHash"`
+	OnlyValidation bool                     `json:"onlyValidation,omitempty"`
+	CRs            map[string]*BookmarkedCR `json:"crs"`
+	lock           sync.Mutex
+}
+
+// BookmarkedCR is the result of the comparison of a single cluster CR.
+type BookmarkedCR struct {
+	ResourceVersion string  `json:"resourceVersion"`
+	Namespace       string  `json:"namespace,omitempty"`
+	TemplatePath    string  `json:"templatePath"`
+	Diff            DiffSum `json:"diff"`
+	// Captured are the values of the capturegroups captured by the template, they are bound again when the result is
+	// reused so the other CRs and the consistent capturegroups still see them
+	Captured map[string][]string `json:"captured,omitempty"`
+}
+
+func newBookmark(metadataHash, optionsHash string, onlyValidation bool) *Bookmark {
+	return &Bookmark{
+		MetadataHash:   metadataHash,
+		OptionsHash:    optionsHash,
+		OnlyValidation: onlyValidation,
+		CRs:            make(map[string]*BookmarkedCR),
+	}
+}
+
+// LoadBookmark reads a bookmark recorded by a previous run. The bookmark is discarded (nil is returned) if it can't be
+// reused with the current reference and options.
+func LoadBookmark(path, metadataHash, optionsHash string, onlyValidation bool) (*Bookmark, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bookmark: %w", err)
+	}
+	b := newBookmark("", "", false)
+	err = yaml.Unmarshal(contents, b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bookmark %s: %w", path, err)
+	}
+	if b.MetadataHash != metadataHash {
+		klog.Warningf(bookmarkIsStale, path, bookmarkRefChanged)
+		return nil, nil
+	}
+	if b.OptionsHash != optionsHash {
+		klog.Warningf(bookmarkIsStale, path, bookmarkOptions)
+		return nil, nil
+	}
+	if b.OnlyValidation && !onlyValidation {
+		klog.Warningf(bookmarkIsStale, path, bookmarkValidation)
+		return nil, nil
+	}
+	return b, nil
+}
+
+// comparisonOptionsHash hashes the options that change the result of the comparison of a cluster CR, other than the
+// reference and --only-validation. The results recorded in a bookmark are only reused with the same options.
+func (o *Options) comparisonOptionsHash() string {
+	options := struct {
+		DiffConfig        UserConfig
+		UserOverrides     []*UserOverride
+		SeverityRules     *SeverityRules
+		ShowManagedFields bool
+		IgnoreManagers    []string
+		PreprocessExecs   []string
+		CRDSchemas        string
+		ValidateTemplates bool
+		SchemaDefaults    bool
+		DiffEngine        string
+		SideBySide        bool
+		Color             bool
+		MatchStrategy     string
+	}{
+		DiffConfig:        o.userConfig,
+		UserOverrides:     o.userOverrides,
+		SeverityRules:     o.severityRules,
+		ShowManagedFields: o.ShowManagedFields,
+		IgnoreManagers:    o.ignoreManagers,
+		PreprocessExecs:   o.preprocessExecs,
+		CRDSchemas:        o.crdSchemasPath,
+		ValidateTemplates: o.validateTemplates,
+		SchemaDefaults:    o.schemaDefaults,
+		DiffEngine:        o.diffEngine,
+		SideBySide:        o.diffFormat.sideBySide,
+		Color:             o.diffFormat.color,
+		MatchStrategy:     o.matchStrategy,
+	}
+	contents, err := json.Marshal(options)
+	if err != nil {
+		klog.Warning("There was an error in hashing the options of the comparison, the bookmark won't be reused")
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(contents))
+}
+
+// Write stores the bookmark in the file.
+func (b *Bookmark) Write(path string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	contents, err := yaml.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bookmark: %w", err)
+	}
+	err = os.WriteFile(path, contents, 0o644) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to write bookmark: %w", err)
+	}
+	return nil
+}
+
+// lookup returns the result recorded for the cluster CR if the CR didn't change since it was recorded and it was
+// compared to one of the templates it's currently correlated to.
+func (b *Bookmark) lookup(clusterCR *unstructured.Unstructured, temps []ReferenceTemplate) (*BookmarkedCR, ReferenceTemplate, bool) {
+	if b == nil || clusterCR.GetResourceVersion() == "" {
+		return nil, nil, false
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	cr, ok := b.CRs[apiKindNamespaceName(clusterCR)]
+	if !ok || cr.ResourceVersion != clusterCR.GetResourceVersion() {
+		return nil, nil, false
+	}
+	for _, temp := range temps {
+		if temp.GetIdentifier() == cr.TemplatePath {
+			return cr, temp, true
+		}
+	}
+	return nil, nil, false
+}
+
+// record adds the result of the comparison of the cluster CR to the bookmark. The resourceVersion is passed
+// separately as it's omitted from the cluster CR when it's compared.
+func (b *Bookmark) record(clusterCR *unstructured.Unstructured, resourceVersion string, temp ReferenceTemplate, diff DiffSum,
+	captured map[string][]string) {
+	if b == nil || resourceVersion == "" {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.CRs[apiKindNamespaceName(clusterCR)] = &BookmarkedCR{
+		ResourceVersion: resourceVersion,
+		Namespace:       clusterCR.GetNamespace(),
+		TemplatePath:    temp.GetIdentifier(),
+		Diff:            diff,
+		Captured:        captured,
+	}
+}
@@ -0,0 +1,57 @@
+package compare
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBookmarkRoundTrip(t *testing.T) {
+	temp := ReferenceTemplateV1{Path: "cm.yaml"}
+	other := ReferenceTemplateV1{Path: "other.yaml"}
+	cr := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":            "cm",
+			"namespace":       "default",
+			"resourceVersion": "10",
+		},
+	}}
+	diff := DiffSum{CRName: apiKindNamespaceName(cr), CorrelatedTemplate: "cm.yaml", DiffOutput: "some diff"}
+
+	b := newBookmark("hash", "options", false)
+	b.record(cr, cr.GetResourceVersion(), temp, diff, map[string][]string{"name": {"cm"}})
+	file := path.Join(t.TempDir(), "bookmark.yaml")
+	require.NoError(t, b.Write(file))
+
+	loaded, err := LoadBookmark(file, "hash", "options", false)
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+
+	prev, matched, ok := loaded.lookup(cr, []ReferenceTemplate{other, temp})
+	require.True(t, ok)
+	assert.Equal(t, temp, matched)
+	assert.Equal(t, diff, prev.Diff)
+	assert.Equal(t, "default", prev.Namespace)
+	assert.Equal(t, map[string][]string{"name": {"cm"}}, prev.Captured)
+
+	_, _, ok = loaded.lookup(cr, []ReferenceTemplate{other})
+	assert.False(t, ok, "CR is correlated to a different template")
+
+	changed := cr.DeepCopy()
+	changed.SetResourceVersion("11")
+	_, _, ok = loaded.lookup(changed, []ReferenceTemplate{temp})
+	assert.False(t, ok, "CR changed since the bookmark was recorded")
+
+	loaded, err = LoadBookmark(file, "other-hash", "options", false)
+	require.NoError(t, err)
+	assert.Nil(t, loaded, "reference changed since the bookmark was recorded")
+
+	loaded, err = LoadBookmark(file, "hash", "other-options", false)
+	require.NoError(t, err)
+	assert.Nil(t, loaded, "options changed since the bookmark was recorded")
+}
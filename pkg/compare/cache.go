@@ -0,0 +1,130 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/discovery"
+	diskcached "k8s.io/client-go/discovery/cached/disk"
+	"k8s.io/client-go/util/homedir"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	cacheNotInLive = "The cluster CRs can only be cached (--cache-ttl) when comparing live clusters"
+
+	// discoveryCacheTTL is how long the resource types of the cluster are cached, the TTL used by kubectl
+	discoveryCacheTTL = 6 * time.Hour
+)
+
+var invalidCacheDirChars = regexp.MustCompile(`[^(\w/.)]`)
+
+// defaultCacheDir returns the cache directory of kubectl, $KUBECACHEDIR or ~/.kube/cache.
+func defaultCacheDir() string {
+	if dir := os.Getenv("KUBECACHEDIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(homedir.HomeDir(), ".kube", "cache")
+}
+
+// hostCacheDir returns the directory of the cache of the API server, as kubectl names it.
+func hostCacheDir(parent, host string) string {
+	schemelessHost := strings.Replace(strings.Replace(host, "https://", "", 1), "http://", "", 1)
+	return filepath.Join(parent, invalidCacheDirChars.ReplaceAllString(schemelessHost, "_"))
+}
+
+// discoveryClient returns the client listing the resource types of the cluster. The factory already caches them in
+// the default cache directory of kubectl, the client caches them in --cache-dir instead when it's passed.
+func (o *Options) discoveryClient(f kcmdutil.Factory) (discovery.CachedDiscoveryInterface, error) {
+	if !o.cacheDirChanged {
+		return f.ToDiscoveryClient() // nolint:wrapcheck
+	}
+	config, err := f.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the cluster config: %w", err)
+	}
+	return diskcached.NewCachedDiscoveryClientForConfig(config, // nolint:wrapcheck
+		hostCacheDir(filepath.Join(o.cacheDir, "discovery"), config.Host), filepath.Join(o.cacheDir, "http"),
+		discoveryCacheTTL)
+}
+
+// resourceCache stores the CRs collected from a live cluster in the cache directory, a later run comparing the same
+// types of CRs of the same cluster reuses them instead of listing them again until they are older than the TTL.
+type resourceCache struct {
+	path string
+	ttl  time.Duration
+}
+
+type cachedResources struct {
+	CollectedAt time.Time        `json:"collectedAt"`
+	Items       []map[string]any `json:"items"`
+}
+
+// newResourceCache returns the cache of the CRs of the types collected from the cluster.
+func newResourceCache(cacheDir, host string, types []string, ttl time.Duration) *resourceCache {
+	sorted := slices.Clone(types)
+	slices.Sort(sorted)
+	hash := sha256.Sum256([]byte(strings.Join(sorted, ",")))
+	return &resourceCache{
+		path: filepath.Join(hostCacheDir(filepath.Join(cacheDir, "cluster-compare"), host), hex.EncodeToString(hash[:])+".json"),
+		ttl:  ttl,
+	}
+}
+
+// load returns the cached CRs, it returns false when there are none or when they expired.
+func (c *resourceCache) load(now time.Time) ([]*unstructured.Unstructured, bool, error) {
+	content, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read the cached CRs: %w", err)
+	}
+	var cached cachedResources
+	if err := json.Unmarshal(content, &cached); err != nil {
+		return nil, false, fmt.Errorf("failed to parse the cached CRs %s: %w", c.path, err)
+	}
+	if now.Sub(cached.CollectedAt) > c.ttl {
+		return nil, false, nil
+	}
+	crs := make([]*unstructured.Unstructured, 0, len(cached.Items))
+	for _, item := range cached.Items {
+		crs = append(crs, &unstructured.Unstructured{Object: item})
+	}
+	return crs, true, nil
+}
+
+// store caches the CRs collected from the cluster.
+func (c *resourceCache) store(crs []*unstructured.Unstructured, now time.Time) error {
+	cached := cachedResources{CollectedAt: now, Items: make([]map[string]any, 0, len(crs))}
+	for _, cr := range crs {
+		cached.Items = append(cached.Items, cr.Object)
+	}
+	content, err := json.Marshal(cached)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the CRs: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create the cache directory: %w", err)
+	}
+	// The CRs are written to a temporary file first so concurrent runs never read a partial cache
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write the cached CRs: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write the cached CRs: %w", err)
+	}
+	return nil
+}
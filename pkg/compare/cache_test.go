@@ -0,0 +1,73 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestResourceCache(t *testing.T) {
+	dir := t.TempDir()
+	collectedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cr := &unstructured.Unstructured{}
+	cr.SetAPIVersion("v1")
+	cr.SetKind("ConfigMap")
+	cr.SetName("cm")
+	cr.SetNamespace("default")
+
+	cache := newResourceCache(dir, "https://api.cluster:6443", []string{"ConfigMap", "Deployment.v1.apps"}, time.Hour)
+	crs, ok, err := cache.load(collectedAt)
+	require.NoError(t, err)
+	assert.False(t, ok, "nothing was cached yet")
+	assert.Empty(t, crs)
+
+	require.NoError(t, cache.store([]*unstructured.Unstructured{cr}, collectedAt))
+
+	t.Run("Fresh", func(t *testing.T) {
+		crs, ok, err := cache.load(collectedAt.Add(30 * time.Minute))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, crs, 1)
+		assert.Equal(t, cr.Object, crs[0].Object)
+	})
+
+	t.Run("Same Types In Another Order", func(t *testing.T) {
+		other := newResourceCache(dir, "https://api.cluster:6443", []string{"Deployment.v1.apps", "ConfigMap"}, time.Hour)
+		_, ok, err := other.load(collectedAt)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Other Types", func(t *testing.T) {
+		other := newResourceCache(dir, "https://api.cluster:6443", []string{"ConfigMap"}, time.Hour)
+		_, ok, err := other.load(collectedAt)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Other Cluster", func(t *testing.T) {
+		other := newResourceCache(dir, "https://api.other:6443", []string{"ConfigMap", "Deployment.v1.apps"}, time.Hour)
+		_, ok, err := other.load(collectedAt)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		_, ok, err := cache.load(collectedAt.Add(2 * time.Hour))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(cache.path, []byte("{"), 0o600))
+		_, ok, err := cache.load(collectedAt)
+		assert.ErrorContains(t, err, "failed to parse the cached CRs")
+		assert.False(t, ok)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"k8s.io/klog/v2"
@@ -50,6 +51,75 @@ func (c *CapturedValues) groupValues(name string) string {
 	return ""
 }
 
+// clone returns a copy of the captured values that can be updated independently.
+func (c *CapturedValues) clone() CapturedValues {
+	res := CapturedValues{}
+	for name, values := range c.caps {
+		for _, value := range values {
+			res.addCapture(name, value)
+		}
+	}
+	return res
+}
+
+// bindings returns the values captured by each capturegroup, multiple values are separated by |.
+func (c *CapturedValues) bindings() map[string]string {
+	if len(c.caps) == 0 {
+		return nil
+	}
+	res := make(map[string]string, len(c.caps))
+	for name, values := range c.caps {
+		res[name] = strings.Join(values, " | ")
+	}
+	return res
+}
+
+// templateCapturegroups records the values of the capturegroups that are shared by all the CRs matched by the same
+// template. It's used concurrently by the workers processing the cluster CRs.
+type templateCapturegroups struct {
+	names  []string
+	lock   sync.Mutex
+	values map[string]CapturedValues
+}
+
+func newTemplateCapturegroups(names []string) *templateCapturegroups {
+	if len(names) == 0 {
+		return nil
+	}
+	return &templateCapturegroups{names: names, values: make(map[string]CapturedValues)}
+}
+
+// get returns the values bound to the shared capturegroups by the previous CRs matched by the template.
+func (t *templateCapturegroups) get(template string) CapturedValues {
+	if t == nil {
+		return CapturedValues{}
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	values := t.values[template]
+	return values.clone()
+}
+
+// bind records the values captured for a CR matched by the template, for the shared capturegroups that aren't bound
+// yet.
+func (t *templateCapturegroups) bind(template string, captured CapturedValues) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	values := t.values[template]
+	for _, name := range t.names {
+		if _, ok := values.caps[name]; ok {
+			continue
+		}
+		for _, value := range captured.caps[name] {
+			values.addCapture(name, value)
+		}
+	}
+	t.values[template] = values
+}
+
 type CapturegroupsInlineDiff struct{}
 
 type diffInfo struct {
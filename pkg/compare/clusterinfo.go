@@ -0,0 +1,91 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog/v2"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	clusterVersionKind  = "ClusterVersion"
+	clusterVersionGroup = "config.openshift.io"
+	clusterVersionName  = "version"
+)
+
+// ClusterInfo identifies the live cluster the CRs were collected from, so a saved output can be traced back to it.
+// The cluster ID and the OpenShift version are only known on OpenShift clusters.
+type ClusterInfo struct {
+	Context           string `json:"Context,omitempty"`
+	Server            string `json:"Server,omitempty"`
+	ClusterID         string `json:"ClusterID,omitempty"`
+	KubernetesVersion string `json:"KubernetesVersion,omitempty"`
+	OpenShiftVersion  string `json:"OpenShiftVersion,omitempty"`
+}
+
+// getClusterInfo collects the information identifying the cluster of the factory. context is the kubeconfig context
+// of the cluster, the current context of the kubeconfig when it's empty. The information that can't be read is left
+// empty, it doesn't prevent comparing the cluster.
+func getClusterInfo(f kcmdutil.Factory, context string, supportedTypes map[string][]schema.GroupVersion) *ClusterInfo {
+	info := &ClusterInfo{Context: context}
+	if info.Context == "" {
+		if rawConfig, err := f.ToRawKubeConfigLoader().RawConfig(); err == nil {
+			info.Context = rawConfig.CurrentContext
+		}
+	}
+	if config, err := f.ToRESTConfig(); err == nil {
+		info.Server = config.Host
+	}
+	if c, err := f.ToDiscoveryClient(); err == nil {
+		if v, err := c.ServerVersion(); err == nil {
+			info.KubernetesVersion = v.GitVersion
+		} else {
+			klog.Warningf("Failed to get the Kubernetes version of the cluster: %s", err)
+		}
+	}
+	for _, gv := range supportedTypes[clusterVersionKind] {
+		if gv.Group != clusterVersionGroup {
+			continue
+		}
+		clusterVersion, err := getClusterVersion(f.NewBuilder(), strings.Join([]string{clusterVersionKind, gv.Version, gv.Group}, "."))
+		if err != nil {
+			klog.Warningf("Failed to get the version of the cluster: %s", err)
+		} else if clusterVersion != nil {
+			info.ClusterID, _, _ = unstructured.NestedString(clusterVersion.Object, "spec", "clusterID")
+			info.OpenShiftVersion, _, _ = unstructured.NestedString(clusterVersion.Object, "status", "desired", "version")
+		}
+		break
+	}
+	return info
+}
+
+// getClusterVersion returns the ClusterVersion of the cluster, nil if there isn't any.
+func getClusterVersion(builder *resource.Builder, clusterVersionType string) (*unstructured.Unstructured, error) {
+	r := builder.
+		Unstructured().
+		ResourceTypes(clusterVersionType).
+		SelectAllParam(true).
+		Flatten().
+		Do()
+	infos, err := r.Infos()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the ClusterVersions: %w", err)
+	}
+	for _, i := range infos {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(i.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", i.Name, err)
+		}
+		if clusterVersion := (&unstructured.Unstructured{Object: obj}); clusterVersion.GetName() == clusterVersionName {
+			return clusterVersion, nil
+		}
+	}
+	return nil, nil
+}
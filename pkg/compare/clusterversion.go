@@ -0,0 +1,146 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/openshift/kube-compare/pkg/compare/outputschema"
+	"k8s.io/klog/v2"
+)
+
+const (
+	clusterVersionNotInLive = "The cluster version (--cluster-version) can only be passed when comparing local CRs, " +
+		"the version of the live cluster is used"
+	invalidClusterVersion      = "Invalid --cluster-version value %q: %s"
+	invalidReferenceVersion    = "invalid %s %q of the reference: %w"
+	clusterVersionRangeInverse = "the minClusterVersion %s of the reference is greater than its maxClusterVersion %s"
+	unknownClusterVersion      = "The reference applies to the cluster versions %s but the version of the cluster isn't " +
+		"known, pass it with --cluster-version when comparing local CRs"
+
+	ClusterVersionGroup = "Reference not applying to the cluster"
+	ClusterVersionIssue = "Cluster version"
+	ClusterVersionMsg   = "The reference applies to the cluster versions %s, the version of the cluster is %s. " +
+		"The cluster CRs weren't compared"
+)
+
+// parseVersion parses a cluster version, its pre-release and build metadata are ignored so the release candidates
+// of a version are considered that version.
+func parseVersion(v string) (*semver.Version, error) {
+	parsed, err := semver.NewVersion(strings.TrimSpace(v))
+	if err != nil {
+		return nil, err // nolint:wrapcheck
+	}
+	return semver.New(parsed.Major(), parsed.Minor(), parsed.Patch(), "", ""), nil
+}
+
+// validateClusterVersionRange checks the minClusterVersion and maxClusterVersion of a reference, both are optional.
+func validateClusterVersionRange(minVersion, maxVersion string) error {
+	var minParsed, maxParsed *semver.Version
+	var err error
+	if minVersion != "" {
+		minParsed, err = parseVersion(minVersion)
+		if err != nil {
+			return fmt.Errorf(invalidReferenceVersion, "minClusterVersion", minVersion, err)
+		}
+	}
+	if maxVersion != "" {
+		maxParsed, err = parseVersion(maxVersion)
+		if err != nil {
+			return fmt.Errorf(invalidReferenceVersion, "maxClusterVersion", maxVersion, err)
+		}
+	}
+	if minParsed != nil && maxParsed != nil && minParsed.GreaterThan(maxParsed) {
+		return fmt.Errorf(clusterVersionRangeInverse, minVersion, maxVersion)
+	}
+	return nil
+}
+
+// describeClusterVersionRange returns the range of cluster versions as it's printed in the validation issue.
+func describeClusterVersionRange(minVersion, maxVersion string) string {
+	switch {
+	case minVersion != "" && maxVersion != "":
+		return fmt.Sprintf("from %s to %s", minVersion, maxVersion)
+	case minVersion != "":
+		return fmt.Sprintf("from %s", minVersion)
+	default:
+		return fmt.Sprintf("up to %s", maxVersion)
+	}
+}
+
+// clusterVersionApplies reports whether the cluster version is in the range of the reference. The versions are
+// inclusive and a version without a patch (4.17) includes all its patch versions (4.17.3).
+func clusterVersionApplies(minVersion, maxVersion, clusterVersion string) (bool, error) {
+	version, err := parseVersion(clusterVersion)
+	if err != nil {
+		return false, fmt.Errorf("invalid cluster version %q: %w", clusterVersion, err)
+	}
+	if minVersion != "" {
+		minParsed, err := parseVersion(minVersion)
+		if err != nil {
+			return false, err
+		}
+		if version.LessThan(minParsed) {
+			return false, nil
+		}
+	}
+	if maxVersion != "" {
+		maxParsed, err := parseVersion(maxVersion)
+		if err != nil {
+			return false, err
+		}
+		if version.GreaterThan(maxParsed) && !versionHasPrefix(version.String(), strings.TrimPrefix(maxVersion, "v")) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// comparedClusterVersion returns the version of the compared cluster: --cluster-version when comparing local CRs, the
+// OpenShift version of the live cluster, or its Kubernetes version if it isn't an OpenShift cluster.
+func (o *Options) comparedClusterVersion() string {
+	if o.local || o.clusterInfo == nil {
+		return o.clusterVersion
+	}
+	if o.clusterInfo.OpenShiftVersion != "" {
+		return o.clusterInfo.OpenShiftVersion
+	}
+	return o.clusterInfo.KubernetesVersion
+}
+
+// clusterVersionIssue returns the validation issue reporting that the reference doesn't apply to the version of the
+// compared cluster, nil when it applies or when the reference doesn't restrict the cluster versions.
+func (o *Options) clusterVersionIssue() (*ValidationIssue, error) {
+	minVersion, maxVersion := o.ref.GetClusterVersionRange()
+	if minVersion == "" && maxVersion == "" {
+		return nil, nil
+	}
+	versionRange := describeClusterVersionRange(minVersion, maxVersion)
+	clusterVersion := o.comparedClusterVersion()
+	if clusterVersion == "" {
+		klog.Warningf(unknownClusterVersion, versionRange)
+		return nil, nil
+	}
+	applies, err := clusterVersionApplies(minVersion, maxVersion, clusterVersion)
+	if err != nil || applies {
+		return nil, err
+	}
+	return &ValidationIssue{Msg: fmt.Sprintf(ClusterVersionMsg, versionRange, clusterVersion)}, nil
+}
+
+// notApplyingOutput is the output of the comparison of a cluster the reference doesn't apply to, the cluster CRs
+// aren't compared as their diffs would be meaningless.
+func (o *Options) notApplyingOutput(issue *ValidationIssue) Output {
+	sum := &Summary{
+		Cluster:      o.clusterInfo,
+		UnmatchedCRS: []string{},
+		MetadataHash: metadataHash(o.ref, o.templates),
+		ValidationIssues: map[string]map[string]ValidationIssue{
+			ClusterVersionGroup: {ClusterVersionIssue: *issue},
+		},
+	}
+	return Output{OutputAPIVersion: outputschema.APIVersion, Summary: sum, Diffs: &[]DiffSum{}, patches: o.newUserOverrides,
+		templates: o.templates}
+}
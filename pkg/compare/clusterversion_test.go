@@ -0,0 +1,44 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterVersionApplies(t *testing.T) {
+	tests := []struct {
+		name           string
+		minVersion     string
+		maxVersion     string
+		clusterVersion string
+		applies        bool
+	}{
+		{name: "in range", minVersion: "4.16", maxVersion: "4.17", clusterVersion: "4.16.5", applies: true},
+		{name: "below min", minVersion: "4.16", maxVersion: "4.17", clusterVersion: "4.15.30", applies: false},
+		{name: "patch of max", minVersion: "4.16", maxVersion: "4.17", clusterVersion: "4.17.12", applies: true},
+		{name: "above max", minVersion: "4.16", maxVersion: "4.17", clusterVersion: "4.18.0", applies: false},
+		{name: "above patch max", maxVersion: "4.17.2", clusterVersion: "4.17.3", applies: false},
+		{name: "release candidate", minVersion: "4.16", clusterVersion: "4.16.0-rc.1", applies: true},
+		{name: "kubernetes version", minVersion: "1.29", clusterVersion: "v1.30.2", applies: true},
+		{name: "prefixed max", maxVersion: "v1.30", clusterVersion: "v1.30.2", applies: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			applies, err := clusterVersionApplies(test.minVersion, test.maxVersion, test.clusterVersion)
+			require.NoError(t, err)
+			assert.Equal(t, test.applies, applies)
+		})
+	}
+}
+
+func TestValidateClusterVersionRange(t *testing.T) {
+	require.NoError(t, validateClusterVersionRange("4.16", "4.17"))
+	require.NoError(t, validateClusterVersionRange("", "4.17"))
+	require.NoError(t, validateClusterVersionRange("4.17", "4.17"))
+	assert.ErrorContains(t, validateClusterVersionRange("four", ""), `invalid minClusterVersion "four" of the reference`)
+	assert.ErrorContains(t, validateClusterVersionRange("4.18", "4.17"), "is greater than its maxClusterVersion")
+}
@@ -4,25 +4,33 @@ package compare
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/gosimple/slug"
+	"github.com/openshift/kube-compare/pkg/compare/outputschema"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/openapi3"
 	"k8s.io/klog/v2"
 	"k8s.io/kubectl/pkg/cmd/diff"
 	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -85,6 +93,9 @@ var (
 		# Compare a known valid reference configuration with a live cluster and with a user config:
 		kubectl cluster-compare -r ./reference/metadata.yaml -c ./user_config
 
+		# Compare a known valid reference configuration with the manifests rendered by helm:
+		helm template ./chart | kubectl cluster-compare -r ./reference/metadata.yaml -f -
+
 		# Run a known valid reference configuration with a must-gather output:
 		kubectl cluster-compare -r ./reference/metadata.yaml -f "must-gather*/*/cluster-scoped-resources","must-gather*/*/namespaces" -R
 	`)
@@ -101,15 +112,40 @@ const (
 	DiffsFoundMsg           = "there are differences between the cluster CRs and the reference CRs"
 	noTemplateForGeneration = "Requested user override generation but no entires for which template to generate overrides for"
 	noReason                = "Reason required when generating overrides"
+	onlyValidationNoPatches = "Override generation requires diffs and can't be used with --only-validation"
+	unknownPatchType        = "Unknown --patch-type value %q, supported values: %s"
+	invalidOverrideSelector = "Invalid --generate-override-for value %q: %s"
+	pruneWithoutOverrides   = "Pruning the user overrides requires the user overrides to be passed with --overrides"
+	unknownGroupBy          = "Unknown --group-by value %q, supported values: %s"
+	unknownOutputFormat     = "Unknown --output value %q, supported values: %s"
+	unknownMatchStrategy    = "Unknown --match-strategy value %q, supported values: %s"
+	correlationReportOutput = "The correlation report is printed as a table, or with --output json or yaml"
 )
 
 const (
 	Json      string = "json"
 	Yaml      string = "yaml"
 	PatchYaml string = "generate-patches"
+	Markdown  string = "markdown"
+	Junit     string = "junit"
+)
+
+var OutputFormats = []string{Json, Yaml, PatchYaml, Markdown, Junit}
+
+const (
+	GroupByNamespace string = "namespace"
+	GroupByPart      string = "part"
+	GroupByKind      string = "kind"
 )
 
-var OutputFormats = []string{Json, Yaml, PatchYaml}
+var GroupByOptions = []string{GroupByNamespace, GroupByPart, GroupByKind}
+
+// queuedCRsPerWorker is the number of cluster CRs queued for each worker while the CRs are collected
+const queuedCRsPerWorker = 4
+
+// now returns the current time, the expiry of severity rules and user overrides and the age of user overrides are
+// relative to it, and it times the comparison of the CRs
+var now = time.Now
 
 type Options struct {
 	CRs                resource.FilenameOptions
@@ -117,27 +153,105 @@ type Options struct {
 	diffConfigFileName string
 	diffAll            bool
 	verboseOutput      bool
+	onlyValidation     bool
+	correlationReport  bool
 	ShowManagedFields  bool
+	ignoreManagers     []string
+	// maintenanceWindow is the annotation that puts the cluster CRs in a maintenance window until the time it's set to
+	maintenanceWindow  string
 	OutputFormat       string
+	groupBy            string
+	coverage           bool
+	minCoverage        float64
+	crdSchemasPath     string
+	publicKeyPath      string
+	validateTemplates  bool
+	schemaDefaults     bool
+	sincePath          string
+	outputDir          string
+	recordDir          string
+	recorder           *crRecorder
+	bookmarkPath       string
+	severityRulesPath  string
+	kustomizeBuildOpts []string
+	detectFlapping     bool
+	diffEngine         string
+	matchStrategy      string
+	diffStyle          string
+	color              string
+	diffFormat         diffFormat
+	progressMode       string
+	progress           *progressReporter
+	cacheDir           string
+	cacheDirChanged    bool
+	cacheTTL           time.Duration
+	resourceCache      *resourceCache
+	chunkSize          int64
+	snapshot           bool
+	snapshotLister     *snapshotLister
+	contexts           []string
+	templateFilter     templateFilter
+	timeout            time.Duration
+	retry              retryPolicy
+	fetchFailures      *fetchFailures
+	warnings           *warningLog
+	// ctx is the context of the running comparison, it's done when the comparison times out
+	ctx      context.Context
+	timedOut bool
+	// contextName is the context of the cluster compared by the options, when comparing the clusters of --contexts
+	contextName string
+	clusterInfo *ClusterInfo
+	// clusterVersion is the version of the cluster of the local CRs
+	clusterVersion string
+
+	referenceCatalogPath string
+	autoReference        bool
+	recommendations      *ReferenceRecommendations
 
 	builder        *resource.Builder
+	newBuilder     func() *resource.Builder
 	correlator     *MultiCorrelator[ReferenceTemplate]
 	metricsTracker *MetricsTracker
+	capturegroups  *templateCapturegroups
 	templates      []ReferenceTemplate
 	local          bool
 	types          []string
 	ref            Reference
+	referenceHash  string
 	userConfig     UserConfig
-	Concurrency    int
+	crdSchemas     *CRDSchemas
+	defaulter      *schemaDefaulter
+	since          *Bookmark
+	bookmark       *Bookmark
+	severityRules  *SeverityRules
+	kustomized     []byte
+	// stdinCRs is the content of the standard input when the CRs are passed by it (-f -)
+	stdinCRs    []byte
+	Concurrency int
+	// liveLookup looks up the CRs of the live cluster for lookupLive, it's nil when comparing local CRs
+	liveLookup *liveLookup
+	// clusterCRs are the compared cluster CRs, they are only kept when the reference has relationship assertions
+	clusterCRs *clusterCRIndex
+	// preprocessors transform the cluster CRs before they are correlated, the registered ones and --pre-process-exec
+	preprocessors   []CRPreprocessor
+	preprocessExecs []string
+	// onDiff is called by the workers with the diff of each cluster CR as soon as it's compared, see Engine.CompareStream
+	onDiff func(DiffSum)
 
 	userOverridesPath               string
 	userOverridesCorrelator         Correlator[*UserOverride]
 	userOverrides                   []*UserOverride
 	newUserOverrides                []*UserOverride
 	templatesToGenerateOverridesFor []string
+	overrideSelectors               []overrideSelector
 	overrideReason                  string
+	overridePatchType               string
+	failOnExpiredOverrides          bool
+	pruneOverridesPath              string
 
 	diff *diff.DiffProgram
+	// diffErrOut is shared by the diff programs running concurrently
+	diffErrOut io.Writer
 	genericiooptions.IOStreams
 }
 
@@ -181,6 +295,18 @@ func NewCmd(f kcmdutil.Factory, streams genericiooptions.IOStreams) *cobra.Comma
 		kcmdutil.CheckDiffErr(kcmdutil.UsageErrorf(cmd, err.Error()))
 		return nil
 	})
+	addFlags(cmd, options)
+	cmd.AddCommand(newServeCmd(f, streams))
+	cmd.AddCommand(newServeGRPCCmd())
+	cmd.AddCommand(newRenderCmd(streams))
+	cmd.AddCommand(newPullCmd(streams))
+	cmd.AddCommand(newReferenceInfoCmd(streams))
+
+	return cmd
+}
+
+// addFlags registers the flags of the comparison, they are shared by the command and by the serve subcommand.
+func addFlags(cmd *cobra.Command, options *Options) {
 	cmd.Flags().IntVar(&options.Concurrency, "concurrency", 4,
 		"Number of objects to process in parallel when diffing against the live version. Larger number = faster,"+
 			" but more memory, I/O and CPU over that shorter period of time.")
@@ -188,16 +314,154 @@ func NewCmd(f kcmdutil.Factory, streams genericiooptions.IOStreams) *cobra.Comma
 	cmd.Flags().StringVarP(&options.diffConfigFileName, "diff-config", "c", "", "Path to the user config file")
 	cmd.Flags().StringVarP(&options.referenceConfig, "reference", "r", "", "Path to reference config file.")
 	cmd.Flags().BoolVar(&options.ShowManagedFields, "show-managed-fields", options.ShowManagedFields, "If true, include managed fields in the diff.")
+	cmd.Flags().StringSliceVar(&options.ignoreManagers, "ignore-fields-managed-by", []string{},
+		"Comma separated names of field managers (metadata.managedFields) of the cluster CRs. The fields owned by them "+
+			"are removed from the cluster CRs and from the rendered templates before they are compared")
+	cmd.Flags().StringArrayVar(&options.preprocessExecs, "pre-process-exec", []string{},
+		"Command that transforms the cluster CRs before they are correlated to the templates, e.g. to normalize the "+
+			"quirks of a site. It's passed each CR as json on its standard input and prints the transformed CR on its "+
+			"standard output. Its arguments are separated by spaces. Can be repeated, the commands run in order")
 	cmd.Flags().BoolVarP(&options.diffAll, "all-resources", "A", options.diffAll,
 		"If present, In live mode will try to match all resources that are from the types mentioned in the reference. "+
 			"In local mode will try to match all resources passed to the command")
 	cmd.Flags().BoolVarP(&options.verboseOutput, "verbose", "v", options.verboseOutput, "Increases the verbosity of the tool")
+	cmd.Flags().BoolVar(&options.onlyValidation, "only-validation", options.onlyValidation,
+		"If present, only correlates the cluster CRs and reports missing and unmatched CRs. "+
+			"Diffs are not generated and the diff program is not run")
+	cmd.Flags().BoolVar(&options.correlationReport, "correlation-report", false,
+		"If present, only correlates the cluster CRs and prints for each of them the candidate templates, the chosen "+
+			"template and the correlator that matched it (exact, regex, owner reference, group fields or fallback). "+
+			"The CRs aren't diffed")
 
 	cmd.Flags().StringVarP(&options.userOverridesPath, "overrides", "p", "", "Path to user overrides")
-	cmd.Flags().StringSliceVar(&options.templatesToGenerateOverridesFor, "generate-override-for", []string{}, "Path for template file you wish to generate a override for")
+	cmd.Flags().StringSliceVar(&options.templatesToGenerateOverridesFor, "generate-override-for", []string{},
+		"Path for template file you wish to generate a override for. Append =kind/namespace/name (or =kind/name for "+
+			"cluster scoped CRs) to only generate overrides for the matching cluster CRs, they can be glob patterns")
 	cmd.Flags().StringVar(&options.overrideReason, "override-reason", "", "Reason for generating the override")
+	cmd.Flags().StringVar(&options.overridePatchType, "patch-type", mergePatch,
+		fmt.Sprintf("Type of the generated overrides. One of: (%s)", strings.Join(PatchTypes, ", ")))
+	cmd.Flags().BoolVar(&options.failOnExpiredOverrides, "fail-on-expired-overrides", false,
+		"If present, the command fails when a user override expired (expiresAt), instead of ignoring it with a warning")
+	cmd.Flags().StringVar(&options.pruneOverridesPath, "prune-overrides", "",
+		"Path of a file to write the user overrides passed by --overrides to, without the overrides that weren't "+
+			"applied to any of the cluster CRs")
+
+	cmd.Flags().StringSliceVar(&options.templateFilter.include, "include-templates", []string{},
+		"Comma separated glob patterns of the paths of the templates in the reference to compare, the other templates "+
+			"are ignored as if they weren't in the reference")
+	cmd.Flags().StringSliceVar(&options.templateFilter.exclude, "exclude-templates", []string{},
+		"Comma separated glob patterns of the paths of the templates in the reference to ignore, as if they weren't in "+
+			"the reference")
+	cmd.Flags().StringSliceVar(&options.templateFilter.parts, "part", []string{},
+		"Comma separated glob patterns of the names of the parts of the reference to compare, the templates of the other "+
+			"parts are ignored")
+	cmd.Flags().StringSliceVar(&options.templateFilter.components, "component", []string{},
+		"Comma separated glob patterns of the names of the components of the reference to compare, the templates of the "+
+			"other components are ignored")
 
 	cmd.Flags().StringVarP(&options.OutputFormat, "output", "o", "", fmt.Sprintf(`Output format. One of: (%s)`, strings.Join(OutputFormats, ", ")))
+	cmd.Flags().StringVar(&options.outputDir, "output-dir", "",
+		"Path of a directory to write the diff of each cluster CR with diffs to, in <part>/<component>/<CR>.diff, and the "+
+			"summary to, in summary.yaml. The output is still printed")
+	cmd.Flags().StringVar(&options.publicKeyPath, "verify-signature", "",
+		"Path to a PEM public key (as generated by cosign generate-key-pair) to verify the signature of the reference with "+
+			"before it's used. The reference must contain the sha256 checksums of its files in "+signedChecksumsFile+" and their "+
+			"signature (cosign sign-blob) in "+signedChecksumsFile+signatureExtension)
+	cmd.Flags().StringVar(&options.recordDir, "record", "",
+		"Path of a directory to write the cluster CRs read from the live cluster to, one file per CR. Comparing the "+
+			"directory later (-f <dir>) reproduces the comparison offline")
+	cmd.Flags().StringVar(&options.crdSchemasPath, "crd-schemas", "",
+		"Path to a directory with CRD definitions. When comparing local CRs the templates are checked against "+
+			"the kinds defined by the CRDs (and Kubernetes) and the rendered templates are validated against the CRD schemas")
+	cmd.Flags().BoolVar(&options.validateTemplates, "validate-templates", false,
+		"Report the rendered templates that don't match the CRD schemas of their kinds (unknown fields, wrong types...) as "+
+			"validation issues, instead of warnings. The CRDs are read from the live cluster, or from --crd-schemas when "+
+			"comparing local CRs")
+	cmd.Flags().BoolVar(&options.schemaDefaults, "schema-defaults", false,
+		"Set the defaults of the OpenAPI schemas of the kinds on the rendered templates before they are compared, as the API "+
+			"server does. The schemas are read from the live cluster, or from --crd-schemas when comparing local CRs")
+	cmd.Flags().StringVar(&options.bookmarkPath, "bookmark", "",
+		"Path of a file to record the resourceVersions of the cluster CRs and the results of the comparison in. "+
+			"Only supported when comparing live clusters")
+	cmd.Flags().StringVar(&options.sincePath, "since", "",
+		"Path of a bookmark file recorded by a previous run (--bookmark). All the cluster CRs are still listed, but the "+
+			"CRs that didn't change since the bookmark was recorded aren't rendered and diffed again, their previous "+
+			"results are reused")
+	cmd.Flags().StringVar(&options.maintenanceWindow, "maintenance-window", "",
+		"Annotation of the cluster CRs that puts them in a maintenance window until the RFC 3339 time it's set to, e.g. "+
+			"2024-06-01T18:00:00Z. The diffs of the CRs in a maintenance window are reported separately, as suppressed "+
+			"during maintenance, and don't make the command fail")
+	cmd.Flags().StringVar(&options.severityRulesPath, "severity-rules", "",
+		"Path to a file with rules that set the severity of diffs or acknowledge them. Only diffs with the error "+
+			"severity make the command fail")
+	cmd.Flags().StringSliceVar(&options.kustomizeBuildOpts, "kustomize-build-options", []string{},
+		"Options used to build the kustomization passed by -k, in the key[=value] format. One or more of: "+
+			"load-restrictor=(LoadRestrictionsRootOnly|LoadRestrictionsNone), reorder=(legacy|none), enable-helm, "+
+			"helm-command=<path>, add-managedby-label")
+	cmd.Flags().BoolVar(&options.detectFlapping, "detect-flapping", false,
+		"Treat each path passed by -f as a snapshot of the cluster CRs, in the order they were captured. The CRs of the "+
+			"last snapshot are compared and fields whose values changed between snapshots, matching the template only in "+
+			"some of them, are reported as flapping")
+	cmd.Flags().StringVar(&options.diffEngine, "diff-engine", DiffEngineExternal,
+		fmt.Sprintf("Engine used to diff the cluster CRs and the rendered templates. One of: (%s). The external engine "+
+			"runs diff, or the program set by KUBECTL_EXTERNAL_DIFF. The internal engine doesn't require diff to be installed "+
+			"and produces the output of diff -u", strings.Join(DiffEngines, ", ")))
+	cmd.Flags().StringVar(&options.matchStrategy, "match-strategy", MatchStrategyFields,
+		fmt.Sprintf("Scoring of the templates a cluster CR is correlated to, the CR is compared to the template with the "+
+			"lowest score. One of: (%s). fields counts the differing fields, lines and chars count the differing lines and "+
+			"characters of the yaml of the CR and of the template and weighted counts the differing fields with the fields "+
+			"of the metadata weighing less", strings.Join(MatchStrategies, ", ")))
+	cmd.Flags().StringVar(&options.diffStyle, "diff-style", DiffStyleUnified,
+		fmt.Sprintf("Style of the diffs. One of: (%s). Styles other than unified use the internal diff engine",
+			strings.Join(DiffStyles, ", ")))
+	cmd.Flags().StringVar(&options.color, "color", ColorAuto,
+		fmt.Sprintf("Colorize the diffs of the internal diff engine. One of: (%s). With auto the diffs are colorized "+
+			"when the text output is written to a terminal. Passing the flag uses the internal diff engine",
+			strings.Join(ColorOptions, ", ")))
+	cmd.Flags().StringVar(&options.progressMode, "progress", ProgressAuto,
+		fmt.Sprintf("Report the progress of the comparison to stderr. One of: (%s). The bar is redrawn after every CR, the "+
+			"log prints a line every %d%% of the CRs. With auto the bar is shown when stderr is a terminal",
+			strings.Join(ProgressOptions, ", "), progressLogStep))
+	cmd.Flags().Int64Var(&options.chunkSize, "chunk-size", defaultChunkSize,
+		"Return the lists of CRs of the live cluster in pages of this many CRs rather than all at once. Pass 0 to disable")
+	cmd.Flags().BoolVar(&options.snapshot, "snapshot", false,
+		"List the CRs of the live cluster at a single resourceVersion, so the compared CRs are a consistent snapshot "+
+			"of the cluster even when it changes while it's listed")
+	cmd.Flags().StringSliceVar(&options.contexts, "contexts", []string{},
+		"Names of kubeconfig contexts whose clusters are compared to the reference, one after the other. The output has a "+
+			"section per cluster and a summary of all the clusters")
+	cmd.Flags().DurationVar(&options.timeout, "timeout", 0,
+		"Maximum duration of the comparison (e.g. 5m), of each cluster with --contexts. When it expires the CRs that "+
+			"were compared are reported with a validation issue, the collection of the CRs, the templates and the diff "+
+			"programs still running are abandoned. Disabled by default")
+	cmd.Flags().IntVar(&options.retry.retries, "request-retries", 0,
+		"Number of times the lists of the live cluster failing with a transient error (429 or 5xx) are retried. The types "+
+			"whose CRs still can't be listed are reported in the summary and the other CRs are compared")
+	cmd.Flags().DurationVar(&options.retry.backoff, "request-backoff", defaultRequestBackoff,
+		"Delay before the first retry of a list of the live cluster (--request-retries), it doubles after each retry")
+	cmd.Flags().StringVar(&options.cacheDir, "cache-dir", defaultCacheDir(),
+		"Directory caching the resource types of the live cluster and, with --cache-ttl, its CRs")
+	cmd.Flags().DurationVar(&options.cacheTTL, "cache-ttl", 0,
+		"Reuse the CRs collected from the live cluster by the previous runs for this long (e.g. 10m) instead of listing "+
+			"them again, the CRs are cached in --cache-dir. Disabled by default")
+	cmd.Flags().StringVar(&options.referenceCatalogPath, "reference-catalog", "",
+		"Path to a catalog of references. The references that apply to the live cluster are recommended based on its "+
+			"version, topology and installed operators. Without a reference (-r) the recommendations are printed and the "+
+			"cluster isn't compared")
+	cmd.Flags().BoolVar(&options.autoReference, "auto-reference", false,
+		"Compare the cluster to the reference the catalog (--reference-catalog) recommends the most for it")
+	cmd.Flags().StringVar(&options.clusterVersion, "cluster-version", "",
+		"Version of the cluster the local CRs were collected from. It's checked against the minClusterVersion and "+
+			"maxClusterVersion of the reference, the version of the live cluster is used when comparing a live cluster")
+	cmd.Flags().StringVar(&options.groupBy, "group-by", "",
+		fmt.Sprintf(`Group the diffs, and additionally roll up the summary by the namespace or the part, by: (%s)`,
+			strings.Join(GroupByOptions, ", ")))
+	cmd.Flags().BoolVar(&options.coverage, "coverage", false,
+		"Report the coverage of the reference: the percentage of its templates matched by at least one cluster CR, in "+
+			"total and by part and component")
+	cmd.Flags().Float64Var(&options.minCoverage, "min-coverage", 0,
+		"Minimum coverage of the reference, a percentage. A lower coverage is reported as a validation issue, which "+
+			"makes the command fail. Implies --coverage")
 	kcmdutil.CheckErr(cmd.RegisterFlagCompletionFunc(
 		"output",
 		func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -210,13 +474,14 @@ func NewCmd(f kcmdutil.Factory, streams genericiooptions.IOStreams) *cobra.Comma
 			return comps, cobra.ShellCompDirectiveNoFileComp
 		},
 	))
-
-	return cmd
 }
 
 func NewOptions(ioStreams genericiooptions.IOStreams) *Options {
 	return &Options{
-		IOStreams: ioStreams,
+		IOStreams:     ioStreams,
+		diffErrOut:    &lockedWriter{w: ioStreams.ErrOut},
+		warnings:      &warningLog{},
+		preprocessors: slices.Clone(crPreprocessors),
 		diff: &diff.DiffProgram{
 			Exec:      exec.New(),
 			IOStreams: ioStreams,
@@ -245,11 +510,13 @@ func GetRefFS(refConfig string) (fs.FS, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
-	return os.DirFS(rootPath), nil
+	return localFS(rootPath), nil
 }
 func (o *Options) Complete(f kcmdutil.Factory, cmd *cobra.Command, args []string) error {
 	var err error
 	o.builder = f.NewBuilder()
+	o.newBuilder = f.NewBuilder
+	o.fetchFailures = &fetchFailures{}
 
 	if o.OutputFormat == PatchYaml {
 		if len(o.templatesToGenerateOverridesFor) == 0 {
@@ -259,11 +526,116 @@ func (o *Options) Complete(f kcmdutil.Factory, cmd *cobra.Command, args []string
 		if o.overrideReason == "" {
 			return kcmdutil.UsageErrorf(cmd, noReason)
 		}
+
+		if o.onlyValidation {
+			return kcmdutil.UsageErrorf(cmd, onlyValidationNoPatches)
+		}
+
+		if !slices.Contains(PatchTypes, o.overridePatchType) {
+			return kcmdutil.UsageErrorf(cmd, unknownPatchType, o.overridePatchType, strings.Join(PatchTypes, ", "))
+		}
+	}
+
+	for _, value := range o.templatesToGenerateOverridesFor {
+		selector, err := parseOverrideSelector(value)
+		if err != nil {
+			return kcmdutil.UsageErrorf(cmd, invalidOverrideSelector, value, err)
+		}
+		o.overrideSelectors = append(o.overrideSelectors, selector)
+	}
+
+	if o.pruneOverridesPath != "" && o.userOverridesPath == "" {
+		return kcmdutil.UsageErrorf(cmd, pruneWithoutOverrides)
+	}
+
+	if o.OutputFormat != "" && !slices.Contains(OutputFormats, o.OutputFormat) {
+		return kcmdutil.UsageErrorf(cmd, unknownOutputFormat, o.OutputFormat, strings.Join(OutputFormats, ", "))
+	}
+
+	if o.correlationReport && o.OutputFormat != "" && o.OutputFormat != Json && o.OutputFormat != Yaml {
+		return kcmdutil.UsageErrorf(cmd, correlationReportOutput)
+	}
+
+	if !slices.Contains(MatchStrategies, o.matchStrategy) {
+		return kcmdutil.UsageErrorf(cmd, unknownMatchStrategy, o.matchStrategy, strings.Join(MatchStrategies, ", "))
+	}
+
+	if flag, pattern, err := o.templateFilter.validate(); err != nil {
+		return kcmdutil.UsageErrorf(cmd, invalidTemplateFilter, flag, pattern, err)
+	}
+
+	if o.groupBy != "" && !slices.Contains(GroupByOptions, o.groupBy) {
+		return kcmdutil.UsageErrorf(cmd, unknownGroupBy, o.groupBy, strings.Join(GroupByOptions, ", "))
+	}
+	if o.minCoverage < 0 || o.minCoverage > 100 {
+		return kcmdutil.UsageErrorf(cmd, invalidMinCoverage, o.minCoverage)
+	}
+
+	if !slices.Contains(DiffEngines, o.diffEngine) {
+		return kcmdutil.UsageErrorf(cmd, unknownDiffEngine, o.diffEngine, strings.Join(DiffEngines, ", "))
+	}
+	if !slices.Contains(DiffStyles, o.diffStyle) {
+		return kcmdutil.UsageErrorf(cmd, unknownDiffStyle, o.diffStyle, strings.Join(DiffStyles, ", "))
+	}
+	if !slices.Contains(ColorOptions, o.color) {
+		return kcmdutil.UsageErrorf(cmd, unknownColor, o.color, strings.Join(ColorOptions, ", "))
+	}
+	if o.diffStyle != DiffStyleUnified || (cmd.Flags().Changed("color") && o.color != ColorNever) {
+		if o.diffEngine != DiffEngineInternal && cmd.Flags().Changed("diff-engine") {
+			return kcmdutil.UsageErrorf(cmd, renderingNeedsBuiltin)
+		}
+		o.diffEngine = DiffEngineInternal
+	}
+	o.diffFormat = diffFormat{
+		sideBySide: o.diffStyle == DiffStyleSideBySide,
+		color:      o.color == ColorAlways || (o.color == ColorAuto && o.OutputFormat == "" && printers.AllowsColorOutput(o.Out)),
+	}
+	if !slices.Contains(ProgressOptions, o.progressMode) {
+		return kcmdutil.UsageErrorf(cmd, unknownProgress, o.progressMode, strings.Join(ProgressOptions, ", "))
+	}
+	progressMode := o.progressMode
+	if progressMode == ProgressAuto {
+		progressMode = ProgressNone
+		if printers.IsTerminal(o.ErrOut) {
+			progressMode = ProgressBar
+		}
+	}
+	o.progress = newProgressReporter(o.ErrOut, progressMode)
+	if o.diffEngine == DiffEngineInternal && os.Getenv("KUBECTL_EXTERNAL_DIFF") != "" {
+		klog.Warningf("KUBECTL_EXTERNAL_DIFF is ignored by the %s diff engine", DiffEngineInternal)
+	}
+
+	if len(o.contexts) > 0 {
+		if err := o.validateContexts(); err != nil {
+			return kcmdutil.UsageErrorf(cmd, err.Error())
+		}
+	}
+
+	if o.autoReference && o.referenceCatalogPath == "" {
+		return kcmdutil.UsageErrorf(cmd, autoReferenceNeedsCatalog)
+	}
+	if o.autoReference && o.referenceConfig != "" {
+		return kcmdutil.UsageErrorf(cmd, autoReferenceWithRef)
+	}
+	if o.referenceCatalogPath != "" {
+		if o.CRs.RequireFilenameOrKustomize() == nil {
+			return kcmdutil.UsageErrorf(cmd, catalogRequiresLive)
+		}
+		if err := o.recommendReferences(f); err != nil {
+			return err
+		}
+		if o.referenceConfig == "" {
+			// Only the recommendations are printed
+			return nil
+		}
 	}
 
 	if o.referenceConfig == "" {
 		return kcmdutil.UsageErrorf(cmd, noRefFileWasPassed)
 	}
+	if isImage(o.referenceConfig) {
+		return fmt.Errorf(imageUnsupported, o.referenceConfig)
+	}
 	if _, err := os.Stat(o.referenceConfig); os.IsNotExist(err) && !isURL(o.referenceConfig) {
 		return fmt.Errorf(refFileNotExistsError)
 	}
@@ -272,12 +644,21 @@ func (o *Options) Complete(f kcmdutil.Factory, cmd *cobra.Command, args []string
 	if err != nil {
 		return err
 	}
+	if o.publicKeyPath != "" {
+		cfs, err = newVerifiedFS(cfs, o.publicKeyPath)
+		if err != nil {
+			return err
+		}
+	}
 
 	referenceFileName := filepath.Base(o.referenceConfig)
 	o.ref, err = GetReference(cfs, referenceFileName)
 	if err != nil {
 		return err
 	}
+	if err := filterReference(o.ref, o.templateFilter); err != nil {
+		return err
+	}
 
 	if o.diffConfigFileName != "" {
 		o.userConfig, err = parseDiffConfig(o.diffConfigFileName)
@@ -285,16 +666,37 @@ func (o *Options) Complete(f kcmdutil.Factory, cmd *cobra.Command, args []string
 			return err
 		}
 	}
-	o.templates, err = ParseTemplates(o.ref, cfs)
+	err = o.parseTemplates(cfs)
 	if err != nil {
 		return err
 	}
 
+	if err := validateMaintenanceWindow(o.maintenanceWindow); err != nil {
+		return kcmdutil.UsageErrorf(cmd, err.Error())
+	}
+
+	if o.severityRulesPath != "" {
+		o.severityRules, err = LoadSeverityRules(o.severityRulesPath, now())
+		if err != nil {
+			return err
+		}
+	}
+
+	o.preprocessors = slices.Clone(crPreprocessors)
+	for _, command := range o.preprocessExecs {
+		p, err := newExecPreprocessor(command)
+		if err != nil {
+			return kcmdutil.UsageErrorf(cmd, err.Error())
+		}
+		o.preprocessors = append(o.preprocessors, p)
+	}
+
 	if o.userOverridesPath != "" {
-		o.userOverrides, err = LoadUserOverrides(o.userOverridesPath)
+		o.userOverrides, err = LoadUserOverrides(o.userOverridesPath, now(), o.failOnExpiredOverrides)
 		if err != nil {
 			return err
 		}
+		warnOnReferenceChange(o.userOverrides, o.referenceHash)
 		o.newUserOverrides = append(o.newUserOverrides, o.userOverrides...)
 	}
 
@@ -316,10 +718,179 @@ func (o *Options) Complete(f kcmdutil.Factory, cmd *cobra.Command, args []string
 	if err == nil {
 		o.local = true
 		o.types = []string{}
+		if o.sincePath != "" || o.bookmarkPath != "" {
+			return kcmdutil.UsageErrorf(cmd, bookmarkNotInLive)
+		}
+		if o.cacheTTL > 0 {
+			return kcmdutil.UsageErrorf(cmd, cacheNotInLive)
+		}
+		if o.recordDir != "" {
+			return kcmdutil.UsageErrorf(cmd, recordNotInLive)
+		}
+		if o.snapshot {
+			return kcmdutil.UsageErrorf(cmd, snapshotNotInLive)
+		}
+		if cmd.Flags().Changed("request-retries") || cmd.Flags().Changed("request-backoff") {
+			return kcmdutil.UsageErrorf(cmd, requestRetriesNotInLive)
+		}
+		if len(o.contexts) > 0 {
+			return kcmdutil.UsageErrorf(cmd, contextsNotInLive)
+		}
+		if o.clusterVersion != "" {
+			if _, err := parseVersion(o.clusterVersion); err != nil {
+				return kcmdutil.UsageErrorf(cmd, invalidClusterVersion, o.clusterVersion, err)
+			}
+		}
+		if o.CRs.Kustomize != "" {
+			kOpts, err := parseKustomizeBuildOptions(o.kustomizeBuildOpts)
+			if err != nil {
+				return kcmdutil.UsageErrorf(cmd, err.Error())
+			}
+			o.kustomized, err = buildKustomization(o.CRs.Kustomize, kOpts)
+			if err != nil {
+				return err
+			}
+		} else if len(o.kustomizeBuildOpts) > 0 {
+			return kcmdutil.UsageErrorf(cmd, kustomizeOptionsWithoutKustomize)
+		}
+		if o.detectFlapping && len(o.CRs.Filenames) < 2 {
+			return kcmdutil.UsageErrorf(cmd, flappingRequiresSnapshots)
+		}
+		if err := o.readStdinCRs(); err != nil {
+			return kcmdutil.UsageErrorf(cmd, err.Error())
+		}
+		if o.crdSchemasPath != "" {
+			o.crdSchemas, err = LoadCRDSchemas(o.crdSchemasPath)
+			if err != nil {
+				return err
+			}
+			if o.schemaDefaults {
+				o.defaulter = newSchemaDefaulter(o.crdSchemas.schemaOf)
+			}
+			_, err = o.findSupportedTypes(o.crdSchemas.supportedTypes())
+			return err
+		}
+		if o.validateTemplates {
+			return kcmdutil.UsageErrorf(cmd, validateWithoutCRDs)
+		}
+		if o.schemaDefaults {
+			return kcmdutil.UsageErrorf(cmd, schemaDefaultsWithoutSchemas)
+		}
 		return nil
 	}
 
-	return o.setLiveSearchTypes(f)
+	if o.crdSchemasPath != "" {
+		return kcmdutil.UsageErrorf(cmd, crdSchemasNotInLocal)
+	}
+	if len(o.kustomizeBuildOpts) > 0 {
+		return kcmdutil.UsageErrorf(cmd, kustomizeOptionsWithoutKustomize)
+	}
+	if o.detectFlapping {
+		return kcmdutil.UsageErrorf(cmd, flappingRequiresSnapshots)
+	}
+	if o.clusterVersion != "" {
+		return kcmdutil.UsageErrorf(cmd, clusterVersionNotInLive)
+	}
+	if o.retry.retries < 0 {
+		return kcmdutil.UsageErrorf(cmd, negativeRequestRetries)
+	}
+	if o.retry.backoff < 0 {
+		return kcmdutil.UsageErrorf(cmd, negativeRequestBackoff)
+	}
+
+	if o.sincePath != "" {
+		o.since, err = LoadBookmark(o.sincePath, o.referenceHash, o.comparisonOptionsHash(), o.onlyValidation)
+		if err != nil {
+			return err
+		}
+	}
+	if o.bookmarkPath != "" {
+		o.bookmark = newBookmark(o.referenceHash, o.comparisonOptionsHash(), o.onlyValidation)
+	}
+	if o.recordDir != "" {
+		// The CRs of a previous recording would be replayed with the CRs of this one
+		if entries, err := os.ReadDir(o.recordDir); err == nil && len(entries) > 0 {
+			return kcmdutil.UsageErrorf(cmd, recordDirNotEmpty, o.recordDir)
+		}
+		o.recorder, err = newCRRecorder(o.recordDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	o.cacheDirChanged = cmd.Flags().Changed("cache-dir")
+	if len(o.contexts) > 0 {
+		// The clusters of the contexts are set up when they are compared, so a cluster that can't be reached doesn't
+		// prevent comparing the others
+		return nil
+	}
+	return o.completeLive(f)
+}
+
+// completeLive sets up the collection of the CRs of the live cluster of the factory.
+func (o *Options) completeLive(f kcmdutil.Factory) error {
+	supportedTypes, err := o.setLiveSearchTypes(f)
+	if err != nil {
+		return err
+	}
+	o.clusterInfo = getClusterInfo(f, o.contextName, supportedTypes)
+	if o.cacheTTL > 0 {
+		config, err := f.ToRESTConfig()
+		if err != nil {
+			return fmt.Errorf("failed to get the cluster config: %w", err)
+		}
+		o.resourceCache = newResourceCache(o.cacheDir, config.Host, o.types, o.cacheTTL)
+	}
+	if o.snapshot {
+		client, err := f.DynamicClient()
+		if err != nil {
+			return fmt.Errorf("failed to create dynamic client: %w", err)
+		}
+		mapper, err := f.ToRESTMapper()
+		if err != nil {
+			return fmt.Errorf("failed to create REST mapper: %w", err)
+		}
+		o.snapshotLister = &snapshotLister{list: dynamicListFunc(client), mapper: mapper, chunkSize: o.chunkSize,
+			retry: o.retry}
+	}
+	if o.schemaDefaults {
+		client, err := f.OpenAPIV3Client()
+		if err != nil {
+			return fmt.Errorf("failed to create OpenAPI client: %w", err)
+		}
+		o.defaulter = newSchemaDefaulter(openAPISchemaOf(openapi3.NewRoot(client)))
+	}
+	if o.validateTemplates {
+		client, err := f.DynamicClient()
+		if err != nil {
+			return fmt.Errorf("failed to create dynamic client: %w", err)
+		}
+		o.crdSchemas, err = fetchCRDSchemas(context.TODO(), dynamicListFunc(client))
+		if err != nil {
+			return err
+		}
+	}
+	o.liveLookup = newLiveLookup(factoryConnectFunc(f), o.context)
+	bindLiveLookup(o.templates, o.liveLookup)
+	return nil
+}
+
+// parseTemplates parses the templates of the reference, maps their namespaces according to the diff config and hashes
+// the reference.
+func (o *Options) parseTemplates(cfs fs.FS) error {
+	var err error
+	o.templates, err = ParseTemplates(o.ref, cfs)
+	if err != nil {
+		return err
+	}
+	if len(o.userConfig.CorrelationSettings.NamespaceMappings) > 0 {
+		o.templates, err = applyNamespaceMappings(o.templates, o.userConfig.CorrelationSettings.NamespaceMappings)
+		if err != nil {
+			return err
+		}
+	}
+	o.referenceHash = metadataHash(o.ref, o.templates)
+	return nil
 }
 
 // These fields are used by the GroupCorrelator who attempts to match templates based on the following priority order:
@@ -344,10 +915,16 @@ var defaultFieldGroups = [][][]string{
 // This function configures the following base correlators:
 //  1. ExactMatchCorrelator - Matches CRs based on pairs specifying, for each cluster CR, its matching template.
 //     The pairs are read from the diff config and provided to the correlator.
-//  2. GroupCorrelator - Matches CRs based on groups of fields that are similar in cluster resources and templates.
+//  2. RegexMatchCorrelator - Matches CRs based on pairs of regular expressions of cluster CR names and templates,
+//     also read from the diff config.
+//  3. OwnerReferenceCorrelator - Matches CRs based on the kind and name of their controller owner, for templates that
+//     declare a controller owner reference. These templates aren't correlated by the GroupCorrelator.
+//  4. GroupCorrelator - Matches CRs based on groups of fields that are similar in cluster resources and templates.
+//     The groups of fields can be overridden by the reference (correlationGroups), otherwise defaultFieldGroups are used.
 //
-// The base correlators are combined using a MultiCorrelator, which attempts to match a template for each base correlator
-// in the specified sequence.
+// Each base correlator is wrapped with a MatchConditionsCorrelator that excludes the templates whose match conditions
+// aren't met by the CR. The base correlators are combined using a MultiCorrelator, which attempts to match a template for
+// each base correlator in the specified sequence.
 func (o *Options) setupCorrelators() error {
 	var correlators []Correlator[ReferenceTemplate]
 	if len(o.userConfig.CorrelationSettings.ManualCorrelation.CorrelationPairs) > 0 {
@@ -357,16 +934,57 @@ func (o *Options) setupCorrelators() error {
 		}
 		correlators = append(correlators, manualCorrelator)
 	}
+	if len(o.userConfig.CorrelationSettings.ManualCorrelation.RegexCorrelationPairs) > 0 {
+		regexCorrelator, err := NewRegexMatchCorrelator(o.userConfig.CorrelationSettings.ManualCorrelation.RegexCorrelationPairs, o.templates)
+		if err != nil {
+			return err
+		}
+		correlators = append(correlators, regexCorrelator)
+	}
+
+	// The catch-all templates are correlated last, by their own correlator
+	var templates, catchAllTemplates []ReferenceTemplate
+	for _, temp := range o.templates {
+		if temp.GetConfig().GetCatchAll() {
+			catchAllTemplates = append(catchAllTemplates, temp)
+		} else {
+			templates = append(templates, temp)
+		}
+	}
+	ownerCorrelator, templates := NewOwnerReferenceCorrelator(templates)
+	if len(ownerCorrelator.fieldCorrelators) > 0 {
+		correlators = append(correlators, ownerCorrelator)
+	}
+	o.warnDuplicateKeys(ownerCorrelator.warnings)
 
-	groupCorrelator, err := NewGroupCorrelator(defaultFieldGroups, o.templates)
+	fieldGroups := defaultFieldGroups
+	if refFieldGroups := o.ref.GetCorrelationGroups(); len(refFieldGroups) > 0 {
+		fieldGroups = refFieldGroups
+	}
+	groupCorrelator, err := NewGroupCorrelator(fieldGroups, templates)
 	if err != nil {
 		return err
 	}
+	o.warnDuplicateKeys(groupCorrelator.warnings)
 
 	correlators = append(correlators, groupCorrelator)
 
+	if len(catchAllTemplates) > 0 {
+		catchAllCorrelator, err := NewCatchAllCorrelator(fieldGroups, catchAllTemplates)
+		if err != nil {
+			return err
+		}
+		o.warnDuplicateKeys(catchAllCorrelator.warnings)
+		correlators = append(correlators, catchAllCorrelator)
+	}
+
+	for i, correlator := range correlators {
+		correlators[i] = NewMatchConditionsCorrelator(correlator)
+	}
+
 	o.correlator = NewMultiCorrelator(correlators)
 	o.metricsTracker = NewMetricsTracker()
+	o.capturegroups = newTemplateCapturegroups(o.ref.GetSharedCapturegroups())
 	return nil
 }
 
@@ -391,6 +1009,7 @@ func (o *Options) setupOverrideCorrelators() error {
 	if err != nil {
 		return err
 	}
+	o.warnDuplicateKeys(groupCorrelator.warnings)
 	correlators = append(correlators, groupCorrelator)
 	o.userOverridesCorrelator = NewMultiCorrelator(correlators)
 
@@ -400,32 +1019,38 @@ func (o *Options) setupOverrideCorrelators() error {
 // setLiveSearchTypes creates a set of resources types to search the live cluster for in order to retrieve cluster resources.
 // The types are gathered from the templates included in the reference. The set of types is filtered, so it will include only
 // types supported by the live cluster in order to not raise errors by the visitor. In a case the reference includes types that
-// are not supported by the user a warning will be created.
-func (o *Options) setLiveSearchTypes(f kcmdutil.Factory) error {
+// are not supported by the user a warning will be created. The types supported by the live cluster are returned.
+func (o *Options) setLiveSearchTypes(f kcmdutil.Factory) (map[string][]schema.GroupVersion, error) {
+	c, err := o.discoveryClient(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	supportedTypes, err := getSupportedResourceTypes(c)
+	if err != nil {
+		return nil, err
+	}
+	o.types, err = o.findSupportedTypes(supportedTypes)
+	return supportedTypes, err
+}
+
+// findSupportedTypes returns the types of the templates that are included in supportedTypes. A warning is created
+// for the types of the templates that are not supported.
+func (o *Options) findSupportedTypes(supportedTypes map[string][]schema.GroupVersion) ([]string, error) {
 	kindSet := make(map[string][]ReferenceTemplate)
 	for _, t := range o.templates {
 		kindSet[t.GetMetadata().GetKind()] = append(kindSet[t.GetMetadata().GetKind()], t)
 	}
 
-	c, err := f.ToDiscoveryClient()
-	if err != nil {
-		return fmt.Errorf("failed to create discovery client: %w", err)
-	}
-	SupportedTypes, err := getSupportedResourceTypes(c)
-	if err != nil {
-		return err
-	}
-	var notSupportedTypes []string
-	o.types, notSupportedTypes = findAllRequestedSupportedTypes(SupportedTypes, kindSet)
-	if len(o.types) == 0 {
-		return errors.New(emptyTypes)
+	types, notSupportedTypes := findAllRequestedSupportedTypes(supportedTypes, kindSet)
+	if len(types) == 0 {
+		return types, errors.New(emptyTypes)
 	}
 	if len(notSupportedTypes) > 0 {
 		sort.Strings(notSupportedTypes)
-		klog.Warningf("Reference Contains Templates With Types (kind) Not Supported By Cluster: %s", strings.Join(notSupportedTypes, ", "))
+		o.warnings.warnf("Reference Contains Templates With Types (kind) Not Supported By Cluster: %s", strings.Join(notSupportedTypes, ", "))
 	}
 
-	return nil
+	return types, nil
 }
 
 // getSupportedResourceTypes retrieves a set of resource types that are supported by the cluster. For each supported
@@ -497,6 +1122,8 @@ func findAllRequestedSupportedTypes(supportedTypesWithGroups map[string][]schema
 			"There may be an issue with the API resources exposed by the cluster. Found kind but missing group/version for %s ",
 			strings.Join(badAPI, ", "))
 	}
+	// The types are listed in a stable order, so the CRs of the live cluster are compared in a stable order
+	slices.Sort(typesIncludingGroup)
 	return typesIncludingGroup, notSupportedTypes
 }
 
@@ -524,19 +1151,10 @@ func countLeaf(d any) int {
 	return count
 }
 
-func countLeaves(uo *UserOverride) (int, error) {
-	var data map[string]any
-	err := json.Unmarshal([]byte(uo.Patch), &data)
-	if err != nil {
-		return 0, fmt.Errorf("failed to unmarshal internal diff: %w", err)
-	}
-	return countLeaf(data), nil
-}
-
 func findBestMatch(matches []*diffResult) *diffResult {
 	var bestLeafMatch *diffResult
 	for _, match := range matches {
-		if bestLeafMatch == nil || match.leafCount < bestLeafMatch.leafCount {
+		if bestLeafMatch == nil || match.score < bestLeafMatch.score {
 			bestLeafMatch = match
 		}
 	}
@@ -544,9 +1162,49 @@ func findBestMatch(matches []*diffResult) *diffResult {
 
 }
 
+// alternativeDiffs returns the diffs of the CR against the other templates of the component of the best match, from the
+// best to the worst match.
+func alternativeDiffs(best *diffResult) []AlternativeDiff {
+	part, component := best.temp.GetPartAndComponent()
+	alternatives := slices.Clone(best.rejected)
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		if alternatives[i].score != alternatives[j].score {
+			return alternatives[i].score < alternatives[j].score
+		}
+		return alternatives[i].temp.GetIdentifier() < alternatives[j].temp.GetIdentifier()
+	})
+	var res []AlternativeDiff
+	for _, alt := range alternatives {
+		if altPart, altComponent := alt.temp.GetPartAndComponent(); altPart != part || altComponent != component {
+			continue
+		}
+		res = append(res, AlternativeDiff{
+			CorrelatedTemplate: alt.temp.GetIdentifier(),
+			DiffOutput:         alt.DiffOutput().String(),
+		})
+	}
+	return res
+}
+
+// candidateScores returns the templates of the results with their scores, from the best to the worst match.
+func candidateScores(results []*diffResult) []CandidateScore {
+	var scores []CandidateScore
+	for _, res := range results {
+		scores = append(scores, CandidateScore{Template: res.temp.GetIdentifier(), DiffScore: res.score})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].DiffScore != scores[j].DiffScore {
+			return scores[i].DiffScore < scores[j].DiffScore
+		}
+		return scores[i].Template < scores[j].Template
+	})
+	return scores
+}
+
 func getBestMatchByLines(templates []ReferenceTemplate, cr *unstructured.Unstructured, userOverrides []*UserOverride, o *Options) (*diffResult, error) {
 	matches := make([]*diffResult, 0)
 	errs := make([]error, 0)
+	var assertions []*diffResult
 
 	for _, temp := range templates {
 		templateOverrides := make([]*UserOverride, 0)
@@ -556,14 +1214,43 @@ func getBestMatchByLines(templates []ReferenceTemplate, cr *unstructured.Unstruc
 			}
 		}
 
-		diffResult, err := diffAgainstTemplate(temp, cr, templateOverrides, o)
+		var res *diffResult
+		var err error
+		switch {
+		case o.onlyValidation && len(templates) == 1:
+			// Nothing to choose between, so there is no need to render the template
+			res = &diffResult{temp: temp}
+		case o.onlyValidation:
+			res, err = scoreAgainstTemplate(temp, cr, templateOverrides, o)
+		default:
+			res, err = diffAgainstTemplate(temp, cr, templateOverrides, o)
+		}
+		var assertionErr TemplateAssertionError
+		if errors.As(err, &assertionErr) {
+			assertions = append(assertions, &diffResult{temp: temp, assertion: assertionErr.Msg})
+			continue
+		}
 		if err != nil {
 			errs = append(errs, err)
 			continue
 		}
-		matches = append(matches, diffResult)
+		matches = append(matches, res)
+	}
+	// A failed assertion is only reported when the CR can't be compared to any of the templates
+	if len(matches) == 0 && len(assertions) > 0 {
+		return assertions[0], errors.Join(errs...)
+	}
+	best := findBestMatch(matches)
+	if best != nil {
+		// The CR was compared to all of the templates to find the best match
+		for _, match := range matches {
+			if match != best {
+				best.duration += match.duration
+				best.rejected = append(best.rejected, match)
+			}
+		}
 	}
-	return findBestMatch(matches), errors.Join(errs...)
+	return best, errors.Join(errs...)
 
 }
 
@@ -574,6 +1261,19 @@ type diffResult struct {
 	userOverride *UserOverride
 	temp         ReferenceTemplate
 	leafCount    int
+	// score ranks the templates the CR is compared to, by the match strategy
+	score    int
+	rendered *unstructured.Unstructured
+	// assertion is the message of the assertion of the template the CR failed, the CR isn't diffed then
+	assertion string
+	// captured are the values of the capturegroups of the inline diff funcs
+	captured CapturedValues
+	// generatedOverride is the override of the requested --patch-type, when it isn't a merge patch
+	generatedOverride *UserOverride
+	// duration is the time spent rendering and diffing the template
+	duration time.Duration
+	// rejected are the results of the other templates the CR was compared to, they have more diffs
+	rejected []*diffResult
 }
 
 func (d diffResult) IsDiff() bool {
@@ -591,22 +1291,98 @@ func (d diffResult) DiffOutput() *bytes.Buffer {
 	return d.output
 }
 
-func diffAgainstTemplate(temp ReferenceTemplate, clusterCR *unstructured.Unstructured, userOverrides []*UserOverride, o *Options) (*diffResult, error) {
-	res := &diffResult{
-		temp: temp,
-	}
-
+func newInfoObject(temp ReferenceTemplate, clusterCR *unstructured.Unstructured, userOverrides []*UserOverride, o *Options) (*InfoObject, error) {
 	localRef, err := temp.Exec(clusterCR.Object)
 	if err != nil {
-		return res, err //nolint: wrapcheck
+		return nil, err //nolint: wrapcheck
+	}
+	// The fields the template doesn't set are taken from the cluster CR when merging, defaulting them would override them
+	if !temp.GetConfig().GetAllowMerge() {
+		if err := o.defaulter.setDefaults(localRef); err != nil {
+			return nil, err
+		}
 	}
-	obj := InfoObject{
+	// The cluster CR is shared by the comparisons to all the candidate templates and by the assertions, the fields
+	// owned by the ignored managers are removed and its listsAsSets are sorted on a copy
+	listsAsSets := append(slices.Clone(o.ref.GetListsAsSets()), temp.GetConfig().GetListsAsSets()...)
+	if len(o.ignoreManagers) > 0 || len(listsAsSets) > 0 {
+		clusterCR = clusterCR.DeepCopy()
+	}
+	if len(o.ignoreManagers) > 0 {
+		owned, err := fieldsManagedBy(clusterCR, o.ignoreManagers)
+		if err != nil {
+			return nil, err
+		}
+		removeManagedFields(clusterCR.Object, owned)
+		removeManagedFields(localRef.Object, owned)
+	}
+	// The template is sorted by Merged, after the user overrides that can patch the items of the lists by index
+	sortListsAsSets(clusterCR.Object, listsAsSets)
+	return &InfoObject{
 		injectedObjFromTemplate: localRef,
 		clusterObj:              clusterCR,
 		FieldsToOmit:            temp.GetFieldsToOmit(o.ref.GetFieldsToOmit()),
 		allowMerge:              temp.GetConfig().GetAllowMerge(),
 		userOverrides:           userOverrides,
 		templateFieldConf:       temp.GetConfig().GetInlineDiffFuncs(),
+		isolatedFields:          temp.GetConfig().GetIsolatedFields(),
+		sharedCapturegroups:     o.capturegroups.get(temp.GetIdentifier()),
+		captured:                &CapturedValues{},
+		listsAsSets:             listsAsSets,
+	}, nil
+}
+
+// setLeafCount creates the merge patch between the rendered template and the cluster CR
+// and counts its leaves as a measure of how different the two are. The match is also scored with the match strategy,
+// to choose between the templates the CR is correlated to.
+func (d *diffResult) setLeafCount(obj *InfoObject, reason, strategy string) error {
+	localRefData, clusterCRData, clusterCR, err := patchDocuments(obj)
+	if err != nil {
+		return err
+	}
+	uo, err := mergePatchOf(d.temp, localRefData, clusterCRData, clusterCR, reason)
+	// if user override is ok we can count the leaves in the patches
+	if err != nil {
+		return err
+	}
+	d.userOverride = uo
+
+	var patch map[string]any
+	if err := json.Unmarshal([]byte(uo.Patch), &patch); err != nil {
+		return fmt.Errorf("failed to unmarshal internal diff: %w", err)
+	}
+	d.leafCount = countLeaf(patch)
+	d.score, err = matchScore(strategy, localRefData, clusterCRData, patch)
+	return err
+}
+
+// scoreAgainstTemplate renders the template and scores it against the cluster CR without running the diff program.
+func scoreAgainstTemplate(temp ReferenceTemplate, clusterCR *unstructured.Unstructured, userOverrides []*UserOverride, o *Options) (*diffResult, error) {
+	res := &diffResult{
+		temp: temp,
+	}
+	obj, err := newInfoObject(temp, clusterCR, userOverrides, o)
+	if err != nil {
+		return res, err
+	}
+	return res, res.setLeafCount(obj, o.overrideReason, o.matchStrategy)
+}
+
+func diffAgainstTemplate(temp ReferenceTemplate, clusterCR *unstructured.Unstructured, userOverrides []*UserOverride, o *Options) (*diffResult, error) {
+	res := &diffResult{
+		temp: temp,
+	}
+	start := now()
+	defer func() {
+		res.duration = now().Sub(start)
+	}()
+
+	obj, err := newInfoObject(temp, clusterCR, userOverrides, o)
+	if err != nil {
+		return res, err
+	}
+	if o.crdSchemas != nil {
+		res.rendered = obj.injectedObjFromTemplate.DeepCopy()
 	}
 
 	differ, err := diff.NewDiffer("MERGED", "LIVE")
@@ -618,11 +1394,15 @@ func diffAgainstTemplate(temp ReferenceTemplate, clusterCR *unstructured.Unstruc
 	}
 	defer differ.TearDown()
 
-	err = differ.Diff(obj, diff.Printer{}, o.ShowManagedFields)
+	err = differ.Diff(*obj, diff.Printer{}, o.ShowManagedFields)
 	if err != nil {
 		return res, fmt.Errorf("error occurered during diff: %w", err)
 	}
-	err = differ.Run(&diff.DiffProgram{Exec: exec.New(), IOStreams: genericiooptions.IOStreams{In: o.IOStreams.In, Out: diffOutput, ErrOut: o.IOStreams.ErrOut}})
+	if o.diffEngine == DiffEngineInternal {
+		err = runInternalDiff(differ.From.Dir.Name, differ.To.Dir.Name, diffOutput, o.diffFormat)
+	} else {
+		err = differ.Run(&diff.DiffProgram{Exec: contextExec{Interface: exec.New(), ctx: o.context()}, IOStreams: genericiooptions.IOStreams{In: o.IOStreams.In, Out: diffOutput, ErrOut: o.diffErrOut}})
+	}
 
 	// If the diff tool runs without issues and detects differences at this level of the code, we would like to report that there are no issues
 	var exitErr exec.ExitError
@@ -633,19 +1413,380 @@ func diffAgainstTemplate(temp ReferenceTemplate, clusterCR *unstructured.Unstruc
 	}
 
 	// Some extra metadata for deciding if its a good diff
-	uo, err := CreateMergePatch(temp, &obj, o.overrideReason)
-	// if user override is ok we can count the leaves in the patches
+	err = res.setLeafCount(obj, o.overrideReason, o.matchStrategy)
 	if err != nil {
 		return res, err
 	}
-	res.userOverride = uo
+	if o.overridePatchType != mergePatch && o.generatesOverrideFor(temp, clusterCR) {
+		res.generatedOverride, err = CreatePatch(temp, obj, o.overrideReason, patchType(o.overridePatchType))
+		if err != nil {
+			return res, err
+		}
+	}
+	res.captured = *obj.captured
 
-	count, err := countLeaves(uo)
+	return res, nil
+}
+
+// generatesOverrideFor returns whether an override is generated for the cluster CR when it's matched to the template.
+func (o *Options) generatesOverrideFor(temp ReferenceTemplate, clusterCR *unstructured.Unstructured) bool {
+	for _, selector := range o.overrideSelectors {
+		if selector.matches(temp, clusterCR) {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedWriter serializes the writes of concurrent writers.
+type lockedWriter struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.w.Write(p) // nolint:wrapcheck
+}
+
+// ignoreError checks if an error collecting or processing the cluster CRs should only be reported in the output rather
+// than fail the command.
+func (o *Options) ignoreError(err error) bool {
+	if strings.Contains(err.Error(), "Object 'Kind' is missing") {
+		o.warnings.warnf(skipInvalidResources, extractPath(err.Error(), 3), "'Kind' is missing")
+		return true
+	}
+	if strings.Contains(err.Error(), "error parsing") {
+		o.warnings.warnf(skipInvalidResources, extractPath(err.Error(), 2), err.Error()[strings.LastIndex(err.Error(), ":"):])
+		return true
+	}
+	return containOnly(err, []error{UnknownMatch{}, MergeError{}, InlineDiffError{}})
+}
+
+// processResult is the result of the processing of a single cluster CR.
+type processResult struct {
+	clusterCR       *unstructured.Unstructured
+	unmatched       bool
+	diff            *DiffSum
+	isDiff          bool
+	isFailing       bool
+	suppressed      bool
+	patched         bool
+	newUserOverride *UserOverride
+	assertion       *TemplateAssertion
+	captured        *CRCapturedValues
+	// schemaIssue is how the template rendered for the cluster CR doesn't match the CRD schema (--validate-templates)
+	schemaIssue *TemplateAssertion
+	// usedOverrides are the user overrides correlated to the cluster CR and to the template it was matched to
+	usedOverrides []*UserOverride
+	// namespaceIssues are how the cluster CR isn't in the namespaces expected by the template it was matched to, or by
+	// the templates with its kind and name when it's unmatched
+	namespaceIssues []TemplateAssertion
+}
+
+// processAll processes the cluster CRs, see processStream. The results are returned in the order of the cluster CRs.
+func (o *Options) processAll(clusterCRs []*unstructured.Unstructured) ([]*processResult, error) {
+	order := make([]int, len(clusterCRs))
+	for i := range order {
+		order[i] = i
+	}
+	if o.capturegroups != nil {
+		// The values of the shared capturegroups are bound by the first CR matched by each template, so the CRs are
+		// processed in a stable order
+		sort.SliceStable(order, func(i, j int) bool {
+			return apiKindNamespaceName(clusterCRs[order[i]]) < apiKindNamespaceName(clusterCRs[order[j]])
+		})
+	}
+	processed, err := o.processStream(func(emit func(*unstructured.Unstructured)) {
+		for _, i := range order {
+			emit(clusterCRs[i])
+		}
+	}, len(clusterCRs))
+	results := make([]*processResult, len(clusterCRs))
+	// The CRs that weren't visited before the comparison timed out don't have a result
+	for k, i := range order[:len(processed)] {
+		results[i] = processed[k]
+	}
+	return results, err
+}
+
+// processStream correlates, renders, diffs and scores the cluster CRs passed by visit using a pool of --concurrency
+// workers. The CRs are queued to the workers as they are visited and the queue is bounded, so only the CRs being
+// processed and a few queued ones are held in memory rather than all the CRs. The results are returned in the order
+// the CRs were visited so the output doesn't depend on the scheduling of the workers. total is the number of CRs, or
+// -1 when it isn't known before visiting them.
+func (o *Options) processStream(visit func(emit func(*unstructured.Unstructured)), total int) ([]*processResult, error) {
+	type job struct {
+		index     int
+		clusterCR *unstructured.Unstructured
+	}
+	workers := max(o.Concurrency, 1)
+	if o.capturegroups != nil {
+		// The CRs are processed one at a time so they bind the shared capturegroups in the order they are visited
+		workers = 1
+	}
+	ctx := o.context()
+	o.clusterCRs = newClusterCRIndex(o.ref)
+	jobs := make(chan job, workers*queuedCRsPerWorker)
+	var lock sync.Mutex
+	var results []*processResult
+	var errs []error
+
+	o.progress.start(total)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if ctx.Err() != nil {
+					// The comparison timed out, the queued CRs aren't compared
+					continue
+				}
+				res, err := o.preprocessAndProcess(ctx, j.index, j.clusterCR)
+				o.progress.processed(j.clusterCR)
+				if res.diff != nil {
+					res.diff.Group = diffGroup(o.groupBy, j.clusterCR, res.diff)
+				}
+				if !res.unmatched {
+					// The CR is only kept to be reported as unmatched
+					res.clusterCR = nil
+				}
+				if o.onDiff != nil && err == nil && res.diff != nil {
+					o.onDiff(*res.diff)
+				}
+				lock.Lock()
+				results[j.index], errs[j.index] = res, err
+				lock.Unlock()
+			}
+		}()
+	}
+	completed := o.untilDeadline(func() {
+		visit(func(clusterCR *unstructured.Unstructured) {
+			if ctx.Err() != nil {
+				return
+			}
+			lock.Lock()
+			index := len(results)
+			results = append(results, nil)
+			errs = append(errs, nil)
+			lock.Unlock()
+			o.progress.found()
+			select {
+			case jobs <- job{index: index, clusterCR: clusterCR}:
+			case <-ctx.Done():
+			}
+		})
+		o.progress.collected()
+		close(jobs)
+		wg.Wait()
+	})
+	o.progress.finish()
+	if completed && ctx.Err() != nil {
+		// The workers stopped when the comparison timed out (e.g. the killed diff programs), the queued CRs weren't
+		// compared
+		completed = false
+		o.timedOut = true
+	}
+
+	// The workers abandoned when the comparison timed out can still record their results, in the slices they share
+	lock.Lock()
+	processed, processErrs := slices.Clone(results), slices.Clone(errs)
+	lock.Unlock()
+	var failed []error
+	for _, err := range processErrs {
+		if err != nil && !o.ignoreError(err) {
+			failed = append(failed, err)
+		}
+	}
+	if !completed && len(failed) > 0 {
+		// The errors can be caused by the timeout (e.g. the killed diff programs), the partial results are reported
+		klog.Warningf("Errors while comparing the cluster CRs before the timeout: %s", errors.Join(failed...))
+		return processed, nil
+	}
+	return processed, errors.Join(failed...)
+}
+
+// preprocessAndProcess passes the cluster CR, the index-th visited one, through the preprocessors and processes the
+// transformed CR.
+func (o *Options) preprocessAndProcess(ctx context.Context, index int, clusterCR *unstructured.Unstructured) (*processResult, error) {
+	clusterCR, err := o.preprocess(ctx, clusterCR)
+	if err != nil {
+		return &processResult{}, err
+	}
+	o.clusterCRs.set(index, clusterCR)
+	return o.process(clusterCR)
+}
+
+// process correlates a single cluster CR, diffs it against its best matching template and records the result. It's
+// called concurrently, everything it updates on the options is thread safe.
+func (o *Options) process(clusterCR *unstructured.Unstructured) (*processResult, error) {
+	res := &processResult{clusterCR: clusterCR}
+	resourceVersion := clusterCR.GetResourceVersion()
+	// The maintenance window is read before the CR is diffed, diffing removes the fieldsToOmit of the template from the
+	// CR and they can include its annotations
+	maintenanceEnd := o.maintenanceWindowEnd(clusterCR)
+
+	temps, err := o.correlator.Match(clusterCR)
+	if err != nil && (!containOnly(err, []error{UnknownMatch{}}) || o.diffAll) {
+		res.unmatched = true
+	}
 	if err != nil {
+		res.namespaceIssues = misplacedCR(clusterCR, o.templates)
 		return res, err
 	}
-	res.leafCount = count
 
+	userOverrides, err := o.userOverridesCorrelator.Match(clusterCR)
+	if err != nil && !containOnly(err, []error{UnknownMatch{}}) {
+		return res, err //nolint: wrapcheck
+	}
+
+	if len(userOverrides) == 0 {
+		if prev, temp, ok := o.since.lookup(clusterCR, temps); ok {
+			o.metricsTracker.addMatch(temp)
+			res.namespaceIssues = unexpectedNamespace(temp, clusterCR)
+			o.capturegroups.bind(temp.GetIdentifier(), CapturedValues{caps: prev.Captured})
+			res.captured = &CRCapturedValues{
+				Template: temp.GetPath(),
+				CRName:   apiKindNamespaceName(clusterCR),
+				Values:   prev.Captured,
+			}
+			o.bookmark.record(clusterCR, resourceVersion, temp, prev.Diff, prev.Captured)
+			if o.onlyValidation {
+				return res, nil
+			}
+			if prev.Diff.DiffOutput != "" {
+				res.isDiff = true
+				res.isFailing = prev.Diff.Severity == "" || prev.Diff.Severity == SeverityError
+			}
+			prev.Diff.crNamespace = prev.Namespace
+			res.diff = &prev.Diff
+			suppressDuringMaintenance(res, maintenanceEnd)
+			return res, nil
+		}
+	}
+
+	bestMatch, err := getBestMatchByLines(temps, clusterCR, userOverrides, o)
+
+	if err != nil {
+		res.unmatched = true
+		return res, err
+	}
+
+	o.metricsTracker.addMatch(bestMatch.temp)
+	res.namespaceIssues = unexpectedNamespace(bestMatch.temp, clusterCR)
+	for _, uo := range userOverrides {
+		if uo.TemplatePath == "" || uo.TemplatePath == bestMatch.temp.GetPath() {
+			res.usedOverrides = append(res.usedOverrides, uo)
+		}
+	}
+
+	if bestMatch.assertion != "" {
+		res.assertion = &TemplateAssertion{
+			Template: bestMatch.temp.GetIdentifier(),
+			CRName:   apiKindNamespaceName(clusterCR),
+			Msg:      bestMatch.assertion,
+		}
+		return res, nil
+	}
+
+	o.capturegroups.bind(bestMatch.temp.GetIdentifier(), bestMatch.captured)
+	res.captured = &CRCapturedValues{
+		Template: bestMatch.temp.GetPath(),
+		CRName:   apiKindNamespaceName(clusterCR),
+		Values:   bestMatch.captured.caps,
+	}
+
+	if bestMatch.rendered != nil {
+		if err := o.crdSchemas.Validate(bestMatch.rendered); err != nil && o.validateTemplates {
+			res.schemaIssue = &TemplateAssertion{
+				Template: bestMatch.temp.GetIdentifier(),
+				CRName:   apiKindNamespaceName(clusterCR),
+				Msg:      err.Error(),
+			}
+		} else if err != nil {
+			klog.Warningf("Template %s rendered for %s doesn't match the CRD schema: %s",
+				bestMatch.temp.GetIdentifier(), apiKindNamespaceName(clusterCR), err)
+		}
+	}
+
+	if o.onlyValidation {
+		o.bookmark.record(clusterCR, resourceVersion, bestMatch.temp, DiffSum{}, bestMatch.captured.caps)
+		return res, nil
+	}
+
+	severity, acknowledgements := "", []string(nil)
+	if bestMatch.IsDiff() {
+		res.isDiff = true
+		templateSeverity := bestMatch.temp.GetConfig().GetSeverity()
+		severity = templateSeverity
+		if severity == "" {
+			severity = SeverityError
+		}
+		if o.severityRules != nil {
+			severity, acknowledgements, err = o.severityRules.classify(bestMatch.temp.GetPath(), clusterCR.GetKind(), bestMatch.userOverride.Patch, severity)
+			if err != nil {
+				return res, err
+			}
+		}
+		res.isFailing = severity == SeverityError
+		// The severity is only reported when the reference or the rules set severities
+		if o.severityRules == nil && templateSeverity == "" {
+			severity = ""
+		}
+	}
+
+	if bestMatch.userOverride != nil && o.generatesOverrideFor(bestMatch.temp, clusterCR) {
+		res.newUserOverride = bestMatch.userOverride
+		if bestMatch.generatedOverride != nil {
+			res.newUserOverride = bestMatch.generatedOverride
+		}
+		res.newUserOverride.CapturedValues = bestMatch.captured.bindings()
+		res.newUserOverride.CreatedAt = now().Format(userOverrideDateFormat)
+		res.newUserOverride.ReferenceHash = o.referenceHash
+	}
+
+	patched := ""
+
+	reasons := make([]string, 0)
+	if len(userOverrides) > 0 {
+		patched = o.userOverridesPath
+		for _, uo := range userOverrides {
+			if uo.Reason != "" {
+				reasons = append(reasons, uo.Reason)
+			}
+		}
+		res.patched = true
+	}
+
+	part, component := bestMatch.temp.GetPartAndComponent()
+	res.diff = &DiffSum{
+		DiffOutput:         bestMatch.DiffOutput().String(),
+		CorrelatedTemplate: bestMatch.temp.GetIdentifier(),
+		CRName:             apiKindNamespaceName(clusterCR),
+		Part:               part,
+		Component:          component,
+		crNamespace:        clusterCR.GetNamespace(),
+		Patched:            patched,
+		OverrideReasons:    reasons,
+		Description:        bestMatch.temp.GetDescription(),
+		DocsURL:            bestMatch.temp.GetConfig().GetDocsURL(),
+		RemediationURL:     bestMatch.temp.GetConfig().GetRemediationURL(),
+		Severity:           severity,
+		Acknowledgements:   acknowledgements,
+		CapturedValues:     bestMatch.captured.bindings(),
+		duration:           bestMatch.duration,
+	}
+	if bestMatch.temp.GetReportAllMatches() {
+		res.diff.Alternatives = alternativeDiffs(bestMatch)
+	}
+	if o.verboseOutput && (o.OutputFormat == Json || o.OutputFormat == Yaml) {
+		res.diff.DiffScore = &bestMatch.score
+		res.diff.RejectedCandidates = candidateScores(bestMatch.rejected)
+	}
+	suppressDuringMaintenance(res, maintenanceEnd)
+	o.bookmark.record(clusterCR, resourceVersion, bestMatch.temp, *res.diff, bestMatch.captured.caps)
 	return res, nil
 }
 
@@ -653,111 +1794,306 @@ func diffAgainstTemplate(temp ReferenceTemplate, clusterCR *unstructured.Unstruc
 // templates types. For each Resource it finds the matching Resource template and
 // injects, compares, and runs against differ.
 func (o *Options) Run() error {
-	diffs := make([]DiffSum, 0)
-	numDiffCRs := 0
-	numPatched := 0
+	if o.recommendations != nil && o.referenceConfig == "" {
+		return o.printRecommendations(o.Out)
+	}
+	if len(o.contexts) > 0 {
+		return o.runContexts()
+	}
+	if o.correlationReport {
+		return o.printCorrelationReport(o.Out)
+	}
+	output, usedOverrides, numFailingDiffCRs, err := o.compare()
+	if err != nil {
+		return err
+	}
+
+	_, err = output.Print(o.OutputFormat, o.Out, o.verboseOutput)
+	if err != nil {
+		return err
+	}
+
+	if o.outputDir != "" {
+		if err := output.WriteDir(o.outputDir); err != nil {
+			return err
+		}
+	}
+
+	if o.bookmark != nil {
+		if err := o.bookmark.Write(o.bookmarkPath); err != nil {
+			return err
+		}
+	}
 
-	r := o.builder.
+	if o.pruneOverridesPath != "" {
+		pruned := make([]*UserOverride, 0, len(usedOverrides))
+		for _, uo := range o.userOverrides {
+			if usedOverrides[uo] {
+				pruned = append(pruned, uo)
+			}
+		}
+		if err := WriteUserOverrides(o.pruneOverridesPath, pruned); err != nil {
+			return err
+		}
+	}
+
+	// We will return exit code 1 in case there are differences between the reference CRs and cluster CRs.
+	// The differences can be differences found in specific CRs (unless their severity was lowered or they were
+	// acknowledged by the severity rules) or any validation issues.
+	// As long as we're not generating a set of user overrides.
+	// The types whose CRs couldn't be listed make the comparison incomplete, they fail it too.
+	if (numFailingDiffCRs != 0 || len(output.Summary.ValidationIssues) != 0 || len(output.Summary.FetchFailures) != 0) &&
+		o.OutputFormat != PatchYaml {
+		return exec.CodeExitError{Err: errors.New(DiffsFoundMsg), Code: 1}
+	}
+	return nil
+}
+
+// compare collects the cluster CRs and compares them to the reference. It returns the output of the comparison, the
+// user overrides that were applied to the cluster CRs and the number of cluster CRs with failing diffs.
+func (o *Options) compare() (Output, map[*UserOverride]bool, int, error) {
+	cancel := o.startDeadline()
+	defer cancel()
+	o.fetchFailures = &fetchFailures{}
+	issue, err := o.clusterVersionIssue()
+	if err != nil {
+		return Output{}, nil, 0, err
+	}
+	if issue != nil {
+		return o.notApplyingOutput(issue), nil, 0, nil
+	}
+	o.progress.collecting()
+	if o.capturegroups != nil || o.resourceCache != nil {
+		// The shared capturegroups need all the CRs to process them in a stable order and the cache stores all of them,
+		// otherwise the CRs are processed as they are collected
+		clusterCRs, err := o.collect()
+		if err != nil {
+			return Output{}, nil, 0, err
+		}
+		return o.compareCRs(clusterCRs)
+	}
+	var visitErr error
+	results, err := o.processStream(func(emit func(*unstructured.Unstructured)) {
+		visitErr = o.visit(emit)
+	}, -1)
+	// The visit is abandoned when the comparison times out
+	if !o.timedOut && visitErr != nil {
+		return Output{}, nil, 0, visitErr
+	}
+	return o.summarize(results, err)
+}
+
+// collect gathers all the cluster CRs from the live cluster or from the local files. The CRs of the live cluster are
+// reused from the cache when it didn't expire.
+func (o *Options) collect() ([]*unstructured.Unstructured, error) {
+	if o.resourceCache != nil {
+		clusterCRs, ok, err := o.resourceCache.load(now())
+		if err != nil {
+			klog.Warningf("Ignoring the cached CRs: %s", err)
+		}
+		if ok {
+			if o.recorder != nil {
+				for _, clusterCR := range clusterCRs {
+					o.recorder.record(clusterCR)
+				}
+				return clusterCRs, o.recorder.firstError()
+			}
+			return clusterCRs, nil
+		}
+	}
+	var clusterCRs []*unstructured.Unstructured
+	var lock sync.Mutex
+	var err error
+	completed := o.untilDeadline(func() {
+		err = o.visit(func(clusterCR *unstructured.Unstructured) {
+			lock.Lock()
+			clusterCRs = append(clusterCRs, clusterCR)
+			lock.Unlock()
+		})
+	})
+	if !completed {
+		// The CRs collected before the timeout aren't compared, they aren't cached as they are incomplete
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if o.resourceCache != nil && len(o.fetchFailures.list()) == 0 {
+		// The CRs aren't cached when some types couldn't be listed, so the next comparison lists them again
+		if err := o.resourceCache.store(clusterCRs, now()); err != nil {
+			klog.Warningf("Failed to cache the CRs: %s", err)
+		}
+	}
+	return clusterCRs, nil
+}
+
+// visit passes the cluster CRs of the live cluster or of the local files to emit, one at a time as they are read. emit
+// can be called concurrently. The CRs are recorded before they are emitted (--record).
+func (o *Options) visit(emit func(*unstructured.Unstructured)) error {
+	if o.recorder == nil {
+		return o.visitCRs(emit)
+	}
+	err := o.visitCRs(func(clusterCR *unstructured.Unstructured) {
+		o.recorder.record(clusterCR)
+		emit(clusterCR)
+	})
+	return errors.Join(err, o.recorder.firstError())
+}
+
+func (o *Options) visitCRs(emit func(*unstructured.Unstructured)) error {
+	if o.snapshotLister != nil {
+		return o.snapshotLister.visit(o.context(), o.types, emit, o.fetchFailures.add)
+	}
+	if !o.local {
+		return o.visitLiveCRs(emit)
+	}
+	b := o.builder.
 		Unstructured().
 		VisitorConcurrency(o.Concurrency).
 		AllNamespaces(true).
-		LocalParam(o.local).
-		FilenameParam(false, &o.CRs).
+		LocalParam(o.local)
+	filenameOptions := o.CRs
+	if o.detectFlapping {
+		// Only the last snapshot is compared, the others are used to find the flapping fields
+		filenameOptions.Filenames = o.CRs.Filenames[len(o.CRs.Filenames)-1:]
+	}
+	if o.kustomized != nil {
+		// The kustomization was already built with the requested build options
+		filenameOptions.Kustomize = ""
+		b = b.Stream(bytes.NewReader(o.kustomized), o.CRs.Kustomize)
+	}
+	if o.stdinCRs != nil {
+		filenameOptions.Filenames = withoutStdin(filenameOptions.Filenames)
+		b = b.Stream(bytes.NewReader(o.stdinCRs), stdinStreamName)
+	}
+	r := b.
+		FilenameParam(false, &filenameOptions).
 		ResourceTypes(o.types...).
 		SelectAllParam(!o.local).
+		RequestChunksOf(o.chunkSize).
 		ContinueOnError().
 		Flatten().
 		Do()
 	if err := r.Err(); err != nil {
 		return fmt.Errorf("failed to collect resources: %w", err)
 	}
-	r.IgnoreErrors(func(err error) bool {
-		if strings.Contains(err.Error(), "Object 'Kind' is missing") {
-			klog.Warningf(skipInvalidResources, extractPath(err.Error(), 3), "'Kind' is missing")
-			return true
-		}
-		if strings.Contains(err.Error(), "error parsing") {
-			klog.Warningf(skipInvalidResources, extractPath(err.Error(), 2), err.Error()[strings.LastIndex(err.Error(), ":"):])
-			return true
-		}
-		return containOnly(err, []error{UnknownMatch{}, MergeError{}, InlineDiffError{}})
-	})
+	r.IgnoreErrors(o.ignoreError)
 
 	err := r.Visit(func(info *resource.Info, _ error) error { // ignoring previous errors
 		clusterCRMapping, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
-		clusterCR := &unstructured.Unstructured{Object: clusterCRMapping}
+		emit(&unstructured.Unstructured{Object: clusterCRMapping})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error occurred while trying to process resources: %w", err)
+	}
+	return nil
+}
+
+// compareCRs compares the cluster CRs to the reference, see compare.
+func (o *Options) compareCRs(clusterCRs []*unstructured.Unstructured) (Output, map[*UserOverride]bool, int, error) {
+	return o.summarize(o.processAll(clusterCRs))
+}
+
+// summarize builds the output of the comparison from the results of the processing of the cluster CRs.
+func (o *Options) summarize(results []*processResult, err error) (Output, map[*UserOverride]bool, int, error) {
+	diffs := make([]DiffSum, 0)
+	var assertions []TemplateAssertion
+	var schemaIssues []TemplateAssertion
+	var namespaceIssues []TemplateAssertion
+	var captured []CRCapturedValues
+	usedOverrides := make(map[*UserOverride]bool)
+	numDiffCRs := 0
+	numSuppressedCRs := 0
+	numFailingDiffCRs := 0
+	numPatched := 0
 
-		temps, err := o.correlator.Match(clusterCR)
-		if err != nil && (!containOnly(err, []error{UnknownMatch{}}) || o.diffAll) {
-			o.metricsTracker.addUNMatch(clusterCR)
+	for _, res := range results {
+		if res == nil {
+			continue
 		}
-		if err != nil {
-			return err
+		if res.unmatched {
+			o.metricsTracker.addUNMatch(res.clusterCR)
 		}
-
-		userOverrides, err := o.userOverridesCorrelator.Match(clusterCR)
-		if err != nil && !containOnly(err, []error{UnknownMatch{}}) {
-			return err //nolint: wrapcheck
+		if res.assertion != nil {
+			assertions = append(assertions, *res.assertion)
 		}
-
-		bestMatch, err := getBestMatchByLines(temps, clusterCR, userOverrides, o)
-
-		if err != nil {
-			o.metricsTracker.addUNMatch(clusterCR)
-			return err
+		if res.schemaIssue != nil {
+			schemaIssues = append(schemaIssues, *res.schemaIssue)
 		}
-
-		o.metricsTracker.addMatch(bestMatch.temp)
-
-		if bestMatch.IsDiff() {
+		namespaceIssues = append(namespaceIssues, res.namespaceIssues...)
+		if res.captured != nil {
+			captured = append(captured, *res.captured)
+		}
+		for _, uo := range res.usedOverrides {
+			usedOverrides[uo] = true
+		}
+		if res.diff == nil {
+			continue
+		}
+		if res.suppressed {
+			numSuppressedCRs += 1
+		} else if res.isDiff {
 			numDiffCRs += 1
 		}
-
-		if bestMatch.userOverride != nil && slices.Contains(o.templatesToGenerateOverridesFor, bestMatch.temp.GetPath()) {
-			o.newUserOverrides = append(o.newUserOverrides, bestMatch.userOverride)
+		if res.isFailing {
+			numFailingDiffCRs += 1
 		}
-
-		patched := ""
-
-		reasons := make([]string, 0)
-		if len(userOverrides) > 0 {
-			patched = o.userOverridesPath
-			for _, uo := range userOverrides {
-				if uo.Reason != "" {
-					reasons = append(reasons, uo.Reason)
-				}
-			}
+		if res.patched {
 			numPatched += 1
 		}
-
-		diffs = append(diffs, DiffSum{
-			DiffOutput:         bestMatch.DiffOutput().String(),
-			CorrelatedTemplate: bestMatch.temp.GetIdentifier(),
-			CRName:             apiKindNamespaceName(clusterCR),
-			Patched:            patched,
-			OverrideReasons:    reasons,
-			Description:        bestMatch.temp.GetDescription(),
-		})
-		return err
-	})
-	if err != nil {
-		return fmt.Errorf("error occurred while trying to process resources: %w", err)
+		if res.newUserOverride != nil {
+			o.newUserOverrides = append(o.newUserOverrides, res.newUserOverride)
+		}
+		diffs = append(diffs, *res.diff)
 	}
-
-	sum := newSummary(o.ref, o.metricsTracker, numDiffCRs, o.templates, numPatched)
-
-	_, err = Output{Summary: sum, Diffs: &diffs, patches: o.newUserOverrides}.Print(o.OutputFormat, o.Out, o.verboseOutput)
 	if err != nil {
-		return err
+		return Output{}, nil, 0, fmt.Errorf("error occurred while trying to process resources: %w", err)
+	}
+
+	tracker := o.metricsTracker
+	if o.timedOut {
+		// The workers abandoned when the comparison timed out can still be matching templates
+		tracker = tracker.snapshot()
+	}
+	sum := newSummary(o.ref, tracker, numDiffCRs, o.templates, numPatched)
+	sum.Cluster = o.clusterInfo
+	sum.OnlyValidation = o.onlyValidation
+	sum.NumSuppressedCRs = numSuppressedCRs
+	sum.addAssertionIssues(assertions)
+	sum.addSchemaIssues(schemaIssues)
+	sum.addNamespaceIssues(namespaceIssues)
+	sum.addCapturegroupIssues(o.ref.GetConsistentCapturegroups(), captured)
+	sum.addRelationshipIssues(o.ref.GetAssertions(), o.clusterCRs, o.assertionFuncs())
+	sum.addOverrides(o.userOverrides, now())
+	sum.addUnusedOverrides(o.userOverrides, usedOverrides)
+	sum.DiffsBySeverity = countBySeverity(diffs)
+	if o.timedOut {
+		sum.addTimeoutIssue(o.timeout)
+	}
+	sum.FetchFailures = o.fetchFailures.list()
+	sum.Warnings = o.warnings.list()
+	if o.detectFlapping {
+		sum.FlappingFields, err = o.detectFlappingFields(o.CRs.Filenames)
+		if err != nil {
+			return Output{}, nil, 0, err
+		}
 	}
-
-	// We will return exit code 1 in case there are differences between the reference CRs and cluster CRs.
-	// The differences can be differences found in specific CRs or any validation issues.
-	// As long as we're not generating a set of user overrides.
-	if (numDiffCRs != 0 || len(sum.ValidationIssues) != 0) && o.OutputFormat != PatchYaml {
-		return exec.CodeExitError{Err: errors.New(DiffsFoundMsg), Code: 1}
+	if o.groupBy == GroupByNamespace {
+		sum.Namespaces = newNamespaceRollup(diffs, tracker.UnMatchedCRs, sum.ValidationIssues, o.templates)
 	}
-	return nil
+	if o.groupBy == GroupByPart {
+		matched := matchedTemplatePaths(tracker.MatchedTemplatesNames, o.templates)
+		sum.Parts = newPartRollup(diffs, sum.ValidationIssues, o.templates, matched)
+	}
+	if o.coverage || o.minCoverage > 0 {
+		sum.Coverage = newCoverage(o.templates, matchedTemplatePaths(tracker.MatchedTemplatesNames, o.templates))
+		sum.addCoverageIssue(o.minCoverage)
+	}
+
+	output := Output{OutputAPIVersion: outputschema.APIVersion, Summary: sum, Diffs: &diffs, patches: o.newUserOverrides,
+		templates: o.templates, groupBy: o.groupBy}
+	return output, usedOverrides, numFailingDiffCRs, nil
 }
 
 // InfoObject matches the diff.Object interface, it contains the objects that shall be compared.
@@ -768,6 +2104,13 @@ type InfoObject struct {
 	allowMerge              bool
 	userOverrides           []*UserOverride
 	templateFieldConf       map[string]inlineDiffType
+	isolatedFields          map[string]bool
+	// sharedCapturegroups are the values bound by the previous CRs matched by the same template
+	sharedCapturegroups CapturedValues
+	// captured records the values of the capturegroups captured by the inline diff funcs
+	captured *CapturedValues
+	// listsAsSets are the lists whose items are sorted before diffing
+	listsAsSets []*ListAsSetV2
 }
 
 // Live Returns the cluster version of the object
@@ -802,6 +2145,7 @@ func (obj InfoObject) Merged() (runtime.Object, error) {
 		}
 		obj.injectedObjFromTemplate = patched
 	}
+	sortListsAsSets(obj.injectedObjFromTemplate.Object, obj.listsAsSets)
 	err = obj.runInlineDiffFuncs()
 	if err != nil {
 		return obj.injectedObjFromTemplate, &InlineDiffError{obj: &obj, err: err}
@@ -829,16 +2173,21 @@ func (obj InfoObject) runInlineDiffFuncs() error {
 	}
 	slices.Sort(sortedPaths)
 
-	// Pass 1: Verify the DiffFn and record any capturegroup matches
+	// Pass 1: Verify the DiffFn and record any capturegroup matches. The capturegroups are shared by all fields, except
+	// the isolated ones that have their own.
 	type DiffValues struct {
 		value        string
 		clusterValue string
 		listedPath   []string
 		pathToKey    string
 		diffFn       InlineDiff
+		// encoded and clusterEncoded are set when the values aren't strings and were encoded as JSON
+		encoded        bool
+		clusterEncoded bool
 	}
 	preprocessedValues := make([]DiffValues, 0, len(obj.templateFieldConf))
-	sharedCapturegroups := CapturedValues{}
+	sharedCapturegroups := obj.sharedCapturegroups.clone()
+	isolatedCapturegroups := make(map[string]CapturedValues)
 	for _, pathToKey := range sortedPaths {
 		inlineDiffFunc := obj.templateFieldConf[pathToKey]
 		listedPath, err := pathToList(pathToKey)
@@ -846,7 +2195,8 @@ func (obj InfoObject) runInlineDiffFuncs() error {
 			errs = append(errs, fmt.Errorf("failed to parse path of field %s that uses inline diff func: %w", pathToKey, err))
 			continue
 		}
-		value, exist, err := NestedString(obj.injectedObjFromTemplate.Object, listedPath...)
+		diffFn, _ := getInlineDiff(inlineDiffFunc)
+		value, encoded, exist, err := nestedInlineDiffValue(obj.injectedObjFromTemplate.Object, diffFn, listedPath...)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to acces value in template of field %s that uses inline diff func: %w", pathToKey, err))
 			continue
@@ -855,7 +2205,7 @@ func (obj InfoObject) runInlineDiffFuncs() error {
 			errs = append(errs, fmt.Errorf("failed to acces value in template of field %s that uses inline diff func: Not found", pathToKey))
 			continue
 		}
-		clusterValue, exist, err := NestedString(obj.clusterObj.Object, listedPath...)
+		clusterValue, clusterEncoded, exist, err := nestedInlineDiffValue(obj.clusterObj.Object, diffFn, listedPath...)
 		if !exist {
 			continue // if value does not appear in cluster CR then there will be a diff anyway and this is not an error
 		}
@@ -863,70 +2213,151 @@ func (obj InfoObject) runInlineDiffFuncs() error {
 			errs = append(errs, fmt.Errorf("failed to acces value in cluster cr of field %s that uses inline diff func: %w", pathToKey, err))
 			continue
 		}
-		diffFn := InlineDiffs[inlineDiffFunc]
 		err = diffFn.Validate(value)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to validate the inline diff for field %s, %w", pathToKey, err))
 			continue
 		}
-		_, updatedCapturegroups := diffFn.Diff(value, clusterValue, sharedCapturegroups)
-		sharedCapturegroups = updatedCapturegroups
+		if obj.isolatedFields[pathToKey] {
+			_, isolatedCapturegroups[pathToKey] = diffFn.Diff(value, clusterValue, CapturedValues{})
+		} else {
+			_, sharedCapturegroups = diffFn.Diff(value, clusterValue, sharedCapturegroups)
+		}
 		preprocessedValues = append(preprocessedValues, DiffValues{
-			value:        value,
-			clusterValue: clusterValue,
-			listedPath:   listedPath,
-			pathToKey:    pathToKey,
-			diffFn:       diffFn,
+			value:          value,
+			clusterValue:   clusterValue,
+			listedPath:     listedPath,
+			pathToKey:      pathToKey,
+			diffFn:         diffFn,
+			encoded:        encoded,
+			clusterEncoded: clusterEncoded,
 		})
 	}
 
 	// Pass 2: Actually do the diff and substitute in any matching results
 	for _, v := range preprocessedValues {
-		patchedString, updatedCapturegroups := v.diffFn.Diff(v.value, v.clusterValue, sharedCapturegroups)
-		sharedCapturegroups = updatedCapturegroups
-		err := SetNestedString(obj.injectedObjFromTemplate.Object, patchedString, v.listedPath...)
+		var patchedString string
+		if captured, ok := isolatedCapturegroups[v.pathToKey]; ok {
+			patchedString, isolatedCapturegroups[v.pathToKey] = v.diffFn.Diff(v.value, v.clusterValue, captured)
+		} else {
+			patchedString, sharedCapturegroups = v.diffFn.Diff(v.value, v.clusterValue, sharedCapturegroups)
+		}
+		var err error
+		switch {
+		case (v.encoded || v.clusterEncoded) && patchedString == v.clusterValue:
+			// The cluster value was accepted, it's copied as is as it's not necessarily encoded
+			var clusterField any
+			if clusterField, _, err = NestedField(obj.clusterObj.Object, v.listedPath...); err == nil {
+				err = SetNestedValue(obj.injectedObjFromTemplate.Object, runtime.DeepCopyJSONValue(clusterField), v.listedPath...)
+			}
+		case v.encoded:
+			var patched any
+			if err = json.Unmarshal([]byte(patchedString), &patched); err == nil {
+				err = SetNestedValue(obj.injectedObjFromTemplate.Object, patched, v.listedPath...)
+			}
+		default:
+			err = SetNestedString(obj.injectedObjFromTemplate.Object, patchedString, v.listedPath...)
+		}
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to update value of inline diff func result for field %s, %w", v.pathToKey, err))
 			continue
 		}
 	}
+
+	// Merged can run more than once for the same object, so the captured values are added to the previous ones
+	if obj.captured != nil {
+		for name, values := range sharedCapturegroups.caps {
+			for _, value := range values {
+				obj.captured.addCapture(name, value)
+			}
+		}
+		for pathToKey, captured := range isolatedCapturegroups {
+			for name, values := range captured.caps {
+				for _, value := range values {
+					obj.captured.addCapture(fmt.Sprintf("%s (%s)", name, pathToKey), value)
+				}
+			}
+		}
+	}
 	return errors.Join(errs...)
 }
 
 func findFieldPaths(object map[string]any, fields []*ManifestPathV1) [][]string {
 	result := make([][]string, 0)
 	for _, f := range fields {
-		if !f.IsPrefix {
+		if !slices.ContainsFunc(f.parts, isWildcard) {
 			result = append(result, f.parts)
 		} else {
-			start := f.parts[:len(f.parts)-1]
-			prefix := f.parts[len(f.parts)-1]
-
-			val, _, _ := NestedField(object, start...)
-			if mapping, ok := val.(map[string]any); ok {
-				for key := range mapping {
-					if strings.HasPrefix(key, prefix) {
-						newPath := append([]string{}, start...)
-						newPath = append(newPath, key)
-						result = append(result, newPath)
-					}
-				}
-			}
+			result = append(result, expandFieldPath(object, []string{}, f.parts)...)
 		}
 	}
 
 	return result
 }
 
+func isWildcard(part string) bool {
+	return strings.Contains(part, "*")
+}
+
+// expandFieldPath returns the paths of the fields of the value that match the parts, that can contain wildcards.
+func expandFieldPath(val any, path, parts []string) [][]string {
+	if len(parts) == 0 {
+		return [][]string{path}
+	}
+	var keys []string
+	switch v := val.(type) {
+	case map[string]any:
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	case []any:
+		for i := range v {
+			keys = append(keys, strconv.Itoa(i))
+		}
+	}
+	result := make([][]string, 0)
+	for _, key := range keys {
+		if !matchPathPart(parts[0], key) {
+			continue
+		}
+		child, _, _ := NestedField(val, key)
+		result = append(result, expandFieldPath(child, append(slices.Clone(path), key), parts[1:])...)
+	}
+	return result
+}
+
+// matchPathPart returns if the key matches the part of a path, in which * matches any sequence of characters.
+func matchPathPart(part, key string) bool {
+	pieces := strings.Split(part, "*")
+	if len(pieces) == 1 {
+		return part == key
+	}
+	if !strings.HasPrefix(key, pieces[0]) {
+		return false
+	}
+	key = key[len(pieces[0]):]
+	for _, piece := range pieces[1 : len(pieces)-1] {
+		i := strings.Index(key, piece)
+		if i < 0 {
+			return false
+		}
+		key = key[i+len(piece):]
+	}
+	return strings.HasSuffix(key, pieces[len(pieces)-1])
+}
+
 func omitFields(object map[string]any, fields []*ManifestPathV1) {
 	fieldPaths := findFieldPaths(object, fields)
 
 	for _, field := range fieldPaths {
-		unstructured.RemoveNestedField(object, field...)
-		for i := 0; i <= len(field); i++ {
-			val, _, _ := NestedField(object, field[:len(field)-i]...)
-			if mapping, ok := val.(map[string]any); ok && len(mapping) == 0 {
-				unstructured.RemoveNestedField(object, field[:len(field)-i]...)
+		removeMapField(object, field...)
+		// Maps left empty are removed too, but not the items of lists as that would change the indexes of the other
+		// fields
+		for i := len(field) - 1; i > 0; i-- {
+			val, _, _ := NestedField(object, field[:i]...)
+			if mapping, ok := val.(map[string]any); !ok || len(mapping) > 0 || !removeMapField(object, field[:i]...) {
+				break
 			}
 		}
 	}
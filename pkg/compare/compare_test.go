@@ -4,6 +4,8 @@ package compare
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -17,7 +19,9 @@ import (
 	"runtime/debug"
 	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/openshift/kube-compare/pkg/testutils"
 	"github.com/samber/lo"
@@ -25,8 +29,11 @@ import (
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/cli-runtime/pkg/genericiooptions"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest/fake"
 	"k8s.io/klog/v2"
 	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
@@ -44,6 +51,13 @@ var TestDirs = "testdata"
 const ResourceDirName = "resources"
 
 var userConfigFileName = "userconfig.yaml"
+
+// unreachableContext is the context of --contexts whose cluster can't be reached in the tests
+const unreachableContext = "unreachable"
+
+// maintenanceAnnotation is the annotation of the maintenance windows of the CRs of the Maintenance Window test
+const maintenanceAnnotation = "cluster-compare.openshift.io/maintenance-until"
+
 var defaultConcurrency = "4"
 
 type checkType string
@@ -185,6 +199,42 @@ type Test struct {
 	outputFormat          string
 	checks                Checks
 	verboseOutput         bool
+	onlyValidation        bool
+	correlationReport     bool
+	matchStrategy         string
+	groupBy               string
+	coverage              bool
+	minCoverage           string
+	crdSchemasDir         string
+	publicKey             string
+	recordDir             string
+	validateTemplates     bool
+	schemaDefaults        bool
+	ignoreManagers        string
+	sinceFileName         string
+	severityRulesFileName string
+	maintenanceWindow     string
+	kustomizeDir          string
+	kustomizeBuildOptions string
+	stdinFileName         string
+	preprocessExecs       []string
+	snapshotDirs          []string
+	referenceCatalog      string
+	autoReference         bool
+	diffEngine            string
+	diffStyle             string
+	color                 string
+	progress              string
+	cacheTTL              string
+	snapshot              bool
+	contexts              []string
+	clusterVersion        string
+	includeTemplates      string
+	excludeTemplates      string
+	parts                 string
+	components            string
+	requestRetries        string
+	failingRequests       map[string]int
 	badAPIResources       bool
 	envVar                map[string]string
 	fixupOpts             testutils.FixupOptions
@@ -192,6 +242,8 @@ type Test struct {
 	userOverridePath   string
 	templToGenPatchFor []string
 	overrideGenReason  string
+	failOnExpired      bool
+	patchType          string
 }
 
 func (test *Test) getTestDir() string {
@@ -211,9 +263,47 @@ func (test Test) Clone() Test {
 		outputFormat:          test.outputFormat,
 		checks:                test.checks,
 		verboseOutput:         test.verboseOutput,
+		onlyValidation:        test.onlyValidation,
+		correlationReport:     test.correlationReport,
+		matchStrategy:         test.matchStrategy,
+		groupBy:               test.groupBy,
+		coverage:              test.coverage,
+		minCoverage:           test.minCoverage,
+		crdSchemasDir:         test.crdSchemasDir,
+		publicKey:             test.publicKey,
+		recordDir:             test.recordDir,
+		validateTemplates:     test.validateTemplates,
+		schemaDefaults:        test.schemaDefaults,
+		ignoreManagers:        test.ignoreManagers,
+		sinceFileName:         test.sinceFileName,
+		severityRulesFileName: test.severityRulesFileName,
+		maintenanceWindow:     test.maintenanceWindow,
+		kustomizeDir:          test.kustomizeDir,
+		kustomizeBuildOptions: test.kustomizeBuildOptions,
+		stdinFileName:         test.stdinFileName,
+		preprocessExecs:       slices.Clone(test.preprocessExecs),
+		snapshotDirs:          slices.Clone(test.snapshotDirs),
+		referenceCatalog:      test.referenceCatalog,
+		autoReference:         test.autoReference,
+		diffEngine:            test.diffEngine,
+		diffStyle:             test.diffStyle,
+		color:                 test.color,
+		progress:              test.progress,
+		cacheTTL:              test.cacheTTL,
+		snapshot:              test.snapshot,
+		contexts:              slices.Clone(test.contexts),
+		clusterVersion:        test.clusterVersion,
+		includeTemplates:      test.includeTemplates,
+		excludeTemplates:      test.excludeTemplates,
+		parts:                 test.parts,
+		components:            test.components,
+		requestRetries:        test.requestRetries,
+		failingRequests:       maps.Clone(test.failingRequests),
 		userOverridePath:      test.userOverridePath,
 		templToGenPatchFor:    slices.Clone(test.templToGenPatchFor),
 		overrideGenReason:     test.overrideGenReason,
+		failOnExpired:         test.failOnExpired,
+		patchType:             test.patchType,
 		referenceFileName:     test.referenceFileName,
 		badAPIResources:       test.badAPIResources,
 		envVar:                maps.Clone(test.envVar),
@@ -263,6 +353,192 @@ func (test Test) withVerboseOutput() Test {
 	return newTest
 }
 
+func (test Test) withOnlyValidation() Test {
+	newTest := test.Clone()
+	newTest.onlyValidation = true
+	return newTest
+}
+
+func (test Test) withCorrelationReport() Test {
+	newTest := test.Clone()
+	newTest.correlationReport = true
+	return newTest
+}
+
+func (test Test) withMatchStrategy(strategy string) Test {
+	newTest := test.Clone()
+	newTest.matchStrategy = strategy
+	return newTest
+}
+
+func (test Test) withGroupBy(groupBy string) Test {
+	newTest := test.Clone()
+	newTest.groupBy = groupBy
+	return newTest
+}
+
+func (test Test) withCoverage() Test {
+	newTest := test.Clone()
+	newTest.coverage = true
+	return newTest
+}
+
+func (test Test) withMinCoverage(minCoverage string) Test {
+	newTest := test.Clone()
+	newTest.minCoverage = minCoverage
+	return newTest
+}
+
+func (test Test) withClusterVersion(version string) Test {
+	newTest := test.Clone()
+	newTest.clusterVersion = version
+	return newTest
+}
+
+func (test Test) withTemplateFilters(include, exclude, parts, components string) Test {
+	newTest := test.Clone()
+	newTest.includeTemplates = include
+	newTest.excludeTemplates = exclude
+	newTest.parts = parts
+	newTest.components = components
+	return newTest
+}
+
+// withFailingRequests makes the lists of the paths of the live cluster fail with an internal error the given number of
+// times before they succeed, or always when it's negative. The lists are retried --request-retries times.
+func (test Test) withFailingRequests(retries string, failing map[string]int) Test {
+	newTest := test.Clone()
+	newTest.requestRetries = retries
+	newTest.failingRequests = failing
+	return newTest
+}
+
+func (test Test) withSeverityRules(fileName string) Test {
+	newTest := test.Clone()
+	newTest.severityRulesFileName = fileName
+	return newTest
+}
+
+func (test Test) withMaintenanceWindow(annotation string) Test {
+	newTest := test.Clone()
+	newTest.maintenanceWindow = annotation
+	return newTest
+}
+
+func (test Test) withSince(fileName string) Test {
+	newTest := test.Clone()
+	newTest.sinceFileName = fileName
+	return newTest
+}
+
+func (test Test) withCRDSchemas(dir string) Test {
+	newTest := test.Clone()
+	newTest.crdSchemasDir = dir
+	return newTest
+}
+
+func (test Test) withVerifySignature(publicKey string) Test {
+	newTest := test.Clone()
+	newTest.publicKey = publicKey
+	return newTest
+}
+
+func (test Test) withRecord(dir string) Test {
+	newTest := test.Clone()
+	newTest.recordDir = dir
+	return newTest
+}
+
+func (test Test) withValidateTemplates() Test {
+	newTest := test.Clone()
+	newTest.validateTemplates = true
+	return newTest
+}
+
+func (test Test) withSchemaDefaults() Test {
+	newTest := test.Clone()
+	newTest.schemaDefaults = true
+	return newTest
+}
+
+func (test Test) withIgnoreFieldsManagedBy(managers string) Test {
+	newTest := test.Clone()
+	newTest.ignoreManagers = managers
+	return newTest
+}
+
+// withStdin pipes the file of the test dir to the standard input of the command, which reads it besides the resources
+// dir (-f -).
+func (test Test) withStdin(fileName string) Test {
+	newTest := test.Clone()
+	newTest.stdinFileName = fileName
+	return newTest
+}
+
+// withPreProcessExec runs the scripts of the test dir, with sh, as the pre-processing commands (--pre-process-exec).
+func (test Test) withPreProcessExec(scripts ...string) Test {
+	newTest := test.Clone()
+	newTest.preprocessExecs = scripts
+	return newTest
+}
+
+func (test Test) withKustomize(dir, buildOptions string) Test {
+	newTest := test.Clone()
+	newTest.kustomizeDir = dir
+	newTest.kustomizeBuildOptions = buildOptions
+	return newTest
+}
+
+func (test Test) withSnapshots(dirs ...string) Test {
+	newTest := test.Clone()
+	newTest.snapshotDirs = dirs
+	return newTest
+}
+
+func (test Test) withReferenceCatalog(fileName string, autoReference bool) Test {
+	newTest := test.Clone()
+	newTest.referenceCatalog = fileName
+	newTest.autoReference = autoReference
+	return newTest
+}
+
+func (test Test) withDiffEngine(engine string) Test {
+	newTest := test.Clone()
+	newTest.diffEngine = engine
+	return newTest
+}
+
+func (test Test) withDiffRendering(style, color string) Test {
+	newTest := test.Clone()
+	newTest.diffStyle = style
+	newTest.color = color
+	return newTest
+}
+
+func (test Test) withProgress(progress string) Test {
+	newTest := test.Clone()
+	newTest.progress = progress
+	return newTest
+}
+
+func (test Test) withCacheTTL(ttl string) Test {
+	newTest := test.Clone()
+	newTest.cacheTTL = ttl
+	return newTest
+}
+
+func (test Test) withSnapshot() Test {
+	newTest := test.Clone()
+	newTest.snapshot = true
+	return newTest
+}
+
+func (test Test) withContexts(contexts ...string) Test {
+	newTest := test.Clone()
+	newTest.contexts = contexts
+	return newTest
+}
+
 func (test Test) withOutputFormat(outputFormat string) Test {
 	newTest := test.Clone()
 	newTest.outputFormat = outputFormat
@@ -287,6 +563,18 @@ func (test Test) withOverrideReason(reason string) Test {
 	return newTest
 }
 
+func (test Test) withPatchType(patchType string) Test {
+	newTest := test.Clone()
+	newTest.patchType = patchType
+	return newTest
+}
+
+func (test Test) withFailOnExpiredOverrides() Test {
+	newTest := test.Clone()
+	newTest.failOnExpired = true
+	return newTest
+}
+
 func (test Test) withMetadataFile(referenceFileName string) Test {
 	newTest := test.Clone()
 	newTest.referenceFileName = referenceFileName
@@ -364,6 +652,7 @@ func TestCompareRun(t *testing.T) {
 		defaultTest("Reference Contains Templates That Dont Exist"),
 		defaultTest("Reference Contains Templates That Dont Parse"),
 		defaultTest("Reference Contains Function Templates That Dont Parse"),
+		defaultTest("Template Isnt YAML After Execution"),
 		defaultTest("Template Isnt YAML After Execution With Empty Map"),
 		defaultTest("Template Has No Kind").
 			withModes([]Mode{{Live, LocalRef}}),
@@ -422,6 +711,440 @@ func TestCompareRun(t *testing.T) {
 		defaultTest("JSON Output").
 			withRealHash().
 			withOutputFormat(Json),
+		defaultTest("SomeDiffs").
+			withOutputFormat(Markdown).
+			withChecks(defaultChecks.withPrefixedSuffix("markdown")),
+		defaultTest("Required Resources Of Optional Component Are Reported Missing If At Least One Of Resources In Group Is Included").
+			withOutputFormat(Markdown).
+			withChecks(defaultChecks.withPrefixedSuffix("markdown")),
+		defaultTest("SomeDiffs").
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("junit")),
+		defaultTest("Required Resources Of Optional Component Are Reported Missing If At Least One Of Resources In Group Is Included").
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("junit")),
+		defaultTest("ReferenceV2 Consistent Capturegroups").
+			withSubTestSuffix("Junit").
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("junit")),
+		defaultTest("When Using Diff All Flag - All Unmatched Resources Appear In Summary").
+			diffAll().
+			withGroupBy(GroupByNamespace).
+			withChecks(defaultChecks.withPrefixedSuffix("groupByNamespace")),
+		defaultTest("Group By"),
+		defaultTest("Group By").
+			withGroupBy(GroupByNamespace).
+			withChecks(defaultChecks.withPrefixedSuffix("namespace")),
+		defaultTest("Group By").
+			withGroupBy(GroupByPart).
+			withChecks(defaultChecks.withPrefixedSuffix("part")),
+		defaultTest("Group By").
+			withGroupBy(GroupByKind).
+			withChecks(defaultChecks.withPrefixedSuffix("kind")),
+		defaultTest("Group By").
+			withGroupBy(GroupByKind).
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("kindJson")),
+		defaultTest("Required Resources Of Optional Component Are Reported Missing If At Least One Of Resources In Group Is Included").
+			withGroupBy(GroupByNamespace).
+			withChecks(defaultChecks.withPrefixedSuffix("groupByNamespace")),
+		defaultTest("SomeDiffs").
+			withGroupBy(GroupByNamespace).
+			withOutputFormat(Markdown).
+			withChecks(defaultChecks.withPrefixedSuffix("groupByNamespaceMarkdown")),
+		defaultTest("SomeDiffs").
+			withGroupBy("team").
+			withChecks(defaultChecks.withPrefixedSuffix("groupByUnknown")),
+		defaultTest("Required Resources Of Optional Component Are Reported Missing If At Least One Of Resources In Group Is Included").
+			withGroupBy(GroupByPart).
+			withChecks(defaultChecks.withPrefixedSuffix("groupByPart")),
+		defaultTest("SomeDiffs").
+			withGroupBy(GroupByPart).
+			withOutputFormat(Markdown).
+			withChecks(defaultChecks.withPrefixedSuffix("groupByPartMarkdown")),
+		defaultTest("Required Resources Of Optional Component Are Reported Missing If At Least One Of Resources In Group Is Included").
+			withCoverage().
+			withChecks(defaultChecks.withPrefixedSuffix("coverage")),
+		defaultTest("Required Resources Of Optional Component Are Reported Missing If At Least One Of Resources In Group Is Included").
+			withMinCoverage("90").
+			withChecks(defaultChecks.withPrefixedSuffix("minCoverage")),
+		defaultTest("Required Resources Of Optional Component Are Reported Missing If At Least One Of Resources In Group Is Included").
+			withMinCoverage("90").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("minCoverageJson")),
+		defaultTest("Required Resources Of Optional Component Are Reported Missing If At Least One Of Resources In Group Is Included").
+			withCoverage().
+			withOutputFormat(Markdown).
+			withChecks(defaultChecks.withPrefixedSuffix("coverageMarkdown")),
+		defaultTest("SomeDiffs").
+			withMinCoverage("50").
+			withChecks(defaultChecks.withPrefixedSuffix("minCoverageMet")),
+		defaultTest("SomeDiffs").
+			withMinCoverage("150").
+			withChecks(defaultChecks.withPrefixedSuffix("minCoverageInvalid")),
+		defaultTest("User Override").
+			withSubTestSuffix("Group By Part").
+			withChecks(defaultChecks.withPrefixedSuffix("groupByPart")).
+			withUserOverridePath("rfc6902.patch").
+			withGroupBy(GroupByPart).
+			withOutputFormat(Json),
+		defaultTest("CRD Schemas").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}}).
+			withCRDSchemas("crds"),
+		defaultTest("CRD Schemas").
+			withCRDSchemas("missing").
+			withChecks(defaultChecks.withPrefixedSuffix("missingDir")),
+		defaultTest("CRD Schemas").
+			withCRDSchemas("crds").
+			withValidateTemplates().
+			withChecks(defaultChecks.withPrefixedSuffix("validateTemplates")),
+		defaultTest("CRD Schemas").
+			withValidateTemplates().
+			withChecks(defaultChecks.withPrefixedSuffix("validateTemplatesWithoutSchemas")),
+		defaultTest("Verify Signature").
+			withModes([]Mode{{Local, LocalRef}, {Local, URL}}).
+			withVerifySignature("cosign.pub"),
+		defaultTest("Verify Signature").
+			withVerifySignature("other.pub").
+			withChecks(defaultChecks.withPrefixedSuffix("otherKey")),
+		defaultTest("Ignore Fields Managed By"),
+		defaultTest("Ignore Fields Managed By").
+			withIgnoreFieldsManagedBy("frontend-operator,frontend-autoscaler").
+			withChecks(defaultChecks.withPrefixedSuffix("ignored")),
+		defaultTest("Schema Defaults").
+			withCRDSchemas("crds"),
+		defaultTest("Schema Defaults").
+			withCRDSchemas("crds").
+			withSchemaDefaults().
+			withChecks(defaultChecks.withPrefixedSuffix("defaulted")),
+		defaultTest("Schema Defaults").
+			withSchemaDefaults().
+			withChecks(defaultChecks.withPrefixedSuffix("withoutSchemas")),
+		defaultTest("Since").
+			withModes([]Mode{{Live, LocalRef}}).
+			withSince("bookmark.yaml"),
+		defaultTest("Since").
+			withModes([]Mode{{Live, LocalRef}}).
+			withSince("bookmark_stale.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("stale")),
+		defaultTest("Since").
+			withModes([]Mode{{Live, LocalRef}}).
+			withSince("bookmark.yaml").
+			withUserConfig(userConfigFileName).
+			withChecks(defaultChecks.withPrefixedSuffix("diffConfigChanged")),
+		defaultTest("Since").
+			withSince("bookmark.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("inLocal")),
+		defaultTest("Since Capturegroups").
+			withModes([]Mode{{Live, LocalRef}}).
+			withSince("bookmark.yaml"),
+		defaultTest("SomeDiffs").
+			withCacheTTL("10m").
+			withChecks(defaultChecks.withPrefixedSuffix("cacheInLocal")),
+		defaultTest("SomeDiffs").
+			withSnapshot().
+			withChecks(defaultChecks.withPrefixedSuffix("snapshotInLocal")),
+		defaultTest("SomeDiffs").
+			withRecord("record").
+			withChecks(defaultChecks.withPrefixedSuffix("recordInLocal")),
+		defaultTest("SomeDiffs").
+			withModes([]Mode{{Live, LocalRef}}).
+			withContexts("cluster-a", "cluster-b").
+			withRecord("record").
+			withChecks(defaultChecks.withPrefixedSuffix("recordWithContexts")),
+		defaultTest("SomeDiffs").
+			withModes([]Mode{{Live, LocalRef}}).
+			withContexts("cluster-a", "cluster-b").
+			withChecks(defaultChecks.withPrefixedSuffix("contexts")),
+		defaultTest("SomeDiffs").
+			withModes([]Mode{{Live, LocalRef}}).
+			withContexts("cluster-a", unreachableContext).
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("contextsJson")),
+		defaultTest("SomeDiffs").
+			withModes([]Mode{{Live, LocalRef}}).
+			withContexts("cluster-a", unreachableContext).
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("contextsJunit")),
+		defaultTest("Since").
+			withModes([]Mode{{Live, LocalRef}}).
+			withSince("bookmark.yaml").
+			withContexts("cluster-a", "cluster-b").
+			withChecks(defaultChecks.withPrefixedSuffix("contexts")),
+		defaultTest("SomeDiffs").
+			withContexts("cluster-a", "cluster-b").
+			withChecks(defaultChecks.withPrefixedSuffix("contextsInLocal")),
+		defaultTest("Template Filters"),
+		defaultTest("Template Filters").
+			withModes([]Mode{{Live, LocalRef}}).
+			withTemplateFilters("", "", "Dashboard", "").
+			withChecks(defaultChecks.withPrefixedSuffix("part")),
+		defaultTest("Template Filters").
+			withTemplateFilters("", "", "", "Dep*").
+			withChecks(defaultChecks.withPrefixedSuffix("component")),
+		defaultTest("Template Filters").
+			withTemplateFilters("deployment*.yaml,scraper/*", "*Metrics.yaml", "", "").
+			withChecks(defaultChecks.withPrefixedSuffix("includeExclude")),
+		defaultTest("Template Filters").
+			withTemplateFilters("", "", "Dashboard", "Scraper").
+			withChecks(defaultChecks.withPrefixedSuffix("noTemplates")),
+		defaultTest("Template Filters").
+			withTemplateFilters("[", "", "", "").
+			withChecks(defaultChecks.withPrefixedSuffix("invalidPattern")),
+		defaultTest("Request Retries").
+			withModes([]Mode{{Live, LocalRef}}).
+			withFailingRequests("2", map[string]int{"/deployments": 2}).
+			withChecks(defaultChecks.withPrefixedSuffix("retried")),
+		defaultTest("Request Retries").
+			withModes([]Mode{{Live, LocalRef}}).
+			withFailingRequests("1", map[string]int{"/configmaps": -1}).
+			withChecks(defaultChecks.withPrefixedSuffix("failed")),
+		defaultTest("Request Retries").
+			withModes([]Mode{{Live, LocalRef}}).
+			withFailingRequests("1", map[string]int{"/configmaps": -1}).
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("failedJson")),
+		defaultTest("Request Retries").
+			withModes([]Mode{{Live, LocalRef}}).
+			withFailingRequests("0", map[string]int{"/configmaps": -1, "/deployments": -1}).
+			withChecks(defaultChecks.withPrefixedSuffix("allFailed")),
+		defaultTest("Request Retries").
+			withFailingRequests("1", nil),
+		defaultTest("Multi Document Templates").
+			withModes([]Mode{{Live, LocalRef}}).
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Cluster Version").
+			withClusterVersion("4.14.2"),
+		defaultTest("Cluster Version").
+			withClusterVersion("4.17.3").
+			withChecks(defaultChecks.withPrefixedSuffix("inRange")),
+		defaultTest("Cluster Version").
+			withClusterVersion("4.14.2").
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("junit")),
+		defaultTest("Cluster Version").
+			withModes([]Mode{{Live, LocalRef}}),
+		defaultTest("Cluster Version").
+			withChecks(defaultChecks.withPrefixedSuffix("unknown")),
+		defaultTest("Cluster Version").
+			withClusterVersion("four").
+			withChecks(defaultChecks.withPrefixedSuffix("invalid")),
+		defaultTest("Cluster Version").
+			withModes([]Mode{{Live, LocalRef}}).
+			withClusterVersion("4.14.2").
+			withChecks(defaultChecks.withPrefixedSuffix("inLive")),
+		defaultTest("Owner Reference Correlation").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}}),
+		defaultTest("Regex Manual Correlation").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}}).
+			withUserConfig(userConfigFileName),
+		defaultTest("Regex Manual Correlation").
+			withUserConfig("userconfig_bad_regex.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("badRegex")),
+		defaultTest("Regex Manual Correlation").
+			withUserConfig(userConfigFileName).
+			withCorrelationReport().
+			withChecks(defaultChecks.withPrefixedSuffix("correlationReport")),
+		defaultTest("Correlation Report").
+			withUserConfig(userConfigFileName).
+			withCorrelationReport(),
+		defaultTest("Correlation Report").
+			withUserConfig(userConfigFileName).
+			withCorrelationReport().
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Correlation Report").
+			withCorrelationReport().
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("junit")),
+		defaultTest("Correlation Report").
+			withUserConfig(userConfigFileName).
+			withVerboseOutput().
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("verboseJson")),
+		defaultTest("Correlation Report").
+			withUserConfig(userConfigFileName).
+			withVerboseOutput().
+			withOutputFormat(Json).
+			withMatchStrategy(MatchStrategyLines).
+			withChecks(defaultChecks.withPrefixedSuffix("linesStrategy")),
+		defaultTest("Correlation Report").
+			withMatchStrategy("bytes").
+			withChecks(defaultChecks.withPrefixedSuffix("unknownStrategy")),
+		defaultTest("CatchAll Templates"),
+		defaultTest("CatchAll Templates").
+			withCorrelationReport().
+			withChecks(defaultChecks.withPrefixedSuffix("correlationReport")),
+		defaultTest("Report All Matches"),
+		defaultTest("Report All Matches").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Report All Matches").
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("junit")),
+		defaultTest("Severity Rules").
+			withSeverityRules("severity_acknowledged.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("acknowledged")),
+		defaultTest("Severity Rules").
+			withSeverityRules("severity_warning.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("warning")),
+		defaultTest("Severity Rules").
+			withSeverityRules("severity_invalid.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("invalid")),
+		defaultTest("Severity Rules").
+			withSeverityRules("severity_acknowledged.yaml").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Severity Rules").
+			withMetadataFile("metadata_v2.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("templateSeverity")),
+		defaultTest("Severity Rules").
+			withMetadataFile("metadata_v2.yaml").
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("templateSeverityJunit")),
+		defaultTest("Severity Rules").
+			withMetadataFile("metadata_invalid_severity.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("templateSeverityInvalid")),
+		defaultTest("Relationship Assertions"),
+		defaultTest("Relationship Assertions").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Relationship Assertions").
+			withMetadataFile("metadata_invalid.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("invalid")),
+		defaultTest("CRs From Stdin").
+			withStdin("stdin.yaml"),
+		defaultTest("Maintenance Window"),
+		defaultTest("Maintenance Window").
+			withMaintenanceWindow(maintenanceAnnotation).
+			withChecks(defaultChecks.withPrefixedSuffix("suppressed")),
+		defaultTest("Maintenance Window").
+			withMaintenanceWindow(maintenanceAnnotation).
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("suppressedJson")),
+		defaultTest("Maintenance Window").
+			withMaintenanceWindow(maintenanceAnnotation).
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("suppressedJunit")),
+		defaultTest("Maintenance Window").
+			withMaintenanceWindow(maintenanceAnnotation).
+			withOutputFormat(Markdown).
+			withChecks(defaultChecks.withPrefixedSuffix("suppressedMarkdown")),
+		defaultTest("Maintenance Window").
+			withMaintenanceWindow("invalid/annotation/key").
+			withChecks(defaultChecks.withPrefixedSuffix("invalidAnnotation")),
+		defaultTest("Maintenance Window").
+			withMetadataFile("metadata_omit_annotations.yaml").
+			withMaintenanceWindow(maintenanceAnnotation).
+			withChecks(defaultChecks.withPrefixedSuffix("omittedAnnotations")),
+		defaultTest("Pre Process Exec"),
+		defaultTest("Pre Process Exec").
+			withPreProcessExec("normalize-mirror.sh").
+			withChecks(defaultChecks.withPrefixedSuffix("normalized")),
+		defaultTest("Pre Process Exec").
+			withPreProcessExec("normalize-mirror.sh", "failing.sh").
+			withChecks(defaultChecks.withPrefixedSuffix("failing")),
+		defaultTest("Template Links"),
+		defaultTest("Template Links").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Template Links").
+			withOutputFormat(Junit).
+			withChecks(defaultChecks.withPrefixedSuffix("junit")),
+		defaultTest("Template Links").
+			withOutputFormat(Markdown).
+			withChecks(defaultChecks.withPrefixedSuffix("markdown")),
+		defaultTest("Template Links").
+			withMetadataFile("metadata_invalid.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("invalid")),
+		defaultTest("Expected Namespaces"),
+		defaultTest("Expected Namespaces").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Match Counts"),
+		defaultTest("Match Counts").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Match Counts").
+			withMetadataFile("metadata_invalid.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("invalid")),
+		defaultTest("Namespace Mappings").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}}).
+			withUserConfig(userConfigFileName),
+		defaultTest("Namespace Mappings").
+			withChecks(defaultChecks.withPrefixedSuffix("withoutMappings")),
+		defaultTest("Namespace Mappings").
+			withUserConfig("userconfig_invalid.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("invalid")),
+		defaultTest("Match Conditions").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}}),
+		defaultTest("Match Conditions").
+			diffAll().
+			withChecks(defaultChecks.withPrefixedSuffix("diffAll")),
+		defaultTest("Match Conditions").
+			withSubTestWithMetadata("bad condition"),
+		defaultTest("Kustomize").
+			withKustomize("kustomize/overlays/prod", "load-restrictor=LoadRestrictionsNone"),
+		defaultTest("Kustomize").
+			withKustomize("kustomize/overlays/prod", "").
+			withChecks(defaultChecks.withPrefixedSuffix("rootOnly")),
+		defaultTest("Kustomize").
+			withKustomize("kustomize/overlays/prod", "load-restrictor=Everything").
+			withChecks(defaultChecks.withPrefixedSuffix("invalidOption")),
+		defaultTest("Kustomize").
+			withModes([]Mode{{Live, LocalRef}}).
+			withKustomize("", "reorder=none").
+			withChecks(defaultChecks.withPrefixedSuffix("withoutKustomization")),
+		defaultTest("Flapping Fields").
+			withSnapshots("snapshot-1", "snapshot-2", "snapshot-3"),
+		defaultTest("Flapping Fields").
+			withSnapshots("snapshot-1", "snapshot-2", "snapshot-3").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Flapping Fields").
+			withSnapshots("snapshot-3").
+			withChecks(defaultChecks.withPrefixedSuffix("singleSnapshot")),
+		defaultTest("Reference Catalog").
+			withModes([]Mode{{Live, LocalRef}}).
+			skipReferenceFlag().
+			withReferenceCatalog("catalog.yaml", false),
+		defaultTest("Reference Catalog").
+			withModes([]Mode{{Live, LocalRef}}).
+			skipReferenceFlag().
+			withReferenceCatalog("catalog.yaml", false).
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Reference Catalog").
+			withModes([]Mode{{Live, LocalRef}}).
+			skipReferenceFlag().
+			withReferenceCatalog("catalog.yaml", true).
+			withChecks(defaultChecks.withPrefixedSuffix("autoReference")),
+		defaultTest("Reference Catalog").
+			withModes([]Mode{{Live, LocalRef}}).
+			skipReferenceFlag().
+			withReferenceCatalog("catalog_ambiguous.yaml", true).
+			withChecks(defaultChecks.withPrefixedSuffix("ambiguous")),
+		defaultTest("Reference Catalog").
+			withModes([]Mode{{Live, LocalRef}}).
+			withReferenceCatalog("", true).
+			withChecks(defaultChecks.withPrefixedSuffix("withoutCatalog")),
+		defaultTest("Reference Catalog").
+			skipReferenceFlag().
+			withReferenceCatalog("catalog.yaml", false).
+			withChecks(defaultChecks.withPrefixedSuffix("inLocal")),
+		defaultTest("Template Assertions").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}}),
+		defaultTest("Template Assertions").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Template Assertions").
+			withOutputFormat(Markdown).
+			withChecks(defaultChecks.withPrefixedSuffix("markdown")),
+		defaultTest("Ignore Comments").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}}),
+		defaultTest("Multi Document Templates").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}}),
 		defaultTest("Check Ignore Unspecified Fields Config"),
 		defaultTest("Check Merging Does Not Overwrite Template Config"),
 		defaultTest("NoDiffs"),
@@ -433,6 +1156,15 @@ func TestCompareRun(t *testing.T) {
 		defaultTest("SomeDiffs").
 			withVerboseOutput().
 			withChecks(defaultChecks.withPrefixedSuffix("withVebosityFlag")),
+		defaultTest("SomeDiffs").
+			withOnlyValidation().
+			withChecks(defaultChecks.withPrefixedSuffix("onlyValidation")),
+		defaultTest("Two Templates With Same Kind Namespace").
+			withOnlyValidation().
+			withChecks(defaultChecks.withPrefixedSuffix("onlyValidation")),
+		defaultTest("Required Resources Of Optional Component Are Reported Missing If At Least One Of Resources In Group Is Included").
+			withOnlyValidation().
+			withChecks(defaultChecks.withPrefixedSuffix("onlyValidation")),
 		defaultTest("Invalid Resources Are Skipped"),
 		defaultTest("Ref Contains Templates With Function Templates In Same File"),
 		defaultTest("User Override").
@@ -441,6 +1173,27 @@ func TestCompareRun(t *testing.T) {
 			withOutputFormat(PatchYaml).
 			withGenerateForTemplate("namespace.yaml").
 			withOverrideReason("For the test"),
+		defaultTest("User Override").
+			withSubTestSuffix("Output rfc6902").
+			withChecks(defaultChecks.withPrefixedSuffix("newOverridesRfc6902")).
+			withOutputFormat(PatchYaml).
+			withPatchType(rfc6902).
+			withGenerateForTemplate("namespace.yaml").
+			withOverrideReason("For the test"),
+		defaultTest("User Override").
+			withSubTestSuffix("Output GoTemplate").
+			withChecks(defaultChecks.withPrefixedSuffix("newOverridesGoTemplate")).
+			withOutputFormat(PatchYaml).
+			withPatchType(gotemplate).
+			withGenerateForTemplate("namespace.yaml").
+			withOverrideReason("For the test"),
+		defaultTest("User Override").
+			withSubTestSuffix("Fail Generation Unknown Patch Type").
+			withChecks(defaultChecks.withPrefixedSuffix("unknownPatchType")).
+			withOutputFormat(PatchYaml).
+			withPatchType("strategic").
+			withGenerateForTemplate("namespace.yaml").
+			withOverrideReason("For the test"),
 		defaultTest("User Override").
 			withSubTestSuffix("OutputFailNoTemplates").
 			withChecks(defaultChecks.withPrefixedSuffix("failOutput")).
@@ -450,6 +1203,14 @@ func TestCompareRun(t *testing.T) {
 			withSubTestSuffix("Input").
 			withChecks(defaultChecks.withPrefixedSuffix("successful")).
 			withUserOverridePath("localnewOverridesWithReasonout.golden"),
+		defaultTest("User Override").
+			withSubTestSuffix("Input Generated rfc6902").
+			withChecks(defaultChecks.withPrefixedSuffix("generatedRfc6902")).
+			withUserOverridePath("localnewOverridesRfc6902out.golden"),
+		defaultTest("User Override").
+			withSubTestSuffix("Input Generated GoTemplate").
+			withChecks(defaultChecks.withPrefixedSuffix("generatedGoTemplate")).
+			withUserOverridePath("localnewOverridesGoTemplateout.golden"),
 		defaultTest("User Override").
 			withSubTestSuffix("Input rfc6902").
 			withChecks(defaultChecks.withPrefixedSuffix("rfc6902")).
@@ -462,6 +1223,27 @@ func TestCompareRun(t *testing.T) {
 			withSubTestSuffix("Input Exact Match").
 			withChecks(defaultChecks.withPrefixedSuffix("exactMatch")).
 			withUserOverridePath("exactMatch.patch"),
+		defaultTest("User Override").
+			withSubTestSuffix("Fail Load Unknown Field").
+			withChecks(defaultChecks.withPrefixedSuffix("unknownFieldLoad")).
+			withUserOverridePath("unknownField.patch"),
+		defaultTest("User Override").
+			withSubTestSuffix("Input Expiry").
+			withChecks(defaultChecks.withPrefixedSuffix("expiry")).
+			withUserOverridePath("expiry.patch"),
+		defaultTest("User Override").
+			withSubTestSuffix("Fail Load Expired").
+			withChecks(defaultChecks.withPrefixedSuffix("expiredLoad")).
+			withUserOverridePath("expiry.patch").
+			withFailOnExpiredOverrides(),
+		defaultTest("User Override").
+			withSubTestSuffix("Input Unused").
+			withChecks(defaultChecks.withPrefixedSuffix("unused")).
+			withUserOverridePath("unused.patch"),
+		defaultTest("User Override").
+			withSubTestSuffix("Input Stale Reference").
+			withChecks(defaultChecks.withPrefixedSuffix("staleReference")).
+			withUserOverridePath("staleReference.patch"),
 		defaultTest("User Override").
 			withSubTestSuffix("Fail Load No Reason").
 			withChecks(defaultChecks.withPrefixedSuffix("noReasonLoad")).
@@ -471,12 +1253,38 @@ func TestCompareRun(t *testing.T) {
 			withOutputFormat(PatchYaml).
 			withGenerateForTemplate("namespace.yaml").
 			withChecks(defaultChecks.withPrefixedSuffix("noReasonGenerate")),
+		defaultTest("User Override").
+			withSubTestSuffix("Fail Generation Only Validation").
+			withOutputFormat(PatchYaml).
+			withOnlyValidation().
+			withGenerateForTemplate("namespace.yaml").
+			withOverrideReason("For the test").
+			withChecks(defaultChecks.withPrefixedSuffix("onlyValidationGenerate")),
 		defaultTest("Reference Has Valid Version"),
 		defaultTest("Reference Has Invalid Version"),
 		defaultTest("All Required Templates Exist And There Are No Diffs Ref V2").
 			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}, {Local, URL}, {Live, URL}}),
 
 		defaultTest("Reference V2 Too Many Keys In Component Group"),
+		defaultTest("Reference V2 Correlation Groups").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}}),
+		defaultTest("Reference V2 Correlation Groups").
+			withSubTestWithMetadata("default"),
+		defaultTest("Reference V2 Correlation Groups").
+			withSubTestWithMetadata("bad path"),
+		defaultTest("Reference V3 Values Ref").
+			withModes([]Mode{{Live, LocalRef}, {Local, LocalRef}, {Local, URL}}),
+		defaultTest("Reference V3 Values Ref").
+			withSubTestWithMetadata("v2 with values ref"),
+		defaultTest("Reference V3 Values Ref").
+			withSubTestWithMetadata("missing values file"),
+		defaultTest("Reference V2 Template Defaults"),
+		defaultTest("Reference Imports").
+			withModes([]Mode{{Local, LocalRef}, {Local, URL}}).
+			withMetadataFile("site/metadata.yaml"),
+		defaultTest("Reference Imports").
+			withMetadataFile("loop/a.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("circular")),
 		defaultTest("Reference V2 Only One").
 			withSubTestSuffix("All Of").
 			withMetadataFile("metadata-all-of.yaml").
@@ -539,7 +1347,72 @@ func TestCompareRun(t *testing.T) {
 			withSubTestSuffix("With Diff In First Line").
 			withMetadataFile("metadata-regex-with-diff-in-first-line.yaml").
 			withChecks(defaultChecks.withPrefixedSuffix("WithDiffInFirstLine")),
+		defaultTest("Capturegroup Scope"),
+		defaultTest("Capturegroup Scope").
+			withVerboseOutput().
+			withChecks(defaultChecks.withPrefixedSuffix("verbose")),
+		defaultTest("Capturegroup Scope").
+			withSubTestSuffix("Default Scope").
+			withMetadataFile("metadata-default-scope.yaml").
+			withVerboseOutput().
+			withChecks(defaultChecks.withPrefixedSuffix("defaultScope")),
+		defaultTest("Capturegroup Scope").
+			withSubTestSuffix("Json").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("Capturegroup Scope").
+			withSubTestSuffix("Generate Override").
+			withOutputFormat(PatchYaml).
+			withGenerateForTemplate("cm.yaml").
+			withOverrideReason("For the test").
+			withChecks(defaultChecks.withPrefixedSuffix("generateOverride")),
+		defaultTest("Capturegroup Scope").
+			withSubTestSuffix("Generate Override For CR").
+			withOutputFormat(PatchYaml).
+			withGenerateForTemplate("cm.yaml=ConfigMap/teams/config-b").
+			withOverrideReason("For the test").
+			withChecks(defaultChecks.withPrefixedSuffix("generateOverrideForCR")),
+		defaultTest("Capturegroup Scope").
+			withSubTestSuffix("Generate Override For CR Glob").
+			withOutputFormat(PatchYaml).
+			withGenerateForTemplate("cm.yaml=ConfigMap/*/config-[ac]").
+			withOverrideReason("For the test").
+			withChecks(defaultChecks.withPrefixedSuffix("generateOverrideForCRGlob")),
+		defaultTest("Capturegroup Scope").
+			withSubTestSuffix("Generate Override Invalid Selector").
+			withOutputFormat(PatchYaml).
+			withGenerateForTemplate("cm.yaml=config-b").
+			withOverrideReason("For the test").
+			withChecks(defaultChecks.withPrefixedSuffix("generateOverrideInvalidSelector")),
+		defaultTest("ReferenceV2 Consistent Capturegroups"),
+		defaultTest("ReferenceV2 Consistent Capturegroups").
+			withSubTestSuffix("Json").
+			withOutputFormat(Json).
+			withChecks(defaultChecks.withPrefixedSuffix("json")),
+		defaultTest("ReferenceV2 Consistent Capturegroups").
+			withSubTestSuffix("Component Scope").
+			withMetadataFile("metadata-component.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("componentScope")),
+		defaultTest("ReferenceV2InlineSemverRange"),
+		defaultTest("ReferenceV2InlineSemverRange").
+			withSubTestSuffix("Invalid Range").
+			withMetadataFile("metadata-invalid-range.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("invalidRange")),
+		defaultTest("ReferenceV2InlineSetCompare"),
+		defaultTest("ReferenceV2ListsAsSets"),
+		defaultTest("ReferenceV2ListsAsSets").
+			withMetadataFile("invalid.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("invalid")),
+		defaultTest("ReferenceV2InlineBase64Decoded"),
+		defaultTest("ReferenceV2InlineK8sQuantity"),
+		defaultTest("ReferenceV2InlineX509"),
+		defaultTest("ReferenceV2InlineIPRange"),
+		defaultTest("ReferenceV2InlineDuration"),
 		defaultTest("ReferenceV2InlineCapturegroups"),
+		defaultTest("ReferenceV2InlineCapturegroups").
+			withSubTestSuffix("Unknown Field").
+			withMetadataFile("metadata-unknown-field.yaml").
+			withChecks(defaultChecks.withPrefixedSuffix("unknownField")),
 		defaultTest("ReferenceV2InlineCapturegroups").
 			withSubTestSuffix("Invalid Capturegroups").
 			withMetadataFile("metadata-invalid-capturegroups.yaml").
@@ -617,6 +1490,8 @@ func TestCompareRun(t *testing.T) {
 				),
 			}),
 		defaultTest("Reference V2 Diff in Custom Omitted Fields Isnt Shown Prefix"),
+		defaultTest("Reference V2 Diff in Custom Omitted Fields Isnt Shown Wildcards"),
+		defaultTest("Built In Omit Groups"),
 
 		defaultTest("Description").withSubTestWithMetadata("shown for diff"),
 		defaultTest("Description").withSubTestWithMetadata("shown for missing file"),
@@ -641,8 +1516,40 @@ func TestCompareRun(t *testing.T) {
 			withEnvVar("KUBECTL_EXTERNAL_DIFF", "diff -y -W 150").
 			withChecks(defaultChecks.withPrefixedSuffix("with_diff_y")),
 		defaultTest("Machine Configs Catch All"),
+		defaultTest("SomeDiffs").
+			withDiffEngine(DiffEngineInternal).
+			withChecks(defaultChecks.withPrefixedSuffix("internalDiffEngine")),
+		defaultTest("Machine Configs Catch All").
+			withDiffEngine(DiffEngineInternal).
+			withChecks(defaultChecks.withPrefixedSuffix("internalDiffEngine")),
+		defaultTest("SomeDiffs").
+			withDiffRendering(DiffStyleSideBySide, "").
+			withChecks(defaultChecks.withPrefixedSuffix("sideBySide")),
+		defaultTest("SomeDiffs").
+			withDiffRendering("", ColorAlways).
+			withChecks(defaultChecks.withPrefixedSuffix("colorAlways")),
+		defaultTest("SomeDiffs").
+			withDiffRendering(DiffStyleSideBySide, ColorAlways).
+			withChecks(defaultChecks.withPrefixedSuffix("sideBySideColorAlways")),
+		defaultTest("SomeDiffs").
+			withDiffEngine(DiffEngineExternal).
+			withDiffRendering(DiffStyleSideBySide, "").
+			withChecks(defaultChecks.withPrefixedSuffix("sideBySideExternal")),
+		defaultTest("SomeDiffs").
+			withDiffRendering("split", "sometimes").
+			withChecks(defaultChecks.withPrefixedSuffix("unknownDiffStyle")),
+		defaultTest("SomeDiffs").
+			withDiffEngine("vimdiff").
+			withChecks(defaultChecks.withPrefixedSuffix("unknownDiffEngine")),
+		defaultTest("SomeDiffs").
+			withProgress("spinner").
+			withChecks(defaultChecks.withPrefixedSuffix("unknownProgress")),
 	}
 
+	// The expiry and the age of the user overrides are relative to a fixed date to keep the golden files stable
+	defer func(n func() time.Time) { now = n }(now)
+	now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+
 	tf := cmdtesting.NewTestFactory()
 	testFlags := flag.NewFlagSet("test", flag.ContinueOnError)
 	klog.InitFlags(testFlags)
@@ -701,15 +1608,130 @@ func getCommand(t *testing.T, test *Test, modeIndex int, tf *cmdtesting.TestFact
 	if test.verboseOutput {
 		require.NoError(t, cmd.Flags().Set("verbose", "true"))
 	}
+	if test.onlyValidation {
+		require.NoError(t, cmd.Flags().Set("only-validation", "true"))
+	}
+	if test.correlationReport {
+		require.NoError(t, cmd.Flags().Set("correlation-report", "true"))
+	}
+	if test.matchStrategy != "" {
+		require.NoError(t, cmd.Flags().Set("match-strategy", test.matchStrategy))
+	}
+	for flag, value := range map[string]string{
+		"include-templates": test.includeTemplates,
+		"exclude-templates": test.excludeTemplates,
+		"part":              test.parts,
+		"component":         test.components,
+	} {
+		if value != "" {
+			require.NoError(t, cmd.Flags().Set(flag, value))
+		}
+	}
+	if test.clusterVersion != "" {
+		require.NoError(t, cmd.Flags().Set("cluster-version", test.clusterVersion))
+	}
+	if test.groupBy != "" {
+		require.NoError(t, cmd.Flags().Set("group-by", test.groupBy))
+	}
+	if test.coverage {
+		require.NoError(t, cmd.Flags().Set("coverage", "true"))
+	}
+	if test.minCoverage != "" {
+		require.NoError(t, cmd.Flags().Set("min-coverage", test.minCoverage))
+	}
+	if test.severityRulesFileName != "" {
+		require.NoError(t, cmd.Flags().Set("severity-rules", path.Join(test.getTestDir(), test.severityRulesFileName)))
+	}
+	if test.maintenanceWindow != "" {
+		require.NoError(t, cmd.Flags().Set("maintenance-window", test.maintenanceWindow))
+	}
+	if test.sinceFileName != "" {
+		require.NoError(t, cmd.Flags().Set("since", path.Join(test.getTestDir(), test.sinceFileName)))
+	}
+	if test.crdSchemasDir != "" {
+		require.NoError(t, cmd.Flags().Set("crd-schemas", path.Join(test.getTestDir(), test.crdSchemasDir)))
+	}
+	if test.publicKey != "" {
+		require.NoError(t, cmd.Flags().Set("verify-signature", path.Join(test.getTestDir(), test.publicKey)))
+	}
+	if test.recordDir != "" {
+		require.NoError(t, cmd.Flags().Set("record", test.recordDir))
+	}
+	if test.validateTemplates {
+		require.NoError(t, cmd.Flags().Set("validate-templates", "true"))
+	}
+	if test.schemaDefaults {
+		require.NoError(t, cmd.Flags().Set("schema-defaults", "true"))
+	}
+	if test.ignoreManagers != "" {
+		require.NoError(t, cmd.Flags().Set("ignore-fields-managed-by", test.ignoreManagers))
+	}
+	for _, script := range test.preprocessExecs {
+		require.NoError(t, cmd.Flags().Set("pre-process-exec", "sh "+path.Join(test.getTestDir(), script)))
+	}
+	if test.kustomizeBuildOptions != "" {
+		require.NoError(t, cmd.Flags().Set("kustomize-build-options", test.kustomizeBuildOptions))
+	}
+	if test.diffEngine != "" {
+		require.NoError(t, cmd.Flags().Set("diff-engine", test.diffEngine))
+	}
+	if test.diffStyle != "" {
+		require.NoError(t, cmd.Flags().Set("diff-style", test.diffStyle))
+	}
+	if test.color != "" {
+		require.NoError(t, cmd.Flags().Set("color", test.color))
+	}
+	if test.snapshot {
+		require.NoError(t, cmd.Flags().Set("snapshot", "true"))
+	}
+	if len(test.contexts) > 0 {
+		require.NoError(t, cmd.Flags().Set("contexts", strings.Join(test.contexts, ",")))
+	}
+	if test.cacheTTL != "" {
+		require.NoError(t, cmd.Flags().Set("cache-ttl", test.cacheTTL))
+	}
+	if test.progress != "" {
+		require.NoError(t, cmd.Flags().Set("progress", test.progress))
+	}
+	if test.referenceCatalog != "" {
+		require.NoError(t, cmd.Flags().Set("reference-catalog", path.Join(test.getTestDir(), test.referenceCatalog)))
+	}
+	if test.autoReference {
+		require.NoError(t, cmd.Flags().Set("auto-reference", "true"))
+	}
+	if test.requestRetries != "" {
+		require.NoError(t, cmd.Flags().Set("request-retries", test.requestRetries))
+		require.NoError(t, cmd.Flags().Set("request-backoff", "1ms"))
+	}
 	resourcesDir := path.Join(test.getTestDir(), ResourceDirName)
 	switch mode.crSource {
 	case Local:
+		if test.kustomizeDir != "" {
+			require.NoError(t, cmd.Flags().Set("kustomize", path.Join(test.getTestDir(), test.kustomizeDir)))
+			break
+		}
+		if len(test.snapshotDirs) > 0 {
+			require.NoError(t, cmd.Flags().Set("detect-flapping", "true"))
+			for _, dir := range test.snapshotDirs {
+				require.NoError(t, cmd.Flags().Set("filename", path.Join(resourcesDir, dir)))
+			}
+			break
+		}
+		if test.stdinFileName != "" {
+			content, err := os.ReadFile(path.Join(test.getTestDir(), test.stdinFileName))
+			require.NoError(t, err)
+			streams.In.(*bytes.Buffer).Write(content) //nolint:forcetypeassert // the streams are the test streams
+			require.NoError(t, cmd.Flags().Set("filename", "-"))
+		}
 		require.NoError(t, cmd.Flags().Set("filename", resourcesDir))
 		require.NoError(t, cmd.Flags().Set("recursive", "true"))
 	case Live:
 		discoveryResources, resources := getResources(t, *test, resourcesDir)
 		updateTestDiscoveryClient(tf, discoveryResources)
-		setClient(t, resources, tf)
+		setClient(t, resources, tf, test.failingRequests)
+		if len(test.contexts) > 0 {
+			setContextFactories(t, discoveryResources, tf)
+		}
 	}
 	switch mode.refSource {
 	case URL:
@@ -743,40 +1765,82 @@ func getCommand(t *testing.T, test *Test, modeIndex int, tf *cmdtesting.TestFact
 	if test.overrideGenReason != "" {
 		require.NoError(t, cmd.Flags().Set("override-reason", test.overrideGenReason))
 	}
+	if test.patchType != "" {
+		require.NoError(t, cmd.Flags().Set("patch-type", test.patchType))
+	}
+	if test.failOnExpired {
+		require.NoError(t, cmd.Flags().Set("fail-on-expired-overrides", "true"))
+	}
 
 	return cmd
 }
 
-func setClient(t *testing.T, resources []*unstructured.Unstructured, tf *cmdtesting.TestFactory) {
+func setClient(t *testing.T, resources []*unstructured.Unstructured, tf *cmdtesting.TestFactory, failing map[string]int) {
 	resourcesByKind := make(map[string][]*unstructured.Unstructured)
 	for _, t := range resources {
 		key := fmt.Sprintf("/%ss", strings.ToLower(t.GetKind()))
 		resourcesByKind[key] = append(resourcesByKind[key], t)
 	}
-	tf.UnstructuredClient = &fake.RESTClient{
-		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
-		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
-			switch p, m := req.URL.Path, req.Method; {
-			case m == "GET":
-				a := unstructured.Unstructured{}
-				exampleResource := resourcesByKind[p][0]
-				a.SetKind(exampleResource.GetKind() + "List")
-				a.SetAPIVersion(exampleResource.GetAPIVersion())
-				a.SetResourceVersion(exampleResource.GetResourceVersion())
-
-				requestedResources := lo.Map(resourcesByKind[p], func(value *unstructured.Unstructured, index int) any {
-					return value.Object
-				})
+	failing = maps.Clone(failing)
+	var lock sync.Mutex
+	client := fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+		lock.Lock()
+		fail := failing[req.URL.Path] != 0
+		if failing[req.URL.Path] > 0 {
+			failing[req.URL.Path]--
+		}
+		lock.Unlock()
+		switch p, m := req.URL.Path, req.Method; {
+		case fail:
+			bodyRC := io.NopCloser(strings.NewReader("etcdserver: request timed out"))
+			return &http.Response{StatusCode: http.StatusInternalServerError, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, nil
+		case m == "GET":
+			a := unstructured.Unstructured{}
+			exampleResource := resourcesByKind[p][0]
+			a.SetKind(exampleResource.GetKind() + "List")
+			a.SetAPIVersion(exampleResource.GetAPIVersion())
+			a.SetResourceVersion(exampleResource.GetResourceVersion())
+
+			requestedResources := lo.Map(resourcesByKind[p], func(value *unstructured.Unstructured, index int) any {
+				return value.Object
+			})
 
-				require.NoError(t, unstructured.SetNestedSlice(a.Object, requestedResources, "items"))
-				b, _ := a.MarshalJSON()
-				bodyRC := io.NopCloser(bytes.NewReader(b))
-				return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, nil
-			default:
-				t.Fatalf("unexpected request: %#v\n%#v", req.URL, req)
-				return nil, nil
-			}
-		}),
+			require.NoError(t, unstructured.SetNestedSlice(a.Object, requestedResources, "items"))
+			b, _ := a.MarshalJSON()
+			bodyRC := io.NopCloser(bytes.NewReader(b))
+			return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, nil
+		default:
+			t.Fatalf("unexpected request: %#v\n%#v", req.URL, req)
+			return nil, nil
+		}
+	})
+	// The types are listed concurrently and fake.RESTClient records the last request it got, each type gets its own
+	// client
+	tf.UnstructuredClientForMappingFunc = func(schema.GroupVersion) (resource.RESTClient, error) {
+		return &fake.RESTClient{
+			NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+			Client:               client,
+		}, nil
+	}
+}
+
+// setContextFactories makes the contexts of --contexts use the clients of the test factory, except the context
+// unreachableContext whose cluster fails every request.
+func setContextFactories(t *testing.T, discoveryResources []v1.APIResource, tf *cmdtesting.TestFactory) {
+	newFactory := newContextFactory
+	t.Cleanup(func() { newContextFactory = newFactory })
+	newContextFactory = func(context string) cmdutil.Factory {
+		if context != unreachableContext {
+			return tf
+		}
+		unreachable := cmdtesting.NewTestFactory()
+		t.Cleanup(unreachable.Cleanup)
+		updateTestDiscoveryClient(unreachable, discoveryResources)
+		unreachable.UnstructuredClient = &fake.RESTClient{
+			NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+			Err:                  errors.New("connection refused"),
+		}
+		return unreachable
 	}
 }
 
@@ -812,10 +1876,158 @@ func getResources(t *testing.T, test Test, resourcesDir string) ([]v1.APIResourc
 	return rL, resources
 }
 
+// testServerVersion is the version of the test clusters, the fake discovery client doesn't implement ServerVersion.
+type testServerVersion struct {
+	discovery.DiscoveryInterface
+}
+
+func (testServerVersion) ServerVersion() (*version.Info, error) {
+	return &version.Info{GitVersion: "v1.30.0"}, nil
+}
+
 func updateTestDiscoveryClient(tf *cmdtesting.TestFactory, discoveryResources []v1.APIResource) {
 	discoveryClient := cmdtesting.NewFakeCachedDiscoveryClient()
+	discoveryClient.DiscoveryInterface = testServerVersion{}
 	ResourceList := v1.APIResourceList{APIResources: discoveryResources}
 	discoveryClient.Resources = append(discoveryClient.Resources, &ResourceList)
 	discoveryClient.PreferredResources = append(discoveryClient.PreferredResources, &ResourceList)
 	tf.WithDiscoveryClient(discoveryClient)
 }
+
+// TestProcessStream checks the pool of workers comparing the cluster CRs.
+func TestProcessStream(t *testing.T) {
+	newEngine := func(t *testing.T, testName, referenceFileName string, opts EngineOptions) (*Engine, []*unstructured.Unstructured) {
+		test := defaultTest(testName)
+		refPath := path.Join(test.getTestDir(), TestRefDirName, referenceFileName)
+		cfs, err := GetRefFS(refPath)
+		require.NoError(t, err)
+		ref, err := GetReference(cfs, filepath.Base(refPath))
+		require.NoError(t, err)
+		engine, err := NewEngine(ref, cfs, opts)
+		require.NoError(t, err)
+		_, clusterCRs := getResources(t, test, path.Join(test.getTestDir(), ResourceDirName))
+		return engine, clusterCRs
+	}
+	// inFlight counts the cluster CRs being compared concurrently, wait is called while the CR is compared
+	type inFlight struct {
+		lock    sync.Mutex
+		current int
+		max     int
+	}
+	counting := func(counter *inFlight, wait func()) CRPreprocessor {
+		return CRPreprocessorFunc(func(_ context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+			counter.lock.Lock()
+			counter.current++
+			counter.max = max(counter.max, counter.current)
+			counter.lock.Unlock()
+			wait()
+			counter.lock.Lock()
+			counter.current--
+			counter.lock.Unlock()
+			return clusterCR, nil
+		})
+	}
+
+	t.Run("Same Output For Any Concurrency", func(t *testing.T) {
+		for _, reference := range []struct{ testName, fileName string }{
+			{"ReferenceV2All", "metadata-all-of.yaml"},
+			{"Multi Document Templates", defaultReferenceFilename},
+			{"CapturegroupScope", defaultReferenceFilename},
+		} {
+			var expected []byte
+			for _, concurrency := range []int{1, 2, 4, 16} {
+				engine, clusterCRs := newEngine(t, reference.testName, reference.fileName,
+					EngineOptions{Concurrency: concurrency, DiffAll: true})
+				output, err := engine.Compare(clusterCRs)
+				require.NoError(t, err)
+				out, err := json.Marshal(output)
+				require.NoError(t, err)
+				// The diff programs are passed temporary files
+				actual := []byte(testutils.RemoveInconsistentInfo(t, string(out), testutils.FixupOptions{}))
+				if expected == nil {
+					require.NotEmpty(t, *output.Diffs, reference.testName)
+					expected = actual
+					continue
+				}
+				require.Equal(t, string(expected), string(actual), "%s with --concurrency %d", reference.testName, concurrency)
+			}
+		}
+	})
+
+	t.Run("Concurrent Workers", func(t *testing.T) {
+		counter := &inFlight{}
+		// The CRs wait for another one to be compared concurrently, or for a while when there is a single worker
+		concurrent := make(chan struct{})
+		var once sync.Once
+		wait := func() {
+			counter.lock.Lock()
+			overlapping := counter.current > 1
+			counter.lock.Unlock()
+			if overlapping {
+				once.Do(func() { close(concurrent) })
+			}
+			select {
+			case <-concurrent:
+			case <-time.After(5 * time.Second):
+			}
+		}
+		engine, clusterCRs := newEngine(t, "ReferenceV2All", "metadata-all-of.yaml",
+			EngineOptions{Concurrency: 4, Preprocessors: []CRPreprocessor{counting(counter, wait)}})
+		_, err := engine.Compare(clusterCRs)
+		require.NoError(t, err)
+		require.Greater(t, counter.max, 1)
+	})
+
+	t.Run("Capturegroups Use A Single Worker", func(t *testing.T) {
+		counter := &inFlight{}
+		engine, clusterCRs := newEngine(t, "CapturegroupScope", defaultReferenceFilename,
+			EngineOptions{Concurrency: 4, Preprocessors: []CRPreprocessor{counting(counter, func() {
+				time.Sleep(20 * time.Millisecond)
+			})}})
+		output, err := engine.Compare(clusterCRs)
+		require.NoError(t, err)
+		require.Equal(t, 1, counter.max)
+		require.Equal(t, 1, output.Summary.NumDiffCRs)
+	})
+
+	t.Run("Timeout In The Middle Of The Stream", func(t *testing.T) {
+		hung := make(chan struct{})
+		t.Cleanup(func() { close(hung) })
+		for _, test := range []struct {
+			name string
+			// compare is called for the deployments, it doesn't complete before the timeout
+			compare func(ctx context.Context) error
+		}{
+			{"hung", func(context.Context) error { <-hung; return nil }},
+			{"interrupted", func(ctx context.Context) error { <-ctx.Done(); return fmt.Errorf("killed: %w", ctx.Err()) }},
+		} {
+			for _, concurrency := range []int{1, 4} {
+				name := fmt.Sprintf("%s with --concurrency %d", test.name, concurrency)
+				engine, clusterCRs := newEngine(t, "ReferenceV2All", "metadata-all-of.yaml", EngineOptions{Concurrency: concurrency})
+				stuck := CRPreprocessorFunc(func(ctx context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+					if clusterCR.GetKind() == "Deployment" {
+						return nil, test.compare(ctx)
+					}
+					return clusterCR, nil
+				})
+				o := *engine.options
+				o.preprocessors = append(slices.Clone(o.preprocessors), stuck)
+				o.metricsTracker = NewMetricsTracker()
+				o.timeout = 100 * time.Millisecond
+				cancel := o.startDeadline()
+				output, _, _, err := o.compareCRs(clusterCRs)
+				cancel()
+				require.NoError(t, err, name)
+				require.True(t, o.timedOut, name)
+				require.Contains(t, output.Summary.ValidationIssues, TimeoutGroup, name)
+				for _, d := range *output.Diffs {
+					require.NotEqual(t, "Deployment", strings.Split(d.CRName, "_")[1], name)
+				}
+				if concurrency > 1 {
+					// The other workers keep comparing the CRs queued after the stuck ones
+					require.NotEmpty(t, *output.Diffs, name)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	tierUnmatched       = "unmatched"
+	chosenByLeastDiffs  = "(least diffs)"
+	correlationNotFound = "-"
+)
+
+// CorrelationReportEntry is how a cluster CR is correlated: the templates it can be compared to, the template it's
+// compared to and the correlator that matched it. When there are several candidates the template with the least diffs
+// is chosen, which is only known by diffing so Chosen is left empty.
+type CorrelationReportEntry struct {
+	CR         string   `json:"cr"`
+	Candidates []string `json:"candidates,omitempty"`
+	Chosen     string   `json:"chosen,omitempty"`
+	Tier       string   `json:"tier"`
+}
+
+// buildCorrelationReport correlates the cluster CRs to the templates without diffing them.
+func (o *Options) buildCorrelationReport(clusterCRs []*unstructured.Unstructured) []CorrelationReportEntry {
+	report := make([]CorrelationReportEntry, 0, len(clusterCRs))
+	for _, clusterCR := range clusterCRs {
+		entry := CorrelationReportEntry{CR: apiKindNamespaceName(clusterCR), Tier: tierUnmatched}
+		temps, tier, err := o.correlator.matchDescribed(clusterCR)
+		if err == nil && len(temps) > 0 {
+			entry.Tier = tier
+			for _, temp := range temps {
+				entry.Candidates = append(entry.Candidates, temp.GetIdentifier())
+			}
+			sort.Strings(entry.Candidates)
+			if len(temps) == 1 {
+				entry.Chosen = entry.Candidates[0]
+			}
+		}
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].CR < report[j].CR
+	})
+	return report
+}
+
+// printCorrelationReport collects the cluster CRs and prints how they are correlated, as a table or in the json or yaml
+// output format.
+func (o *Options) printCorrelationReport(w io.Writer) error {
+	clusterCRs, err := o.collect()
+	if err != nil {
+		return err
+	}
+	report := o.buildCorrelationReport(clusterCRs)
+	var content []byte
+	switch o.OutputFormat {
+	case Json:
+		content, err = json.MarshalIndent(report, "", "    ")
+	case Yaml:
+		content, err = yaml.Marshal(report)
+	default:
+		content = []byte(correlationTable(report))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal the correlation report: %w", err)
+	}
+	_, err = fmt.Fprintln(w, strings.TrimRight(string(content), "\n"))
+	return err
+}
+
+func correlationTable(report []CorrelationReportEntry) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER CR\tCANDIDATE TEMPLATES\tCHOSEN TEMPLATE\tTIER")
+	for _, entry := range report {
+		candidates, chosen := correlationNotFound, correlationNotFound
+		if len(entry.Candidates) > 0 {
+			candidates = strings.Join(entry.Candidates, ", ")
+			chosen = entry.Chosen
+			if chosen == "" {
+				chosen = chosenByLeastDiffs
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.CR, candidates, chosen, entry.Tier)
+	}
+	_ = w.Flush()
+	return buf.String()
+}
@@ -5,11 +5,16 @@ package compare
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/klog/v2"
 )
@@ -48,16 +53,33 @@ func NewMultiCorrelator[T CorrelationEntry](correlators []Correlator[T]) *MultiC
 }
 
 func (c MultiCorrelator[T]) Match(object *unstructured.Unstructured) ([]T, error) {
+	temps, _, err := c.matchDescribed(object)
+	return temps, err
+}
+
+// describedCorrelator is implemented by the correlators that can tell how they matched a Resource, it's shown by the
+// correlation report.
+type describedCorrelator interface {
+	describeMatch(object *unstructured.Unstructured) string
+}
+
+// matchDescribed matches the Resource like Match and also returns the description of the match by the correlator that
+// matched it.
+func (c MultiCorrelator[T]) matchDescribed(object *unstructured.Unstructured) ([]T, string, error) {
 	var errs []error
 	for _, core := range c.correlators {
 		temp, err := core.Match(object)
 		if err == nil || !errors.As(err, &UnknownMatch{}) {
-			return temp, err // nolint:wrapcheck
+			description := ""
+			if described, ok := core.(describedCorrelator); ok && err == nil {
+				description = described.describeMatch(object)
+			}
+			return temp, description, err // nolint:wrapcheck
 		}
 		errs = append(errs, err)
 	}
 	var res []T
-	return res, errors.Join(errs...) // nolint:wrapcheck
+	return res, "", errors.Join(errs...) // nolint:wrapcheck
 }
 
 type CorrelationEntry interface {
@@ -98,6 +120,72 @@ func (c ExactMatchCorrelator[T]) Match(object *unstructured.Unstructured) ([]T,
 	return []T{temp}, nil
 }
 
+func (c ExactMatchCorrelator[T]) describeMatch(_ *unstructured.Unstructured) string {
+	return "exact"
+}
+
+// RegexMatchCorrelator Matches templates by predefined pairs of regular expressions and templates. A Resource is matched
+// to the templates of all the regular expressions that fully match its name in the apiVersion-kind-namespace-name format
+// (apiVersion-kind-name for resources that are not namespaced). Used for resources with generated (hash-suffixed) names
+// that can't be matched by ExactMatchCorrelator.
+type RegexMatchCorrelator[T CorrelationEntry] struct {
+	pairs []regexMatchPair[T]
+}
+
+type regexMatchPair[T CorrelationEntry] struct {
+	regex *regexp.Regexp
+	temp  T
+}
+
+func NewRegexMatchCorrelator[T CorrelationEntry](matchPairs map[string]string, templates []T) (*RegexMatchCorrelator[T], error) {
+	core := RegexMatchCorrelator[T]{}
+	nameToObject := make(map[string]T)
+	for _, temp := range templates {
+		nameToObject[temp.GetIdentifier()] = temp
+	}
+	var errs []error
+	exprs := lo.Keys(matchPairs)
+	sort.Strings(exprs)
+	for _, expr := range exprs {
+		temp := matchPairs[expr]
+		obj, ok := nameToObject[temp]
+		if !ok {
+			errs = append(errs, fmt.Errorf("error in template manual matching for resources matching: %s no template in the name of %s", expr, temp))
+			continue
+		}
+		regex, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error in template manual matching, %s isn't a valid regex: %w", expr, err))
+			continue
+		}
+		core.pairs = append(core.pairs, regexMatchPair[T]{regex: regex, temp: obj})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &core, nil
+}
+
+func (c RegexMatchCorrelator[T]) Match(object *unstructured.Unstructured) ([]T, error) {
+	name := apiKindNamespaceName(object)
+	var temps []T
+	seen := make(map[string]bool)
+	for _, pair := range c.pairs {
+		if pair.regex.MatchString(name) && !seen[pair.temp.GetIdentifier()] {
+			seen[pair.temp.GetIdentifier()] = true
+			temps = append(temps, pair.temp)
+		}
+	}
+	if len(temps) == 0 {
+		return []T{}, UnknownMatch{Resource: object}
+	}
+	return temps, nil
+}
+
+func (c RegexMatchCorrelator[T]) describeMatch(_ *unstructured.Unstructured) string {
+	return "regex"
+}
+
 // GroupCorrelator Matches templates by hashing predefined fields.
 // All The templates are indexed by  hashing groups of `indexed` fields. The `indexed` fields can be nested.
 // Resources will be attempted to be matched with hashing by the group with the largest amount of `indexed` fields.
@@ -107,6 +195,8 @@ func (c ExactMatchCorrelator[T]) Match(object *unstructured.Unstructured) ([]T,
 // Templates will be only indexed by a group of fields only if all fields in group are not templated.
 type GroupCorrelator[T CorrelationEntry] struct {
 	fieldCorrelators []*FieldCorrelator[T]
+	// warnings report the templates that have the same correlation keys
+	warnings []string
 }
 
 // NewGroupCorrelator creates a new GroupCorrelator using inputted fieldGroups and generated GroupFunctions and templatesByGroups.
@@ -134,7 +224,7 @@ func NewGroupCorrelator[T CorrelationEntry](fieldGroups [][][]string, objects []
 
 		err := fc.ValidateTemplates()
 		if err != nil {
-			klog.Warning(err)
+			core.warnings = append(core.warnings, err.Error())
 		}
 
 		if len(objects) == 0 {
@@ -196,6 +286,143 @@ func (c *GroupCorrelator[T]) Match(object *unstructured.Unstructured) ([]T, erro
 	return []T{}, UnknownMatch{Resource: object}
 }
 
+// describeMatch returns the fields the Resource was matched by. Matches by a group with less fields than the first group
+// of the correlator are fallbacks.
+func (c *GroupCorrelator[T]) describeMatch(object *unstructured.Unstructured) string {
+	for i, fc := range c.fieldCorrelators {
+		if temp, err := fc.Match(object); err != nil || len(temp) == 0 {
+			continue
+		}
+		if i > 0 && len(fc.Fields) < len(c.fieldCorrelators[0].Fields) {
+			return fmt.Sprintf("fallback (%s)", getFields(fc.Fields))
+		}
+		return fmt.Sprintf("group fields (%s)", getFields(fc.Fields))
+	}
+	return ""
+}
+
+// CatchAllCorrelator Matches the catch-all templates (catchAll: true) by hashing predefined fields like GroupCorrelator.
+// It's the last correlator, so the catch-all templates are only matched to the Resources that no other template is
+// matched to, instead of competing with the more specific templates.
+type CatchAllCorrelator[T CorrelationEntry] struct {
+	GroupCorrelator[T]
+}
+
+func NewCatchAllCorrelator[T CorrelationEntry](fieldGroups [][][]string, objects []T) (*CatchAllCorrelator[T], error) {
+	core, err := NewGroupCorrelator(fieldGroups, objects)
+	if err != nil {
+		return nil, err
+	}
+	return &CatchAllCorrelator[T]{GroupCorrelator: *core}, nil
+}
+
+func (c *CatchAllCorrelator[T]) describeMatch(object *unstructured.Unstructured) string {
+	return fmt.Sprintf("catch-all, %s", c.GroupCorrelator.describeMatch(object))
+}
+
+// OwnerReferenceCorrelator Matches templates by the controller owner of the Resource (the entry of
+// metadata.ownerReferences with controller: true). Resources generated by controllers, like ReplicaSets or the Pods of
+// DaemonSets, have random names and can't be correlated by name, but the kind and name of their owner are fixed.
+// Templates are indexed by their kind and the kind and name of the controller owner reference they declare, Resources
+// are first matched including their namespace and then without it. Templates without a fully defined controller owner
+// reference aren't indexed and are returned by NewOwnerReferenceCorrelator to be correlated by other correlators.
+type OwnerReferenceCorrelator[T CorrelationEntry] struct {
+	GroupCorrelator[T]
+}
+
+var ownerReferenceFields = [][]string{{"kind"}, {"metadata", "ownerReferences", "controller", "kind"}, {"metadata", "ownerReferences", "controller", "name"}}
+
+func NewOwnerReferenceCorrelator[T CorrelationEntry](objects []T) (*OwnerReferenceCorrelator[T], []T) {
+	core := OwnerReferenceCorrelator[T]{}
+	for _, withNamespace := range []bool{true, false} {
+		fields := ownerReferenceFields
+		if withNamespace {
+			fields = append([][]string{{"metadata", "namespace"}}, fields...)
+		}
+		fc := FieldCorrelator[T]{Fields: fields, hashFunc: createOwnerReferenceHashFunc(withNamespace)}
+		newObjects := fc.ClaimTemplates(objects)
+		if len(newObjects) == len(objects) {
+			continue
+		}
+		objects = newObjects
+		core.fieldCorrelators = append(core.fieldCorrelators, &fc)
+
+		err := fc.ValidateTemplates()
+		if err != nil {
+			core.warnings = append(core.warnings, err.Error())
+		}
+	}
+	return &core, objects
+}
+
+func (c *OwnerReferenceCorrelator[T]) describeMatch(_ *unstructured.Unstructured) string {
+	return "owner reference"
+}
+
+// createOwnerReferenceHashFunc creates a hashing function for the kind (and namespace) of a resource and the kind and
+// name of its controller owner.
+func createOwnerReferenceHashFunc(withNamespace bool) templateHashFunc {
+	return func(cr *unstructured.Unstructured, replaceEmptyWith string) (group string, err error) {
+		var owner *metav1.OwnerReference
+		for _, ref := range cr.GetOwnerReferences() {
+			if ref.Controller != nil && *ref.Controller {
+				owner = &ref
+				break
+			}
+		}
+		if owner == nil || owner.Kind == "" || owner.Name == "" {
+			return "", errors.New("the resource doesn't have a controller owner reference")
+		}
+		values := []string{cr.GetKind(), owner.Kind, owner.Name}
+		if withNamespace {
+			if cr.GetNamespace() == "" {
+				return "", errors.New("the field metadata_namespace doesn't exist in resource")
+			}
+			values = append([]string{cr.GetNamespace()}, values...)
+		}
+		return strings.Join(values, FieldSeparator), nil
+	}
+}
+
+// MatchConditionsCorrelator decorates a Correlator by excluding the matched templates whose match conditions aren't met
+// by the Resource. In case none of the templates is left UnknownMatch is returned so the next correlator can be tried.
+type MatchConditionsCorrelator struct {
+	correlator Correlator[ReferenceTemplate]
+}
+
+func NewMatchConditionsCorrelator(correlator Correlator[ReferenceTemplate]) *MatchConditionsCorrelator {
+	return &MatchConditionsCorrelator{correlator: correlator}
+}
+
+func (c MatchConditionsCorrelator) Match(object *unstructured.Unstructured) ([]ReferenceTemplate, error) {
+	temps, err := c.correlator.Match(object)
+	if err != nil {
+		return temps, err // nolint:wrapcheck
+	}
+	var res []ReferenceTemplate
+	for _, temp := range temps {
+		ok, err := temp.MatchesConditions(object)
+		if err != nil {
+			klog.Warningf("Template %s isn't matched to %s: %s", temp.GetIdentifier(), apiKindNamespaceName(object), err)
+			continue
+		}
+		if ok {
+			res = append(res, temp)
+		}
+	}
+	if len(res) == 0 {
+		return res, UnknownMatch{Resource: object}
+	}
+	return res, nil
+}
+
+func (c MatchConditionsCorrelator) describeMatch(object *unstructured.Unstructured) string {
+	if described, ok := c.correlator.(describedCorrelator); ok {
+		return described.describeMatch(object)
+	}
+	return ""
+}
+
 // MetricsTracker Matches templates by using an existing correlator and gathers summary info related the correlation.
 type MetricsTracker struct {
 	UnMatchedCRs          []*unstructured.Unstructured
@@ -247,6 +474,18 @@ func (c *MetricsTracker) addUNMatch(cr *unstructured.Unstructured) {
 	c.unMatchedLock.Unlock()
 }
 
+// snapshot returns a copy of the tracker that isn't updated anymore.
+func (c *MetricsTracker) snapshot() *MetricsTracker {
+	res := NewMetricsTracker()
+	c.unMatchedLock.Lock()
+	res.UnMatchedCRs = slices.Clone(c.UnMatchedCRs)
+	c.unMatchedLock.Unlock()
+	c.matchedLock.Lock()
+	res.MatchedTemplatesNames = maps.Clone(c.MatchedTemplatesNames)
+	c.matchedLock.Unlock()
+	return res
+}
+
 func (c *MetricsTracker) getTotalCRs() int {
 	count := 0
 	for _, v := range c.MatchedTemplatesNames {
@@ -282,8 +521,14 @@ func (f *FieldCorrelator[T]) ClaimTemplates(templates []T) []T {
 
 func (f *FieldCorrelator[T]) ValidateTemplates() error {
 	errs := make([]error, 0)
-	for _, values := range f.objects {
-		if len(values) > 1 {
+	// The keys are sorted so the warnings are always in the same order
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if values := f.objects[key]; len(values) > 1 {
 			errs = append(errs, fmt.Errorf(
 				"More then one template with same %s. By Default for each Cluster CR that is correlated "+
 					"to one of these templates the template with the least number of diffs will be used. "+
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samber/lo"
+)
+
+const (
+	CoverageGroup    = "Insufficient template coverage"
+	CoverageIssue    = "Coverage"
+	CoverageIssueMsg = "The coverage of the reference, %s, is below the --min-coverage of %s. Templates not matched " +
+		"by any cluster CR"
+	invalidMinCoverage = "--min-coverage must be a percentage between 0 and 100, got %v"
+)
+
+// TemplateCoverage counts the templates matched by at least one cluster CR, Percentage is their share of the
+// templates.
+type TemplateCoverage struct {
+	Templates        int     `json:"Templates"`
+	MatchedTemplates int     `json:"MatchedTemplates"`
+	Percentage       float64 `json:"Percentage"`
+}
+
+// PartCoverage is the coverage of a part of the reference, in total and by component.
+type PartCoverage struct {
+	TemplateCoverage
+	Components map[string]*TemplateCoverage `json:"Components"`
+}
+
+// Coverage is the coverage of the reference (--coverage), in total and by part. Like the coverage of a test suite it
+// tells how much of the reference was exercised by the cluster: the templates that no cluster CR was matched to
+// weren't checked.
+type Coverage struct {
+	TemplateCoverage
+	Parts map[string]*PartCoverage `json:"Parts"`
+	// unmatched are the paths of the templates no cluster CR was matched to
+	unmatched []string
+}
+
+func (c *TemplateCoverage) add(matched bool) {
+	c.Templates++
+	if matched {
+		c.MatchedTemplates++
+	}
+	c.Percentage = 100
+	if c.Templates != 0 {
+		c.Percentage = math.Round(float64(c.MatchedTemplates)*10000/float64(c.Templates)) / 100
+	}
+}
+
+// newCoverage computes the coverage of the reference from the number of matches of each template file, see
+// matchedTemplatePaths.
+func newCoverage(templates []ReferenceTemplate, matchedTemplates map[string]int) *Coverage {
+	coverage := &Coverage{TemplateCoverage: TemplateCoverage{Percentage: 100}, Parts: make(map[string]*PartCoverage)}
+	counted := make(map[string]bool)
+	for _, t := range templates {
+		partName, componentName := t.GetPartAndComponent()
+		// The documents of a multi-document template are a single template of the reference
+		key := strings.Join([]string{partName, componentName, t.GetPath()}, "/")
+		if counted[key] {
+			continue
+		}
+		counted[key] = true
+		part, ok := coverage.Parts[partName]
+		if !ok {
+			part = &PartCoverage{Components: make(map[string]*TemplateCoverage)}
+			coverage.Parts[partName] = part
+		}
+		component, ok := part.Components[componentName]
+		if !ok {
+			component = &TemplateCoverage{}
+			part.Components[componentName] = component
+		}
+		matched := matchedTemplates[t.GetPath()] > 0
+		coverage.add(matched)
+		part.add(matched)
+		component.add(matched)
+		if !matched {
+			coverage.unmatched = append(coverage.unmatched, t.GetPath())
+		}
+	}
+	sort.Strings(coverage.unmatched)
+	return coverage
+}
+
+// formatPercentage formats the percentage with at most 2 decimals.
+func formatPercentage(percentage float64) string {
+	return strconv.FormatFloat(percentage, 'f', -1, 64) + "%"
+}
+
+// coverageTable renders the coverage of the parts and of their components as a table, the components are indented
+// under their part.
+func coverageTable(coverage *Coverage) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PART/COMPONENT\tTEMPLATES\tMATCHED\tCOVERAGE")
+	row := func(name string, c TemplateCoverage) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", name, c.Templates, c.MatchedTemplates, formatPercentage(c.Percentage))
+	}
+	partNames := lo.Keys(coverage.Parts)
+	sort.Strings(partNames)
+	for _, partName := range partNames {
+		part := coverage.Parts[partName]
+		row(partName, part.TemplateCoverage)
+		componentNames := lo.Keys(part.Components)
+		sort.Strings(componentNames)
+		for _, componentName := range componentNames {
+			row("  "+componentName, *part.Components[componentName])
+		}
+	}
+	_ = w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// addCoverageIssue reports that the coverage of the reference is below the --min-coverage, with the templates that no
+// cluster CR was matched to.
+func (s *Summary) addCoverageIssue(minCoverage float64) {
+	if s.Coverage == nil || s.Coverage.Percentage >= minCoverage {
+		return
+	}
+	if s.ValidationIssues == nil {
+		s.ValidationIssues = make(map[string]map[string]ValidationIssue)
+	}
+	s.ValidationIssues[CoverageGroup] = map[string]ValidationIssue{
+		CoverageIssue: {
+			Msg: fmt.Sprintf(CoverageIssueMsg, formatPercentage(s.Coverage.Percentage), formatPercentage(minCoverage)),
+			CRs: s.Coverage.unmatched,
+		},
+	}
+}
@@ -0,0 +1,269 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	crdSchemasNotDir     = "CRD schemas path %s must be a directory containing CRD definitions"
+	crdSchemasNotInLocal = "CRD schemas can only be used when comparing local CRs (-f), in live mode the cluster is used"
+	validateWithoutCRDs  = "Validating the templates (--validate-templates) when comparing local CRs requires the CRD definitions (--crd-schemas)"
+	crdKind              = "CustomResourceDefinition"
+)
+
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// CRDSchemas contains the kinds, versions and OpenAPI schemas defined by a bundle of CRDs.
+// It is used in local mode to check the templates of the reference in the same way the live cluster is used in live
+// mode: kinds that aren't defined by the bundle or by Kubernetes itself are reported as not supported and the rendered
+// templates of the custom kinds are validated against their schemas. In live mode the CRDs are read from the cluster
+// when the rendered templates are validated (--validate-templates).
+type CRDSchemas struct {
+	versions map[string][]schema.GroupVersion
+	schemas  map[schema.GroupVersionKind]map[string]any
+}
+
+// LoadCRDSchemas reads all the CRDs in the yaml/json files found in the directory (recursively).
+func LoadCRDSchemas(dir string) (*CRDSchemas, error) {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf(crdSchemasNotDir, dir)
+	}
+	c := newCRDSchemas()
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(path)
+		if d.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			return nil
+		}
+		return c.addFile(path)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CRD schemas: %w", err)
+	}
+	return c, nil
+}
+
+// fetchCRDSchemas reads all the CRDs of the live cluster.
+func fetchCRDSchemas(ctx context.Context, list listFunc) (*CRDSchemas, error) {
+	crds, err := list(ctx, crdResource, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the CRDs of the cluster: %w", err)
+	}
+	c := newCRDSchemas()
+	for i := range crds.Items {
+		c.addCRD(&crds.Items[i])
+	}
+	return c, nil
+}
+
+func newCRDSchemas() *CRDSchemas {
+	return &CRDSchemas{
+		versions: make(map[string][]schema.GroupVersion),
+		schemas:  make(map[schema.GroupVersionKind]map[string]any),
+	}
+}
+
+func (c *CRDSchemas) addFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		obj := make(map[string]any)
+		err := decoder.Decode(&obj)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		crd := unstructured.Unstructured{Object: obj}
+		if crd.GetKind() != crdKind {
+			continue
+		}
+		c.addCRD(&crd)
+	}
+}
+
+func (c *CRDSchemas) addCRD(crd *unstructured.Unstructured) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(version, "name")
+		gv := schema.GroupVersion{Group: group, Version: name}
+		if !slices.Contains(c.versions[kind], gv) {
+			c.versions[kind] = append(c.versions[kind], gv)
+		}
+		if s, ok, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema"); ok {
+			c.schemas[gv.WithKind(kind)] = s
+		}
+	}
+}
+
+// supportedTypes returns the kinds defined by the CRDs together with the built-in Kubernetes kinds, in the same
+// format as getSupportedResourceTypes.
+func (c *CRDSchemas) supportedTypes() map[string][]schema.GroupVersion {
+	resources := make(map[string][]schema.GroupVersion)
+	for gvk := range scheme.Scheme.AllKnownTypes() {
+		gv := gvk.GroupVersion()
+		if gv.Version == "__internal" || slices.Contains(resources[gvk.Kind], gv) {
+			continue
+		}
+		resources[gvk.Kind] = append(resources[gvk.Kind], gv)
+	}
+	for kind, gvs := range c.versions {
+		for _, gv := range gvs {
+			if !slices.Contains(resources[kind], gv) {
+				resources[kind] = append(resources[kind], gv)
+			}
+		}
+	}
+	return resources
+}
+
+// Validate validates the object against the schema of its kind, objects of kinds that don't have a schema in the
+// bundle are not validated.
+func (c *CRDSchemas) Validate(obj *unstructured.Unstructured) error {
+	s, ok := c.schemas[obj.GroupVersionKind()]
+	if !ok {
+		return nil
+	}
+	issues := make([]string, 0)
+	for key, value := range obj.Object {
+		// metadata, apiVersion and kind are validated by the api server itself
+		if key == "metadata" || key == "apiVersion" || key == "kind" {
+			continue
+		}
+		issues = append(issues, validateSchema(value, s, key, key)...)
+	}
+	for _, req := range requiredFields(s) {
+		if _, ok := obj.Object[req]; !ok {
+			issues = append(issues, fmt.Sprintf("%s: Required value", req))
+		}
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	sort.Strings(issues)
+	return errors.New(strings.Join(issues, ", "))
+}
+
+func requiredFields(s map[string]any) []string {
+	required, _, _ := unstructured.NestedStringSlice(s, "required")
+	return required
+}
+
+// validateSchema validates a value of an object with the (structural) schema of the object, the name of the
+// value is used to find its schema.
+func validateSchema(value any, parent map[string]any, name, path string) []string {
+	s, found, _ := unstructured.NestedMap(parent, "properties", name)
+	if !found {
+		s, found, _ = unstructured.NestedMap(parent, "additionalProperties")
+	}
+	if !found {
+		if preserve, _, _ := unstructured.NestedBool(parent, "x-kubernetes-preserve-unknown-fields"); preserve {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: unknown field", path)}
+	}
+	return validateValue(value, s, path)
+}
+
+func validateValue(value any, s map[string]any, path string) []string {
+	if value == nil {
+		if nullable, _, _ := unstructured.NestedBool(s, "nullable"); nullable {
+			return nil
+		}
+	}
+	if intOrString, _, _ := unstructured.NestedBool(s, "x-kubernetes-int-or-string"); intOrString {
+		switch value.(type) {
+		case string, int64, float64:
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: must be an integer or a string", path)}
+	}
+
+	issues := make([]string, 0)
+	if enum, ok, _ := unstructured.NestedSlice(s, "enum"); ok && !slices.Contains(enum, value) {
+		issues = append(issues, fmt.Sprintf("%s: Unsupported value: %v", path, value))
+	}
+
+	t, _, _ := unstructured.NestedString(s, "type")
+	switch t {
+	case "object":
+		mapping, ok := value.(map[string]any)
+		if !ok {
+			return append(issues, fmt.Sprintf("%s: must be of type object", path))
+		}
+		for k, v := range mapping {
+			issues = append(issues, validateSchema(v, s, k, path+"."+k)...)
+		}
+		for _, req := range requiredFields(s) {
+			if _, ok := mapping[req]; !ok {
+				issues = append(issues, fmt.Sprintf("%s.%s: Required value", path, req))
+			}
+		}
+	case "array":
+		list, ok := value.([]any)
+		if !ok {
+			return append(issues, fmt.Sprintf("%s: must be of type array", path))
+		}
+		items, _, _ := unstructured.NestedMap(s, "items")
+		for i, v := range list {
+			issues = append(issues, validateValue(v, items, fmt.Sprintf("%s.%d", path, i))...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			issues = append(issues, fmt.Sprintf("%s: must be of type string", path))
+		}
+	case "integer":
+		switch v := value.(type) {
+		case int64:
+		case float64:
+			if v != float64(int64(v)) {
+				issues = append(issues, fmt.Sprintf("%s: must be of type integer", path))
+			}
+		default:
+			issues = append(issues, fmt.Sprintf("%s: must be of type integer", path))
+		}
+	case "number":
+		switch value.(type) {
+		case int64, float64:
+		default:
+			issues = append(issues, fmt.Sprintf("%s: must be of type number", path))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			issues = append(issues, fmt.Sprintf("%s: must be of type boolean", path))
+		}
+	}
+	return issues
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+func TestFetchCRDSchemas(t *testing.T) {
+	crd := `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  versions:
+  - name: v1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                type: integer
+`
+	obj := make(map[string]any)
+	require.NoError(t, yaml.Unmarshal([]byte(crd), &obj))
+	var listed []schema.GroupVersionResource
+	list := func(_ context.Context, gvr schema.GroupVersionResource, _ metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+		listed = append(listed, gvr)
+		return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: obj}}}, nil
+	}
+
+	c, err := fetchCRDSchemas(context.TODO(), list)
+	require.NoError(t, err)
+	assert.Equal(t, []schema.GroupVersionResource{crdResource}, listed)
+	assert.Equal(t, []schema.GroupVersion{{Group: "example.com", Version: "v1"}}, c.versions["Widget"])
+
+	widget := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"spec":       map[string]any{"size": "large", "color": "red"},
+	}}
+	assert.EqualError(t, c.Validate(widget), "spec.color: unknown field, spec.size: must be of type integer")
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// inlineDiffsLock guards InlineDiffs, the functions can be registered while an Engine compares CRs in another
+// goroutine.
+var inlineDiffsLock sync.RWMutex
+
+// getInlineDiff returns the inline diff function registered with the name.
+func getInlineDiff(name inlineDiffType) (InlineDiff, bool) {
+	inlineDiffsLock.RLock()
+	defer inlineDiffsLock.RUnlock()
+	diff, ok := InlineDiffs[name]
+	return diff, ok
+}
+
+// RegisterInlineDiff registers a custom inline diff function, programs embedding the command can use it to plug in the
+// domain-specific comparisons of their references. The templates use it by its name in the inlineDiffFunc of their
+// perField config, like the built-in functions, whose names can't be reused. The functions should be registered from
+// the init functions of the program, the references using them fail to load before they are registered. They are
+// called concurrently when the CRs are compared with --concurrency.
+func RegisterInlineDiff(name string, diff InlineDiff) error {
+	if name == "" || diff == nil {
+		return errors.New("inline diff functions require a name and a function")
+	}
+	inlineDiffsLock.Lock()
+	defer inlineDiffsLock.Unlock()
+	if _, ok := InlineDiffs[inlineDiffType(name)]; ok {
+		return fmt.Errorf("inline diff function %q is already registered", name)
+	}
+	InlineDiffs[inlineDiffType(name)] = diff
+	return nil
+}
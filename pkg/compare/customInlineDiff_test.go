@@ -0,0 +1,76 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// domainInlineDiff accepts the values that are subdomains of the domain of the template.
+type domainInlineDiff struct{}
+
+func (domainInlineDiff) Diff(templateValue, crValue string, sharedCapturedValues CapturedValues) (string, CapturedValues) {
+	if crValue == templateValue || strings.HasSuffix(crValue, "."+templateValue) {
+		return crValue, sharedCapturedValues
+	}
+	return templateValue, sharedCapturedValues
+}
+
+func (domainInlineDiff) Validate(templateValue string) error {
+	if strings.HasPrefix(templateValue, ".") {
+		return errors.New("the domain can't start with a dot")
+	}
+	return nil
+}
+
+func TestRegisterInlineDiff(t *testing.T) {
+	name := "subdomain"
+	require.NoError(t, RegisterInlineDiff(name, domainInlineDiff{}))
+	t.Cleanup(func() {
+		inlineDiffsLock.Lock()
+		defer inlineDiffsLock.Unlock()
+		delete(InlineDiffs, inlineDiffType(name))
+	})
+
+	assert.Error(t, RegisterInlineDiff(name, domainInlineDiff{}))
+	assert.Error(t, RegisterInlineDiff(string(regex), domainInlineDiff{}))
+	assert.IsType(t, RegexInlineDiff{}, InlineDiffs[regex])
+	assert.Error(t, RegisterInlineDiff("", domainInlineDiff{}))
+	assert.Error(t, RegisterInlineDiff("other", nil))
+
+	refPath := "testdata/CustomInlineDiff/reference/metadata.yaml"
+	cfs, err := GetRefFS(refPath)
+	require.NoError(t, err)
+	ref, err := GetReference(cfs, filepath.Base(refPath))
+	require.NoError(t, err)
+	engine, err := NewEngine(ref, cfs, EngineOptions{})
+	require.NoError(t, err)
+
+	output, err := engine.Compare([]*unstructured.Unstructured{loadCR(t, "testdata/CustomInlineDiff/resources/subdomain.yaml")})
+	require.NoError(t, err)
+	assert.Equal(t, 0, output.Summary.NumDiffCRs)
+
+	// The functions can be registered while CRs are compared
+	registered := make(chan error)
+	go func() {
+		registered <- RegisterInlineDiff("concurrent", domainInlineDiff{})
+	}()
+	output, err = engine.Compare([]*unstructured.Unstructured{loadCR(t, "testdata/CustomInlineDiff/resources/otherDomain.yaml")})
+	require.NoError(t, <-registered)
+	t.Cleanup(func() {
+		inlineDiffsLock.Lock()
+		defer inlineDiffsLock.Unlock()
+		delete(InlineDiffs, "concurrent")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, output.Summary.NumDiffCRs)
+	require.Len(t, *output.Diffs, 1)
+	assert.Contains(t, (*output.Diffs)[0].DiffOutput, "+  domain: example.org")
+}
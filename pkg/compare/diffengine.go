@@ -0,0 +1,323 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"k8s.io/utils/exec"
+)
+
+const (
+	DiffEngineExternal = "external"
+	DiffEngineInternal = "internal"
+
+	DiffStyleUnified    = "unified"
+	DiffStyleSideBySide = "side-by-side"
+
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+
+	unknownDiffEngine     = "Unknown --diff-engine value %q, supported values: %s"
+	unknownDiffStyle      = "Unknown --diff-style value %q, supported values: %s"
+	unknownColor          = "Unknown --color value %q, supported values: %s"
+	renderingNeedsBuiltin = "--diff-style and --color are only supported by the internal diff engine (--diff-engine=internal)"
+
+	// unifiedContext is the number of unchanged lines shown around the changes, as with diff -u
+	unifiedContext = 3
+	// unifiedTimeFormat is the format of the modification times in the file headers, as printed by GNU diff
+	unifiedTimeFormat = "2006-01-02 15:04:05.000000000 -0700"
+	// sideBySideWidth is the width of the side-by-side lines, the default of diff -y
+	sideBySideWidth = 130
+
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+var DiffEngines = []string{DiffEngineExternal, DiffEngineInternal}
+var DiffStyles = []string{DiffStyleUnified, DiffStyleSideBySide}
+var ColorOptions = []string{ColorAuto, ColorAlways, ColorNever}
+
+// diffFormat is how the internal diff engine renders the differences.
+type diffFormat struct {
+	sideBySide bool
+	color      bool
+}
+
+func (f diffFormat) colorize(color, text string) string {
+	if !f.color || text == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// runInternalDiff compares the files of the from and to directories and writes the differences to out, by default in
+// the unified format of 'diff -u -N from to'. Like diff, it returns an exit error with code 1 if the directories
+// differ, so the result is handled the same as the result of the external diff program.
+func runInternalDiff(from, to string, out io.Writer, format diffFormat) error {
+	names, err := dirFileNames(from, to)
+	if err != nil {
+		return err
+	}
+	differ := false
+	for _, name := range names {
+		fromPath, toPath := filepath.Join(from, name), filepath.Join(to, name)
+		fromContent, fromTime, err := readDiffFile(fromPath)
+		if err != nil {
+			return err
+		}
+		toContent, toTime, err := readDiffFile(toPath)
+		if err != nil {
+			return err
+		}
+		if fromContent == toContent {
+			continue
+		}
+		differ = true
+		headers := []string{fmt.Sprintf("diff -u -N %s %s", fromPath, toPath),
+			fmt.Sprintf("--- %s\t%s", fromPath, fromTime.Format(unifiedTimeFormat)),
+			fmt.Sprintf("+++ %s\t%s", toPath, toTime.Format(unifiedTimeFormat))}
+		if format.sideBySide {
+			headers = []string{fmt.Sprintf("diff -y -N %s %s", fromPath, toPath)}
+		}
+		for _, header := range headers {
+			if _, err = fmt.Fprintln(out, format.colorize(colorBold, header)); err != nil {
+				return fmt.Errorf("failed to write diff: %w", err)
+			}
+		}
+		if err := writeHunks(out, diffLines(fromContent, toContent), format); err != nil {
+			return err
+		}
+	}
+	if differ {
+		return exec.CodeExitError{Err: fmt.Errorf("%s and %s differ", from, to), Code: 1}
+	}
+	return nil
+}
+
+// dirFileNames returns the sorted names of the files in any of the directories.
+func dirFileNames(dirs ...string) ([]string, error) {
+	nameSet := make(map[string]bool)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read diff directory: %w", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				nameSet[entry.Name()] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(nameSet))
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// readDiffFile returns the content and modification time of a file, a missing file is treated as empty (diff -N).
+func readDiffFile(path string) (string, time.Time, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", time.Unix(0, 0), nil
+	}
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read diff file: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read diff file: %w", err)
+	}
+	return string(content), info.ModTime(), nil
+}
+
+type diffLine struct {
+	op   diffmatchpatch.Operation
+	text string
+}
+
+// diffLines returns the lines of both texts, each marked as deleted, inserted or unchanged.
+func diffLines(from, to string) []diffLine {
+	dmp := diffmatchpatch.New()
+	// The diff has to be minimal and deterministic, not bounded by time
+	dmp.DiffTimeout = 0
+	// Each distinct line is encoded as a rune and the runes are diffed. DiffLinesToRunes isn't used as it encodes the
+	// lines as sequences of digits, which are then diffed character by character.
+	var lineArray []string
+	lineRunes := make(map[string]rune)
+	encode := func(text string) []rune {
+		var runes []rune
+		for _, line := range strings.SplitAfter(text, "\n") {
+			if line == "" {
+				continue
+			}
+			r, ok := lineRunes[line]
+			if !ok {
+				r = lineRune(len(lineArray))
+				lineRunes[line] = r
+				lineArray = append(lineArray, line)
+			}
+			runes = append(runes, r)
+		}
+		return runes
+	}
+	fromRunes, toRunes := encode(from), encode(to)
+	lineIndexes := make(map[rune]int, len(lineArray))
+	for i := range lineArray {
+		lineIndexes[lineRune(i)] = i
+	}
+
+	var lines []diffLine
+	for _, d := range dmp.DiffMainRunes(fromRunes, toRunes, false) {
+		for _, r := range d.Text {
+			lines = append(lines, diffLine{op: d.Type, text: lineArray[lineIndexes[r]]})
+		}
+	}
+	return lines
+}
+
+// lineRune returns the rune encoding the line of index i, skipping the surrogates that aren't valid runes.
+func lineRune(i int) rune {
+	r := rune(i + 1)
+	if r >= 0xD800 {
+		r += 0x800
+	}
+	return r
+}
+
+// writeHunks writes the changed lines with unifiedContext unchanged lines around them. Changes separated by up to
+// twice the context are written in the same hunk.
+func writeHunks(out io.Writer, lines []diffLine, format diffFormat) error {
+	var changes []int
+	// fromLine and toLine are the number of lines of each side before each line of the diff
+	fromLine, toLine := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	for i, line := range lines {
+		fromLine[i+1], toLine[i+1] = fromLine[i], toLine[i]
+		if line.op != diffmatchpatch.DiffInsert {
+			fromLine[i+1]++
+		}
+		if line.op != diffmatchpatch.DiffDelete {
+			toLine[i+1]++
+		}
+		if line.op != diffmatchpatch.DiffEqual {
+			changes = append(changes, i)
+		}
+	}
+
+	for i := 0; i < len(changes); i++ {
+		start := max(changes[i]-unifiedContext, 0)
+		for i+1 < len(changes) && changes[i+1]-changes[i]-1 <= 2*unifiedContext {
+			i++
+		}
+		end := min(changes[i]+unifiedContext+1, len(lines))
+
+		var sb strings.Builder
+		sb.WriteString(format.colorize(colorCyan, fmt.Sprintf("@@ -%s +%s @@",
+			hunkRange(fromLine[start], fromLine[end]-fromLine[start]), hunkRange(toLine[start], toLine[end]-toLine[start]))))
+		sb.WriteString("\n")
+		if format.sideBySide {
+			writeSideBySideLines(&sb, lines[start:end], format)
+		} else {
+			writeUnifiedLines(&sb, lines[start:end], format)
+		}
+		if _, err := io.WriteString(out, sb.String()); err != nil {
+			return fmt.Errorf("failed to write diff: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeUnifiedLines(sb *strings.Builder, lines []diffLine, format diffFormat) {
+	for _, line := range lines {
+		text := strings.TrimSuffix(line.text, "\n")
+		switch line.op {
+		case diffmatchpatch.DiffDelete:
+			sb.WriteString(format.colorize(colorRed, "-"+text))
+		case diffmatchpatch.DiffInsert:
+			sb.WriteString(format.colorize(colorGreen, "+"+text))
+		case diffmatchpatch.DiffEqual:
+			sb.WriteString(" " + text)
+		}
+		sb.WriteString("\n")
+		if !strings.HasSuffix(line.text, "\n") {
+			sb.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
+
+// writeSideBySideLines writes the lines in two columns, like diff -y. Deleted and inserted lines of the same change
+// are paired and marked with |, the remaining ones with < and >.
+func writeSideBySideLines(sb *strings.Builder, lines []diffLine, format diffFormat) {
+	for i := 0; i < len(lines); {
+		if lines[i].op == diffmatchpatch.DiffEqual {
+			writeSideBySideRow(sb, lines[i].text, ' ', lines[i].text, format)
+			i++
+			continue
+		}
+		var deleted, inserted []string
+		for ; i < len(lines) && lines[i].op != diffmatchpatch.DiffEqual; i++ {
+			if lines[i].op == diffmatchpatch.DiffDelete {
+				deleted = append(deleted, lines[i].text)
+			} else {
+				inserted = append(inserted, lines[i].text)
+			}
+		}
+		for j := 0; j < max(len(deleted), len(inserted)); j++ {
+			switch {
+			case j >= len(deleted):
+				writeSideBySideRow(sb, "", '>', inserted[j], format)
+			case j >= len(inserted):
+				writeSideBySideRow(sb, deleted[j], '<', "", format)
+			default:
+				writeSideBySideRow(sb, deleted[j], '|', inserted[j], format)
+			}
+		}
+	}
+}
+
+func writeSideBySideRow(sb *strings.Builder, left string, marker rune, right string, format diffFormat) {
+	column := (sideBySideWidth - 3) / 2
+	left = truncateColumn(left, column)
+	right = truncateColumn(right, column)
+	padding := strings.Repeat(" ", column-len([]rune(left)))
+	if marker != ' ' {
+		left = format.colorize(colorRed, left)
+		right = format.colorize(colorGreen, right)
+	}
+	sb.WriteString(strings.TrimRight(fmt.Sprintf("%s%s %c %s", left, padding, marker, right), " "))
+	sb.WriteString("\n")
+}
+
+func truncateColumn(text string, width int) string {
+	runes := []rune(strings.TrimSuffix(text, "\n"))
+	if len(runes) > width {
+		runes = runes[:width]
+	}
+	return string(runes)
+}
+
+// hunkRange formats the range of a hunk, linesBefore is the number of lines of the file before the hunk. As in GNU
+// diff, the count is omitted when it's 1 and an empty range starts at the line before it.
+func hunkRange(linesBefore, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", linesBefore)
+	case 1:
+		return fmt.Sprintf("%d", linesBefore+1)
+	default:
+		return fmt.Sprintf("%d,%d", linesBefore+1, count)
+	}
+}
@@ -0,0 +1,104 @@
+package compare
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/exec"
+)
+
+func TestWriteHunks(t *testing.T) {
+	var base []string
+	for i := 1; i <= 20; i++ {
+		base = append(base, strings.Repeat("x", i))
+	}
+	text := func(l ...string) string {
+		return strings.Join(l, "\n") + "\n"
+	}
+	changed := slices.Clone(base)
+	changed[2], changed[9] = "changed", "changed"
+
+	tests := []struct {
+		name     string
+		from, to string
+		format   diffFormat
+		expected string
+	}{
+		{
+			name: "changes close to each other share a hunk",
+			from: text(base...),
+			to:   text(changed...),
+			expected: "@@ -1,13 +1,13 @@\n x\n xx\n-xxx\n+changed\n xxxx\n xxxxx\n xxxxxx\n xxxxxxx\n xxxxxxxx\n xxxxxxxxx\n" +
+				"-xxxxxxxxxx\n+changed\n xxxxxxxxxxx\n xxxxxxxxxxxx\n xxxxxxxxxxxxx\n",
+		},
+		{
+			name: "changes far from each other are in separate hunks",
+			from: text(base...),
+			to:   text(slices.Delete(slices.Clone(base), 18, 19)[1:]...),
+			expected: "@@ -1,4 +1,3 @@\n-x\n xx\n xxx\n xxxx\n" +
+				"@@ -16,5 +15,4 @@\n xxxxxxxxxxxxxxxx\n xxxxxxxxxxxxxxxxx\n xxxxxxxxxxxxxxxxxx\n-xxxxxxxxxxxxxxxxxxx\n xxxxxxxxxxxxxxxxxxxx\n",
+		},
+		{
+			name:     "empty file",
+			from:     "",
+			to:       "a\n",
+			expected: "@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:     "missing newline",
+			from:     "a\nb",
+			to:       "a\nc\n",
+			expected: "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n",
+		},
+		{
+			name:   "side by side pairs the changed lines",
+			from:   "a\nb\nc\nd\n",
+			to:     "a\nB\nC\nC2\nd\n",
+			format: diffFormat{sideBySide: true},
+			expected: "@@ -1,4 +1,5 @@\n" +
+				"a" + strings.Repeat(" ", 65) + "a\n" +
+				"b" + strings.Repeat(" ", 62) + " | B\n" +
+				"c" + strings.Repeat(" ", 62) + " | C\n" +
+				strings.Repeat(" ", 63) + " > C2\n" +
+				"d" + strings.Repeat(" ", 65) + "d\n",
+		},
+		{
+			name:     "colors",
+			from:     "a\n",
+			to:       "b\n",
+			format:   diffFormat{color: true},
+			expected: colorCyan + "@@ -1 +1 @@" + colorReset + "\n" + colorRed + "-a" + colorReset + "\n" + colorGreen + "+b" + colorReset + "\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			require.NoError(t, writeHunks(out, diffLines(test.from, test.to), test.format))
+			assert.Equal(t, test.expected, out.String())
+		})
+	}
+}
+
+func TestRunInternalDiff(t *testing.T) {
+	from, to := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(from, "same"), []byte("a\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(to, "same"), []byte("a\n"), 0o600))
+
+	out := new(bytes.Buffer)
+	require.NoError(t, runInternalDiff(from, to, out, diffFormat{}))
+	assert.Empty(t, out.String())
+
+	require.NoError(t, os.WriteFile(filepath.Join(to, "added"), []byte("b\n"), 0o600))
+	err := runInternalDiff(from, to, out, diffFormat{})
+	var exitErr exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 1, exitErr.ExitStatus())
+	assert.Contains(t, out.String(), "diff -u -N "+filepath.Join(from, "added")+" "+filepath.Join(to, "added")+"\n")
+	assert.True(t, strings.HasSuffix(out.String(), "@@ -0,0 +1 @@\n+b\n"))
+}
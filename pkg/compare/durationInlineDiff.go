@@ -0,0 +1,114 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	goDuration inlineDiffType = "duration"
+)
+
+// DurationInlineDiff compares the values as Go durations, as the durations of the Kubernetes APIs ("1h30m"), so equal
+// durations written differently, such as 90s and 1m30s, match. The template is one of:
+//
+//   - a duration, the cluster value has to be equal to it: "5m"
+//   - a duration with a tolerance, the cluster value can differ from it by at most the tolerance: "5m ±30s", or
+//     "5m +-30s"
+//   - bounds separated by spaces, the cluster value has to satisfy all of them: ">=1m <=10m". The operators are >=, >,
+//     <=, < and =.
+type DurationInlineDiff struct{}
+
+// durationConstraint is a comparison of the cluster duration with the duration of the template.
+type durationConstraint struct {
+	operator string
+	value    time.Duration
+}
+
+func (c durationConstraint) check(d time.Duration) bool {
+	switch c.operator {
+	case ">=":
+		return d >= c.value
+	case ">":
+		return d > c.value
+	case "<=":
+		return d <= c.value
+	case "<":
+		return d < c.value
+	default:
+		return d == c.value
+	}
+}
+
+var durationOperators = []string{">=", "<=", ">", "<", "="}
+
+// parseDurationConstraints parses the template of the duration inline diff to the constraints the cluster value has to
+// satisfy.
+func parseDurationConstraints(templateValue string) ([]durationConstraint, error) {
+	templateValue = strings.ReplaceAll(templateValue, "+-", "±")
+	if value, tolerance, ok := strings.Cut(templateValue, "±"); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %w", err)
+		}
+		t, err := time.ParseDuration(strings.TrimSpace(tolerance))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tolerance: %w", err)
+		}
+		if t < 0 {
+			return nil, errors.New("the tolerance can't be negative")
+		}
+		return []durationConstraint{{operator: ">=", value: d - t}, {operator: "<=", value: d + t}}, nil
+	}
+	fields := strings.Fields(templateValue)
+	if len(fields) == 0 {
+		return nil, errors.New("no duration")
+	}
+	constraints := make([]durationConstraint, 0, len(fields))
+	for _, field := range fields {
+		c := durationConstraint{operator: "="}
+		for _, operator := range durationOperators {
+			if rest, ok := strings.CutPrefix(field, operator); ok {
+				c.operator, field = operator, rest
+				break
+			}
+		}
+		d, err := time.ParseDuration(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %w", err)
+		}
+		c.value = d
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
+
+func (id DurationInlineDiff) Diff(templateValue, crValue string, sharedCapturedValues CapturedValues) (string, CapturedValues) {
+	constraints, err := parseDurationConstraints(templateValue)
+	if err != nil {
+		return templateValue, sharedCapturedValues
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(crValue))
+	if err != nil {
+		return templateValue, sharedCapturedValues
+	}
+	for _, c := range constraints {
+		if !c.check(d) {
+			return templateValue, sharedCapturedValues
+		}
+	}
+	return crValue, sharedCapturedValues
+}
+
+func (id DurationInlineDiff) Validate(templateValue string) error {
+	// The value is empty when the template is rendered without data
+	if strings.TrimSpace(templateValue) == "" {
+		return nil
+	}
+	if _, err := parseDurationConstraints(templateValue); err != nil {
+		return fmt.Errorf("invalid duration passed to inline duration diff function: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineDurationDiff(t *testing.T) {
+	tests := []struct {
+		template string
+		input    string
+		match    bool
+	}{
+		{template: "90s", input: "1m30s", match: true},
+		{template: "90s", input: "1m31s"},
+		{template: "5m ±30s", input: "4m30s", match: true},
+		{template: "5m ±30s", input: "5m30s", match: true},
+		{template: "5m ±30s", input: "5m31s"},
+		{template: "5m +-30s", input: "270s", match: true},
+		{template: "5m+-30s", input: "4m", match: false},
+		{template: ">=1m <=10m", input: "10m", match: true},
+		{template: ">=1m <=10m", input: "59s"},
+		{template: ">1m <10m", input: "1m"},
+		{template: ">1m <10m", input: "9m59s", match: true},
+		{template: "=2h", input: "120m", match: true},
+		{template: ">=1m", input: "1 minute"},
+		{template: ">=1m", input: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.template+" "+test.input, func(t *testing.T) {
+			diff := DurationInlineDiff{}
+			assert.NoError(t, diff.Validate(test.template))
+			result, _ := diff.Diff(test.template, test.input, CapturedValues{})
+			if test.match {
+				assert.Equal(t, test.input, result)
+			} else {
+				assert.Equal(t, test.template, result)
+			}
+		})
+	}
+}
+
+func TestInlineDurationValidate(t *testing.T) {
+	assert.ErrorContains(t, DurationInlineDiff{}.Validate("5 minutes"), "invalid duration")
+	assert.ErrorContains(t, DurationInlineDiff{}.Validate("5m ±a bit"), "invalid tolerance")
+	assert.ErrorContains(t, DurationInlineDiff{}.Validate("5m ±-1s"), "the tolerance can't be negative")
+	assert.ErrorContains(t, DurationInlineDiff{}.Validate("~5m"), "invalid duration")
+	assert.NoError(t, DurationInlineDiff{}.Validate(""))
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+// EngineOptions are the options of the comparisons of an Engine, they match the flags of the compare command.
+type EngineOptions struct {
+	// DiffConfig correlates the CRs manually and maps the namespaces of the templates, as the diff config (-c)
+	DiffConfig UserConfig
+	// DiffAll reports the CRs that didn't match any template as unmatched, as --all-resources
+	DiffAll bool
+	// OnlyValidation validates the CRs without diffing them, as --only-validation
+	OnlyValidation bool
+	// ShowManagedFields keeps the managed fields of the CRs in the diffs, as --show-managed-fields
+	ShowManagedFields bool
+	// IgnoreFieldsManagedBy are the field managers whose fields aren't compared, as --ignore-fields-managed-by
+	IgnoreFieldsManagedBy []string
+	// DiffEngine is the engine producing the diffs, one of DiffEngines. Defaults to DiffEngineInternal so the
+	// comparison doesn't depend on the diff program of the host.
+	DiffEngine string
+	// MatchStrategy scores the templates a CR is correlated to, one of MatchStrategies, as --match-strategy. Defaults to
+	// MatchStrategyFields.
+	MatchStrategy string
+	// SeverityRules classify the diffs, as --severity-rules
+	SeverityRules *SeverityRules
+	// UserOverrides are the patches applied to the templates before diffing them, as -p
+	UserOverrides []*UserOverride
+	// Concurrency is the number of CRs compared in parallel, as --concurrency. Defaults to 1.
+	Concurrency int
+	// Preprocessors transform the CRs before they are correlated to the templates, after the registered ones (see
+	// RegisterCRPreprocessor), as --pre-process-exec
+	Preprocessors []CRPreprocessor
+}
+
+// Engine compares CRs to a reference, without the command line: the CRs are passed to Compare instead of being
+// collected from a cluster or from files and the output is returned instead of being printed. It lets operators and
+// test suites embed the drift checks of cluster-compare.
+type Engine struct {
+	options *Options
+}
+
+// NewEngine parses the templates of the reference, that are read from cfs, and returns an Engine comparing CRs to them.
+func NewEngine(ref Reference, cfs fs.FS, opts EngineOptions) (*Engine, error) {
+	diffEngine := opts.DiffEngine
+	if diffEngine == "" {
+		diffEngine = DiffEngineInternal
+	}
+	if !slices.Contains(DiffEngines, diffEngine) {
+		return nil, fmt.Errorf(unknownDiffEngine, diffEngine, strings.Join(DiffEngines, ", "))
+	}
+	matchStrategy := opts.MatchStrategy
+	if matchStrategy == "" {
+		matchStrategy = MatchStrategyFields
+	}
+	if !slices.Contains(MatchStrategies, matchStrategy) {
+		return nil, fmt.Errorf(unknownMatchStrategy, matchStrategy, strings.Join(MatchStrategies, ", "))
+	}
+	o := NewOptions(genericiooptions.IOStreams{In: strings.NewReader(""), Out: io.Discard, ErrOut: io.Discard})
+	o.ref = ref
+	o.userConfig = opts.DiffConfig
+	o.diffAll = opts.DiffAll
+	o.onlyValidation = opts.OnlyValidation
+	o.ShowManagedFields = opts.ShowManagedFields
+	o.ignoreManagers = opts.IgnoreFieldsManagedBy
+	o.diffEngine = diffEngine
+	o.matchStrategy = matchStrategy
+	o.severityRules = opts.SeverityRules
+	o.userOverrides = opts.UserOverrides
+	o.Concurrency = opts.Concurrency
+	o.preprocessors = append(o.preprocessors, opts.Preprocessors...)
+
+	if err := o.parseTemplates(cfs); err != nil {
+		return nil, err
+	}
+	warnOnReferenceChange(o.userOverrides, o.referenceHash)
+	if err := o.setupCorrelators(); err != nil {
+		return nil, err
+	}
+	if err := o.setupOverrideCorrelators(); err != nil {
+		return nil, err
+	}
+	return &Engine{options: o}, nil
+}
+
+// Templates returns the templates of the reference the CRs are compared to.
+func (e *Engine) Templates() []ReferenceTemplate {
+	return e.options.templates
+}
+
+// Compare compares the CRs to the reference and returns the output of the comparison, in the format printed by the
+// compare command with -o json. The engine can run any number of comparisons, each comparison only reports the CRs
+// passed to it.
+func (e *Engine) Compare(clusterCRs []*unstructured.Unstructured) (Output, error) {
+	o := *e.options
+	o.metricsTracker = NewMetricsTracker()
+	o.capturegroups = newTemplateCapturegroups(o.ref.GetSharedCapturegroups())
+	o.newUserOverrides = slices.Clone(o.userOverrides)
+	o.warnings = o.warnings.clone()
+	output, _, _, err := o.compareCRs(clusterCRs)
+	return output, err
+}
+
+// CompareStream compares the CRs to the reference like Compare and calls onDiff with the diff of each CR as soon as the
+// CR is compared, so the diffs can be consumed before the comparison completes. The diffs are passed in the order the
+// CRs are compared, onDiff is called concurrently by the --concurrency workers.
+func (e *Engine) CompareStream(clusterCRs []*unstructured.Unstructured, onDiff func(DiffSum)) (Output, error) {
+	o := *e.options
+	o.onDiff = onDiff
+	return (&Engine{options: &o}).Compare(clusterCRs)
+}
+
+// withFlags returns an engine sharing the parsed templates of e that compares the CRs with the given --only-validation
+// and --all-resources, these flags don't change how the reference is parsed.
+func (e *Engine) withFlags(onlyValidation, diffAll bool) *Engine {
+	o := *e.options
+	o.onlyValidation = onlyValidation
+	o.diffAll = diffAll
+	return &Engine{options: &o}
+}
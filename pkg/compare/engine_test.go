@@ -0,0 +1,160 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func loadCR(t *testing.T, path string) *unstructured.Unstructured {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	obj := make(map[string]any)
+	require.NoError(t, yaml.Unmarshal(content, &obj))
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func TestEngine(t *testing.T) {
+	refPath := "testdata/SomeDiffs/reference/metadata.yaml"
+	cfs, err := GetRefFS(refPath)
+	require.NoError(t, err)
+	ref, err := GetReference(cfs, filepath.Base(refPath))
+	require.NoError(t, err)
+	crs := []*unstructured.Unstructured{
+		loadCR(t, "testdata/SomeDiffs/resources/d2.yaml"),
+		loadCR(t, "testdata/SomeDiffs/resources/deploymentDashboard.yaml"),
+	}
+
+	t.Run("Compare", func(t *testing.T) {
+		engine, err := NewEngine(ref, cfs, EngineOptions{})
+		require.NoError(t, err)
+		assert.Len(t, engine.Templates(), 2)
+
+		// The engine can run several comparisons, each one only reports its CRs
+		for i := 0; i < 2; i++ {
+			output, err := engine.Compare(crs)
+			require.NoError(t, err)
+			assert.Equal(t, 2, output.Summary.TotalCRs)
+			assert.Equal(t, 1, output.Summary.NumDiffCRs)
+			assert.Empty(t, output.Summary.UnmatchedCRS)
+			require.Len(t, *output.Diffs, 2)
+			for _, d := range *output.Diffs {
+				if d.CorrelatedTemplate == "deploymentMetrics.yaml" {
+					assert.Contains(t, d.DiffOutput, "+      k8s-app: dashboard-metrics-scraper-diff")
+				} else {
+					assert.Empty(t, d.DiffOutput)
+				}
+			}
+		}
+	})
+
+	t.Run("Compare Stream", func(t *testing.T) {
+		// The second CR is only compared once the diff of the first one was passed, so the diffs are passed while
+		// the comparison runs
+		first := make(chan struct{})
+		wait := CRPreprocessorFunc(func(ctx context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+			if clusterCR.GetName() == crs[1].GetName() {
+				select {
+				case <-first:
+				case <-time.After(time.Minute):
+					return nil, errors.New("the diff of the first CR wasn't passed before the comparison completed")
+				}
+			}
+			return clusterCR, nil
+		})
+		for _, concurrency := range []int{1, 4} {
+			engine, err := NewEngine(ref, cfs, EngineOptions{Concurrency: concurrency, Preprocessors: []CRPreprocessor{wait}})
+			require.NoError(t, err)
+			first = make(chan struct{})
+			var lock sync.Mutex
+			var streamed []string
+			output, err := engine.CompareStream(crs, func(d DiffSum) {
+				lock.Lock()
+				defer lock.Unlock()
+				if len(streamed) == 0 {
+					close(first)
+				}
+				streamed = append(streamed, d.CRName)
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{apiKindNamespaceName(crs[0]), apiKindNamespaceName(crs[1])}, streamed)
+			assert.Len(t, *output.Diffs, 2)
+		}
+	})
+
+	t.Run("Missing CRs", func(t *testing.T) {
+		engine, err := NewEngine(ref, cfs, EngineOptions{})
+		require.NoError(t, err)
+		output, err := engine.Compare(crs[1:])
+		require.NoError(t, err)
+		assert.Equal(t, 0, output.Summary.NumDiffCRs)
+		assert.Equal(t, 1, output.Summary.NumMissing)
+	})
+
+	t.Run("Preprocessors", func(t *testing.T) {
+		registered := 0
+		require.NoError(t, RegisterCRPreprocessor(CRPreprocessorFunc(
+			func(_ context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+				registered++
+				return clusterCR, nil
+			})))
+		t.Cleanup(func() { crPreprocessors = nil })
+		normalize := CRPreprocessorFunc(func(_ context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+			selector, _, _ := unstructured.NestedString(clusterCR.Object, "spec", "selector", "matchLabels", "k8s-app")
+			if err := unstructured.SetNestedField(clusterCR.Object, strings.TrimSuffix(selector, "-diff"),
+				"spec", "selector", "matchLabels", "k8s-app"); err != nil {
+				return nil, err
+			}
+			return clusterCR, nil
+		})
+		engine, err := NewEngine(ref, cfs, EngineOptions{Preprocessors: []CRPreprocessor{normalize}})
+		require.NoError(t, err)
+		output, err := engine.Compare(crs)
+		require.NoError(t, err)
+		assert.Equal(t, 0, output.Summary.NumDiffCRs)
+		assert.Equal(t, 2, registered)
+
+		failing := CRPreprocessorFunc(func(context.Context, *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+			return nil, nil
+		})
+		engine, err = NewEngine(ref, cfs, EngineOptions{Preprocessors: []CRPreprocessor{failing}})
+		require.NoError(t, err)
+		_, err = engine.Compare(crs[:1])
+		assert.ErrorContains(t, err, "failed to pre-process apps/v1_Deployment_kubernetes-dashboard_dashboard-metrics-scraper: "+
+			"the preprocessor didn't return a CR")
+		assert.Error(t, RegisterCRPreprocessor(nil))
+	})
+
+	t.Run("Unknown Diff Engine", func(t *testing.T) {
+		_, err := NewEngine(ref, cfs, EngineOptions{DiffEngine: "unknown"})
+		assert.ErrorContains(t, err, `Unknown --diff-engine value "unknown"`)
+	})
+
+	t.Run("Cluster CRs Are Not Changed", func(t *testing.T) {
+		// Sorting the listsAsSets of a template must not change the CRs compared to the other templates
+		refPath := "testdata/ReferenceV2ListsAsSets/reference/metadata.yaml"
+		cfs, err := GetRefFS(refPath)
+		require.NoError(t, err)
+		ref, err := GetReference(cfs, filepath.Base(refPath))
+		require.NoError(t, err)
+		clusterCR := loadCR(t, "testdata/ReferenceV2ListsAsSets/resources/deployment.yaml")
+		original := clusterCR.DeepCopy()
+		engine, err := NewEngine(ref, cfs, EngineOptions{})
+		require.NoError(t, err)
+		_, err = engine.Compare([]*unstructured.Unstructured{clusterCR})
+		require.NoError(t, err)
+		assert.Equal(t, original, clusterCR)
+	})
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	unexpectedNamespaceReason = "in namespace %q, expected in %s"
+	misplacedCRReason         = "unmatched, in namespace %q, expected in %s"
+)
+
+// unexpectedNamespace returns how the cluster CR isn't in the namespaces expected by the template it was matched to,
+// nothing when the template doesn't expect namespaces or the CR is in one of them.
+func unexpectedNamespace(temp ReferenceTemplate, clusterCR *unstructured.Unstructured) []TemplateAssertion {
+	expected := temp.GetExpectedNamespaces()
+	if len(expected) == 0 || slices.Contains(expected, clusterCR.GetNamespace()) {
+		return nil
+	}
+	return []TemplateAssertion{{
+		Template: temp.GetIdentifier(),
+		CRName:   apiKindNamespaceName(clusterCR),
+		Msg:      fmt.Sprintf(unexpectedNamespaceReason, clusterCR.GetNamespace(), strings.Join(expected, ", ")),
+	}}
+}
+
+// misplacedCR links a cluster CR that isn't matched to any template to the templates expecting namespaces with the
+// same kind and name: the CR exists but isn't matched to the templates as it's in another namespace, and would only
+// be reported as a missing template otherwise.
+func misplacedCR(clusterCR *unstructured.Unstructured, templates []ReferenceTemplate) []TemplateAssertion {
+	var res []TemplateAssertion
+	for _, temp := range templates {
+		expected := temp.GetExpectedNamespaces()
+		md := temp.GetMetadata()
+		if len(expected) == 0 || md == nil || md.GetName() != clusterCR.GetName() || md.GetKind() != clusterCR.GetKind() ||
+			slices.Contains(expected, clusterCR.GetNamespace()) {
+			continue
+		}
+		res = append(res, TemplateAssertion{
+			Template: temp.GetIdentifier(),
+			CRName:   apiKindNamespaceName(clusterCR),
+			Msg:      fmt.Sprintf(misplacedCRReason, clusterCR.GetNamespace(), strings.Join(expected, ", ")),
+		})
+	}
+	return res
+}
+
+// addNamespaceIssues reports the cluster CRs that aren't in the namespaces expected by their templates as validation
+// issues, grouped by template.
+func (s *Summary) addNamespaceIssues(issues []TemplateAssertion) {
+	s.addTemplateIssues(UnexpectedNamespacesGroup, UnexpectedNamespaceMsg, issues)
+}
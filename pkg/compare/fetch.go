@@ -0,0 +1,174 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog/v2"
+)
+
+const (
+	requestRetriesNotInLive = "--request-retries and --request-backoff can only be used when comparing live clusters"
+	negativeRequestRetries  = "--request-retries can't be negative"
+	negativeRequestBackoff  = "--request-backoff can't be negative"
+
+	defaultRequestBackoff = time.Second
+)
+
+// FetchFailure is a type of resource whose CRs couldn't be listed from the live cluster. Its CRs aren't compared and
+// its templates are reported missing.
+type FetchFailure struct {
+	Kind  string `json:"Kind"`
+	Error string `json:"Error"`
+}
+
+// retryPolicy retries the requests to the live cluster that fail with a transient error (--request-retries), the
+// delay between the attempts starts at --request-backoff and doubles after each attempt.
+type retryPolicy struct {
+	retries int
+	backoff time.Duration
+}
+
+// do runs f until it succeeds, fails with an error that isn't transient or runs out of retries. It stops waiting
+// for the next attempt when ctx is done.
+func (p retryPolicy) do(ctx context.Context, what string, f func() error) error {
+	delay := p.backoff
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || attempt > p.retries || !isTransientError(err) {
+			return err
+		}
+		klog.Warningf("Retrying to list %s in %s (attempt %d/%d): %s", what, delay, attempt, p.retries, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// isTransientError reports whether a request failed because the API server is throttling the requests (429) or
+// temporarily failing (5xx), so it can succeed when it's retried.
+func isTransientError(err error) bool {
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		return slices.ContainsFunc(agg.Errors(), isTransientError)
+	}
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return false
+	}
+	code := int(status.Status().Code)
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// fetchFailures records the types whose CRs couldn't be listed, the types are listed concurrently.
+type fetchFailures struct {
+	lock     sync.Mutex
+	failures []FetchFailure
+}
+
+func (f *fetchFailures) add(kind string, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.failures = append(f.failures, FetchFailure{Kind: kind, Error: err.Error()})
+}
+
+// list returns the failures sorted by kind, nil when all the types were listed or the CRs weren't listed from a live
+// cluster.
+func (f *fetchFailures) list() []FetchFailure {
+	if f == nil {
+		return nil
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	res := slices.Clone(f.failures)
+	slices.SortFunc(res, func(a, b FetchFailure) int {
+		return strings.Compare(a.Kind, b.Kind)
+	})
+	return res
+}
+
+// visitLiveCRs lists the CRs of each type of the live cluster with its own request, so a type that can't be listed is
+// reported as a fetch failure instead of failing the whole comparison, unless no type can be listed. The CRs of a type
+// are only emitted once all of them are listed, so a list that is retried doesn't emit the same CRs twice. The types
+// are listed concurrently but their CRs are emitted in the order of the types, so the results are stable.
+func (o *Options) visitLiveCRs(emit func(*unstructured.Unstructured)) error {
+	workers := make(chan struct{}, max(o.Concurrency, 1))
+	listed := make([][]*unstructured.Unstructured, len(o.types))
+	done := make([]chan struct{}, len(o.types))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	go func() {
+		for i, t := range o.types {
+			workers <- struct{}{}
+			go func() {
+				defer func() {
+					<-workers
+					close(done[i])
+				}()
+				err := o.retry.do(o.context(), t, func() error {
+					listed[i] = nil
+					return o.listType(t, func(clusterCR *unstructured.Unstructured) {
+						listed[i] = append(listed[i], clusterCR)
+					})
+				})
+				if err != nil {
+					listed[i] = nil
+					o.fetchFailures.add(t, err)
+				}
+			}()
+		}
+	}()
+	for i := range o.types {
+		<-done[i]
+		for _, clusterCR := range listed[i] {
+			emit(clusterCR)
+		}
+		listed[i] = nil
+	}
+	if failures := o.fetchFailures.list(); len(o.types) > 0 && len(failures) == len(o.types) {
+		// None of the CRs could be listed, the cluster is likely unreachable
+		return fmt.Errorf("error occurred while trying to process resources: %s", failures[0].Error)
+	}
+	return nil
+}
+
+// listType lists the CRs of a type of the live cluster, the list is paginated by --chunk-size.
+func (o *Options) listType(t string, emit func(*unstructured.Unstructured)) error {
+	r := o.newBuilder().
+		Unstructured().
+		AllNamespaces(true).
+		ResourceTypes(t).
+		SelectAllParam(true).
+		RequestChunksOf(o.chunkSize).
+		ContinueOnError().
+		Flatten().
+		Do()
+	if err := r.Err(); err != nil {
+		return fmt.Errorf("failed to collect resources: %w", err)
+	}
+	return r.Visit(func(info *resource.Info, err error) error { // nolint:wrapcheck
+		if err != nil {
+			return err
+		}
+		clusterCRMapping, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+		emit(&unstructured.Unstructured{Object: clusterCRMapping})
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+func TestIsTransientError(t *testing.T) {
+	configMaps := schema.GroupResource{Resource: "configmaps"}
+	assert.True(t, isTransientError(apierrors.NewTooManyRequests("throttled", 1)))
+	assert.True(t, isTransientError(apierrors.NewInternalError(errors.New("etcd is unavailable"))))
+	assert.True(t, isTransientError(apierrors.NewServiceUnavailable("unavailable")))
+	assert.True(t, isTransientError(utilerrors.NewAggregate([]error{errors.New("other"), apierrors.NewTimeoutError("timeout", 1)})))
+	assert.False(t, isTransientError(apierrors.NewForbidden(configMaps, "", errors.New("denied"))))
+	assert.False(t, isTransientError(apierrors.NewNotFound(configMaps, "")))
+	assert.False(t, isTransientError(errors.New("connection refused")))
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := retryPolicy{retries: 2, backoff: time.Millisecond}
+	t.Run("Retries Transient Errors", func(t *testing.T) {
+		attempts := 0
+		err := policy.do(context.TODO(), "ConfigMap", func() error {
+			attempts++
+			return apierrors.NewTooManyRequests("throttled", 1)
+		})
+		assert.True(t, apierrors.IsTooManyRequests(err))
+		assert.Equal(t, 3, attempts)
+	})
+	t.Run("Doesn't Retry Other Errors", func(t *testing.T) {
+		attempts := 0
+		err := policy.do(context.TODO(), "ConfigMap", func() error {
+			attempts++
+			return apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "", errors.New("denied"))
+		})
+		assert.True(t, apierrors.IsForbidden(err))
+		assert.Equal(t, 1, attempts)
+	})
+}
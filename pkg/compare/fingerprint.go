@@ -0,0 +1,337 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog/v2"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	catalogRequiresLive       = "A reference catalog (--reference-catalog) can only be used when comparing a live cluster"
+	autoReferenceNeedsCatalog = "Selecting the reference automatically (--auto-reference) requires a reference catalog (--reference-catalog)"
+	autoReferenceWithRef      = "A reference (-r) can't be passed when selecting the reference automatically (--auto-reference)"
+	noRecommendedReference    = "none of the references in the catalog apply to the cluster"
+	ambiguousRecommendation   = "the references %s apply equally to the cluster, pass one of them with -r"
+	referenceNotRecommended   = "The reference %s isn't recommended for the cluster by the reference catalog, recommended: %s. " +
+		"Comparing the cluster to a reference that doesn't apply to it reports differences that aren't drift"
+)
+
+// fingerprintTypes are the kinds (and their groups) the cluster fingerprint is computed from.
+var fingerprintTypes = map[string]string{
+	"ClusterVersion": "config.openshift.io",
+	"Infrastructure": "config.openshift.io",
+	"Subscription":   "operators.coreos.com",
+}
+
+// ClusterFingerprint describes the properties of a cluster that decide which references apply to it.
+type ClusterFingerprint struct {
+	Version   string   `json:"version,omitempty"`
+	Topology  string   `json:"topology,omitempty"`
+	Operators []string `json:"operators,omitempty"`
+}
+
+// ReferenceCatalog is an index of the available references and the clusters each of them applies to.
+type ReferenceCatalog struct {
+	References []*CatalogEntry `json:"references"`
+}
+
+// CatalogEntry is a reference in the catalog. Reference is the path of the reference config file, relative paths are
+// relative to the catalog file, or its URL.
+type CatalogEntry struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Reference   string       `json:"reference"`
+	Match       CatalogMatch `json:"match,omitempty"`
+}
+
+// CatalogMatch are the criteria of the clusters a reference applies to, empty criteria match all the clusters.
+// Versions are prefixes of the cluster version (4.16 matches 4.16.3), the cluster topology has to be one of Topologies
+// and all the Operators (package names) have to be installed.
+type CatalogMatch struct {
+	Versions   []string `json:"versions,omitempty"`
+	Topologies []string `json:"topologies,omitempty"`
+	Operators  []string `json:"operators,omitempty"`
+}
+
+// Recommendation is a catalog entry that applies to the cluster. The score counts the criteria matched by the
+// entry, more specific entries are recommended first.
+type Recommendation struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Reference   string `json:"reference"`
+	Score       int    `json:"score"`
+}
+
+// ReferenceRecommendations is the output of the command when a reference catalog is passed without a reference.
+type ReferenceRecommendations struct {
+	Fingerprint     ClusterFingerprint `json:"fingerprint"`
+	Recommendations []Recommendation   `json:"recommendations"`
+}
+
+// LoadReferenceCatalog reads the catalog file, the references of its entries are resolved relative to it.
+func LoadReferenceCatalog(path string) (*ReferenceCatalog, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference catalog: %w", err)
+	}
+	var catalog ReferenceCatalog
+	err = yaml.UnmarshalStrict(contents, &catalog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reference catalog %s: %w", path, err)
+	}
+	var errs []error
+	for i, entry := range catalog.References {
+		if entry.Name == "" || entry.Reference == "" {
+			errs = append(errs, fmt.Errorf("reference catalog entry %d requires a name and a reference", i))
+			continue
+		}
+		if !isURL(entry.Reference) && !filepath.IsAbs(entry.Reference) {
+			entry.Reference = filepath.Join(filepath.Dir(path), entry.Reference)
+		}
+	}
+	return &catalog, errors.Join(errs...)
+}
+
+// score returns the number of criteria of the entry matched by the cluster, and false if the entry doesn't apply.
+func (e *CatalogEntry) score(fp ClusterFingerprint) (int, bool) {
+	score := 0
+	if len(e.Match.Versions) > 0 {
+		if !slices.ContainsFunc(e.Match.Versions, func(v string) bool { return versionHasPrefix(fp.Version, v) }) {
+			return 0, false
+		}
+		score++
+	}
+	if len(e.Match.Topologies) > 0 {
+		if !slices.Contains(e.Match.Topologies, fp.Topology) {
+			return 0, false
+		}
+		score++
+	}
+	for _, operator := range e.Match.Operators {
+		if !slices.Contains(fp.Operators, operator) {
+			return 0, false
+		}
+		score++
+	}
+	return score, true
+}
+
+// versionHasPrefix reports whether version is prefix or one of its patch versions, 4.1 doesn't match 4.16.
+func versionHasPrefix(version, prefix string) bool {
+	return version == prefix || strings.HasPrefix(version, strings.TrimSuffix(prefix, ".")+".")
+}
+
+// Recommend returns the entries of the catalog that apply to the cluster, the most specific first.
+func (c *ReferenceCatalog) Recommend(fp ClusterFingerprint) []Recommendation {
+	recommendations := make([]Recommendation, 0)
+	for _, entry := range c.References {
+		if score, ok := entry.score(fp); ok {
+			recommendations = append(recommendations, Recommendation{
+				Name:        entry.Name,
+				Description: entry.Description,
+				Reference:   entry.Reference,
+				Score:       score,
+			})
+		}
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+	return recommendations
+}
+
+// selectReference returns the reference of the best recommendation, it fails if there isn't a single best one.
+func selectReference(recommendations []Recommendation) (string, error) {
+	if len(recommendations) == 0 {
+		return "", errors.New(noRecommendedReference)
+	}
+	var best []string
+	for _, r := range recommendations {
+		if r.Score == recommendations[0].Score {
+			best = append(best, r.Name)
+		}
+	}
+	if len(best) > 1 {
+		return "", fmt.Errorf(ambiguousRecommendation, strings.Join(best, ", "))
+	}
+	return recommendations[0].Reference, nil
+}
+
+// getFingerprint computes the fingerprint of the live cluster. Kinds that aren't supported by the cluster (for example
+// on clusters that aren't OpenShift) leave the related properties of the fingerprint empty.
+func getFingerprint(builder *resource.Builder, supportedTypes map[string][]schema.GroupVersion) (ClusterFingerprint, error) {
+	fp := ClusterFingerprint{Operators: []string{}}
+	var types []string
+	for kind, group := range fingerprintTypes {
+		for _, gv := range supportedTypes[kind] {
+			if gv.Group == group {
+				types = append(types, strings.Join([]string{kind, gv.Version, gv.Group}, "."))
+			}
+		}
+	}
+	if len(types) == 0 {
+		kinds := lo.Keys(fingerprintTypes)
+		sort.Strings(kinds)
+		klog.Warningf("The cluster doesn't support any of the kinds used to compute its fingerprint: %s",
+			strings.Join(kinds, ", "))
+		return fp, nil
+	}
+	sort.Strings(types)
+
+	r := builder.
+		Unstructured().
+		AllNamespaces(true).
+		ResourceTypes(types...).
+		SelectAllParam(true).
+		ContinueOnError().
+		Flatten().
+		Do()
+	if err := r.Err(); err != nil {
+		return fp, fmt.Errorf("failed to collect the resources of the cluster fingerprint: %w", err)
+	}
+	err := r.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s: %w", info.Name, err)
+		}
+		addToFingerprint(&fp, &unstructured.Unstructured{Object: obj})
+		return nil
+	})
+	if err != nil {
+		return fp, fmt.Errorf("failed to compute the cluster fingerprint: %w", err)
+	}
+	slices.Sort(fp.Operators)
+	fp.Operators = slices.Compact(fp.Operators)
+	return fp, nil
+}
+
+func addToFingerprint(fp *ClusterFingerprint, obj *unstructured.Unstructured) {
+	switch obj.GetKind() {
+	case "ClusterVersion":
+		if obj.GetName() == "version" {
+			fp.Version, _, _ = unstructured.NestedString(obj.Object, "status", "desired", "version")
+		}
+	case "Infrastructure":
+		if obj.GetName() == "cluster" {
+			fp.Topology, _, _ = unstructured.NestedString(obj.Object, "status", "controlPlaneTopology")
+		}
+	case "Subscription":
+		if name, _, _ := unstructured.NestedString(obj.Object, "spec", "name"); name != "" {
+			fp.Operators = append(fp.Operators, name)
+		}
+	}
+}
+
+// recommendReferences computes the cluster fingerprint and the references of the catalog that apply to it. With
+// --auto-reference the best recommendation is used as the reference, otherwise a passed reference that isn't
+// recommended is reported with a warning.
+func (o *Options) recommendReferences(f kcmdutil.Factory) error {
+	catalog, err := LoadReferenceCatalog(o.referenceCatalogPath)
+	if err != nil {
+		return err
+	}
+	c, err := f.ToDiscoveryClient()
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	supportedTypes, err := getSupportedResourceTypes(c)
+	if err != nil {
+		return err
+	}
+	fp, err := getFingerprint(f.NewBuilder(), supportedTypes)
+	if err != nil {
+		return err
+	}
+	o.recommendations = &ReferenceRecommendations{Fingerprint: fp, Recommendations: catalog.Recommend(fp)}
+
+	if o.autoReference {
+		o.referenceConfig, err = selectReference(o.recommendations.Recommendations)
+		if err != nil {
+			return fmt.Errorf("failed to select a reference for the cluster: %w", err)
+		}
+		klog.Infof("Selected the reference %s for the cluster", o.referenceConfig)
+		return nil
+	}
+	if o.referenceConfig != "" && !slices.ContainsFunc(o.recommendations.Recommendations, func(r Recommendation) bool {
+		return sameReference(r.Reference, o.referenceConfig)
+	}) {
+		klog.Warningf(referenceNotRecommended, o.referenceConfig, strings.Join(lo.Map(o.recommendations.Recommendations,
+			func(r Recommendation, _ int) string { return r.Name }), ", "))
+	}
+	return nil
+}
+
+func sameReference(a, b string) bool {
+	if isURL(a) || isURL(b) {
+		return a == b
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// printRecommendations prints the cluster fingerprint and the recommended references, in the requested output format.
+func (o *Options) printRecommendations(w io.Writer) error {
+	var content []byte
+	var err error
+	switch o.OutputFormat {
+	case Json:
+		content, err = json.MarshalIndent(o.recommendations, "", "    ")
+	case Yaml:
+		content, err = yaml.Marshal(o.recommendations)
+	default:
+		content = []byte(o.recommendations.String())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal the reference recommendations: %w", err)
+	}
+	_, err = fmt.Fprintln(w, strings.TrimRight(string(content), "\n"))
+	return err
+}
+
+func (r *ReferenceRecommendations) String() string {
+	var sb strings.Builder
+	sb.WriteString("Cluster fingerprint:\n")
+	fmt.Fprintf(&sb, "  Version: %s\n", valueOrUnknown(r.Fingerprint.Version))
+	fmt.Fprintf(&sb, "  Topology: %s\n", valueOrUnknown(r.Fingerprint.Topology))
+	fmt.Fprintf(&sb, "  Operators: %s\n", valueOrUnknown(strings.Join(r.Fingerprint.Operators, ", ")))
+	if len(r.Recommendations) == 0 {
+		sb.WriteString("No reference in the catalog applies to the cluster\n")
+		return sb.String()
+	}
+	sb.WriteString("Recommended references:\n")
+	for _, rec := range r.Recommendations {
+		fmt.Fprintf(&sb, "  %s (score %d): %s\n", rec.Name, rec.Score, rec.Reference)
+		if rec.Description != "" {
+			fmt.Fprintf(&sb, "    %s\n", rec.Description)
+		}
+	}
+	return sb.String()
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
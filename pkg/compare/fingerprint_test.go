@@ -0,0 +1,86 @@
+package compare
+
+import (
+	"path"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAddToFingerprint(t *testing.T) {
+	fp := ClusterFingerprint{}
+	for _, obj := range []map[string]any{
+		{"kind": "ClusterVersion", "metadata": map[string]any{"name": "version"},
+			"status": map[string]any{"desired": map[string]any{"version": "4.16.3"}}},
+		{"kind": "Infrastructure", "metadata": map[string]any{"name": "cluster"},
+			"status": map[string]any{"controlPlaneTopology": "SingleReplica"}},
+		{"kind": "Infrastructure", "metadata": map[string]any{"name": "other"},
+			"status": map[string]any{"controlPlaneTopology": "External"}},
+		{"kind": "Subscription", "metadata": map[string]any{"name": "ptp-operator-subscription"},
+			"spec": map[string]any{"name": "ptp-operator"}},
+	} {
+		addToFingerprint(&fp, &unstructured.Unstructured{Object: obj})
+	}
+	assert.Equal(t, ClusterFingerprint{Version: "4.16.3", Topology: "SingleReplica", Operators: []string{"ptp-operator"}}, fp)
+}
+
+func TestRecommend(t *testing.T) {
+	catalogPath := path.Join(TestDirs, "ReferenceCatalog", "catalog.yaml")
+	catalog, err := LoadReferenceCatalog(catalogPath)
+	require.NoError(t, err)
+	assert.Equal(t, path.Join(TestDirs, "ReferenceCatalog", "reference", "metadata.yaml"), catalog.References[0].Reference)
+
+	tests := []struct {
+		name        string
+		fingerprint ClusterFingerprint
+		expected    []string
+		selected    string
+		err         string
+	}{
+		{
+			name:        "most specific reference first",
+			fingerprint: ClusterFingerprint{Version: "4.16.3", Topology: "SingleReplica", Operators: []string{"ptp-operator"}},
+			expected:    []string{"ran", "baseline"},
+			selected:    catalog.References[0].Reference,
+		},
+		{
+			name:        "missing operator",
+			fingerprint: ClusterFingerprint{Version: "4.16.3", Topology: "SingleReplica"},
+			expected:    []string{"baseline"},
+			selected:    catalog.References[3].Reference,
+		},
+		{
+			name:        "version prefix matches whole components",
+			fingerprint: ClusterFingerprint{Version: "4.1.2", Topology: "HighlyAvailable"},
+			expected:    []string{"ran-4.1", "baseline"},
+			selected:    catalog.References[2].Reference,
+		},
+		{
+			name:        "exact version",
+			fingerprint: ClusterFingerprint{Version: "4.16", Topology: "HighlyAvailable"},
+			expected:    []string{"core", "baseline"},
+			selected:    catalog.References[1].Reference,
+		},
+		{
+			name:        "unknown cluster",
+			fingerprint: ClusterFingerprint{},
+			expected:    []string{"baseline"},
+			selected:    catalog.References[3].Reference,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recommendations := catalog.Recommend(test.fingerprint)
+			assert.Equal(t, test.expected, lo.Map(recommendations, func(r Recommendation, _ int) string { return r.Name }))
+			selected, err := selectReference(recommendations)
+			require.NoError(t, err)
+			assert.Equal(t, test.selected, selected)
+		})
+	}
+
+	_, err = selectReference(nil)
+	assert.EqualError(t, err, noRecommendedReference)
+}
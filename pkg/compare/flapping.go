@@ -0,0 +1,178 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+const (
+	flappingRequiresSnapshots = "Flapping detection (--detect-flapping) requires two or more snapshots passed by -f"
+)
+
+// FlappingField is a field of a cluster CR whose value changed between snapshots, and that matched the template in
+// some of the snapshots but not in others. Flapping fields are candidates for fieldsToOmit.
+type FlappingField struct {
+	CRName   string `json:"CRName"`
+	Template string `json:"Template"`
+	Path     string `json:"Path"`
+}
+
+// snapshotResult is the comparison of a cluster CR, as captured in one snapshot, with its best matching template.
+type snapshotResult struct {
+	template   string
+	cr         map[string]any
+	diffFields [][]string
+}
+
+// detectFlappingFields compares the CRs of each snapshot with the reference and returns the flapping fields of the CRs
+// found in more than one snapshot. Snapshots are expected to be passed in the order they were captured.
+func (o *Options) detectFlappingFields(snapshots []string) ([]FlappingField, error) {
+	results := make(map[string][]*snapshotResult)
+	for _, snapshot := range snapshots {
+		r := o.newBuilder().
+			Unstructured().
+			LocalParam(true).
+			FilenameParam(false, &resource.FilenameOptions{Filenames: []string{snapshot}, Recursive: o.CRs.Recursive}).
+			ContinueOnError().
+			Flatten().
+			Do()
+		// Invalid and unmatched CRs are already reported by the comparison of the last snapshot
+		r.IgnoreErrors(func(err error) bool { return true })
+		err := r.Visit(func(info *resource.Info, _ error) error {
+			clusterCRMapping, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+			if err != nil {
+				return nil //nolint: nilerr
+			}
+			clusterCR, err := o.preprocess(o.context(), &unstructured.Unstructured{Object: clusterCRMapping})
+			if err != nil {
+				return nil //nolint: nilerr
+			}
+			res, err := o.compareSnapshotCR(clusterCR)
+			if err != nil || res == nil {
+				return nil //nolint: nilerr
+			}
+			name := apiKindNamespaceName(clusterCR)
+			results[name] = append(results[name], res)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to process snapshot %s: %w", snapshot, err)
+		}
+	}
+	return flappingFields(results), nil
+}
+
+func (o *Options) compareSnapshotCR(clusterCR *unstructured.Unstructured) (*snapshotResult, error) {
+	res := &snapshotResult{cr: clusterCR.DeepCopy().Object}
+	temps, err := o.correlator.Match(clusterCR)
+	if err != nil {
+		return nil, err
+	}
+	userOverrides, err := o.userOverridesCorrelator.Match(clusterCR)
+	if err != nil && !containOnly(err, []error{UnknownMatch{}}) {
+		return nil, err
+	}
+	matches := make([]*diffResult, 0, len(temps))
+	for _, temp := range temps {
+		templateOverrides := make([]*UserOverride, 0)
+		for _, uo := range userOverrides {
+			if uo.TemplatePath == "" || uo.TemplatePath == temp.GetPath() {
+				templateOverrides = append(templateOverrides, uo)
+			}
+		}
+		match, err := scoreAgainstTemplate(temp, clusterCR.DeepCopy(), templateOverrides, o)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, match)
+	}
+	bestMatch := findBestMatch(matches)
+	if bestMatch == nil || bestMatch.userOverride == nil {
+		return nil, nil
+	}
+	res.template = bestMatch.temp.GetIdentifier()
+	var patch map[string]any
+	if err := json.Unmarshal([]byte(bestMatch.userOverride.Patch), &patch); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal internal diff: %w", err)
+	}
+	res.diffFields = leafFields(patch, nil)
+	return res, nil
+}
+
+// flappingFields returns the fields whose value changed between snapshots, and that matched the template in at least
+// one of the snapshots and differed from it in another.
+func flappingFields(results map[string][]*snapshotResult) []FlappingField {
+	res := make([]FlappingField, 0)
+	for name, snapshots := range results {
+		if len(snapshots) < 2 {
+			continue
+		}
+		fields := make(map[string][]string)
+		for _, s := range snapshots {
+			for _, f := range leafFields(s.cr, nil) {
+				fields[strings.Join(f, ".")] = f
+			}
+		}
+		for p, f := range fields {
+			changed, matched, differed := false, false, false
+			first, firstFound, _ := unstructured.NestedFieldNoCopy(snapshots[0].cr, f...)
+			for _, s := range snapshots {
+				value, found, _ := unstructured.NestedFieldNoCopy(s.cr, f...)
+				if found != firstFound || !reflect.DeepEqual(value, first) {
+					changed = true
+				}
+				if overlapsAny(f, s.diffFields) {
+					differed = true
+				} else {
+					matched = true
+				}
+			}
+			if changed && matched && differed {
+				res = append(res, FlappingField{CRName: name, Template: snapshots[len(snapshots)-1].template, Path: p})
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].CRName != res[j].CRName {
+			return res[i].CRName < res[j].CRName
+		}
+		return res[i].Path < res[j].Path
+	})
+	return res
+}
+
+// leafFields returns the paths of the leaves of an object, lists are leaves.
+func leafFields(d any, prefix []string) [][]string {
+	m, ok := d.(map[string]any)
+	if !ok || len(m) == 0 {
+		if len(prefix) == 0 {
+			return nil
+		}
+		return [][]string{prefix}
+	}
+	var fields [][]string
+	for k, v := range m {
+		fields = append(fields, leafFields(v, append(append([]string{}, prefix...), k))...)
+	}
+	return fields
+}
+
+// overlapsAny checks if one of the paths is the field itself, nested in it or one of its parents.
+func overlapsAny(field []string, paths [][]string) bool {
+	for _, p := range paths {
+		n := min(len(p), len(field))
+		if reflect.DeepEqual(p[:n], field[:n]) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ package compare
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"text/template"
 
@@ -25,9 +26,10 @@ import (
 //
 //   - "include"
 //   - "tpl"
+//   - "lookupLive"
 //
-// These are late-bound in Engine.Render().  The
-// version included in the FuncMap is a placeholder.
+// These are late-bound in Engine.Render(), and lookupLive when comparing a
+// live cluster. The version included in the FuncMap is a placeholder.
 func FuncMap() template.FuncMap {
 	f := sprig.TxtFuncMap()
 	delete(f, "env")
@@ -42,6 +44,9 @@ func FuncMap() template.FuncMap {
 		"toJson":        toJSON,
 		"fromJson":      fromJSON,
 		"fromJsonArray": fromJSONArray,
+		"required":      required,
+		"failCompare":   failCompare,
+		lookupLiveFunc:  lookupNotLive,
 	}
 
 	for k, v := range extra {
@@ -51,6 +56,46 @@ func FuncMap() template.FuncMap {
 	return f
 }
 
+// TemplateAssertionError is returned by the required and failCompare functions when a template is rendered for a
+// cluster CR that doesn't meet its assertions. It's reported as a validation issue of the CR.
+type TemplateAssertionError struct {
+	Msg string
+}
+
+func (e TemplateAssertionError) Error() string {
+	return e.Msg
+}
+
+// required returns the value, or fails with the message when the value is missing or an empty string, as in Helm.
+//
+// This is designed to be called from a template.
+func required(msg string, val any) (any, error) {
+	if s, ok := val.(string); val == nil || (ok && s == "") {
+		return val, TemplateAssertionError{Msg: msg}
+	}
+	return val, nil
+}
+
+// failCompare always fails with the message, templates call it when the cluster CR doesn't meet a precondition.
+//
+// This is designed to be called from a template.
+func failCompare(msg string) (string, error) {
+	return "", TemplateAssertionError{Msg: msg}
+}
+
+// withoutAssertions returns a copy of the template whose assertion functions never fail. It's used when the template
+// is rendered without cluster data, for example to extract its metadata, as there is nothing to assert then.
+func withoutAssertions(t *template.Template) (*template.Template, error) {
+	clone, err := t.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone template %s: %w", t.Name(), err)
+	}
+	return clone.Funcs(template.FuncMap{
+		"required":    func(_ string, val any) any { return val },
+		"failCompare": func(string) string { return "" },
+	}), nil
+}
+
 // toYAML takes an interface, marshals it to yaml, and returns a string. It will
 // always return a string, even on marshal error (empty string).
 //
@@ -0,0 +1,383 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	comparev1alpha1 "github.com/openshift/kube-compare/api/compare/v1alpha1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	serveGRPCLong = templates.LongDesc(`
+		Serve the Compare gRPC API (api/compare/v1alpha1), for tooling that drives comparisons without running the
+		command.
+
+		A client loads a reference with LoadReference and then compares any number of CR sets against it with
+		CompareObjects, or with StreamResults to receive the diff of each CR as soon as it's compared. The clients
+		can only load the references passed by --reference, a directory allows all the references it contains. The
+		references are kept in memory until they are unloaded with UnloadReference, at most --max-references at a
+		time.
+
+		The API has no authentication, the server only listens on the loopback interface unless --allow-remote is
+		set.
+	`)
+
+	serveGRPCExample = templates.Examples(`
+		# Serve the Compare gRPC API on port 9090, for the references in ./references:
+		kubectl cluster-compare serve-grpc --reference ./references --listen-address localhost:9090
+	`)
+)
+
+const (
+	noReferencePassed     = "the reference is required"
+	noServedReference     = "at least one --reference must be passed"
+	referenceNotServed    = "reference %s isn't served, the server only loads the references passed by --reference"
+	referenceNotLoaded    = "reference %s isn't loaded, load it with LoadReference"
+	tooManyReferences     = "the server already holds %d references, unload one with UnloadReference"
+	invalidObject         = "object %d isn't a valid yaml or json document: %s"
+	invalidMaxReferences  = "The --max-references value must be positive, got %d"
+	remoteListenerRefused = "%s isn't a loopback address and the API has no authentication, pass --allow-remote to " +
+		"serve it to other hosts"
+)
+
+func newServeGRPCCmd() *cobra.Command {
+	var listenAddress string
+	var allowRemote bool
+	var references []string
+	concurrency, maxReferences := 4, 16
+
+	cmd := &cobra.Command{
+		Use:                   "serve-grpc --reference <Reference File or Directory>",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Serve the Compare gRPC API."),
+		Long:                  serveGRPCLong,
+		Example:               serveGRPCExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if maxReferences <= 0 {
+				kcmdutil.CheckErr(kcmdutil.UsageErrorf(cmd, invalidMaxReferences, maxReferences))
+			}
+			s, err := newGRPCServer(concurrency, maxReferences, references)
+			if err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageErrorf(cmd, "%s", err))
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			lis, err := listen(listenAddress, allowRemote)
+			kcmdutil.CheckErr(err)
+			klog.Infof("Serving the Compare gRPC API on %s", lis.Addr())
+			kcmdutil.CheckErr(s.serve(ctx, lis))
+		},
+	}
+	cmd.Flags().StringArrayVar(&references, "reference", nil,
+		"Reference (path or URL of its metadata.yaml) the clients can load, or a directory whose references they can "+
+			"load. Can be repeated")
+	cmd.Flags().StringVar(&listenAddress, "listen-address", "localhost:9090", "Address the server listens on")
+	cmd.Flags().BoolVar(&allowRemote, "allow-remote", false,
+		"Allow listening on a non-loopback address, the API has no authentication")
+	cmd.Flags().IntVar(&concurrency, "concurrency", concurrency, "Number of CRs compared in parallel by each comparison")
+	cmd.Flags().IntVar(&maxReferences, "max-references", maxReferences, "Maximum number of references loaded at a time")
+	return cmd
+}
+
+// listen listens on the address, which must be a loopback address unless allowRemote is set as the API has no
+// authentication.
+func listen(address string, allowRemote bool) (net.Listener, error) {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	if addr, ok := lis.Addr().(*net.TCPAddr); ok && !addr.IP.IsLoopback() && !allowRemote {
+		lis.Close()
+		return nil, fmt.Errorf(remoteListenerRefused, address)
+	}
+	return lis, nil
+}
+
+// grpcServer implements the Compare gRPC API with an Engine for each loaded reference.
+type grpcServer struct {
+	comparev1alpha1.UnimplementedCompareServer
+	concurrency   int
+	maxReferences int
+	// references are the references the clients can load, the URLs and the absolute paths of the local references and
+	// of the directories containing them
+	references []string
+
+	lock    sync.RWMutex
+	engines map[string]*Engine
+}
+
+func newGRPCServer(concurrency, maxReferences int, references []string) (*grpcServer, error) {
+	if len(references) == 0 {
+		return nil, errors.New(noServedReference)
+	}
+	s := &grpcServer{concurrency: concurrency, maxReferences: maxReferences, engines: make(map[string]*Engine)}
+	for _, ref := range references {
+		if isURL(ref) {
+			s.references = append(s.references, ref)
+			continue
+		}
+		path, err := resolvePath(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve --reference %s: %w", ref, err)
+		}
+		s.references = append(s.references, path)
+	}
+	return s, nil
+}
+
+// resolvePath returns the absolute path of a local file, with the symbolic links resolved.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	return filepath.EvalSymlinks(abs) // nolint:wrapcheck
+}
+
+// served returns whether the clients can load the reference: it's one of the references of the server or, for a local
+// reference, it's in one of their directories.
+func (s *grpcServer) served(refPath string) bool {
+	if isURL(refPath) {
+		return slices.Contains(s.references, refPath)
+	}
+	// The references that don't exist aren't served either, so the clients can't probe the files of the server
+	path, err := resolvePath(refPath)
+	if err != nil {
+		return false
+	}
+	for _, ref := range s.references {
+		if isURL(ref) {
+			continue
+		}
+		rel, err := filepath.Rel(ref, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// serve serves the API on the listener until the context is done.
+func (s *grpcServer) serve(ctx context.Context, lis net.Listener) error {
+	server := grpc.NewServer()
+	comparev1alpha1.RegisterCompareServer(server, s)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Serve(lis)
+	}()
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("failed to serve the Compare gRPC API: %w", err)
+	case <-ctx.Done():
+		server.GracefulStop()
+		return nil
+	}
+}
+
+// referenceID identifies a reference loaded with its configs, so loading it again returns the same id.
+func referenceID(req *comparev1alpha1.LoadReferenceRequest) string {
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(req.GetReference()), req.GetDiffConfig(), req.GetUserOverrides()} {
+		// The length prefix keeps the concatenation of the parts unambiguous
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *grpcServer) LoadReference(_ context.Context, req *comparev1alpha1.LoadReferenceRequest) (*comparev1alpha1.LoadReferenceResponse, error) {
+	refPath := req.GetReference()
+	if refPath == "" {
+		return nil, status.Error(codes.InvalidArgument, noReferencePassed)
+	}
+	if isImage(refPath) {
+		return nil, status.Errorf(codes.InvalidArgument, imageUnsupported, refPath)
+	}
+	if !s.served(refPath) {
+		return nil, status.Errorf(codes.PermissionDenied, referenceNotServed, refPath)
+	}
+	id := referenceID(req)
+	s.lock.RLock()
+	_, loaded := s.engines[id]
+	numLoaded := len(s.engines)
+	s.lock.RUnlock()
+	if !loaded && numLoaded >= s.maxReferences {
+		return nil, status.Errorf(codes.ResourceExhausted, tooManyReferences, numLoaded)
+	}
+	engine, err := s.newEngine(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// Another reference can have been loaded in the meantime
+	if _, loaded := s.engines[id]; !loaded && len(s.engines) >= s.maxReferences {
+		return nil, status.Errorf(codes.ResourceExhausted, tooManyReferences, len(s.engines))
+	}
+	s.engines[id] = engine
+	return &comparev1alpha1.LoadReferenceResponse{
+		ReferenceId:  id,
+		MetadataHash: engine.options.referenceHash,
+		NumTemplates: int32(len(engine.Templates())), // nolint:gosec
+	}, nil
+}
+
+// newEngine loads the reference and the configs of the request, as Complete does for the flags of the command.
+func (s *grpcServer) newEngine(req *comparev1alpha1.LoadReferenceRequest) (*Engine, error) {
+	refPath := req.GetReference()
+	cfs, err := GetRefFS(refPath)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := GetReference(cfs, filepath.Base(refPath))
+	if err != nil {
+		return nil, err
+	}
+	opts := EngineOptions{Concurrency: s.concurrency}
+	if len(req.GetDiffConfig()) != 0 {
+		if opts.DiffConfig, err = parseDiffConfigContent(req.GetDiffConfig()); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.GetUserOverrides()) != 0 {
+		if opts.UserOverrides, err = ParseUserOverrides(req.GetUserOverrides(), now(), false); err != nil {
+			return nil, err
+		}
+	}
+	return NewEngine(ref, cfs, opts)
+}
+
+func (s *grpcServer) UnloadReference(_ context.Context, req *comparev1alpha1.UnloadReferenceRequest) (*comparev1alpha1.UnloadReferenceResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.engines[req.GetReferenceId()]; !ok {
+		return nil, status.Errorf(codes.NotFound, referenceNotLoaded, req.GetReferenceId())
+	}
+	delete(s.engines, req.GetReferenceId())
+	return &comparev1alpha1.UnloadReferenceResponse{}, nil
+}
+
+func (s *grpcServer) CompareObjects(_ context.Context, req *comparev1alpha1.CompareObjectsRequest) (*comparev1alpha1.CompareObjectsResponse, error) {
+	output, err := s.compare(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := &comparev1alpha1.CompareObjectsResponse{}
+	if resp.Summary, err = toProtoSummary(output.Summary); err != nil {
+		return nil, err
+	}
+	for _, d := range output.sortedDiffs(true) {
+		resp.Diffs = append(resp.Diffs, toProtoDiff(d))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) StreamResults(req *comparev1alpha1.CompareObjectsRequest, stream grpc.ServerStreamingServer[comparev1alpha1.CompareResult]) error {
+	// The diffs are sent by the workers of the comparison, a stream can't be sent to concurrently
+	var lock sync.Mutex
+	var sendErr error
+	output, err := s.compare(req, func(d DiffSum) {
+		lock.Lock()
+		defer lock.Unlock()
+		if sendErr == nil {
+			sendErr = stream.Send(&comparev1alpha1.CompareResult{Result: &comparev1alpha1.CompareResult_Diff{Diff: toProtoDiff(d)}})
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	summary, err := toProtoSummary(output.Summary)
+	if err != nil {
+		return err
+	}
+	return stream.Send(&comparev1alpha1.CompareResult{Result: &comparev1alpha1.CompareResult_Summary{Summary: summary}})
+}
+
+// compare compares the objects of the request with the loaded reference, onDiff is called with the diff of each
+// object as soon as it's compared when it isn't nil.
+func (s *grpcServer) compare(req *comparev1alpha1.CompareObjectsRequest, onDiff func(DiffSum)) (Output, error) {
+	s.lock.RLock()
+	engine, ok := s.engines[req.GetReferenceId()]
+	s.lock.RUnlock()
+	if !ok {
+		return Output{}, status.Errorf(codes.NotFound, referenceNotLoaded, req.GetReferenceId())
+	}
+	crs := make([]*unstructured.Unstructured, 0, len(req.GetObjects()))
+	for i, content := range req.GetObjects() {
+		obj := make(map[string]any)
+		if err := yaml.Unmarshal(content, &obj); err != nil {
+			return Output{}, status.Errorf(codes.InvalidArgument, invalidObject, i, err)
+		}
+		crs = append(crs, &unstructured.Unstructured{Object: obj})
+	}
+	output, err := engine.withFlags(req.GetOnlyValidation(), req.GetAllResources()).CompareStream(crs, onDiff)
+	if err != nil {
+		klog.Errorf("Failed to compare the objects to reference %s: %s", req.GetReferenceId(), err)
+		return Output{}, status.Error(codes.Internal, err.Error())
+	}
+	if output.Diffs == nil {
+		output.Diffs = &[]DiffSum{}
+	}
+	return output, nil
+}
+
+func toProtoDiff(d DiffSum) *comparev1alpha1.Diff {
+	return &comparev1alpha1.Diff{
+		CrName:             d.CRName,
+		CorrelatedTemplate: d.CorrelatedTemplate,
+		DiffOutput:         d.DiffOutput,
+		Patched:            d.Patched,
+		OverrideReasons:    d.OverrideReasons,
+		Description:        d.Description,
+		Severity:           d.Severity,
+		Acknowledgements:   d.Acknowledgements,
+		Part:               d.Part,
+		Component:          d.Component,
+	}
+}
+
+func toProtoSummary(sum *Summary) (*comparev1alpha1.Summary, error) {
+	if sum == nil {
+		return nil, nil
+	}
+	issues, err := json.Marshal(sum.ValidationIssues)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal the validation issues: %s", err)
+	}
+	return &comparev1alpha1.Summary{
+		NumDiffCrs:       int32(sum.NumDiffCRs), // nolint:gosec
+		TotalCrs:         int32(sum.TotalCRs),   // nolint:gosec
+		NumMissing:       int32(sum.NumMissing), // nolint:gosec
+		UnmatchedCrs:     sum.UnmatchedCRS,
+		MetadataHash:     sum.MetadataHash,
+		PatchedCrs:       int32(sum.PatchedCRs), // nolint:gosec
+		ValidationIssues: issues,
+	}, nil
+}
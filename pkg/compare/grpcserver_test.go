@@ -0,0 +1,310 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	comparev1alpha1 "github.com/openshift/kube-compare/api/compare/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newGRPCClient(t *testing.T, maxReferences int) comparev1alpha1.CompareClient {
+	s, err := newGRPCServer(2, maxReferences, []string{
+		"testdata/SomeDiffs/reference",
+		"testdata/UserOverride/reference/metadata.yaml",
+		"https://example.com/reference/metadata.yaml",
+	})
+	require.NoError(t, err)
+	lis := bufconn.Listen(1024 * 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.serve(ctx, lis)
+	}()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		require.NoError(t, <-serveErr)
+	})
+	return comparev1alpha1.NewCompareClient(conn)
+}
+
+func readFiles(t *testing.T, paths ...string) [][]byte {
+	var contents [][]byte
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		contents = append(contents, content)
+	}
+	return contents
+}
+
+func TestGRPCServer(t *testing.T) {
+	ctx := context.Background()
+	client := newGRPCClient(t, 16)
+	objects := readFiles(t, "testdata/SomeDiffs/resources/d2.yaml", "testdata/SomeDiffs/resources/deploymentDashboard.yaml")
+
+	loaded, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{
+		Reference: "testdata/SomeDiffs/reference/metadata.yaml",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), loaded.GetNumTemplates())
+	assert.NotEmpty(t, loaded.GetMetadataHash())
+	id := loaded.GetReferenceId()
+
+	t.Run("Load The Same Reference Again", func(t *testing.T) {
+		again, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{
+			Reference: "testdata/SomeDiffs/reference/metadata.yaml",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, id, again.GetReferenceId())
+	})
+
+	t.Run("Compare Objects", func(t *testing.T) {
+		resp, err := client.CompareObjects(ctx, &comparev1alpha1.CompareObjectsRequest{ReferenceId: id, Objects: objects})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), resp.GetSummary().GetTotalCrs())
+		assert.Equal(t, int32(1), resp.GetSummary().GetNumDiffCrs())
+		assert.Equal(t, loaded.GetMetadataHash(), resp.GetSummary().GetMetadataHash())
+		assert.JSONEq(t, "{}", string(resp.GetSummary().GetValidationIssues()))
+		require.Len(t, resp.GetDiffs(), 2)
+		for _, d := range resp.GetDiffs() {
+			if d.GetCorrelatedTemplate() == "deploymentMetrics.yaml" {
+				assert.Contains(t, d.GetDiffOutput(), "+      k8s-app: dashboard-metrics-scraper-diff")
+			} else {
+				assert.Empty(t, d.GetDiffOutput())
+			}
+		}
+	})
+
+	t.Run("Stream Results", func(t *testing.T) {
+		expected, err := client.CompareObjects(ctx, &comparev1alpha1.CompareObjectsRequest{ReferenceId: id, Objects: objects})
+		require.NoError(t, err)
+		stream, err := client.StreamResults(ctx, &comparev1alpha1.CompareObjectsRequest{ReferenceId: id, Objects: objects})
+		require.NoError(t, err)
+		var results []*comparev1alpha1.CompareResult
+		for {
+			result, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			results = append(results, result)
+		}
+		// A message per diff, in the order the CRs were compared, then the summary
+		require.Len(t, results, len(expected.GetDiffs())+1)
+		streamed := make(map[string]*comparev1alpha1.Diff)
+		for _, result := range results[:len(results)-1] {
+			require.NotNil(t, result.GetDiff())
+			streamed[result.GetDiff().GetCrName()] = result.GetDiff()
+		}
+		for _, d := range expected.GetDiffs() {
+			require.Contains(t, streamed, d.GetCrName())
+			assert.Equal(t, d.GetCorrelatedTemplate(), streamed[d.GetCrName()].GetCorrelatedTemplate())
+			// The diffs only differ by the paths of their temporary files
+			assert.Equal(t, d.GetDiffOutput() == "", streamed[d.GetCrName()].GetDiffOutput() == "")
+		}
+		assert.Equal(t, expected.GetSummary().GetNumDiffCrs(), results[len(results)-1].GetSummary().GetNumDiffCrs())
+	})
+
+	t.Run("Only Validation", func(t *testing.T) {
+		resp, err := client.CompareObjects(ctx, &comparev1alpha1.CompareObjectsRequest{
+			ReferenceId: id, Objects: objects[:1], OnlyValidation: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), resp.GetSummary().GetTotalCrs())
+		assert.Equal(t, int32(1), resp.GetSummary().GetNumMissing())
+		for _, d := range resp.GetDiffs() {
+			assert.Empty(t, d.GetDiffOutput())
+		}
+	})
+
+	t.Run("User Overrides", func(t *testing.T) {
+		loaded, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{
+			Reference:     "testdata/UserOverride/reference/metadata.yaml",
+			UserOverrides: readFiles(t, "testdata/UserOverride/rfc6902.patch")[0],
+		})
+		require.NoError(t, err)
+		resp, err := client.CompareObjects(ctx, &comparev1alpha1.CompareObjectsRequest{
+			ReferenceId: loaded.GetReferenceId(),
+			Objects:     readFiles(t, "testdata/UserOverride/resources/ns1.yaml", "testdata/UserOverride/resources/ns2.yaml"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), resp.GetSummary().GetPatchedCrs())
+	})
+
+	t.Run("Unload Reference", func(t *testing.T) {
+		loaded, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{
+			Reference:  "testdata/SomeDiffs/reference/metadata.yaml",
+			DiffConfig: []byte("correlationSettings: {}"),
+		})
+		require.NoError(t, err)
+		assert.NotEqual(t, id, loaded.GetReferenceId())
+		_, err = client.UnloadReference(ctx, &comparev1alpha1.UnloadReferenceRequest{ReferenceId: loaded.GetReferenceId()})
+		require.NoError(t, err)
+		_, err = client.CompareObjects(ctx, &comparev1alpha1.CompareObjectsRequest{ReferenceId: loaded.GetReferenceId(), Objects: objects})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = client.UnloadReference(ctx, &comparev1alpha1.UnloadReferenceRequest{ReferenceId: loaded.GetReferenceId()})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		// The other references stay loaded
+		_, err = client.CompareObjects(ctx, &comparev1alpha1.CompareObjectsRequest{ReferenceId: id, Objects: objects})
+		require.NoError(t, err)
+	})
+
+	t.Run("Max References", func(t *testing.T) {
+		client := newGRPCClient(t, 1)
+		someDiffs := &comparev1alpha1.LoadReferenceRequest{Reference: "testdata/SomeDiffs/reference/metadata.yaml"}
+		userOverride := &comparev1alpha1.LoadReferenceRequest{Reference: "testdata/UserOverride/reference/metadata.yaml"}
+		loaded, err := client.LoadReference(ctx, someDiffs)
+		require.NoError(t, err)
+		_, err = client.LoadReference(ctx, userOverride)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		// Loading a loaded reference again replaces it
+		_, err = client.LoadReference(ctx, someDiffs)
+		require.NoError(t, err)
+		_, err = client.UnloadReference(ctx, &comparev1alpha1.UnloadReferenceRequest{ReferenceId: loaded.GetReferenceId()})
+		require.NoError(t, err)
+		_, err = client.LoadReference(ctx, userOverride)
+		require.NoError(t, err)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			call func() error
+			code codes.Code
+			msg  string
+		}{
+			{
+				name: "Missing Reference",
+				call: func() error {
+					_, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{})
+					return err
+				},
+				code: codes.InvalidArgument,
+				msg:  noReferencePassed,
+			},
+			{
+				name: "Image Reference",
+				call: func() error {
+					_, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{Reference: "oci://quay.io/reference:latest"})
+					return err
+				},
+				code: codes.InvalidArgument,
+				msg:  "is a container image",
+			},
+			{
+				name: "Reference Not Served",
+				call: func() error {
+					_, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{
+						Reference: "testdata/UserConfigIsntCorrectYAML/reference/metadata.yaml",
+					})
+					return err
+				},
+				code: codes.PermissionDenied,
+				msg:  "isn't served",
+			},
+			{
+				name: "Reference Outside Of A Served Directory",
+				call: func() error {
+					_, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{
+						Reference: "testdata/SomeDiffs/reference/../../SomeDiffs/resources/d2.yaml",
+					})
+					return err
+				},
+				code: codes.PermissionDenied,
+				msg:  "isn't served",
+			},
+			{
+				name: "Missing Reference File",
+				call: func() error {
+					_, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{Reference: "/etc/missing/metadata.yaml"})
+					return err
+				},
+				code: codes.PermissionDenied,
+				msg:  "isn't served",
+			},
+			{
+				name: "URL Not Served",
+				call: func() error {
+					_, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{Reference: "https://example.com/other/metadata.yaml"})
+					return err
+				},
+				code: codes.PermissionDenied,
+				msg:  "isn't served",
+			},
+			{
+				name: "Invalid Diff Config",
+				call: func() error {
+					_, err := client.LoadReference(ctx, &comparev1alpha1.LoadReferenceRequest{
+						Reference:  "testdata/SomeDiffs/reference/metadata.yaml",
+						DiffConfig: readFiles(t, "testdata/UserConfigIsntCorrectYAML/userconfig.yaml")[0],
+					})
+					return err
+				},
+				code: codes.InvalidArgument,
+				msg:  "User config file isn't in correct format",
+			},
+			{
+				name: "Reference Not Loaded",
+				call: func() error {
+					_, err := client.CompareObjects(ctx, &comparev1alpha1.CompareObjectsRequest{ReferenceId: "unknown"})
+					return err
+				},
+				code: codes.NotFound,
+				msg:  "reference unknown isn't loaded",
+			},
+			{
+				name: "Invalid Object",
+				call: func() error {
+					_, err := client.CompareObjects(ctx, &comparev1alpha1.CompareObjectsRequest{
+						ReferenceId: id, Objects: [][]byte{[]byte("NOT YAML")},
+					})
+					return err
+				},
+				code: codes.InvalidArgument,
+				msg:  "object 0 isn't a valid yaml or json document",
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				err := test.call()
+				require.Error(t, err)
+				assert.Equal(t, test.code, status.Code(err))
+				assert.Contains(t, status.Convert(err).Message(), test.msg)
+			})
+		}
+	})
+}
+
+func TestGRPCServerSetup(t *testing.T) {
+	_, err := newGRPCServer(1, 1, nil)
+	assert.EqualError(t, err, noServedReference)
+	_, err = newGRPCServer(1, 1, []string{"testdata/missing"})
+	assert.ErrorContains(t, err, "failed to resolve --reference testdata/missing")
+
+	lis, err := listen("localhost:0", false)
+	require.NoError(t, err)
+	lis.Close()
+	_, err = listen("0.0.0.0:0", false)
+	assert.ErrorContains(t, err, "0.0.0.0:0 isn't a loopback address")
+	lis, err = listen("0.0.0.0:0", true)
+	require.NoError(t, err)
+	lis.Close()
+}
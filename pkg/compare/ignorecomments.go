@@ -0,0 +1,117 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	yamlv3 "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// ignoreDiffComment marks a field of a template as non-authoritative, when it's the comment of the line above the
+// field or of the line of the field:
+//
+//	# cluster-compare-ignore
+//	replicas: 3
+//	image: {{ .spec.image }} # cluster-compare-ignore
+//
+// The marked fields, with all the fields they contain, never produce diffs. It's an inline alternative to fieldsToOmit
+// for the fields of a single template.
+const ignoreDiffComment = "cluster-compare-ignore"
+
+// ignoreMarkedFields takes the fields of the rendered template that are marked with ignoreDiffComment from the cluster
+// CR, so they are identical to the fields of the CR, and removes them when the CR doesn't set them.
+func ignoreMarkedFields(content []byte, rendered, clusterCR map[string]any) error {
+	if !bytes.Contains(content, []byte(ignoreDiffComment)) {
+		return nil
+	}
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("failed to parse the comments of the template: %w", err)
+	}
+	for _, path := range markedPaths(&doc, nil) {
+		parent, ok := nestedContainer(rendered, path[:len(path)-1])
+		if !ok {
+			continue
+		}
+		key := path[len(path)-1].(string) //nolint:forcetypeassert // the marked fields are keys of maps
+		if value, ok := nestedValue(clusterCR, path); ok {
+			parent[key] = runtime.DeepCopyJSONValue(value)
+		} else {
+			delete(parent, key)
+		}
+	}
+	return nil
+}
+
+// markedPaths returns the paths of the fields marked with ignoreDiffComment in the node. The items of the paths are
+// the keys of the maps, and the indexes of the lists.
+func markedPaths(node *yamlv3.Node, path []any) [][]any {
+	var res [][]any
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		for _, child := range node.Content {
+			res = append(res, markedPaths(child, path)...)
+		}
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := append(append([]any{}, path...), key.Value)
+			if isIgnoreComment(key.HeadComment) || isIgnoreComment(key.LineComment) || isIgnoreComment(value.LineComment) {
+				res = append(res, fieldPath)
+				continue
+			}
+			res = append(res, markedPaths(value, fieldPath)...)
+		}
+	case yamlv3.SequenceNode:
+		for i, item := range node.Content {
+			res = append(res, markedPaths(item, append(append([]any{}, path...), i))...)
+		}
+	}
+	return res
+}
+
+func isIgnoreComment(comment string) bool {
+	for _, line := range strings.Split(comment, "\n") {
+		if strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")) == ignoreDiffComment {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedValue returns the value at the path of the object.
+func nestedValue(obj any, path []any) (any, bool) {
+	for _, item := range path {
+		switch item := item.(type) {
+		case string:
+			m, ok := obj.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if obj, ok = m[item]; !ok {
+				return nil, false
+			}
+		case int:
+			l, ok := obj.([]any)
+			if !ok || item >= len(l) {
+				return nil, false
+			}
+			obj = l[item]
+		}
+	}
+	return obj, true
+}
+
+// nestedContainer returns the map at the path of the object.
+func nestedContainer(obj map[string]any, path []any) (map[string]any, bool) {
+	value, ok := nestedValue(obj, path)
+	if !ok {
+		return nil, false
+	}
+	m, ok := value.(map[string]any)
+	return m, ok
+}
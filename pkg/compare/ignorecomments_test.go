@@ -0,0 +1,61 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestIgnoreMarkedFields(t *testing.T) {
+	content := []byte(`apiVersion: apps/v1
+kind: Deployment
+spec:
+  # cluster-compare-ignore
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0 # cluster-compare-ignore
+        args: # cluster-compare-ignore
+        - --verbose
+      - name: sidecar
+        # the sidecar is pinned
+        image: sidecar:1.0
+`)
+	clusterCR := map[string]any{}
+	require.NoError(t, yaml.Unmarshal([]byte(`apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.1
+      - name: sidecar
+        image: sidecar:1.1
+`), &clusterCR))
+	rendered := map[string]any{}
+	require.NoError(t, yaml.Unmarshal(content, &rendered))
+
+	require.NoError(t, ignoreMarkedFields(content, rendered, clusterCR))
+	expected := map[string]any{}
+	require.NoError(t, yaml.Unmarshal([]byte(`apiVersion: apps/v1
+kind: Deployment
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.1
+      - name: sidecar
+        image: sidecar:1.0
+`), &expected))
+	assert.Equal(t, expected, rendered)
+}
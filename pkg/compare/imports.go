@@ -0,0 +1,179 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	importNotExists     = "imported reference not found. error: %w"
+	importNotInFormat   = "imported reference isn't in correct format. error: %w"
+	importUnsupported   = "imported reference %s has apiVersion %q, only references with apiVersion v2 or v3 can be imported"
+	importCircular      = "circular import of references found %s"
+	importFailedToParse = "failed to import reference %s: %w"
+)
+
+// localFS is a local directory as a fs.FS. Unlike os.DirFS, its paths can go up to the parent directories with "..", so
+// a reference can import the references of the directories next to it.
+type localFS string
+
+func (dir localFS) Open(name string) (fs.File, error) {
+	f, err := os.Open(filepath.Join(string(dir), filepath.FromSlash(name)))
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		// Report the path relative to the reference, as os.DirFS
+		pathErr.Path = name
+	}
+	return f, err // nolint:wrapcheck
+}
+
+// importReferences extends the reference with the references it imports. The imports are merged in order, each one
+// extending the previous ones, and the reference itself is merged last:
+//   - parts are merged by name, a component replaces the component with the same name of the same part and the other
+//     components are added to the part
+//   - fieldsToOmit items replace the items with the same key, defaultOmitRef replaces the previous one when it's set
+//   - templateFunctionFiles, correlationGroups, sharedCapturegroups, consistentCapturegroups, listsAsSets and
+//     assertions are added
+//
+// The paths in the imported references are relative to the imported reference and are rebased to be relative to the
+// reference given to the command, referenceFileName is the path of the reference from it. importing are the
+// references that are importing this one, to detect circular imports.
+func (r *ReferenceV2) importReferences(fsys fs.FS, referenceFileName string, importing []string) error {
+	r.rebasePaths(path.Dir(referenceFileName))
+	if len(r.Imports) == 0 {
+		return nil
+	}
+	importing = append(slices.Clone(importing), referenceFileName)
+	merged := &ReferenceV2{}
+	for _, imp := range r.Imports {
+		importPath := path.Join(path.Dir(referenceFileName), imp)
+		if slices.Contains(importing, importPath) {
+			return fmt.Errorf(importCircular, strings.Join(append(importing, importPath), " -> "))
+		}
+		base := &ReferenceV2{}
+		err := parseYaml(fsys, importPath, &base, importNotExists, importNotInFormat)
+		if err != nil {
+			return fmt.Errorf(importFailedToParse, importPath, err)
+		}
+		version := strings.TrimSpace(base.Version)
+		if !strings.EqualFold(version, ReferenceVersionV2) && !strings.EqualFold(version, ReferenceVersionV3) {
+			return fmt.Errorf(importUnsupported, importPath, version)
+		}
+		err = base.importReferences(fsys, importPath, importing)
+		if err != nil {
+			return err
+		}
+		merged.extendWith(base)
+	}
+	merged.extendWith(r)
+	r.Parts = merged.Parts
+	r.TemplateFunctionFiles = merged.TemplateFunctionFiles
+	r.FieldsToOmit = merged.FieldsToOmit
+	r.CorrelationGroups = merged.CorrelationGroups
+	r.SharedCapturegroups = merged.SharedCapturegroups
+	r.ListsAsSets = merged.ListsAsSets
+	r.Assertions = merged.Assertions
+	r.MinClusterVersion = merged.MinClusterVersion
+	r.MaxClusterVersion = merged.MaxClusterVersion
+	return nil
+}
+
+// rebasePaths prefixes the paths of the files of the reference with dir.
+func (r *ReferenceV2) rebasePaths(dir string) {
+	if dir == "." {
+		return
+	}
+	for i, file := range r.TemplateFunctionFiles {
+		r.TemplateFunctionFiles[i] = path.Join(dir, file)
+	}
+	for _, part := range r.Parts {
+		for _, comp := range part.Components {
+			for _, g := range comp.groups() {
+				for _, temp := range g.templates {
+					temp.Path = path.Join(dir, temp.Path)
+					if temp.ValuesRef != "" {
+						temp.ValuesRef = path.Join(dir, temp.ValuesRef)
+					}
+				}
+			}
+		}
+	}
+}
+
+// extendWith merges other into the reference, other takes precedence.
+func (r *ReferenceV2) extendWith(other *ReferenceV2) {
+	for _, part := range other.Parts {
+		i := slices.IndexFunc(r.Parts, func(p *PartV2) bool { return p.Name == part.Name })
+		if i < 0 {
+			r.Parts = append(r.Parts, part)
+			continue
+		}
+		r.Parts[i].extendWith(part)
+	}
+	r.TemplateFunctionFiles = appendMissing(r.TemplateFunctionFiles, other.TemplateFunctionFiles...)
+	r.CorrelationGroups = append(r.CorrelationGroups, other.CorrelationGroups...)
+	r.SharedCapturegroups = appendMissing(r.SharedCapturegroups, other.SharedCapturegroups...)
+	r.ListsAsSets = append(r.ListsAsSets, other.ListsAsSets...)
+	r.Assertions = append(r.Assertions, other.Assertions...)
+	if other.MinClusterVersion != "" {
+		r.MinClusterVersion = other.MinClusterVersion
+	}
+	if other.MaxClusterVersion != "" {
+		r.MaxClusterVersion = other.MaxClusterVersion
+	}
+	if other.FieldsToOmit == nil {
+		return
+	}
+	if r.FieldsToOmit == nil {
+		r.FieldsToOmit = &FieldsToOmitV2{}
+	}
+	if other.FieldsToOmit.DefaultOmitRef != "" {
+		r.FieldsToOmit.DefaultOmitRef = other.FieldsToOmit.DefaultOmitRef
+	}
+	for key, entries := range other.FieldsToOmit.Items {
+		if r.FieldsToOmit.Items == nil {
+			r.FieldsToOmit.Items = make(map[string][]*FieldsToOmitV2Entry)
+		}
+		r.FieldsToOmit.Items[key] = entries
+	}
+}
+
+// extendWith merges the components of other into the part, the components of other replace the ones with the same
+// name.
+func (p *PartV2) extendWith(other *PartV2) {
+	if other.Description != "" {
+		p.Description = other.Description
+	}
+	for _, comp := range other.Components {
+		i := slices.IndexFunc(p.Components, func(c *ComponentV2) bool { return c.Name == comp.Name })
+		if i < 0 {
+			p.Components = append(p.Components, comp)
+			continue
+		}
+		p.Components[i] = comp
+	}
+	p.ConsistentCapturegroups = appendMissing(p.ConsistentCapturegroups, other.ConsistentCapturegroups...)
+}
+
+// groups returns all the groups of templates of the component, including the empty ones.
+func (comp *ComponentV2) groups() []*componentGroup {
+	return []*componentGroup{&comp.OneOf.componentGroup, &comp.NoneOf.componentGroup, &comp.AllOf.componentGroup,
+		&comp.AnyOf.componentGroup, &comp.AnyOneOf.componentGroup, &comp.AllOrNoneOf.componentGroup}
+}
+
+func appendMissing(values []string, others ...string) []string {
+	for _, v := range others {
+		if !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,136 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ipRange inlineDiffType = "ipRange"
+)
+
+// IPRangeInlineDiff matches the cluster value if all its addresses fall within the CIDRs of the template, for example
+// "10.0.0.0/16, fd00::/48", so the addresses that legitimately vary per site within a range don't produce diffs. The
+// template and the cluster value can hold addresses, CIDRs and ranges of addresses ("10.0.0.10-10.0.0.20"), each one of
+// the cluster value has to be contained in one of the template. The values are separated by commas or spaces, or are
+// lists, so lists of addresses such as spec.externalIPs are supported.
+type IPRangeInlineDiff struct{}
+
+func (id IPRangeInlineDiff) structured() {}
+
+// ipValues returns the items of the value, which is a YAML or JSON list, or a string separated by commas or spaces.
+func ipValues(value string) ([]string, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var list []string
+		if err := yaml.Unmarshal([]byte(value), &list); err != nil {
+			return nil, fmt.Errorf("failed to parse list: %w", err)
+		}
+		return list, nil
+	}
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	}), nil
+}
+
+// addrRange is a range of addresses of the same family, from first to last.
+type addrRange struct {
+	first, last netip.Addr
+}
+
+func (r addrRange) contains(other addrRange) bool {
+	return r.first.BitLen() == other.first.BitLen() && r.first.Compare(other.first) <= 0 && other.last.Compare(r.last) <= 0
+}
+
+// parseAddrRange parses an address, a CIDR or a range of addresses ("10.0.0.10-10.0.0.20").
+func parseAddrRange(value string) (addrRange, error) {
+	value = strings.TrimSpace(value)
+	if first, last, ok := strings.Cut(value, "-"); ok {
+		firstAddr, err := netip.ParseAddr(strings.TrimSpace(first))
+		if err != nil {
+			return addrRange{}, fmt.Errorf("invalid range %q", value)
+		}
+		lastAddr, err := netip.ParseAddr(strings.TrimSpace(last))
+		if err != nil || firstAddr.BitLen() != lastAddr.BitLen() || lastAddr.Less(firstAddr) {
+			return addrRange{}, fmt.Errorf("invalid range %q", value)
+		}
+		return addrRange{first: firstAddr, last: lastAddr}, nil
+	}
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return addrRange{}, fmt.Errorf("invalid CIDR %q", value)
+		}
+		return addrRange{first: prefix.Masked().Addr(), last: lastAddr(prefix)}, nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return addrRange{}, fmt.Errorf("invalid address %q", value)
+	}
+	return addrRange{first: addr, last: addr}, nil
+}
+
+// lastAddr returns the last address of the CIDR, the one with all the host bits set.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Addr().As16()
+	offset := 128 - prefix.Addr().BitLen()
+	for bit := offset + prefix.Bits(); bit < 128; bit++ {
+		addr[bit/8] |= 1 << (7 - bit%8)
+	}
+	if prefix.Addr().Is4() {
+		return netip.AddrFrom16(addr).Unmap()
+	}
+	return netip.AddrFrom16(addr)
+}
+
+// parseAddrRanges parses the addresses, CIDRs and ranges of addresses of the value.
+func parseAddrRanges(value string) ([]addrRange, error) {
+	values, err := ipValues(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no CIDR")
+	}
+	ranges := make([]addrRange, 0, len(values))
+	for _, value := range values {
+		r, err := parseAddrRange(value)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func (id IPRangeInlineDiff) Diff(templateValue, crValue string, sharedCapturedValues CapturedValues) (string, CapturedValues) {
+	ranges, err := parseAddrRanges(templateValue)
+	if err != nil {
+		return templateValue, sharedCapturedValues
+	}
+	crRanges, err := parseAddrRanges(crValue)
+	if err != nil {
+		return templateValue, sharedCapturedValues
+	}
+	for _, crRange := range crRanges {
+		if !slices.ContainsFunc(ranges, func(r addrRange) bool { return r.contains(crRange) }) {
+			return templateValue, sharedCapturedValues
+		}
+	}
+	return crValue, sharedCapturedValues
+}
+
+func (id IPRangeInlineDiff) Validate(templateValue string) error {
+	// The value is empty when the template is rendered without data
+	if strings.TrimSpace(templateValue) == "" {
+		return nil
+	}
+	if _, err := parseAddrRanges(templateValue); err != nil {
+		return fmt.Errorf("invalid CIDRs passed to inline ipRange diff function: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineIPRangeDiff(t *testing.T) {
+	tests := []struct {
+		template string
+		input    string
+		match    bool
+	}{
+		{template: "10.0.0.0/16", input: "10.0.42.7", match: true},
+		{template: "10.0.0.0/16", input: "10.1.0.1"},
+		{template: "10.0.0.0/16", input: "10.0.128.0/17", match: true},
+		{template: "10.0.0.0/16", input: "10.0.0.0/8"},
+		{template: "10.0.0.0/16", input: "10.0.0.10-10.0.0.20", match: true},
+		{template: "10.0.0.0/16", input: "10.0.255.250-10.1.0.5"},
+		{template: "10.0.0.0/16", input: "10.0.0.20-10.0.0.10"},
+		{template: "10.0.0.0/16, fd00::/48", input: "fd00::1", match: true},
+		{template: "10.0.0.0/16, fd00::/48", input: "fd01::1"},
+		{template: "10.0.0.0/16", input: "::ffff:10.0.0.1"},
+		{template: "192.168.1.10", input: "192.168.1.10", match: true},
+		{template: "192.168.1.10", input: "192.168.1.11"},
+		{template: "192.168.1.0/24 192.168.2.0/24", input: `["192.168.1.1","192.168.2.1"]`, match: true},
+		{template: `["192.168.1.0/24"]`, input: "192.168.1.1,192.168.2.1"},
+		{template: "10.0.0.0/16", input: "10.0.0.300"},
+		{template: "10.0.0.0/16", input: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.template+" "+test.input, func(t *testing.T) {
+			diff := IPRangeInlineDiff{}
+			assert.NoError(t, diff.Validate(test.template))
+			result, _ := diff.Diff(test.template, test.input, CapturedValues{})
+			if test.match {
+				assert.Equal(t, test.input, result)
+			} else {
+				assert.Equal(t, test.template, result)
+			}
+		})
+	}
+}
+
+func TestInlineIPRangeValidate(t *testing.T) {
+	assert.ErrorContains(t, IPRangeInlineDiff{}.Validate("10.0.0.0/33"), `invalid CIDR "10.0.0.0/33"`)
+	assert.ErrorContains(t, IPRangeInlineDiff{}.Validate("10.0.0.0/16, example.com"), `invalid address "example.com"`)
+	assert.ErrorContains(t, IPRangeInlineDiff{}.Validate("10.0.0.1-fd00::1"), `invalid range "10.0.0.1-fd00::1"`)
+	assert.NoError(t, IPRangeInlineDiff{}.Validate(""))
+}
@@ -0,0 +1,163 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/kube-compare/pkg/junit"
+)
+
+const (
+	junitSuitesName    = "cluster-compare"
+	junitDefaultSuite  = "Reference"
+	junitDiffFailure   = "diff"
+	junitIssueFailure  = "validation"
+	junitDiffFoundMsg  = "The cluster CR differs from the reference"
+	junitDiffPassedMsg = "The cluster CR differs from the reference, the diff has the %s severity%s:\n%s"
+	junitSuppressedMsg = "The cluster CR differs from the reference, the diff is suppressed during the maintenance " +
+		"window until %s%s:\n%s"
+	junitIssueCRPrefix  = "- "
+	junitDocsMsg        = ", docs: %s"
+	junitRemediationMsg = ", remediation: %s"
+)
+
+// JUnit renders the output as a JUnit report with a test suite per part of the reference, and the components of the
+// part as the class names of the test cases. Each cluster CR is a test case that fails when it has a diff with the
+// error severity, the diffs with the other severities are reported in the output (system-out) of the test case. Each
+// validation issue is a failed test case of the suite of its part (or of its group, e.g. the failed template
+// assertions). The CRs matched to templates that don't belong to a part are in the Reference suite, with the path of
+// the template as their class name. The warnings of the comparison are the error output (system-err) of each suite.
+func (o Output) JUnit() ([]byte, error) {
+	return marshalJUnit(o.junitSuites())
+}
+
+// junitSuites returns the test suites of the JUnit report of the output, sorted by name.
+func (o Output) junitSuites() []*junit.TestSuite {
+	templates := make(map[string]ReferenceTemplate, len(o.templates))
+	for _, t := range o.templates {
+		templates[t.GetIdentifier()] = t
+	}
+
+	suites := make(map[string]*junit.TestSuite)
+	durations := make(map[string]time.Duration)
+	addTestCase := func(suiteName string, tc junit.TestCase, duration time.Duration) {
+		suite, ok := suites[suiteName]
+		if !ok {
+			suite = &junit.TestSuite{Name: suiteName}
+			suites[suiteName] = suite
+		}
+		durations[suiteName] += duration
+		suite.AddTestCase(tc)
+	}
+
+	for _, d := range o.sortedDiffs(true) {
+		suiteName, classname := junitDefaultSuite, d.CorrelatedTemplate
+		if t, ok := templates[d.CorrelatedTemplate]; ok {
+			if part, component := t.GetPartAndComponent(); part != "" {
+				suiteName, classname = part, component
+			}
+		}
+		tc := junit.TestCase{Name: d.CRName, Classname: classname, Time: junit.Time(d.duration)}
+		switch {
+		case !d.HasDiff():
+		case d.InMaintenance():
+			tc.SystemOut = &junit.Output{Contents: fmt.Sprintf(junitSuppressedMsg, d.MaintenanceWindowEnd, d.junitLinks(), d.DiffOutput)}
+		case d.Severity == "" || d.Severity == SeverityError:
+			tc.Failure = &junit.Failure{Message: junitDiffFoundMsg + d.junitLinks(), Type: junitDiffFailure, Contents: d.DiffOutput}
+		default:
+			tc.SystemOut = &junit.Output{Contents: fmt.Sprintf(junitDiffPassedMsg, d.Severity, d.junitLinks(), d.DiffOutput)}
+		}
+		addTestCase(suiteName, tc, d.duration)
+	}
+
+	if o.Summary != nil {
+		for groupName, group := range o.Summary.ValidationIssues {
+			for issueName, issue := range group {
+				var contents strings.Builder
+				for _, cr := range issue.CRs {
+					contents.WriteString(junitIssueCRPrefix + cr)
+					if reason := issue.CRMetadata[cr].Reason; reason != "" {
+						contents.WriteString(": " + reason)
+					}
+					contents.WriteString("\n")
+				}
+				addTestCase(groupName, junit.TestCase{
+					Name:      issue.Msg,
+					Classname: issueName,
+					Failure:   &junit.Failure{Message: issue.Msg, Type: junitIssueFailure, Contents: contents.String()},
+				}, 0)
+			}
+		}
+	}
+
+	var properties *junit.Properties
+	var systemErr *junit.Output
+	if o.Summary != nil {
+		properties = o.Summary.Cluster.junitProperties()
+		if len(o.Summary.Warnings) > 0 {
+			systemErr = &junit.Output{Contents: strings.Join(o.Summary.Warnings, "\n") + "\n"}
+		}
+	}
+	var res []*junit.TestSuite
+	for _, suite := range suites {
+		suite.Time = junit.Time(durations[suite.Name])
+		suite.Properties = properties
+		suite.SystemErr = systemErr
+		sort.SliceStable(suite.TestCases, func(i, j int) bool {
+			a, b := suite.TestCases[i], suite.TestCases[j]
+			return a.Classname+"/"+a.Name < b.Classname+"/"+b.Name
+		})
+		res = append(res, suite)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// junitLinks returns the links of the template of the diff, to append to the messages of its test case.
+func (d DiffSum) junitLinks() string {
+	var res string
+	if d.DocsURL != "" {
+		res += fmt.Sprintf(junitDocsMsg, d.DocsURL)
+	}
+	if d.RemediationURL != "" {
+		res += fmt.Sprintf(junitRemediationMsg, d.RemediationURL)
+	}
+	return res
+}
+
+// junitProperties returns the properties of the test suites of the cluster, the information that isn't known is left
+// out.
+func (c *ClusterInfo) junitProperties() *junit.Properties {
+	if c == nil {
+		return nil
+	}
+	var properties []junit.Property
+	for _, p := range []junit.Property{
+		{Name: "cluster.context", Value: c.Context},
+		{Name: "cluster.server", Value: c.Server},
+		{Name: "cluster.id", Value: c.ClusterID},
+		{Name: "cluster.kubernetesVersion", Value: c.KubernetesVersion},
+		{Name: "cluster.openshiftVersion", Value: c.OpenShiftVersion},
+	} {
+		if p.Value != "" {
+			properties = append(properties, p)
+		}
+	}
+	if len(properties) == 0 {
+		return nil
+	}
+	return &junit.Properties{Properties: properties}
+}
+
+// marshalJUnit renders the test suites as a JUnit report.
+func marshalJUnit(suites []*junit.TestSuite) ([]byte, error) {
+	content, err := junit.Marshal(junit.TestSuites{Name: junitSuitesName, Suites: suites})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output to junit: %w", err)
+	}
+	return content, nil
+}
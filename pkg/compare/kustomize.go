@@ -0,0 +1,71 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+const (
+	kustomizeLoadRestrictor   = "load-restrictor"
+	kustomizeReorder          = "reorder"
+	kustomizeEnableHelm       = "enable-helm"
+	kustomizeHelmCommand      = "helm-command"
+	kustomizeAddManagedBy     = "add-managedby-label"
+	kustomizeBuildOptionUsage = "Unknown kustomize build option %q, supported options: " +
+		"load-restrictor=(LoadRestrictionsRootOnly|LoadRestrictionsNone), reorder=(legacy|none), enable-helm, " +
+		"helm-command=<path>, add-managedby-label"
+	kustomizeOptionsWithoutKustomize = "Kustomize build options (--kustomize-build-options) require a kustomization (-k)"
+)
+
+// parseKustomizeBuildOptions parses options in the key[=value] format, following the flags of 'kubectl kustomize'.
+// By default the kustomization is built the same way kubectl does when passed -k.
+func parseKustomizeBuildOptions(options []string) (*krusty.Options, error) {
+	kOpts := krusty.MakeDefaultOptions()
+	kOpts.Reorder = krusty.ReorderOptionLegacy
+	var errs []error
+	for _, option := range options {
+		key, value, hasValue := strings.Cut(option, "=")
+		switch {
+		case key == kustomizeLoadRestrictor && value == types.LoadRestrictionsRootOnly.String():
+			kOpts.LoadRestrictions = types.LoadRestrictionsRootOnly
+		case key == kustomizeLoadRestrictor && value == types.LoadRestrictionsNone.String():
+			kOpts.LoadRestrictions = types.LoadRestrictionsNone
+		case key == kustomizeReorder && (value == string(krusty.ReorderOptionLegacy) || value == string(krusty.ReorderOptionNone)):
+			kOpts.Reorder = krusty.ReorderOption(value)
+		case key == kustomizeEnableHelm && !hasValue:
+			kOpts.PluginConfig.HelmConfig.Enabled = true
+			if kOpts.PluginConfig.HelmConfig.Command == "" {
+				kOpts.PluginConfig.HelmConfig.Command = "helm"
+			}
+		case key == kustomizeHelmCommand && value != "":
+			kOpts.PluginConfig.HelmConfig.Command = value
+		case key == kustomizeAddManagedBy && !hasValue:
+			kOpts.AddManagedbyLabel = true
+		default:
+			errs = append(errs, fmt.Errorf(kustomizeBuildOptionUsage, option))
+		}
+	}
+	return kOpts, errors.Join(errs...)
+}
+
+// buildKustomization runs kustomize build on a local directory or a remote kustomization (for example
+// https://github.com/org/repo//overlays/prod?ref=v1). Remote bases referenced by the kustomization are fetched by
+// kustomize, this requires git.
+func buildKustomization(path string, kOpts *krusty.Options) ([]byte, error) {
+	resMap, err := krusty.MakeKustomizer(kOpts).Run(filesys.MakeFsOnDisk(), path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kustomization %s: %w", path, err)
+	}
+	content, err := resMap.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert kustomization %s to yaml: %w", path, err)
+	}
+	return content, nil
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ListAsSetV2 is a list field whose order is insignificant. The items of the list are sorted in the template and in the
+// cluster CR before they are diffed, by the value of their key field when it's set and then by their content.
+// In the reference it's either the path of the list or a map with the path and the key.
+type ListAsSetV2 struct {
+	PathToKey string `json:"pathToKey"`
+	Key       string `json:"key,omitempty"`
+	parts     []string
+}
+
+func (l *ListAsSetV2) UnmarshalJSON(b []byte) error {
+	var pathToKey string
+	if err := json.Unmarshal(b, &pathToKey); err == nil {
+		l.PathToKey = pathToKey
+		return nil
+	}
+	type listAsSet ListAsSetV2
+	return json.Unmarshal(b, (*listAsSet)(l)) // nolint:wrapcheck
+}
+
+func (l *ListAsSetV2) process() error {
+	if l.PathToKey == "" {
+		return fmt.Errorf("listsAsSets entry must have a pathToKey")
+	}
+	parts, err := pathToList(l.PathToKey)
+	if err != nil {
+		return fmt.Errorf("listsAsSets entry has a path that is not in supported format. path: %s. error: %w",
+			l.PathToKey, err)
+	}
+	l.parts = parts
+	return nil
+}
+
+func processListsAsSets(lists []*ListAsSetV2) error {
+	for _, l := range lists {
+		if err := l.process(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortListsAsSets sorts the items of the lists of the object, the paths of the lists can contain wildcards.
+func sortListsAsSets(object map[string]any, lists []*ListAsSetV2) {
+	for _, l := range lists {
+		for _, listedPath := range expandFieldPath(object, []string{}, l.parts) {
+			val, _, _ := NestedField(object, listedPath...)
+			if items, ok := val.([]any); ok {
+				l.sort(items)
+			}
+		}
+	}
+}
+
+func (l *ListAsSetV2) sort(items []any) {
+	type sortedItem struct {
+		item         any
+		key, content string
+	}
+	sorted := make([]sortedItem, 0, len(items))
+	for _, item := range items {
+		s := sortedItem{item: item, content: canonicalJSON(item)}
+		if mapping, ok := item.(map[string]any); ok && l.Key != "" {
+			s.key = canonicalJSON(mapping[l.Key])
+		}
+		sorted = append(sorted, s)
+	}
+	slices.SortStableFunc(sorted, func(a, b sortedItem) int {
+		if c := strings.Compare(a.key, b.key); c != 0 {
+			return c
+		}
+		return strings.Compare(a.content, b.content)
+	})
+	for i, s := range sorted {
+		items[i] = s.item
+	}
+}
+
+// canonicalJSON encodes the value in JSON, the keys of the maps are sorted so equal values have the same encoding.
+func canonicalJSON(val any) string {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+	return string(b)
+}
@@ -0,0 +1,153 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const lookupLiveFunc = "lookupLive"
+
+// lookupNotLive is the lookupLive template function when the cluster CRs aren't compared to a live cluster, there is
+// nothing to look up so it always returns an empty map, as Helm's lookup when it isn't connected to a cluster.
+//
+// This is designed to be called from a template.
+func lookupNotLive(_, _, _, _ string) (map[string]any, error) {
+	return map[string]any{}, nil
+}
+
+// getFunc gets a CR of the cluster, or lists the CRs of a resource when name is empty.
+type getFunc func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (map[string]any, error)
+
+func dynamicGetFunc(client dynamic.Interface) getFunc {
+	return func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (map[string]any, error) {
+		resource := client.Resource(gvr).Namespace(namespace)
+		if name == "" {
+			list, err := resource.List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err // nolint:wrapcheck
+			}
+			return list.UnstructuredContent(), nil
+		}
+		cr, err := resource.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err // nolint:wrapcheck
+		}
+		return cr.UnstructuredContent(), nil
+	}
+}
+
+type lookupResult struct {
+	obj map[string]any
+	err error
+}
+
+// connectFunc creates the clients used to look up the CRs of the live cluster.
+type connectFunc func() (getFunc, meta.RESTMapper, error)
+
+func factoryConnectFunc(f kcmdutil.Factory) connectFunc {
+	return func() (getFunc, meta.RESTMapper, error) {
+		client, err := f.DynamicClient()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create dynamic client: %w", err)
+		}
+		mapper, err := f.ToRESTMapper()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create REST mapper: %w", err)
+		}
+		return dynamicGetFunc(client), mapper, nil
+	}
+}
+
+// liveLookup looks up CRs of the live cluster for the lookupLive template function, including CRs of kinds that no
+// template references (e.g. Nodes or the Infrastructure). The clients are only created by the first lookup, and the
+// results are cached for the whole comparison as the same CRs are usually looked up by the templates of many cluster
+// CRs.
+type liveLookup struct {
+	connect    connectFunc
+	ctx        func() context.Context
+	connected  bool
+	connectErr error
+	get        getFunc
+	mapper     meta.RESTMapper
+
+	lock  sync.Mutex
+	cache map[string]lookupResult
+}
+
+// newLiveLookup returns the lookup of the CRs of the live cluster, ctx returns the context of the running comparison.
+func newLiveLookup(connect connectFunc, ctx func() context.Context) *liveLookup {
+	return &liveLookup{connect: connect, ctx: ctx, cache: make(map[string]lookupResult)}
+}
+
+// lookup returns the CR of the cluster, or the list of the CRs of the kind (with their items under items) when name is
+// empty. The namespace is ignored for cluster scoped kinds, and is all the namespaces when it's empty. A CR that
+// doesn't exist is an empty map.
+//
+// This is designed to be called from a template, by the workers processing the cluster CRs.
+func (l *liveLookup) lookup(apiVersion, kind, namespace, name string) (map[string]any, error) {
+	key := strings.Join([]string{apiVersion, kind, namespace, name}, "/")
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if res, ok := l.cache[key]; ok {
+		return res.obj, res.err
+	}
+	if !l.connected {
+		l.get, l.mapper, l.connectErr = l.connect()
+		l.connected = true
+	}
+	if l.connectErr != nil {
+		return nil, fmt.Errorf("%s: %w", lookupLiveFunc, l.connectErr)
+	}
+	obj, err := l.lookupUncached(apiVersion, kind, namespace, name)
+	l.cache[key] = lookupResult{obj: obj, err: err}
+	return obj, err
+}
+
+func (l *liveLookup) lookupUncached(apiVersion, kind, namespace, name string) (map[string]any, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid apiVersion %q: %w", lookupLiveFunc, apiVersion, err)
+	}
+	mapping, err := l.mapper.RESTMapping(gv.WithKind(kind).GroupKind(), gv.Version)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to find the resource of %s %s: %w", lookupLiveFunc, apiVersion, kind, err)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		namespace = ""
+	}
+	obj, err := l.get(l.ctx(), mapping.Resource, namespace, name)
+	if apierrors.IsNotFound(err) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to get %s %s %s/%s: %w", lookupLiveFunc, apiVersion, kind, namespace, name, err)
+	}
+	return obj, nil
+}
+
+// funcsSetter is implemented by the templates whose functions can be replaced after they are parsed.
+type funcsSetter interface {
+	setFuncs(funcs template.FuncMap)
+}
+
+// bindLiveLookup makes the lookupLive function of the templates look up the CRs of the live cluster.
+func bindLiveLookup(templates []ReferenceTemplate, l *liveLookup) {
+	funcs := template.FuncMap{lookupLiveFunc: l.lookup}
+	for _, temp := range templates {
+		if s, ok := temp.(funcsSetter); ok {
+			s.setFuncs(funcs)
+		}
+	}
+}
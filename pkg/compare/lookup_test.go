@@ -0,0 +1,90 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestLiveLookup(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}, {Group: "config.openshift.io", Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "Infrastructure"}, meta.RESTScopeRoot)
+
+	var calls []string
+	get := func(_ context.Context, gvr schema.GroupVersionResource, namespace, name string) (map[string]any, error) {
+		calls = append(calls, fmt.Sprintf("%s namespace=%s name=%s", gvr.Resource, namespace, name))
+		switch {
+		case gvr.Resource == "infrastructures" && name == "cluster":
+			return map[string]any{"status": map[string]any{"platform": "BareMetal"}}, nil
+		case gvr.Resource == "nodes" && name == "":
+			return map[string]any{"items": []any{map[string]any{"metadata": map[string]any{"name": "master-0"}}}}, nil
+		}
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+	}
+	connects := 0
+	l := newLiveLookup(func() (getFunc, meta.RESTMapper, error) {
+		connects++
+		return get, mapper, nil
+	}, context.Background)
+
+	infra, err := l.lookup("config.openshift.io/v1", "Infrastructure", "ignored", "cluster")
+	require.NoError(t, err)
+	assert.Equal(t, "BareMetal", infra["status"].(map[string]any)["platform"])
+	_, err = l.lookup("config.openshift.io/v1", "Infrastructure", "ignored", "cluster")
+	require.NoError(t, err)
+
+	nodes, err := l.lookup("v1", "Node", "", "")
+	require.NoError(t, err)
+	assert.Len(t, nodes["items"], 1)
+
+	missing, err := l.lookup("v1", "ConfigMap", "default", "missing")
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	_, err = l.lookup("route.openshift.io/v1", "Route", "default", "console")
+	assert.ErrorContains(t, err, "lookupLive: failed to find the resource of route.openshift.io/v1 Route")
+
+	assert.Equal(t, 1, connects)
+	assert.Equal(t, []string{
+		"infrastructures namespace= name=cluster",
+		"nodes namespace= name=",
+		"configmaps namespace=default name=missing",
+	}, calls)
+
+	t.Run("Bound To Templates", func(t *testing.T) {
+		parsed, err := template.New("infra").Funcs(FuncMap()).
+			Parse(`{{ (lookupLive "config.openshift.io/v1" "Infrastructure" "" "cluster").status.platform }}`)
+		require.NoError(t, err)
+		temp := &ReferenceTemplateV2{ReferenceTemplateV1: ReferenceTemplateV1{Template: parsed}}
+
+		var buf bytes.Buffer
+		require.NoError(t, parsed.Execute(&buf, nil))
+		assert.Equal(t, noValue, buf.String())
+
+		bindLiveLookup([]ReferenceTemplate{temp}, l)
+		buf.Reset()
+		require.NoError(t, parsed.Execute(&buf, nil))
+		assert.Equal(t, "BareMetal", buf.String())
+	})
+
+	t.Run("Connection Failure", func(t *testing.T) {
+		l := newLiveLookup(func() (getFunc, meta.RESTMapper, error) {
+			return nil, nil, errors.New("no cluster")
+		}, context.Background)
+		_, err := l.lookup("v1", "Node", "", "")
+		assert.ErrorContains(t, err, "lookupLive: no cluster")
+	})
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	invalidMaintenanceWindow = "The annotation of the maintenance windows (--maintenance-window) %q isn't a valid " +
+		"annotation key: %s"
+	invalidMaintenanceEnd = "Ignoring the maintenance window of %s, its annotation %s isn't a RFC 3339 time: %q"
+)
+
+// validateMaintenanceWindow checks the annotation key of --maintenance-window, when it's set.
+func validateMaintenanceWindow(annotation string) error {
+	if annotation == "" {
+		return nil
+	}
+	if errs := validation.IsQualifiedName(annotation); len(errs) > 0 {
+		return fmt.Errorf(invalidMaintenanceWindow, annotation, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// maintenanceWindowEnd returns the end of the maintenance window of the cluster CR, the RFC 3339 time set by its
+// --maintenance-window annotation, or "" when the CR isn't in a maintenance window: it isn't annotated, or the window
+// already ended. The diffs of the CRs in a maintenance window are reported separately and don't fail the comparison.
+func (o *Options) maintenanceWindowEnd(clusterCR *unstructured.Unstructured) string {
+	if o.maintenanceWindow == "" {
+		return ""
+	}
+	value, ok := clusterCR.GetAnnotations()[o.maintenanceWindow]
+	if !ok {
+		return ""
+	}
+	end, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		o.warnings.warnf(invalidMaintenanceEnd, apiKindNamespaceName(clusterCR), o.maintenanceWindow, value)
+		return ""
+	}
+	if !now().Before(end) {
+		return ""
+	}
+	return end.UTC().Format(time.RFC3339)
+}
+
+// suppressDuringMaintenance suppresses the diff of the result when the cluster CR is in a maintenance window, ending at
+// end (see maintenanceWindowEnd), the diff doesn't fail the comparison anymore.
+func suppressDuringMaintenance(res *processResult, end string) {
+	res.diff.MaintenanceWindowEnd = ""
+	if !res.isDiff {
+		return
+	}
+	if end != "" {
+		res.diff.MaintenanceWindowEnd = end
+		res.suppressed = true
+		res.isFailing = false
+	}
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/value"
+)
+
+// fieldsManagedBy returns the fields of the cluster CR that are owned by the managers, according to its
+// metadata.managedFields. The managers are matched by their exact name, whatever the operation (Apply or Update).
+func fieldsManagedBy(clusterCR *unstructured.Unstructured, managers []string) (*fieldpath.Set, error) {
+	owned := &fieldpath.Set{}
+	for _, entry := range clusterCR.GetManagedFields() {
+		if !slices.Contains(managers, entry.Manager) || entry.FieldsV1 == nil {
+			continue
+		}
+		fields := &fieldpath.Set{}
+		if err := fields.FromJSON(bytes.NewReader(entry.FieldsV1.Raw)); err != nil {
+			return nil, fmt.Errorf("failed to parse the fields managed by %s of %s: %w",
+				entry.Manager, apiKindNamespaceName(clusterCR), err)
+		}
+		owned = owned.Union(fields)
+	}
+	return owned.Leaves(), nil
+}
+
+// removeManagedFields removes the fields of the set from the value, the fields are looked up in the value so the same
+// set removes the same fields from the cluster CR and from the rendered template, even when the items of their lists
+// aren't in the same order. The maps and the items of lists that are left empty are removed too, as the fields the
+// managers own are usually the only fields of their parents. It returns the value without the fields and whether
+// fields were removed.
+func removeManagedFields(val any, fields *fieldpath.Set) (any, bool) {
+	switch v := val.(type) {
+	case map[string]any:
+		removed := false
+		for key, field := range v {
+			pe := fieldpath.PathElement{FieldName: &key}
+			if fields.Members.Has(pe) {
+				delete(v, key)
+				removed = true
+				continue
+			}
+			children, ok := fields.Children.Get(pe)
+			if !ok {
+				continue
+			}
+			if field, ok := removeManagedFields(field, children); ok {
+				removed = true
+				if isEmptyValue(field) {
+					delete(v, key)
+				} else {
+					v[key] = field
+				}
+			}
+		}
+		return v, removed
+	case []any:
+		removed := false
+		items := make([]any, 0, len(v))
+		for i, item := range v {
+			if listElementIn(item, i, &fields.Members) {
+				removed = true
+				continue
+			}
+			if children := listElementChildren(item, i, fields); children != nil {
+				var ok bool
+				if item, ok = removeManagedFields(item, children); ok {
+					removed = true
+					if isEmptyValue(item) {
+						continue
+					}
+				}
+			}
+			items = append(items, item)
+		}
+		return items, removed
+	}
+	return val, false
+}
+
+func isEmptyValue(val any) bool {
+	switch v := val.(type) {
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// listElementIn returns whether the item at the index of a list is one of the elements of the set.
+func listElementIn(item any, index int, elements *fieldpath.PathElementSet) bool {
+	found := false
+	elements.Iterate(func(pe fieldpath.PathElement) {
+		found = found || isListElement(item, index, pe)
+	})
+	return found
+}
+
+// listElementChildren returns the fields of the set that are under the item at the index of a list, nil if there are
+// none.
+func listElementChildren(item any, index int, fields *fieldpath.Set) *fieldpath.Set {
+	var children *fieldpath.Set
+	fields.Children.Iterate(func(pe fieldpath.PathElement) {
+		if children == nil && isListElement(item, index, pe) {
+			children, _ = fields.Children.Get(pe)
+		}
+	})
+	return children
+}
+
+// isListElement returns whether the path element identifies the item at the index of a list: by the values of its
+// keys (k:), by its value for the lists of scalars (v:) or by its index (i:) for atomic lists.
+func isListElement(item any, index int, pe fieldpath.PathElement) bool {
+	switch {
+	case pe.Key != nil:
+		mapping, ok := item.(map[string]any)
+		if !ok {
+			return false
+		}
+		for _, key := range *pe.Key {
+			field, ok := mapping[key.Name]
+			if !ok || !value.Equals(value.NewValueInterface(field), key.Value) {
+				return false
+			}
+		}
+		return true
+	case pe.Value != nil:
+		return value.Equals(value.NewValueInterface(item), *pe.Value)
+	case pe.Index != nil:
+		return *pe.Index == index
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRemoveManagedFields(t *testing.T) {
+	clusterCR := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": "app", "namespace": "default"},
+		"spec": map[string]any{
+			"finalizers": []any{"kubernetes", "example.com/cleanup"},
+			"args":       []any{"--verbose", "--port=8080"},
+			"containers": []any{
+				map[string]any{"name": "app", "image": "app:v2"},
+				map[string]any{"name": "proxy", "image": "proxy:v1"},
+			},
+		},
+	}}
+	clusterCR.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "deployer", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:args":{}}}`)}},
+		{Manager: "injector", FieldsV1: &metav1.FieldsV1{Raw: []byte(
+			`{"f:spec":{"f:finalizers":{"v:\"example.com/cleanup\"":{}},` +
+				`"f:containers":{"k:{\"name\":\"proxy\"}":{".":{},"f:name":{},"f:image":{}}}}}`)}},
+		{Manager: "updater", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:args":{"i:1":{}}}}`)}},
+	})
+
+	owned, err := fieldsManagedBy(clusterCR, []string{"injector", "updater"})
+	require.NoError(t, err)
+	template := map[string]any{
+		"spec": map[string]any{
+			"finalizers": []any{"kubernetes"},
+			"args":       []any{"--verbose", "--port=9090"},
+			"containers": []any{map[string]any{"name": "app", "image": "app:v1"}},
+		},
+	}
+	removeManagedFields(clusterCR.Object, owned)
+	removeManagedFields(template, owned)
+
+	expected := map[string]any{
+		"finalizers": []any{"kubernetes"},
+		"args":       []any{"--verbose"},
+	}
+	expected["containers"] = []any{map[string]any{"name": "app", "image": "app:v2"}}
+	assert.Equal(t, expected, clusterCR.Object["spec"])
+	expected["containers"] = []any{map[string]any{"name": "app", "image": "app:v1"}}
+	assert.Equal(t, expected, template["spec"])
+
+	clusterCR.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "injector", FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"k:{name}":{}}`)}},
+	})
+	_, err = fieldsManagedBy(clusterCR, []string{"injector"})
+	assert.ErrorContains(t, err, "failed to parse the fields managed by injector of v1_Pod_default_app")
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	MatchStrategyChars    = "chars"
+	MatchStrategyLines    = "lines"
+	MatchStrategyFields   = "fields"
+	MatchStrategyWeighted = "weighted"
+)
+
+var MatchStrategies = []string{MatchStrategyChars, MatchStrategyLines, MatchStrategyFields, MatchStrategyWeighted}
+
+// metadataWeight and specWeight are the weights of the differing fields of the metadata and of the other top level fields
+// with the weighted strategy. The labels and annotations are often set by controllers and differ between clusters more
+// than the other fields.
+const (
+	metadataWeight = 1
+	specWeight     = 3
+)
+
+// matchScore scores how different the rendered template and the cluster CR are with the strategy, the CR is compared
+// to the template with the lowest score. The documents are the JSON encodings of both and the patch is the merge patch
+// between them.
+func matchScore(strategy string, localRefData, clusterCRData []byte, patch map[string]any) (int, error) {
+	switch strategy {
+	case MatchStrategyChars, MatchStrategyLines:
+		localRef, err := yaml.JSONToYAML(localRefData)
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert reference CR to yaml: %w", err)
+		}
+		clusterCR, err := yaml.JSONToYAML(clusterCRData)
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert cluster CR to yaml: %w", err)
+		}
+		if strategy == MatchStrategyLines {
+			return changedLines(string(localRef), string(clusterCR)), nil
+		}
+		return changedChars(string(localRef), string(clusterCR)), nil
+	case MatchStrategyWeighted:
+		score := 0
+		for field, value := range patch {
+			weight := specWeight
+			if field == "metadata" {
+				weight = metadataWeight
+			}
+			score += weight * countLeaf(value)
+		}
+		return score, nil
+	}
+	return countLeaf(patch), nil
+}
+
+func changedLines(from, to string) int {
+	count := 0
+	for _, line := range diffLines(from, to) {
+		if line.op != diffmatchpatch.DiffEqual {
+			count++
+		}
+	}
+	return count
+}
+
+func changedChars(from, to string) int {
+	dmp := diffmatchpatch.New()
+	// The scores have to be deterministic, not bounded by time. The lines are diffed first to speed up the diff of
+	// large CRs.
+	dmp.DiffTimeout = 0
+	count := 0
+	for _, d := range dmp.DiffMain(from, to, true) {
+		if d.Type != diffmatchpatch.DiffEqual {
+			count += len([]rune(d.Text))
+		}
+	}
+	return count
+}
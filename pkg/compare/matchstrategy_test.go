@@ -0,0 +1,61 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchScore(t *testing.T) {
+	clusterCR := `{"metadata":{"name":"app","labels":{"team":"a","tier":"web"}},` +
+		`"spec":{"replicas":2,"paused":false,"image":"registry.example.com/team/app:v1.2.3"}}`
+	templates := map[string]string{
+		// Two short values differ
+		"values": `{"metadata":{"name":"app","labels":{"team":"a","tier":"web"}},` +
+			`"spec":{"replicas":3,"paused":true,"image":"registry.example.com/team/app:v1.2.3"}}`,
+		// A single long value differs
+		"image": `{"metadata":{"name":"app","labels":{"team":"a","tier":"web"}},` +
+			`"spec":{"replicas":2,"paused":false,"image":"quay.io/other-organization/application:v2.0.0-rc1"}}`,
+		// Two labels differ
+		"labels": `{"metadata":{"name":"app","labels":{"team":"storage-backend","tier":"database"}},` +
+			`"spec":{"replicas":2,"paused":false,"image":"registry.example.com/team/app:v1.2.3"}}`,
+	}
+	best := func(strategy string) (string, map[string]int) {
+		scores := make(map[string]int)
+		bestName := ""
+		for _, name := range []string{"image", "labels", "values"} {
+			patchData, err := jsonpatch.CreateMergePatch([]byte(templates[name]), []byte(clusterCR))
+			require.NoError(t, err)
+			var patch map[string]any
+			require.NoError(t, json.Unmarshal(patchData, &patch))
+			score, err := matchScore(strategy, []byte(templates[name]), []byte(clusterCR), patch)
+			require.NoError(t, err)
+			scores[name] = score
+			if bestName == "" || score < scores[bestName] {
+				bestName = name
+			}
+		}
+		return bestName, scores
+	}
+
+	name, scores := best(MatchStrategyFields)
+	assert.Equal(t, "image", name)
+	assert.Equal(t, map[string]int{"image": 1, "labels": 2, "values": 2}, scores)
+
+	name, scores = best(MatchStrategyWeighted)
+	assert.Equal(t, "labels", name)
+	assert.Equal(t, map[string]int{"image": 3, "labels": 2, "values": 6}, scores)
+
+	name, scores = best(MatchStrategyLines)
+	assert.Equal(t, "image", name)
+	assert.Equal(t, map[string]int{"image": 2, "labels": 4, "values": 4}, scores)
+
+	name, scores = best(MatchStrategyChars)
+	assert.Equal(t, "values", name)
+	assert.Equal(t, map[string]int{"image": 55, "labels": 23, "values": 9}, scores)
+}
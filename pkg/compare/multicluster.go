@@ -0,0 +1,262 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/openshift/kube-compare/pkg/junit"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/utils/exec"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	contextsNotInLive    = "--contexts can only be used when comparing live clusters"
+	contextsUnsupported  = "--contexts can't be combined with %s"
+	contextsOutputFormat = "--contexts supports the output formats: %s"
+
+	junitErrorFailure = "error"
+	junitErrorMsg     = "The cluster couldn't be compared"
+)
+
+// contextsOutputFormats are the output formats that have a section per cluster.
+var contextsOutputFormats = []string{Json, Yaml, Junit, Markdown}
+
+// newContextFactory returns the factory of the clients of the cluster of a context of the kubeconfig.
+var newContextFactory = func(context string) kcmdutil.Factory {
+	flags := genericclioptions.NewConfigFlags(true)
+	flags.Context = &context
+	return kcmdutil.NewFactory(flags)
+}
+
+// ClusterOutput is the output of the comparison of the cluster of a context of --contexts.
+type ClusterOutput struct {
+	Context string  `json:"Context"`
+	Output  *Output `json:"Output,omitempty"`
+	// Error is why the cluster couldn't be compared
+	Error string `json:"Error,omitempty"`
+
+	numFailingDiffCRs int
+}
+
+// hasDiffs returns whether the cluster has diffs that fail the comparison, validation issues or types whose CRs
+// couldn't be listed.
+func (c ClusterOutput) hasDiffs() bool {
+	return c.Output != nil && (c.numFailingDiffCRs != 0 || len(c.Output.Summary.ValidationIssues) != 0 ||
+		len(c.Output.Summary.FetchFailures) != 0)
+}
+
+// ClustersSummary sums up the comparisons of the clusters of --contexts.
+type ClustersSummary struct {
+	NumClusters          int `json:"NumClusters"`
+	NumClustersWithDiffs int `json:"NumClustersWithDiffs"`
+	NumFailedClusters    int `json:"NumFailedClusters"`
+}
+
+// MultiClusterOutput is the output of the comparison of the clusters of --contexts, in the order of the contexts.
+type MultiClusterOutput struct {
+	Clusters []ClusterOutput `json:"Clusters"`
+	Summary  ClustersSummary `json:"Summary"`
+}
+
+func newMultiClusterOutput(clusters []ClusterOutput) MultiClusterOutput {
+	res := MultiClusterOutput{Clusters: clusters, Summary: ClustersSummary{NumClusters: len(clusters)}}
+	for _, c := range clusters {
+		if c.Error != "" {
+			res.Summary.NumFailedClusters++
+		}
+		if c.hasDiffs() {
+			res.Summary.NumClustersWithDiffs++
+		}
+	}
+	return res
+}
+
+// validateContexts checks the options that can't be used when comparing several clusters.
+func (o *Options) validateContexts() error {
+	unsupported := []struct {
+		flag string
+		used bool
+	}{
+		{"--bookmark and --since", o.bookmarkPath != "" || o.sincePath != ""},
+		{"--prune-overrides", o.pruneOverridesPath != ""},
+		{"--output-dir", o.outputDir != ""},
+		{"--record", o.recordDir != ""},
+		{"--correlation-report", o.correlationReport},
+		{"--reference-catalog", o.referenceCatalogPath != ""},
+	}
+	for _, u := range unsupported {
+		if u.used {
+			return fmt.Errorf(contextsUnsupported, u.flag)
+		}
+	}
+	if o.OutputFormat != "" && !slices.Contains(contextsOutputFormats, o.OutputFormat) {
+		return fmt.Errorf(contextsOutputFormat, strings.Join(contextsOutputFormats, ", "))
+	}
+	return nil
+}
+
+// forContext returns the options comparing the cluster of the context.
+func (o *Options) forContext(context string) (*Options, error) {
+	f := newContextFactory(context)
+	c := *o
+	c.contextName = context
+	c.builder = f.NewBuilder()
+	c.newBuilder = f.NewBuilder
+	c.metricsTracker = NewMetricsTracker()
+	c.capturegroups = newTemplateCapturegroups(o.ref.GetSharedCapturegroups())
+	c.newUserOverrides = slices.Clone(o.userOverrides)
+	// The kinds the cluster doesn't support are only reported in the summary of the cluster
+	c.warnings = o.warnings.clone()
+	if err := c.completeLive(f); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// runContexts compares the clusters of the contexts one after the other and prints their outputs. A cluster that
+// can't be compared is reported in the output and the other clusters are still compared.
+func (o *Options) runContexts() error {
+	var clusters []ClusterOutput
+	var errs []error
+	for _, context := range o.contexts {
+		cluster := ClusterOutput{Context: context}
+		c, err := o.forContext(context)
+		if err == nil {
+			var output Output
+			output, _, cluster.numFailingDiffCRs, err = c.compare()
+			if err == nil {
+				cluster.Output = &output
+			}
+		}
+		if err != nil {
+			cluster.Error = err.Error()
+			errs = append(errs, fmt.Errorf("context %s: %w", context, err))
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	res := newMultiClusterOutput(clusters)
+	if _, err := res.Print(o.OutputFormat, o.Out, o.verboseOutput); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if res.Summary.NumClustersWithDiffs != 0 {
+		return exec.CodeExitError{Err: errors.New(DiffsFoundMsg), Code: 1}
+	}
+	return nil
+}
+
+// Print writes the outputs of the clusters in the format, with a section per cluster followed by the summary of all
+// the clusters.
+func (m MultiClusterOutput) Print(format string, out io.Writer, showEmptyDiffs bool) (int, error) {
+	var content []byte
+	var err error
+	switch format {
+	case Json:
+		content, err = json.Marshal(m)
+		content = append(content, '\n')
+	case Yaml:
+		content, err = yaml.Marshal(m)
+	case Junit:
+		content, err = m.JUnit()
+	case Markdown:
+		content = []byte(m.Markdown(showEmptyDiffs))
+	default:
+		content = []byte(m.String(showEmptyDiffs))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal output to %s: %w", format, err)
+	}
+	n, err := out.Write(content)
+	if err != nil {
+		return n, fmt.Errorf("error occurred when writing output: %w", err)
+	}
+	return n, nil
+}
+
+func (m MultiClusterOutput) String(showEmptyDiffs bool) string {
+	var b strings.Builder
+	for _, c := range m.Clusters {
+		fmt.Fprintf(&b, "Cluster: %s\n\n", c.Context)
+		if c.Output != nil {
+			b.WriteString(c.Output.String(showEmptyDiffs))
+		} else {
+			fmt.Fprintf(&b, "Error: %s\n", c.Error)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(m.summaryString())
+	return b.String()
+}
+
+func (m MultiClusterOutput) summaryString() string {
+	var b strings.Builder
+	b.WriteString("Clusters Summary\n")
+	fmt.Fprintf(&b, "Clusters with diffs: %d/%d\n", m.Summary.NumClustersWithDiffs, m.Summary.NumClusters)
+	if m.Summary.NumFailedClusters != 0 {
+		fmt.Fprintf(&b, "Clusters that couldn't be compared: %d\n", m.Summary.NumFailedClusters)
+	}
+	for _, c := range m.Clusters {
+		if c.Output == nil {
+			fmt.Fprintf(&b, "  %s: error\n", c.Context)
+			continue
+		}
+		fmt.Fprintf(&b, "  %s: %d/%d CRs with diffs, %d validation issues, %d unmatched CRs\n", c.Context,
+			c.Output.Summary.NumDiffCRs, c.Output.Summary.TotalCRs, len(c.Output.Summary.ValidationIssues),
+			len(c.Output.Summary.UnmatchedCRS))
+	}
+	return b.String()
+}
+
+// Markdown renders the outputs of the clusters as GitHub flavored markdown, with a section per cluster.
+func (m MultiClusterOutput) Markdown(showEmptyDiffs bool) string {
+	var b strings.Builder
+	for _, c := range m.Clusters {
+		fmt.Fprintf(&b, "# Cluster `%s`\n", c.Context)
+		if c.Output != nil {
+			b.WriteString(c.Output.Markdown(showEmptyDiffs))
+		} else {
+			fmt.Fprintf(&b, "\n%s: %s\n", junitErrorMsg, c.Error)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("# Clusters Summary\n\n```\n" + m.summaryString() + "```\n")
+	return b.String()
+}
+
+// JUnit renders the outputs of the clusters as a JUnit report, the test suites of each cluster are prefixed with its
+// context. A cluster that couldn't be compared has a single failed test case.
+func (m MultiClusterOutput) JUnit() ([]byte, error) {
+	var suites []*junit.TestSuite
+	for _, c := range m.Clusters {
+		if c.Output == nil {
+			suites = append(suites, &junit.TestSuite{
+				Name:     c.Context,
+				Tests:    1,
+				Failures: 1,
+				Time:     junit.Time(0),
+				TestCases: []junit.TestCase{{
+					Name:      junitErrorMsg,
+					Classname: c.Context,
+					Failure:   &junit.Failure{Message: junitErrorMsg, Type: junitErrorFailure, Contents: c.Error},
+				}},
+			})
+			continue
+		}
+		for _, suite := range c.Output.junitSuites() {
+			suite.Name = c.Context + "/" + suite.Name
+			suites = append(suites, suite)
+		}
+	}
+	return marshalJUnit(suites)
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"text/template"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var placeholderRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// namespaceMappedTemplate is a template rendered with the namespace mappings of the user config. Templates with
+// namespaces set by placeholders (e.g. namespace: {{ .ztp_ns }}) get a fixed namespace in their metadata, so they can be
+// correlated by the field groups that include the namespace instead of falling back to the groups without it.
+type namespaceMappedTemplate struct {
+	ReferenceTemplate
+	mappings map[string]any
+	metadata *unstructured.Unstructured
+}
+
+// applyNamespaceMappings wraps the templates so they are rendered with the mappings as additional parameters.
+// Fields of the cluster CR take precedence over the mappings.
+func applyNamespaceMappings(templates []ReferenceTemplate, mappings map[string]string) ([]ReferenceTemplate, error) {
+	var errs []error
+	keys := lo.Keys(mappings)
+	sort.Strings(keys)
+	params := make(map[string]any, len(mappings))
+	for _, k := range keys {
+		if !placeholderRe.MatchString(k) {
+			errs = append(errs, fmt.Errorf("namespace mapping placeholder %q isn't a valid template field name", k))
+		}
+		if mappings[k] == "" {
+			errs = append(errs, fmt.Errorf("namespace mapping placeholder %q is mapped to an empty namespace", k))
+		}
+		params[k] = mappings[k]
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	res := make([]ReferenceTemplate, 0, len(templates))
+	for _, temp := range templates {
+		mapped := namespaceMappedTemplate{ReferenceTemplate: temp, mappings: params}
+		md, err := mapped.Exec(map[string]any{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s with the namespace mappings: %w", temp.GetPath(), err)
+		}
+		mapped.metadata = md
+		res = append(res, mapped)
+	}
+	return res, nil
+}
+
+func (t namespaceMappedTemplate) Exec(params map[string]any) (*unstructured.Unstructured, error) {
+	paramsWithMappings := make(map[string]any, len(params)+len(t.mappings))
+	for k, v := range t.mappings {
+		paramsWithMappings[k] = v
+	}
+	for k, v := range params {
+		paramsWithMappings[k] = v
+	}
+	return t.ReferenceTemplate.Exec(paramsWithMappings) // nolint:wrapcheck
+}
+
+func (t namespaceMappedTemplate) GetMetadata() *unstructured.Unstructured {
+	return t.metadata
+}
+
+func (t namespaceMappedTemplate) setFuncs(funcs template.FuncMap) {
+	if s, ok := t.ReferenceTemplate.(funcsSetter); ok {
+		s.setFuncs(funcs)
+	}
+}
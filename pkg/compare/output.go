@@ -4,11 +4,17 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"text/tabwriter"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/samber/lo"
@@ -20,12 +26,53 @@ import (
 
 // DiffSum Contains the diff output and correlation info of a specific CR
 type DiffSum struct {
-	DiffOutput         string   `json:"DiffOutput"`
-	CorrelatedTemplate string   `json:"CorrelatedTemplate"`
-	CRName             string   `json:"CRName"`
-	Patched            string   `json:"Patched,omitempty"`
-	OverrideReasons    []string `json:"OverrideReason,omitempty"`
-	Description        string   `json:"description,omitempty"`
+	DiffOutput         string `json:"DiffOutput"`
+	CorrelatedTemplate string `json:"CorrelatedTemplate"`
+	CRName             string `json:"CRName"`
+	// Part and Component are the names of the part and component of the reference the template belongs to
+	Part            string `json:"Part,omitempty"`
+	Component       string `json:"Component,omitempty"`
+	crNamespace     string
+	Patched         string   `json:"Patched,omitempty"`
+	OverrideReasons []string `json:"OverrideReason,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	// DocsURL and RemediationURL are the links of the config of the template (docsURL, remediationURL)
+	DocsURL          string   `json:"docsURL,omitempty"`
+	RemediationURL   string   `json:"remediationURL,omitempty"`
+	Severity         string   `json:"Severity,omitempty"`
+	Acknowledgements []string `json:"Acknowledgements,omitempty"`
+	// MaintenanceWindowEnd is the end of the maintenance window the CR is in (--maintenance-window), its diff is
+	// suppressed until then
+	MaintenanceWindowEnd string `json:"maintenanceWindowEnd,omitempty"`
+	// Group is the namespace, the part or the kind of the cluster CR, the key the diffs are grouped by with --group-by
+	Group string `json:"group,omitempty"`
+	// CapturedValues are the values bound to the capturegroups of the inline diff funcs, the text output only shows them
+	// in verbose mode
+	CapturedValues map[string]string `json:"capturedValues,omitempty"`
+	// DiffScore is the score of the match strategy, by default the number of fields that differ between the CR and the
+	// template. The CR is compared to the template with the lowest score. It's only set in the json and yaml outputs in
+	// verbose mode, as RejectedCandidates.
+	DiffScore *int `json:"diffScore,omitempty"`
+	// RejectedCandidates are the other templates the CR was compared to, from the best to the worst match
+	RejectedCandidates []CandidateScore `json:"rejectedCandidates,omitempty"`
+	// Alternatives are the diffs of the CR against the other templates of the component it was compared to, when the
+	// component reports all the matches (reportAllMatches)
+	Alternatives []AlternativeDiff `json:"alternatives,omitempty"`
+	// duration is the time spent rendering and diffing the templates the CR was compared to
+	duration time.Duration
+}
+
+// CandidateScore is a template a cluster CR was compared to and the score of the match strategy.
+type CandidateScore struct {
+	Template  string `json:"template"`
+	DiffScore int    `json:"diffScore"`
+}
+
+// AlternativeDiff is the diff of a cluster CR against a template of the component it was compared to, other than the
+// template with the lowest score.
+type AlternativeDiff struct {
+	CorrelatedTemplate string `json:"CorrelatedTemplate"`
+	DiffOutput         string `json:"DiffOutput"`
 }
 
 func (s DiffSum) String() string {
@@ -36,7 +83,32 @@ Reference File: {{ .CorrelatedTemplate }}
 Description:
 {{ .Description | indent 2 }}
 {{- end }}
+{{- if .DocsURL }}
+Docs: {{ .DocsURL }}
+{{- end }}
+{{- if .RemediationURL }}
+Remediation: {{ .RemediationURL }}
+{{- end }}
 Diff Output: {{or .DiffOutput "None" }}
+{{- if .Severity }}
+Severity: {{ .Severity }}
+{{- end }}
+{{- range $reason := .Acknowledgements }}
+Acknowledged: {{ $reason }}
+{{- end }}
+{{- with .MaintenanceWindowEnd }}
+Maintenance Window End: {{ . }}
+{{- end }}
+{{- range $alt := .Alternatives }}
+Alternative Reference File: {{ $alt.CorrelatedTemplate }}
+Alternative Diff Output: {{ or $alt.DiffOutput "None" }}
+{{- end }}
+{{- if .CapturedValues }}
+Captured Values:
+{{- range $name, $value := .CapturedValues }}
+  {{ $name }}: {{ $value }}
+{{- end }}
+{{- end }}
 {{- if ne (len  .Patched) 0 }}
 Patched with {{ .Patched }}
 {{- if or (eq .OverrideReasons nil) (eq (len .OverrideReasons ) 0)}}
@@ -63,25 +135,401 @@ func (s DiffSum) WasPatched() bool {
 	return s.Patched != ""
 }
 
+// InMaintenance returns if the diff is suppressed because the CR is in a maintenance window.
+func (s DiffSum) InMaintenance() bool {
+	return s.MaintenanceWindowEnd != ""
+}
+
 // Summary Contains all info included in the Summary output of the compare command
 type Summary struct {
+	// Cluster identifies the live cluster that was compared, it's empty when comparing local CRs
+	Cluster          *ClusterInfo                          `json:"Cluster,omitempty"`
 	ValidationIssues map[string]map[string]ValidationIssue `json:"ValidationIssuses"`
 	NumMissing       int                                   `json:"NumMissing"`
 	UnmatchedCRS     []string                              `json:"UnmatchedCRS"`
 	NumDiffCRs       int                                   `json:"NumDiffCRs"`
+	NumSuppressedCRs int                                   `json:"NumSuppressedCRs,omitempty"`
 	TotalCRs         int                                   `json:"TotalCRs"`
 	MetadataHash     string                                `json:"MetadataHash"`
 	PatchedCRs       int                                   `json:"patchedCRs"`
+	OnlyValidation   bool                                  `json:"OnlyValidation,omitempty"`
+	DiffsBySeverity  map[string]int                        `json:"DiffsBySeverity,omitempty"`
+	Namespaces       map[string]*NamespaceSummary          `json:"Namespaces,omitempty"`
+	Parts            map[string]*PartSummary               `json:"Parts,omitempty"`
+	Coverage         *Coverage                             `json:"Coverage,omitempty"`
+	FlappingFields   []FlappingField                       `json:"FlappingFields,omitempty"`
+	Overrides        []OverrideStatus                      `json:"Overrides,omitempty"`
+	UnusedOverrides  []string                              `json:"UnusedOverrides,omitempty"`
+	// FetchFailures are the types whose CRs couldn't be listed from the live cluster
+	FetchFailures []FetchFailure `json:"FetchFailures,omitempty"`
+	// Warnings are the warnings logged while parsing the reference and collecting the cluster CRs, the text output
+	// doesn't show them as they are already logged
+	Warnings []string `json:"Warnings,omitempty"`
+}
+
+const (
+	noNamespace = "<no namespace>"
+
+	summaryFileName   = "summary.yaml"
+	diffFileExtension = ".diff"
+
+	suppressedDiffsTitle = "Diffs suppressed during maintenance:"
+
+	TemplateAssertionsGroup = "Failed template assertions"
+	TemplateAssertionMsg    = "Cluster CRs don't meet the assertions of the template"
+
+	TemplateSchemaGroup = "Templates not matching the CRD schemas"
+	TemplateSchemaMsg   = "The template rendered for the cluster CRs doesn't match the CRD schema of its kind"
+
+	InconsistentCapturegroupsGroup = "Inconsistent capturegroups"
+	UnexpectedNamespacesGroup      = "CRs in unexpected namespaces"
+	UnexpectedNamespaceMsg         = "The cluster CRs aren't in the namespaces expected by the template"
+	InconsistentCapturegroupMsg    = "Capturegroup (?<%s>…) matched different values: « %s »"
+)
+
+// TemplateAssertion is a failed assertion (required, failCompare) of a template rendered for a cluster CR.
+type TemplateAssertion struct {
+	Template string
+	CRName   string
+	Msg      string
+}
+
+// addAssertionIssues reports the failed assertions as validation issues, grouped by template. The message of the
+// assertion is the reason of the issue of each CR.
+func (s *Summary) addAssertionIssues(assertions []TemplateAssertion) {
+	s.addTemplateIssues(TemplateAssertionsGroup, TemplateAssertionMsg, assertions)
+}
+
+// addSchemaIssues reports the templates rendered for cluster CRs that don't match the CRD schemas as validation
+// issues, grouped by template. The schema errors are the reason of the issue of each CR.
+func (s *Summary) addSchemaIssues(schemaIssues []TemplateAssertion) {
+	s.addTemplateIssues(TemplateSchemaGroup, TemplateSchemaMsg, schemaIssues)
+}
+
+func (s *Summary) addTemplateIssues(group, msg string, assertions []TemplateAssertion) {
+	if len(assertions) == 0 {
+		return
+	}
+	if s.ValidationIssues == nil {
+		s.ValidationIssues = make(map[string]map[string]ValidationIssue)
+	}
+	issues := make(map[string]ValidationIssue)
+	for _, a := range assertions {
+		issue, ok := issues[a.Template]
+		if !ok {
+			issue = ValidationIssue{Msg: msg, CRMetadata: make(map[string]CRMetadata)}
+		}
+		issue.CRs = append(issue.CRs, a.CRName)
+		issue.CRMetadata[a.CRName] = CRMetadata{Reason: a.Msg}
+		issues[a.Template] = issue
+	}
+	for _, issue := range issues {
+		sort.Strings(issue.CRs)
+	}
+	s.ValidationIssues[group] = issues
+}
+
+// CRCapturedValues are the values of the capturegroups captured for a cluster CR by the template it was matched to.
+type CRCapturedValues struct {
+	Template string
+	CRName   string
+	Values   map[string][]string
+}
+
+// addCapturegroupIssues reports the capturegroups that captured different values in the CRs matched by the templates
+// of a part or a component that requires them to be consistent. The issues are keyed by the scope and the name of the
+// capturegroup, the values captured for each CR are the reason of its issue.
+func (s *Summary) addCapturegroupIssues(groups []ConsistentCapturegroups, captured []CRCapturedValues) {
+	issues := make(map[string]ValidationIssue)
+	for _, group := range groups {
+		for _, name := range group.Names {
+			var values []string
+			issue := ValidationIssue{CRMetadata: make(map[string]CRMetadata)}
+			for _, c := range captured {
+				if !slices.Contains(group.Templates, c.Template) || len(c.Values[name]) == 0 {
+					continue
+				}
+				for _, v := range c.Values[name] {
+					if !slices.Contains(values, v) {
+						values = append(values, v)
+					}
+				}
+				issue.CRs = append(issue.CRs, c.CRName)
+				issue.CRMetadata[c.CRName] = CRMetadata{
+					Reason: fmt.Sprintf("(?<%s>=%s)", name, strings.Join(c.Values[name], " | ")),
+				}
+			}
+			if len(values) < 2 {
+				continue
+			}
+			issue.Msg = fmt.Sprintf(InconsistentCapturegroupMsg, name, strings.Join(values, " | "))
+			sort.Strings(issue.CRs)
+			issues[fmt.Sprintf("%s: %s", group.Scope, name)] = issue
+		}
+	}
+	if len(issues) == 0 {
+		return
+	}
+	if s.ValidationIssues == nil {
+		s.ValidationIssues = make(map[string]map[string]ValidationIssue)
+	}
+	s.ValidationIssues[InconsistentCapturegroupsGroup] = issues
+}
+
+// OverrideStatus is the age and the ownership of a user override that was applied, to keep track of temporary
+// overrides. AgeDays is -1 when the creation date of the override isn't known.
+type OverrideStatus struct {
+	Name         string `json:"name"`
+	TemplatePath string `json:"templatePath"`
+	AgeDays      int    `json:"ageDays"`
+	ExpiresAt    string `json:"expiresAt,omitempty"`
+	Owner        string `json:"owner,omitempty"`
+	TicketURL    string `json:"ticketURL,omitempty"`
+}
+
+// addOverrides reports the age and the ownership of the loaded user overrides that are tracked by any of them.
+func (s *Summary) addOverrides(overrides []*UserOverride, now time.Time) {
+	for _, o := range overrides {
+		if o.CreatedAt == "" && o.ExpiresAt == "" && o.Owner == "" && o.TicketURL == "" {
+			continue
+		}
+		s.Overrides = append(s.Overrides, OverrideStatus{
+			Name:         apiKindNamespaceName(o.GetMetadata()),
+			TemplatePath: o.TemplatePath,
+			AgeDays:      o.age(now),
+			ExpiresAt:    o.ExpiresAt,
+			Owner:        o.Owner,
+			TicketURL:    o.TicketURL,
+		})
+	}
+}
+
+// addUnusedOverrides reports the loaded user overrides that weren't applied to any of the cluster CRs.
+func (s *Summary) addUnusedOverrides(overrides []*UserOverride, used map[*UserOverride]bool) {
+	for _, o := range overrides {
+		if !used[o] {
+			s.UnusedOverrides = append(s.UnusedOverrides, o.describe())
+		}
+	}
+}
+
+// NamespaceSummary Contains the summary info of the CRs that belong to a specific namespace
+type NamespaceSummary struct {
+	NumDiffCRs   int      `json:"NumDiffCRs"`
+	TotalCRs     int      `json:"TotalCRs"`
+	MissingCRs   []string `json:"MissingCRs,omitempty"`
+	UnmatchedCRs []string `json:"UnmatchedCRs,omitempty"`
+}
+
+// newNamespaceRollup rolls up the diffs, unmatched CRs and missing CRs by their namespace. Missing CRs are
+// attributed to the namespace set in their template, templates without a fixed namespace are grouped under noNamespace.
+func newNamespaceRollup(diffs []DiffSum, unmatched []*unstructured.Unstructured, issues map[string]map[string]ValidationIssue, templates []ReferenceTemplate) map[string]*NamespaceSummary {
+	namespaces := make(map[string]*NamespaceSummary)
+	get := func(namespace string) *NamespaceSummary {
+		if namespace == "" {
+			namespace = noNamespace
+		}
+		if _, ok := namespaces[namespace]; !ok {
+			namespaces[namespace] = &NamespaceSummary{}
+		}
+		return namespaces[namespace]
+	}
+
+	for _, d := range diffs {
+		ns := get(d.crNamespace)
+		ns.TotalCRs++
+		if d.HasDiff() && !d.InMaintenance() {
+			ns.NumDiffCRs++
+		}
+	}
+
+	for _, cr := range unmatched {
+		ns := get(cr.GetNamespace())
+		ns.UnmatchedCRs = append(ns.UnmatchedCRs, apiKindNamespaceName(cr))
+	}
+
+	templateNamespaces := make(map[string]string)
+	for _, t := range templates {
+		if md := t.GetMetadata(); md != nil {
+			templateNamespaces[t.GetPath()] = md.GetNamespace()
+		}
+	}
+	for _, part := range issues {
+		for _, issue := range part {
+			if issue.Msg != MissingCRsMsg && issue.Msg != OneOfRequiredMsg {
+				continue
+			}
+			for _, cr := range issue.CRs {
+				ns := get(templateNamespaces[cr])
+				ns.MissingCRs = append(ns.MissingCRs, cr)
+			}
+		}
+	}
+
+	for _, ns := range namespaces {
+		sort.Strings(ns.MissingCRs)
+		sort.Strings(ns.UnmatchedCRs)
+	}
+	return namespaces
+}
+
+// ComponentSummary Contains the counters of the templates of a component of the reference and of the CRs matched to them
+type ComponentSummary struct {
+	Templates        int `json:"Templates"`
+	MatchedTemplates int `json:"MatchedTemplates"`
+	TotalCRs         int `json:"TotalCRs"`
+	NumDiffCRs       int `json:"NumDiffCRs"`
+	NumMissing       int `json:"NumMissing"`
+	PatchedCRs       int `json:"patchedCRs"`
+}
+
+// PartSummary Contains the counters of the templates of a part of the reference, in total and by component
+type PartSummary struct {
+	ComponentSummary
+	Components map[string]*ComponentSummary `json:"Components"`
+}
+
+// newPartRollup rolls up the templates, the diffs and the missing CRs by the part and the component of their template.
+// A template is matched when at least one cluster CR was matched to it, matchedTemplates are the numbers of matches of
+// the template files.
+func newPartRollup(diffs []DiffSum, issues map[string]map[string]ValidationIssue, templates []ReferenceTemplate, matchedTemplates map[string]int) map[string]*PartSummary {
+	parts := make(map[string]*PartSummary)
+	add := func(partName, componentName string, f func(*ComponentSummary)) {
+		part, ok := parts[partName]
+		if !ok {
+			part = &PartSummary{Components: make(map[string]*ComponentSummary)}
+			parts[partName] = part
+		}
+		comp, ok := part.Components[componentName]
+		if !ok {
+			comp = &ComponentSummary{}
+			part.Components[componentName] = comp
+		}
+		f(&part.ComponentSummary)
+		f(comp)
+	}
+
+	counted := make(map[string]bool)
+	for _, t := range templates {
+		partName, componentName := t.GetPartAndComponent()
+		// The documents of a multi-document template are a single template of the reference
+		key := strings.Join([]string{partName, componentName, t.GetPath()}, "/")
+		if counted[key] {
+			continue
+		}
+		counted[key] = true
+		add(partName, componentName, func(c *ComponentSummary) {
+			c.Templates++
+			if matchedTemplates[t.GetPath()] > 0 {
+				c.MatchedTemplates++
+			}
+		})
+	}
+
+	for _, d := range diffs {
+		add(d.Part, d.Component, func(c *ComponentSummary) {
+			c.TotalCRs++
+			if d.HasDiff() && !d.InMaintenance() {
+				c.NumDiffCRs++
+			}
+			if d.WasPatched() {
+				c.PatchedCRs++
+			}
+		})
+	}
+
+	for partName, part := range issues {
+		for componentName, issue := range part {
+			missing := 0
+			switch issue.Msg {
+			case MissingCRsMsg:
+				missing = len(issue.CRs)
+			case OneOfRequiredMsg:
+				missing = 1
+			default:
+				continue
+			}
+			add(partName, componentName, func(c *ComponentSummary) { c.NumMissing += missing })
+		}
+	}
+	return parts
+}
+
+// partsTable renders the counters of the parts and of their components as a table, the components are indented under
+// their part.
+func partsTable(parts map[string]*PartSummary) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PART/COMPONENT\tTEMPLATES\tMATCHED\tCRS\tDIFFS\tMISSING\tPATCHED")
+	row := func(name string, c ComponentSummary) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			name, c.Templates, c.MatchedTemplates, c.TotalCRs, c.NumDiffCRs, c.NumMissing, c.PatchedCRs)
+	}
+	partNames := lo.Keys(parts)
+	sort.Strings(partNames)
+	for _, partName := range partNames {
+		part := parts[partName]
+		row(partName, part.ComponentSummary)
+		componentNames := lo.Keys(part.Components)
+		sort.Strings(componentNames)
+		for _, componentName := range componentNames {
+			row("  "+componentName, *part.Components[componentName])
+		}
+	}
+	_ = w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// countBySeverity returns the number of CRs with diffs of each severity.
+func countBySeverity(diffs []DiffSum) map[string]int {
+	res := make(map[string]int)
+	for _, d := range diffs {
+		if d.Severity != "" && !d.InMaintenance() {
+			res[d.Severity]++
+		}
+	}
+	return res
 }
 
 func newSummary(reference Reference, c *MetricsTracker, numDiffCRs int, templates []ReferenceTemplate, numPatchedCRs int) *Summary {
 	s := Summary{NumDiffCRs: numDiffCRs, PatchedCRs: numPatchedCRs}
-	s.ValidationIssues, s.NumMissing = reference.GetValidationIssues(c.MatchedTemplatesNames)
+	s.ValidationIssues, s.NumMissing = reference.GetValidationIssues(matchedTemplatePaths(c.MatchedTemplatesNames, templates))
 	s.TotalCRs = c.getTotalCRs()
 	s.UnmatchedCRS = lo.Map(c.UnMatchedCRs, func(r *unstructured.Unstructured, i int) string {
 		return apiKindNamespaceName(r)
 	})
 
+	s.MetadataHash = metadataHash(reference, templates)
+
+	return &s
+}
+
+// matchedTemplatePaths returns the number of matches of each template file. A template file rendering multiple yaml
+// documents is only considered matched when all of its documents were matched.
+func matchedTemplatePaths(matchedTemplates map[string]int, templates []ReferenceTemplate) map[string]int {
+	res := make(map[string]int, len(matchedTemplates))
+	for k, v := range matchedTemplates {
+		res[k] = v
+	}
+	unmatchedPaths := make(map[string]bool)
+	for _, temp := range templates {
+		if temp.GetIdentifier() == temp.GetPath() {
+			continue
+		}
+		n := matchedTemplates[temp.GetIdentifier()]
+		if n == 0 {
+			unmatchedPaths[temp.GetPath()] = true
+		}
+		res[temp.GetPath()] += n
+	}
+	for p := range unmatchedPaths {
+		res[p] = 0
+	}
+	return res
+}
+
+// metadataHash returns a hash of the reference and of its templates.
+func metadataHash(reference Reference, templates []ReferenceTemplate) string {
 	hash := sha256.New()
 
 	refBytes, err := yaml.Marshal(reference)
@@ -96,22 +544,42 @@ func newSummary(reference Reference, c *MetricsTracker, numDiffCRs int, template
 		}
 	}
 
-	s.MetadataHash = fmt.Sprintf("%x", hash.Sum(nil))
-
-	return &s
+	return fmt.Sprintf("%x", hash.Sum(nil))
 }
 
 func (s Summary) String() string {
 	t := `
 Summary
+{{- with .Cluster }}
+Cluster: {{ .Server }}{{ with .Context }} (context {{ . }}){{ end }}
+{{- with .ClusterID }}
+Cluster ID: {{ . }}
+{{- end }}
+{{- with .KubernetesVersion }}
+Kubernetes Version: {{ . }}
+{{- end }}
+{{- with .OpenShiftVersion }}
+OpenShift Version: {{ . }}
+{{- end }}
+{{- end }}
+{{- if .OnlyValidation }}
+CRs matched to reference CRs: {{ .TotalCRs }} (diffs were not generated)
+{{- else }}
 CRs with diffs: {{ .NumDiffCRs }}/{{ .TotalCRs }}
+{{- range $severity, $count := .DiffsBySeverity }}
+  {{ $severity }}: {{ $count }}
+{{- end }}
+{{- with .NumSuppressedCRs }}
+CRs with diffs suppressed during maintenance: {{ . }}
+{{- end }}
+{{- end }}
 {{- if ne (len  .ValidationIssues) 0 }}
 CRs in reference missing from the cluster: {{.NumMissing}}
 {{- range $groupname, $group := .ValidationIssues }}
 {{ $groupname }}:
   {{- range $partname, $issue := $group }}
   {{ $partname }}:
-    {{ $issue.Msg }}:
+    {{ $issue.Msg }}{{ if $issue.CRs }}:{{ end }}
     {{- range $cr := $issue.CRs }}
     - {{ $cr }}
       {{- $md := index $issue.CRMetadata $cr }}
@@ -119,6 +587,9 @@ CRs in reference missing from the cluster: {{.NumMissing}}
       Description:
         {{- $md.Description | nindent 8 }}
       {{- end }}
+      {{- if $md.Reason }}
+      Reason: {{ $md.Reason }}
+      {{- end }}
     {{- end }}
   {{- end }}
 {{- end }}
@@ -131,73 +602,381 @@ Cluster CRs unmatched to reference CRs: {{len  .UnmatchedCRS}}
 {{- else}}
 No CRs are unmatched to reference CRs
 {{- end }}
+{{- if .FetchFailures }}
+Kinds that couldn't be listed from the cluster: {{ len .FetchFailures }}
+{{- range $f := .FetchFailures }}
+- {{ $f.Kind }}: {{ $f.Error }}
+{{- end }}
+{{- end }}
+{{- if .Namespaces }}
+Namespaces:
+{{- range $name, $ns := .Namespaces }}
+  {{ $name }}:
+    CRs with diffs: {{ $ns.NumDiffCRs }}/{{ $ns.TotalCRs }}
+    {{- if $ns.MissingCRs }}
+    Missing CRs:
+    {{- range $cr := $ns.MissingCRs }}
+    - {{ $cr }}
+    {{- end }}
+    {{- end }}
+    {{- if $ns.UnmatchedCRs }}
+    Unmatched CRs:
+    {{- range $cr := $ns.UnmatchedCRs }}
+    - {{ $cr }}
+    {{- end }}
+    {{- end }}
+{{- end }}
+{{- end }}
+{{- if .Parts }}
+Parts:
+{{ partsTable .Parts | indent 2 }}
+{{- end }}
+{{- with .Coverage }}
+Template coverage: {{ formatPercentage .Percentage }} ({{ .MatchedTemplates }}/{{ .Templates }} templates matched)
+{{ coverageTable . | indent 2 }}
+{{- end }}
+{{- if .FlappingFields }}
+Flapping fields (changed between snapshots, candidates for fieldsToOmit): {{ len .FlappingFields }}
+{{- range $f := .FlappingFields }}
+- {{ $f.CRName }} ({{ $f.Template }}): {{ $f.Path }}
+{{- end }}
+{{- end }}
 Metadata Hash: {{.MetadataHash}}
 {{- if ne .PatchedCRs 0}}
 Cluster CRs with patches applied: {{ .PatchedCRs }}
 {{- else}}
 No patched CRs
 {{- end }}
+{{- if .Overrides }}
+User overrides: {{ len .Overrides }}
+{{- range $o := .Overrides }}
+- {{ $o.Name }} ({{ $o.TemplatePath }}):
+  {{- if ge $o.AgeDays 0 }} age {{ $o.AgeDays }}d{{ else }} age unknown{{ end }}
+  {{- with $o.ExpiresAt }}, expires {{ . }}{{ end }}
+  {{- with $o.Owner }}, owner {{ . }}{{ end }}
+  {{- with $o.TicketURL }}, ticket {{ . }}{{ end }}
+{{- end }}
+{{- end }}
+{{- if .UnusedOverrides }}
+User overrides not applied to any CR: {{ len .UnusedOverrides }}
+{{- range $o := .UnusedOverrides }}
+- {{ $o }}
+{{- end }}
+{{- end }}
 `
 	var buf bytes.Buffer
-	tmpl, _ := template.New("Summary").Funcs(sprig.TxtFuncMap()).Funcs(template.FuncMap{"toYaml": toYAML}).Parse(t)
+	tmpl, _ := template.New("Summary").Funcs(sprig.TxtFuncMap()).Funcs(template.FuncMap{"toYaml": toYAML,
+		"partsTable": partsTable, "coverageTable": coverageTable, "formatPercentage": formatPercentage}).Parse(t)
 	_ = tmpl.Execute(&buf, s)
 	return strings.TrimSpace(buf.String())
 }
 
 // Output Contains the complete output of the command
 type Output struct {
-	Summary *Summary   `json:"Summary"`
-	Diffs   *[]DiffSum `json:"Diffs"`
-	patches []*UserOverride
+	// OutputAPIVersion is the version of the json and yaml outputs, described by the outputschema package
+	OutputAPIVersion string     `json:"outputApiVersion"`
+	Summary          *Summary   `json:"Summary"`
+	Diffs            *[]DiffSum `json:"Diffs"`
+	patches          []*UserOverride
+	// templates are the templates of the reference, used to group the CRs by the parts of the reference
+	templates []ReferenceTemplate
+	// groupBy is the key the diffs are grouped by (--group-by), the text output adds a heading before each group
+	groupBy string
 }
 
-func (o Output) String(showEmptyDiffs bool) string {
+// groupTitles are the headings of the groups of diffs of the text output, by --group-by.
+var groupTitles = map[string]string{
+	GroupByNamespace: "Namespace",
+	GroupByPart:      "Part",
+	GroupByKind:      "Kind",
+}
+
+// diffGroup returns the key of the group of the diff of the cluster CR with --group-by, or "" when the diffs aren't
+// grouped. Cluster scoped CRs are grouped under noNamespace.
+func diffGroup(groupBy string, clusterCR *unstructured.Unstructured, diff *DiffSum) string {
+	switch groupBy {
+	case GroupByNamespace:
+		if clusterCR.GetNamespace() == "" {
+			return noNamespace
+		}
+		return clusterCR.GetNamespace()
+	case GroupByPart:
+		return diff.Part
+	case GroupByKind:
+		return clusterCR.GetKind()
+	default:
+		return ""
+	}
+}
+
+func (o Output) sortedDiffs(showEmptyDiffs bool) []DiffSum {
 	sort.Slice(*o.Diffs, func(i, j int) bool {
+		if (*o.Diffs)[i].Group != (*o.Diffs)[j].Group {
+			return (*o.Diffs)[i].Group < (*o.Diffs)[j].Group
+		}
 		return (*o.Diffs)[i].CorrelatedTemplate+(*o.Diffs)[i].CRName < (*o.Diffs)[j].CorrelatedTemplate+(*o.Diffs)[j].CRName
 	})
 
-	diffParts := []string{}
-
+	diffs := []DiffSum{}
 	for _, diffSum := range *o.Diffs {
 		if showEmptyDiffs || diffSum.HasDiff() || diffSum.WasPatched() {
-			diffParts = append(diffParts, fmt.Sprintln(diffSum.String()))
+			diffs = append(diffs, diffSum)
+		}
+	}
+	return diffs
+}
+
+func (o Output) String(showEmptyDiffs bool) string {
+	var diffs, suppressed []DiffSum
+
+	for _, diffSum := range o.sortedDiffs(showEmptyDiffs) {
+		if !showEmptyDiffs {
+			diffSum.CapturedValues = nil
+		}
+		if diffSum.InMaintenance() {
+			suppressed = append(suppressed, diffSum)
+		} else {
+			diffs = append(diffs, diffSum)
 		}
 	}
+	diffParts := o.diffParts(diffs)
+	suppressedParts := o.diffParts(suppressed)
 
 	var str string
 	if len(diffParts) > 0 {
 		partsStr := strings.Join(diffParts, fmt.Sprintf("\n%s\n", DiffSeparator))
 		str = fmt.Sprintf("%s\n%s\n%s\n", DiffSeparator, partsStr, DiffSeparator)
 	}
+	if len(suppressedParts) > 0 {
+		// The diffs of the CRs in a maintenance window are listed in their own section, after the other diffs
+		partsStr := strings.Join(suppressedParts, fmt.Sprintf("\n%s\n", DiffSeparator))
+		str += fmt.Sprintf("%s\n\n%s\n%s\n%s\n", suppressedDiffsTitle, DiffSeparator, partsStr, DiffSeparator)
+	}
 
 	return fmt.Sprintf("%s%s\n", str, o.Summary.String())
 }
 
-func (o Output) Print(format string, out io.Writer, showEmptyDiffs bool) (int, error) {
-	var (
-		content []byte
-		err     error
-	)
-	switch format {
-	case Json:
-		content, err = json.Marshal(o)
-		if err != nil {
-			return 0, fmt.Errorf("failed to marshal output to json: %w", err)
+// diffParts renders the diffs, with --group-by the first diff of each group is preceded by the heading of the group.
+func (o Output) diffParts(diffs []DiffSum) []string {
+	parts := []string{}
+	for i, diffSum := range diffs {
+		part := fmt.Sprintln(diffSum.String())
+		if o.groupBy != "" && (i == 0 || diffs[i-1].Group != diffSum.Group) {
+			part = fmt.Sprintf("%s: %s\n\n%s", groupTitles[o.groupBy], diffSum.Group, part)
 		}
-		content = append(content, []byte("\n")...)
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// Markdown renders the output as GitHub flavored markdown, suitable for posting as a pull request comment.
+func (o Output) Markdown(showEmptyDiffs bool) string {
+	t := `
+## Cluster Compare Summary
+
+| | |
+| --- | --- |
+{{- with .Summary }}
+{{- if .OnlyValidation }}
+| CRs matched to reference CRs | {{ .TotalCRs }} |
+{{- else }}
+| CRs with diffs | {{ .NumDiffCRs }}/{{ .TotalCRs }} |
+{{- end }}
+{{- with .NumSuppressedCRs }}
+| CRs with diffs suppressed during maintenance | {{ . }} |
+{{- end }}
+| CRs in reference missing from the cluster | {{ .NumMissing }} |
+| Cluster CRs unmatched to reference CRs | {{ len .UnmatchedCRS }} |
+| Cluster CRs with patches applied | {{ .PatchedCRs }} |
+| Metadata Hash | ` + "`{{ .MetadataHash }}`" + ` |
+{{- if ne (len .ValidationIssues) 0 }}
+
+### Validation Issues
+
+| Part | Component | Issue | CRs |
+| --- | --- | --- | --- |
+{{- range $partname, $part := .ValidationIssues }}
+{{- range $compname, $issue := $part }}
+| {{ $partname }} | {{ $compname }} | {{ $issue.Msg }} | {{ range $i, $cr := $issue.CRs }}{{ if $i }}<br>{{ end }}` + "`{{ $cr }}`" + `{{ with (index $issue.CRMetadata $cr).Reason }}: {{ . }}{{ end }}{{ end }} |
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if .Namespaces }}
+
+### Namespaces
+
+| Namespace | CRs with diffs | Missing CRs | Unmatched CRs |
+| --- | --- | --- | --- |
+{{- range $name, $ns := .Namespaces }}
+| {{ $name }} | {{ $ns.NumDiffCRs }}/{{ $ns.TotalCRs }} | {{ len $ns.MissingCRs }} | {{ len $ns.UnmatchedCRs }} |
+{{- end }}
+{{- end }}
+{{- if .Parts }}
+
+### Parts
+
+| Part | Component | Templates matched | CRs with diffs | Missing CRs | Patched CRs |
+| --- | --- | --- | --- | --- | --- |
+{{- range $name, $part := .Parts }}
+| {{ $name }} | | {{ $part.MatchedTemplates }}/{{ $part.Templates }} | {{ $part.NumDiffCRs }}/{{ $part.TotalCRs }} | {{ $part.NumMissing }} | {{ $part.PatchedCRs }} |
+{{- range $compname, $comp := $part.Components }}
+| {{ $name }} | {{ $compname }} | {{ $comp.MatchedTemplates }}/{{ $comp.Templates }} | {{ $comp.NumDiffCRs }}/{{ $comp.TotalCRs }} | {{ $comp.NumMissing }} | {{ $comp.PatchedCRs }} |
+{{- end }}
+{{- end }}
+{{- end }}
+{{- with .Coverage }}
+
+### Template coverage
+
+{{ formatPercentage .Percentage }} of the templates are matched by at least one cluster CR ({{ .MatchedTemplates }}/{{ .Templates }}).
+
+| Part | Component | Templates matched | Coverage |
+| --- | --- | --- | --- |
+{{- range $name, $part := .Parts }}
+| {{ $name }} | | {{ $part.MatchedTemplates }}/{{ $part.Templates }} | {{ formatPercentage $part.Percentage }} |
+{{- range $compname, $comp := $part.Components }}
+| {{ $name }} | {{ $compname }} | {{ $comp.MatchedTemplates }}/{{ $comp.Templates }} | {{ formatPercentage $comp.Percentage }} |
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if ne (len .UnmatchedCRS) 0 }}
 
-	case Yaml:
-		content, err = yaml.Marshal(o)
+### Unmatched Cluster CRs
+{{ range $cr := .UnmatchedCRS }}
+- ` + "`{{ $cr }}`" + `
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if ne (len .Diffs) 0 }}
+
+### Diffs
+{{- range $diff := .Diffs }}
+{{ template "diff" $diff }}
+{{- end }}
+{{- end }}
+{{- if ne (len .Suppressed) 0 }}
+
+### Diffs suppressed during maintenance
+{{- range $diff := .Suppressed }}
+{{ template "diff" $diff }}
+{{- end }}
+{{- end }}
+{{- define "diff" }}
+<details>
+<summary>{{ if .InMaintenance }}:wrench:{{ else if .HasDiff }}:x:{{ else }}:white_check_mark:{{ end }} <code>{{ .CRName }}</code> compared to <code>{{ .CorrelatedTemplate }}</code></summary>
+{{- if .Description }}
+
+{{ .Description }}
+{{- end }}
+{{- if or .DocsURL .RemediationURL }}
+{{ if .DocsURL }}
+- [Docs]({{ .DocsURL }})
+{{- end }}
+{{- if .RemediationURL }}
+- [Remediation]({{ .RemediationURL }})
+{{- end }}
+{{- end }}
+{{- with .MaintenanceWindowEnd }}
+
+In a maintenance window until {{ . }}
+{{- end }}
+
+` + "```diff" + `
+{{ or .DiffOutput "None" | trimSuffix "\n" }}
+` + "```" + `
+{{- if .WasPatched }}
+
+Patched with ` + "`{{ .Patched }}`" + `
+{{- range $reason := .OverrideReasons }}
+- {{ $reason }}
+{{- end }}
+{{- end }}
+
+</details>
+{{- end }}
+`
+	var buf bytes.Buffer
+	tmpl, _ := template.New("Markdown").Funcs(sprig.TxtFuncMap()).
+		Funcs(template.FuncMap{"formatPercentage": formatPercentage}).Parse(t)
+	diffs, suppressed := []DiffSum{}, []DiffSum{}
+	for _, d := range o.sortedDiffs(showEmptyDiffs) {
+		if d.InMaintenance() {
+			suppressed = append(suppressed, d)
+		} else {
+			diffs = append(diffs, d)
+		}
+	}
+	_ = tmpl.Execute(&buf, map[string]any{"Summary": o.Summary, "Diffs": diffs, "Suppressed": suppressed})
+	return strings.TrimSpace(buf.String()) + "\n"
+}
+
+// OutputFormatter serializes the output of a comparison for an output format (--output).
+type OutputFormatter interface {
+	Format(o Output, showEmptyDiffs bool) ([]byte, error)
+}
+
+// OutputFormatterFunc adapts a function to the OutputFormatter interface.
+type OutputFormatterFunc func(o Output, showEmptyDiffs bool) ([]byte, error)
+
+func (f OutputFormatterFunc) Format(o Output, showEmptyDiffs bool) ([]byte, error) {
+	return f(o, showEmptyDiffs)
+}
+
+var outputFormatters = map[string]OutputFormatter{
+	Json: OutputFormatterFunc(func(o Output, _ bool) ([]byte, error) {
+		content, err := json.Marshal(o)
 		if err != nil {
-			return 0, fmt.Errorf("failed to marshal output to yaml: %w", err)
+			return nil, fmt.Errorf("failed to marshal output to json: %w", err)
 		}
-	case PatchYaml:
-		content, err = yaml.Marshal(o.patches)
+		return append(content, []byte("\n")...), nil
+	}),
+	Yaml: OutputFormatterFunc(func(o Output, _ bool) ([]byte, error) {
+		content, err := yaml.Marshal(o)
 		if err != nil {
-			return 0, fmt.Errorf("failed to marshal patches to yaml: %w", err)
+			return nil, fmt.Errorf("failed to marshal output to yaml: %w", err)
 		}
-	default:
-		content = []byte(o.String(showEmptyDiffs))
+		return content, nil
+	}),
+	PatchYaml: OutputFormatterFunc(func(o Output, _ bool) ([]byte, error) {
+		content, err := yaml.Marshal(o.patches)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal patches to yaml: %w", err)
+		}
+		return content, nil
+	}),
+	Markdown: OutputFormatterFunc(func(o Output, showEmptyDiffs bool) ([]byte, error) {
+		return []byte(o.Markdown(showEmptyDiffs)), nil
+	}),
+	Junit: OutputFormatterFunc(func(o Output, _ bool) ([]byte, error) {
+		return o.JUnit()
+	}),
+}
+
+// RegisterOutputFormatter registers a formatter for an additional output format, programs embedding the command can
+// use it to plug in their own serializers. Formatters should be registered before the command is created by NewCmd for
+// the format to be listed in the help of the --output flag.
+func RegisterOutputFormatter(format string, formatter OutputFormatter) error {
+	if format == "" || formatter == nil {
+		return errors.New("output formatters require a format name and a formatter")
+	}
+	if _, ok := outputFormatters[format]; ok {
+		return fmt.Errorf("output format %q is already registered", format)
+	}
+	outputFormatters[format] = formatter
+	OutputFormats = append(OutputFormats, format)
+	return nil
+}
+
+func (o Output) Print(format string, out io.Writer, showEmptyDiffs bool) (int, error) {
+	formatter, ok := outputFormatters[format]
+	if !ok {
+		formatter = OutputFormatterFunc(func(o Output, showEmptyDiffs bool) ([]byte, error) {
+			return []byte(o.String(showEmptyDiffs)), nil
+		})
+	}
+	content, err := formatter.Format(o, showEmptyDiffs)
+	if err != nil {
+		return 0, err // nolint:wrapcheck
 	}
 	n, err := out.Write(content)
 	if err != nil {
@@ -205,3 +984,42 @@ func (o Output) Print(format string, out io.Writer, showEmptyDiffs bool) (int, e
 	}
 	return n, nil
 }
+
+// WriteDir writes the diff of each cluster CR with diffs to <dir>/<part>/<component>/<CR name>.diff, and the summary
+// to <dir>/summary.yaml. The CRs matched to templates that don't belong to a part are written to the directory itself.
+// Files of previous runs are overwritten but not removed.
+func (o Output) WriteDir(dir string) error {
+	for _, d := range o.sortedDiffs(false) {
+		if !d.HasDiff() {
+			continue
+		}
+		crDir := filepath.Join(dir, outputFileName(d.Part), outputFileName(d.Component))
+		if err := os.MkdirAll(crDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create the output directory: %w", err)
+		}
+		path := filepath.Join(crDir, outputFileName(d.CRName)+diffFileExtension)
+		if err := os.WriteFile(path, []byte(d.DiffOutput), 0o644); err != nil { // nolint:gosec
+			return fmt.Errorf("failed to write the diff of %s: %w", d.CRName, err)
+		}
+	}
+	summary, err := yaml.Marshal(o.Summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary to yaml: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create the output directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, summaryFileName), summary, 0o644); err != nil { // nolint:gosec
+		return fmt.Errorf("failed to write the summary: %w", err)
+	}
+	return nil
+}
+
+// outputFileName returns the name as a file name, the separators of the apiVersions (apps/v1) are replaced.
+func outputFileName(name string) string {
+	name = strings.ReplaceAll(name, "/", "-")
+	if name == "." || name == ".." {
+		return strings.Repeat("_", len(name))
+	}
+	return name
+}
@@ -0,0 +1,84 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openshift/kube-compare/pkg/junit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterOutputFormatter(t *testing.T) {
+	format := "count"
+	formatter := OutputFormatterFunc(func(o Output, _ bool) ([]byte, error) {
+		return []byte(fmt.Sprintf("%d/%d\n", o.Summary.NumDiffCRs, o.Summary.TotalCRs)), nil
+	})
+	require.NoError(t, RegisterOutputFormatter(format, formatter))
+	t.Cleanup(func() {
+		delete(outputFormatters, format)
+		OutputFormats = OutputFormats[:len(OutputFormats)-1]
+	})
+	assert.Contains(t, OutputFormats, format)
+
+	var buf bytes.Buffer
+	_, err := Output{Summary: &Summary{NumDiffCRs: 1, TotalCRs: 3}, Diffs: &[]DiffSum{}}.Print(format, &buf, false)
+	require.NoError(t, err)
+	assert.Equal(t, "1/3\n", buf.String())
+
+	assert.Error(t, RegisterOutputFormatter(format, formatter))
+	assert.Error(t, RegisterOutputFormatter(Json, formatter))
+	assert.Error(t, RegisterOutputFormatter("", formatter))
+}
+
+func TestJUnitTimes(t *testing.T) {
+	part := &PartV2{Name: "Part"}
+	component := &ComponentV2{Name: "Component"}
+	temp := &ReferenceTemplateV2{ReferenceTemplateV1: ReferenceTemplateV1{Path: "cm.yaml"}, part: part, component: component}
+	diffs := []DiffSum{
+		{CRName: "v1_ConfigMap_a", CorrelatedTemplate: "cm.yaml", duration: 1500 * time.Millisecond},
+		{CRName: "v1_ConfigMap_b", CorrelatedTemplate: "cm.yaml", DiffOutput: "diff", duration: 250 * time.Millisecond},
+	}
+	content, err := Output{Summary: &Summary{}, Diffs: &diffs, templates: []ReferenceTemplate{temp}}.JUnit()
+	require.NoError(t, err)
+
+	var report junit.TestSuites
+	require.NoError(t, xml.Unmarshal(content, &report))
+	require.Len(t, report.Suites, 1)
+	suite := report.Suites[0]
+	assert.Equal(t, "Part", suite.Name)
+	assert.Equal(t, "1.750", suite.Time)
+	assert.Equal(t, 1, suite.Failures)
+	require.Len(t, suite.TestCases, 2)
+	assert.Equal(t, "Component", suite.TestCases[0].Classname)
+	assert.Equal(t, "1.500", suite.TestCases[0].Time)
+	assert.Equal(t, "0.250", suite.TestCases[1].Time)
+}
+
+func TestWriteDir(t *testing.T) {
+	diffs := []DiffSum{
+		{CRName: "apps/v1_Deployment_ns_app", CorrelatedTemplate: "deploy.yaml", Part: "Apps", Component: "Frontend", DiffOutput: "app diff"},
+		{CRName: "v1_ConfigMap_ns_settings", CorrelatedTemplate: "cm.yaml", Part: "Apps", Component: "Frontend"},
+		{CRName: "v1_Namespace_ns", CorrelatedTemplate: "ns.yaml", DiffOutput: "ns diff"},
+	}
+	dir := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, Output{Summary: &Summary{NumDiffCRs: 2, TotalCRs: 3}, Diffs: &diffs}.WriteDir(dir))
+
+	content, err := os.ReadFile(filepath.Join(dir, "Apps", "Frontend", "apps-v1_Deployment_ns_app.diff"))
+	require.NoError(t, err)
+	assert.Equal(t, "app diff", string(content))
+	content, err = os.ReadFile(filepath.Join(dir, "v1_Namespace_ns.diff"))
+	require.NoError(t, err)
+	assert.Equal(t, "ns diff", string(content))
+	assert.NoFileExists(t, filepath.Join(dir, "Apps", "Frontend", "v1_ConfigMap_ns_settings.diff"))
+
+	content, err = os.ReadFile(filepath.Join(dir, summaryFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "NumDiffCRs: 2")
+	assert.Contains(t, string(content), "TotalCRs: 3")
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier:Apache-2.0
+
+// Package outputschema describes the json and yaml outputs of cluster-compare (-o json, -o yaml), for the programs
+// that read the reports. The outputs carry their version in outputApiVersion. Within a version the outputs only gain
+// fields: fields are never removed, renamed or given another type, so programs decoding a version with these structs
+// or validating it with JSONSchema keep working with later releases. Changes that break these guarantees get a new
+// version.
+package outputschema
+
+import (
+	_ "embed"
+	"slices"
+)
+
+// APIVersion is the version of the outputs described by this package.
+const APIVersion = "v1"
+
+//go:embed output.schema.json
+var schema []byte
+
+// JSONSchema returns the JSON Schema (draft-07) of the outputs of the APIVersion version.
+func JSONSchema() []byte {
+	return slices.Clone(schema)
+}
+
+// Output is the output of the comparison of a cluster.
+type Output struct {
+	OutputAPIVersion string    `json:"outputApiVersion"`
+	Summary          *Summary  `json:"Summary"`
+	Diffs            []DiffSum `json:"Diffs"`
+}
+
+// Summary sums up the comparison.
+type Summary struct {
+	Cluster *ClusterInfo `json:"Cluster,omitempty"`
+	// ValidationIssues are grouped by part (or by kind of issue) and by component. The field name keeps its historical
+	// spelling.
+	ValidationIssues map[string]map[string]ValidationIssue `json:"ValidationIssuses"`
+	NumMissing       int                                   `json:"NumMissing"`
+	UnmatchedCRS     []string                              `json:"UnmatchedCRS"`
+	NumDiffCRs       int                                   `json:"NumDiffCRs"`
+	NumSuppressedCRs int                                   `json:"NumSuppressedCRs,omitempty"`
+	TotalCRs         int                                   `json:"TotalCRs"`
+	MetadataHash     string                                `json:"MetadataHash"`
+	PatchedCRs       int                                   `json:"patchedCRs"`
+	OnlyValidation   bool                                  `json:"OnlyValidation,omitempty"`
+	DiffsBySeverity  map[string]int                        `json:"DiffsBySeverity,omitempty"`
+	Namespaces       map[string]*NamespaceSummary          `json:"Namespaces,omitempty"`
+	Parts            map[string]*PartSummary               `json:"Parts,omitempty"`
+	Coverage         *Coverage                             `json:"Coverage,omitempty"`
+	FlappingFields   []FlappingField                       `json:"FlappingFields,omitempty"`
+	Overrides        []OverrideStatus                      `json:"Overrides,omitempty"`
+	UnusedOverrides  []string                              `json:"UnusedOverrides,omitempty"`
+	FetchFailures    []FetchFailure                        `json:"FetchFailures,omitempty"`
+	Warnings         []string                              `json:"Warnings,omitempty"`
+}
+
+// ClusterInfo identifies the live cluster that was compared.
+type ClusterInfo struct {
+	Context           string `json:"Context,omitempty"`
+	Server            string `json:"Server,omitempty"`
+	ClusterID         string `json:"ClusterID,omitempty"`
+	KubernetesVersion string `json:"KubernetesVersion,omitempty"`
+	OpenShiftVersion  string `json:"OpenShiftVersion,omitempty"`
+}
+
+// ValidationIssue is an issue of a component of the reference, e.g. its missing CRs.
+type ValidationIssue struct {
+	Msg        string                `json:"Msg,omitempty"`
+	CRs        []string              `json:"CRs,omitempty"`
+	CRMetadata map[string]CRMetadata `json:"crMetadata,omitempty"`
+}
+
+// CRMetadata describes a CR of a validation issue.
+type CRMetadata struct {
+	Description string `json:"description,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// NamespaceSummary sums up the CRs of a namespace (--group-by namespace).
+type NamespaceSummary struct {
+	NumDiffCRs   int      `json:"NumDiffCRs"`
+	TotalCRs     int      `json:"TotalCRs"`
+	MissingCRs   []string `json:"MissingCRs,omitempty"`
+	UnmatchedCRs []string `json:"UnmatchedCRs,omitempty"`
+}
+
+// ComponentSummary counts the templates and the CRs of a component of the reference (--group-by part).
+type ComponentSummary struct {
+	Templates        int `json:"Templates"`
+	MatchedTemplates int `json:"MatchedTemplates"`
+	TotalCRs         int `json:"TotalCRs"`
+	NumDiffCRs       int `json:"NumDiffCRs"`
+	NumMissing       int `json:"NumMissing"`
+	PatchedCRs       int `json:"patchedCRs"`
+}
+
+// PartSummary counts the templates and the CRs of a part of the reference, in total and by component.
+type PartSummary struct {
+	ComponentSummary
+	Components map[string]*ComponentSummary `json:"Components"`
+}
+
+// TemplateCoverage counts the templates matched by at least one cluster CR, Percentage is their share of the
+// templates.
+type TemplateCoverage struct {
+	Templates        int     `json:"Templates"`
+	MatchedTemplates int     `json:"MatchedTemplates"`
+	Percentage       float64 `json:"Percentage"`
+}
+
+// PartCoverage is the coverage of a part of the reference, in total and by component.
+type PartCoverage struct {
+	TemplateCoverage
+	Components map[string]*TemplateCoverage `json:"Components"`
+}
+
+// Coverage is the coverage of the reference (--coverage or --min-coverage), in total and by part.
+type Coverage struct {
+	TemplateCoverage
+	Parts map[string]*PartCoverage `json:"Parts"`
+}
+
+// FlappingField is a field of a CR that changed between the snapshots (--detect-flapping).
+type FlappingField struct {
+	CRName   string `json:"CRName"`
+	Template string `json:"Template"`
+	Path     string `json:"Path"`
+}
+
+// OverrideStatus describes a user override that was loaded.
+type OverrideStatus struct {
+	Name         string `json:"name"`
+	TemplatePath string `json:"templatePath"`
+	AgeDays      int    `json:"ageDays"`
+	ExpiresAt    string `json:"expiresAt,omitempty"`
+	Owner        string `json:"owner,omitempty"`
+	TicketURL    string `json:"ticketURL,omitempty"`
+}
+
+// FetchFailure is a kind whose CRs couldn't be listed from the live cluster.
+type FetchFailure struct {
+	Kind  string `json:"Kind"`
+	Error string `json:"Error"`
+}
+
+// DiffSum is the comparison of a cluster CR to the template it was matched to.
+type DiffSum struct {
+	DiffOutput           string            `json:"DiffOutput"`
+	CorrelatedTemplate   string            `json:"CorrelatedTemplate"`
+	CRName               string            `json:"CRName"`
+	Part                 string            `json:"Part,omitempty"`
+	Component            string            `json:"Component,omitempty"`
+	Patched              string            `json:"Patched,omitempty"`
+	OverrideReasons      []string          `json:"OverrideReason,omitempty"`
+	Description          string            `json:"description,omitempty"`
+	DocsURL              string            `json:"docsURL,omitempty"`
+	RemediationURL       string            `json:"remediationURL,omitempty"`
+	Severity             string            `json:"Severity,omitempty"`
+	Acknowledgements     []string          `json:"Acknowledgements,omitempty"`
+	MaintenanceWindowEnd string            `json:"maintenanceWindowEnd,omitempty"`
+	Group                string            `json:"group,omitempty"`
+	CapturedValues       map[string]string `json:"capturedValues,omitempty"`
+	DiffScore            *int              `json:"diffScore,omitempty"`
+	RejectedCandidates   []CandidateScore  `json:"rejectedCandidates,omitempty"`
+	Alternatives         []AlternativeDiff `json:"alternatives,omitempty"`
+}
+
+// CandidateScore is a template a cluster CR was compared to and the score of the match strategy (--verbose).
+type CandidateScore struct {
+	Template  string `json:"template"`
+	DiffScore int    `json:"diffScore"`
+}
+
+// AlternativeDiff is the diff of a cluster CR against another template of its component (reportAllMatches).
+type AlternativeDiff struct {
+	CorrelatedTemplate string `json:"CorrelatedTemplate"`
+	DiffOutput         string `json:"DiffOutput"`
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package outputschema_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openshift/kube-compare/pkg/compare"
+	"github.com/openshift/kube-compare/pkg/compare/outputschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// TestGoldenOutputs validates the json outputs of the tests of the compare command against the schema, and decodes
+// them with the structs of the package without ignoring any field.
+func TestGoldenOutputs(t *testing.T) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(outputschema.JSONSchema()))
+	require.NoError(t, err)
+	goldens, err := filepath.Glob("../testdata/*/*out.golden")
+	require.NoError(t, err)
+	found := 0
+	for _, golden := range goldens {
+		content, err := os.ReadFile(golden)
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(nil, len(content)+1)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if !bytes.HasPrefix(line, []byte(`{"outputApiVersion"`)) {
+				continue
+			}
+			found++
+			t.Run(golden, func(t *testing.T) {
+				result, err := schema.Validate(gojsonschema.NewBytesLoader(line))
+				require.NoError(t, err)
+				assert.Empty(t, result.Errors())
+
+				decoder := json.NewDecoder(bytes.NewReader(line))
+				decoder.DisallowUnknownFields()
+				output := outputschema.Output{}
+				require.NoError(t, decoder.Decode(&output))
+				assert.Equal(t, outputschema.APIVersion, output.OutputAPIVersion)
+			})
+		}
+	}
+	assert.NotZero(t, found, "no json output found in the golden files")
+}
+
+// TestSchemaFields checks that the schema and the structs of the package describe the fields of the outputs of the
+// compare command.
+func TestSchemaFields(t *testing.T) {
+	var schema struct {
+		Properties  map[string]any `json:"properties"`
+		Definitions map[string]struct {
+			Properties map[string]any `json:"properties"`
+		} `json:"definitions"`
+	}
+	require.NoError(t, json.Unmarshal(outputschema.JSONSchema(), &schema))
+
+	published := map[string][]string{}
+	jsonFields(reflect.TypeOf(outputschema.Output{}), published)
+	for name, fields := range published {
+		properties := schema.Properties
+		if name != "Output" {
+			require.Contains(t, schema.Definitions, name)
+			properties = schema.Definitions[name].Properties
+		}
+		assert.ElementsMatch(t, fields, keys(properties), "fields of %s", name)
+	}
+
+	actual := map[string][]string{}
+	jsonFields(reflect.TypeOf(compare.Output{}), actual)
+	for name, fields := range actual {
+		require.Contains(t, published, name)
+		assert.ElementsMatch(t, fields, published[name], "fields of %s", name)
+	}
+}
+
+// jsonFields collects the json fields of the struct and of the structs of its fields, by struct name.
+func jsonFields(typ reflect.Type, fields map[string][]string) {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+	if _, ok := fields[typ.Name()]; ok {
+		return
+	}
+	name := typ.Name()
+	fields[name] = []string{}
+	var visit func(reflect.Type)
+	visit = func(typ reflect.Type) {
+		for i := range typ.NumField() {
+			field := typ.Field(i)
+			if field.Anonymous {
+				// The fields of embedded structs are inlined
+				visit(field.Type)
+				continue
+			}
+			jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || jsonName == "-" {
+				continue
+			}
+			fields[name] = append(fields[name], jsonName)
+			jsonFields(field.Type, fields)
+		}
+	}
+	visit(typ)
+}
+
+func keys(m map[string]any) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	return res
+}
@@ -7,6 +7,9 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"text/template/parse"
 
@@ -20,6 +23,15 @@ type Reference interface {
 	GetValidationIssues(matchedTemplates map[string]int) (map[string]map[string]ValidationIssue, int)
 	GetFieldsToOmit() FieldsToOmit
 	GetTemplateFunctionFiles() []string
+	GetCorrelationGroups() [][][]string
+	GetSharedCapturegroups() []string
+	GetConsistentCapturegroups() []ConsistentCapturegroups
+	GetListsAsSets() []*ListAsSetV2
+	GetClusterVersionRange() (string, string)
+	GetAssertions() []*AssertionV2
+	GetPackage() ReferencePackage
+	// selectTemplates removes the templates that aren't selected from the reference
+	selectTemplates(selected func(part, component, templatePath string) bool)
 }
 
 type ReferenceTemplate interface {
@@ -31,12 +43,23 @@ type ReferenceTemplate interface {
 	GetConfig() TemplateConfig
 	GetTemplateTree() *parse.Tree
 	GetDescription() string
+	GetPartAndComponent() (string, string)
+	GetReportAllMatches() bool
+	GetExpectedNamespaces() []string
+	MatchesConditions(clusterCR *unstructured.Unstructured) (bool, error)
 }
 
 type TemplateConfig interface {
 	GetAllowMerge() bool
 	GetFieldsToOmitRefs() []string
 	GetInlineDiffFuncs() map[string]inlineDiffType
+	GetIsolatedFields() map[string]bool
+	GetDefaults() map[string]any
+	GetListsAsSets() []*ListAsSetV2
+	GetSeverity() string
+	GetCatchAll() bool
+	GetDocsURL() string
+	GetRemediationURL() string
 }
 
 type FieldsToOmit interface {
@@ -73,6 +96,9 @@ func GetReference(fsys fs.FS, referenceFileName string) (Reference, error) {
 	} else if strings.EqualFold(version, ReferenceVersionV2) {
 		ref, err := getReferenceV2(fsys, referenceFileName)
 		return ref, err
+	} else if strings.EqualFold(version, ReferenceVersionV3) {
+		ref, err := getReferenceV3(fsys, referenceFileName)
+		return ref, err
 	}
 	return nil, fmt.Errorf("unknown reference file apiVersion: '%s'", version)
 
@@ -83,23 +109,98 @@ func parseYaml[T any](fsys fs.FS, filePath string, structType *T, fileNotFoundEr
 	if err != nil {
 		return fmt.Errorf(fileNotFoundError, err)
 	}
-	err = yaml.UnmarshalStrict(file, structType)
+	return unmarshalYaml(file, structType, parsingError)
+}
+
+func unmarshalYaml[T any](content []byte, structType *T, parsingError string) error {
+	err := yaml.UnmarshalStrict(content, structType)
 	if err != nil {
-		return fmt.Errorf(parsingError, err)
+		return fmt.Errorf(parsingError, suggestFieldName(err, reflect.TypeOf(structType)))
 	}
 	return nil
 }
 
+var unknownFieldRe = regexp.MustCompile(`unknown field "([^"]+)"`)
+
+// suggestFieldName adds the most similar field name to the error of an unknown field, the names are the json names of
+// the fields of the type and of all the types it contains.
+func suggestFieldName(err error, t reflect.Type) error {
+	match := unknownFieldRe.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	unknown := strings.ToLower(match[1])
+	best, bestDistance := "", len(unknown)/3+2
+	for _, name := range jsonFieldNames(t, map[reflect.Type]bool{}) {
+		if distance := levenshtein(unknown, strings.ToLower(name)); distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	if best == "" {
+		return err
+	}
+	return fmt.Errorf("%w, did you mean %q?", err, best)
+}
+
+func jsonFieldNames(t reflect.Type, visited map[reflect.Type]bool) []string {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return jsonFieldNames(t.Elem(), visited)
+	case reflect.Struct:
+	default:
+		return nil
+	}
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+			names = append(names, name)
+		}
+		names = append(names, jsonFieldNames(field.Type, visited)...)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// levenshtein returns the number of single character edits needed to change a into b.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ar {
+		cur := make([]int, len(br)+1)
+		cur[0] = i + 1
+		for j := range br {
+			cost := 1
+			if ar[i] == br[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(br)]
+}
+
 type UserConfig struct {
 	CorrelationSettings CorrelationSettings `json:"correlationSettings"`
 }
 
 type CorrelationSettings struct {
 	ManualCorrelation ManualCorrelation `json:"manualCorrelation"`
+	// NamespaceMappings maps placeholders used in the templates (e.g. {{ .ztp_ns }}) to the namespaces of the cluster.
+	NamespaceMappings map[string]string `json:"namespaceMappings"`
 }
 
 type ManualCorrelation struct {
-	CorrelationPairs map[string]string `json:"correlationPairs"`
+	CorrelationPairs      map[string]string `json:"correlationPairs"`
+	RegexCorrelationPairs map[string]string `json:"regexCorrelationPairs"`
 }
 
 func parseDiffConfig(filePath string) (UserConfig, error) {
@@ -112,6 +213,13 @@ func parseDiffConfig(filePath string) (UserConfig, error) {
 	return result, err
 }
 
+// parseDiffConfigContent parses the content of a diff config, as passed to the gRPC server.
+func parseDiffConfigContent(content []byte) (UserConfig, error) {
+	result := UserConfig{}
+	err := unmarshalYaml(content, &result, userConfigNotInFormat)
+	return result, err
+}
+
 func ParseTemplates(ref Reference, fsys fs.FS) ([]ReferenceTemplate, error) {
 	if strings.EqualFold(ref.GetAPIVersion(), ReferenceVersionV1) {
 		refV1 := ref.(*ReferenceV1)
@@ -119,13 +227,25 @@ func ParseTemplates(ref Reference, fsys fs.FS) ([]ReferenceTemplate, error) {
 	} else if strings.EqualFold(ref.GetAPIVersion(), ReferenceVersionV2) {
 		refV2 := ref.(*ReferenceV2)
 		return ParseV2Templates(refV2, fsys)
+	} else if strings.EqualFold(ref.GetAPIVersion(), ReferenceVersionV3) {
+		refV3 := ref.(*ReferenceV3)
+		return ParseV2Templates(&refV3.ReferenceV2, fsys)
 	}
 
 	return nil, fmt.Errorf("unknown reference file apiVersion: '%s'", ref.GetAPIVersion())
 }
 
+// ConsistentCapturegroups are the names of the capturegroups that must capture the same value in all the CRs matched by
+// the templates of a part or a component.
+type ConsistentCapturegroups struct {
+	Scope     string
+	Names     []string
+	Templates []string
+}
+
 type CRMetadata struct {
 	Description string `json:"description,omitempty"`
+	Reason      string `json:"reason,omitempty"`
 }
 
 type ValidationIssue struct {
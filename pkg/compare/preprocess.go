@@ -0,0 +1,111 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	emptyPreprocessExec  = "The pre-processing commands (--pre-process-exec) can't be empty"
+	preprocessFailed     = "failed to pre-process %s: %w"
+	preprocessExecFailed = "command %q failed: %w"
+	preprocessExecOutput = "command %q didn't print a CR: %w"
+)
+
+// CRPreprocessor transforms the cluster CRs before they are correlated to the templates of the reference, so the
+// quirks of a site (e.g. sealed secrets, injected sidecars) can be normalized without modifying the reference. The
+// preprocessors are called concurrently, and they can modify the CR they are passed.
+type CRPreprocessor interface {
+	Preprocess(ctx context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error)
+}
+
+// CRPreprocessorFunc adapts a function to the CRPreprocessor interface.
+type CRPreprocessorFunc func(ctx context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error)
+
+func (f CRPreprocessorFunc) Preprocess(ctx context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return f(ctx, clusterCR)
+}
+
+var crPreprocessors []CRPreprocessor
+
+// RegisterCRPreprocessor registers a preprocessor of the cluster CRs, programs embedding the command can use it to plug
+// in their own normalizations. The registered preprocessors run in the order they were registered, before the
+// commands of --pre-process-exec, for the commands and engines created after their registration.
+func RegisterCRPreprocessor(preprocessor CRPreprocessor) error {
+	if preprocessor == nil {
+		return errors.New("the preprocessor is required")
+	}
+	crPreprocessors = append(crPreprocessors, preprocessor)
+	return nil
+}
+
+// execPreprocessor runs a command that is passed the cluster CR as json on its standard input and prints the
+// transformed CR, as json or yaml, on its standard output (--pre-process-exec).
+type execPreprocessor struct {
+	command []string
+}
+
+// newExecPreprocessor returns the preprocessor of a command, its arguments are separated by spaces.
+func newExecPreprocessor(command string) (*execPreprocessor, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New(emptyPreprocessExec)
+	}
+	return &execPreprocessor{command: fields}, nil
+}
+
+func (p *execPreprocessor) String() string {
+	return strings.Join(p.command, " ")
+}
+
+func (p *execPreprocessor) Preprocess(ctx context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	input, err := json.Marshal(clusterCR.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the CR: %w", err)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...) //nolint:gosec // the commands are passed by the user
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, fmt.Errorf(preprocessExecFailed, p, err)
+	}
+	obj := map[string]any{}
+	if err := yaml.Unmarshal(stdout.Bytes(), &obj); err != nil {
+		return nil, fmt.Errorf(preprocessExecOutput, p, err)
+	}
+	if len(obj) == 0 {
+		return nil, fmt.Errorf(preprocessExecOutput, p, errors.New("the output is empty"))
+	}
+	return &unstructured.Unstructured{Object: obj}, nil
+}
+
+// preprocess passes the cluster CR through the preprocessors, in order.
+func (o *Options) preprocess(ctx context.Context, clusterCR *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	name := apiKindNamespaceName(clusterCR)
+	for _, p := range o.preprocessors {
+		res, err := p.Preprocess(ctx, clusterCR)
+		if err == nil && res == nil {
+			err = errors.New("the preprocessor didn't return a CR")
+		}
+		if err != nil {
+			return nil, fmt.Errorf(preprocessFailed, name, err)
+		}
+		clusterCR = res
+	}
+	return clusterCR, nil
+}
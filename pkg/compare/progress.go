@@ -0,0 +1,167 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	ProgressAuto = "auto"
+	ProgressNone = "none"
+	ProgressBar  = "bar"
+	ProgressLog  = "log"
+
+	unknownProgress = "Unknown --progress value %q, supported values: %s"
+
+	// progressBarWidth is the number of characters of the bar, without the percent and the counts
+	progressBarWidth = 30
+	// progressLogStep is the percent of the CRs processed between the lines of the log progress
+	progressLogStep = 10
+	// progressLogCount is the number of CRs processed between the lines of the log progress while the CRs are still
+	// collected and their total isn't known
+	progressLogCount = 1000
+)
+
+var ProgressOptions = []string{ProgressAuto, ProgressNone, ProgressBar, ProgressLog}
+
+// progressReporter reports the progress of the processing of the cluster CRs to stderr: the percent of the CRs
+// processed and the kind of the last one. The bar is redrawn on the same line after every CR and is meant for
+// terminals, the log prints a line every progressLogStep percent. When the CRs are processed while they are collected
+// the percent is only known once all of them were collected, until then the number of CRs processed is reported. A nil
+// reporter reports nothing.
+type progressReporter struct {
+	lock        sync.Mutex
+	w           io.Writer
+	bar         bool
+	total       int
+	known       bool
+	done        int
+	lastLogStep int
+}
+
+func newProgressReporter(w io.Writer, mode string) *progressReporter {
+	switch mode {
+	case ProgressBar:
+		return &progressReporter{w: w, bar: true}
+	case ProgressLog:
+		return &progressReporter{w: w}
+	}
+	return nil
+}
+
+// collecting reports that the cluster CRs are being collected, before their number is known.
+func (p *progressReporter) collecting() {
+	if p == nil {
+		return
+	}
+	if p.bar {
+		fmt.Fprint(p.w, "\rCollecting the CRs...")
+	} else {
+		fmt.Fprintln(p.w, "Collecting the CRs")
+	}
+}
+
+// start resets the progress for the processing of total cluster CRs, -1 when the total isn't known yet.
+func (p *progressReporter) start(total int) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.total, p.known, p.done, p.lastLogStep = max(total, 0), total >= 0, 0, 0
+	switch {
+	case p.bar:
+		p.drawBar("")
+	case p.known:
+		fmt.Fprintf(p.w, "Comparing %d CRs\n", total)
+	default:
+		fmt.Fprintln(p.w, "Comparing the CRs as they are collected")
+	}
+}
+
+// found counts a cluster CR that was collected when the total wasn't known.
+func (p *progressReporter) found() {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.known {
+		p.total++
+	}
+}
+
+// collected reports that all the cluster CRs were collected, their total is known.
+func (p *progressReporter) collected() {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.known = true
+}
+
+// processed reports that the cluster CR was processed. It's called concurrently by the workers processing the CRs.
+func (p *progressReporter) processed(clusterCR *unstructured.Unstructured) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.done++
+	if p.bar {
+		p.drawBar(clusterCR.GetKind())
+		return
+	}
+	if !p.known {
+		if p.done%progressLogCount == 0 {
+			fmt.Fprintf(p.w, "Processed %d CRs, current kind: %s\n", p.done, clusterCR.GetKind())
+		}
+		return
+	}
+	step := p.percent() / progressLogStep
+	if step > p.lastLogStep {
+		p.lastLogStep = step
+		fmt.Fprintf(p.w, "Processed %d/%d CRs (%d%%), current kind: %s\n", p.done, p.total, p.percent(), clusterCR.GetKind())
+	}
+}
+
+// finish ends the line of the bar so the next messages aren't printed over it.
+func (p *progressReporter) finish() {
+	if p == nil || !p.bar {
+		return
+	}
+	fmt.Fprintln(p.w)
+}
+
+func (p *progressReporter) percent() int {
+	if p.total == 0 {
+		return 100
+	}
+	return p.done * 100 / p.total
+}
+
+func (p *progressReporter) drawBar(kind string) {
+	if !p.known {
+		line := fmt.Sprintf("\rProcessed %d/%d CRs collected so far", p.done, p.total)
+		if kind != "" {
+			line += " " + kind
+		}
+		fmt.Fprint(p.w, line+"\x1b[K")
+		return
+	}
+	filled := p.percent() * progressBarWidth / 100
+	line := fmt.Sprintf("\r[%s%s] %3d%% (%d/%d)", strings.Repeat("#", filled), strings.Repeat(".", progressBarWidth-filled),
+		p.percent(), p.done, p.total)
+	if kind != "" {
+		line += " " + kind
+	}
+	// Erase the end of the previous line, that may be longer
+	fmt.Fprint(p.w, line+"\x1b[K")
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestProgressReporter(t *testing.T) {
+	crs := make([]*unstructured.Unstructured, 4)
+	for i := range crs {
+		crs[i] = &unstructured.Unstructured{}
+		crs[i].SetKind("Deployment")
+	}
+	crs[3].SetKind("ConfigMap")
+	run := func(mode string) string {
+		var out bytes.Buffer
+		p := newProgressReporter(&out, mode)
+		p.collecting()
+		p.start(len(crs))
+		for _, cr := range crs {
+			p.processed(cr)
+		}
+		p.finish()
+		return out.String()
+	}
+
+	t.Run("None", func(t *testing.T) {
+		assert.Empty(t, run(ProgressNone))
+	})
+
+	t.Run("Log", func(t *testing.T) {
+		assert.Equal(t, "Collecting the CRs\n"+
+			"Comparing 4 CRs\n"+
+			"Processed 1/4 CRs (25%), current kind: Deployment\n"+
+			"Processed 2/4 CRs (50%), current kind: Deployment\n"+
+			"Processed 3/4 CRs (75%), current kind: Deployment\n"+
+			"Processed 4/4 CRs (100%), current kind: ConfigMap\n", run(ProgressLog))
+	})
+
+	t.Run("Log While Collecting", func(t *testing.T) {
+		var out bytes.Buffer
+		p := newProgressReporter(&out, ProgressLog)
+		p.start(-1)
+		for i := 0; i < progressLogCount+1; i++ {
+			p.found()
+			p.processed(crs[0])
+		}
+		p.found()
+		p.collected()
+		p.processed(crs[3])
+		assert.Equal(t, "Comparing the CRs as they are collected\n"+
+			"Processed 1000 CRs, current kind: Deployment\n"+
+			"Processed 1002/1002 CRs (100%), current kind: ConfigMap\n", out.String())
+	})
+
+	t.Run("Bar", func(t *testing.T) {
+		out := run(ProgressBar)
+		assert.Contains(t, out, "\r[##############################] 100% (4/4) ConfigMap\x1b[K\n")
+		assert.Contains(t, out, "\r[#######.......................]  25% (1/4) Deployment\x1b[K")
+	})
+}
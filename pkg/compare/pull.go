@@ -0,0 +1,295 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	pullLong = templates.LongDesc(`
+		Download a reference configuration and all the files it uses (its templates, template function files and the
+		references it imports) into a local bundle, to transfer it into disconnected environments.
+
+		The bundle is a directory, or a tar archive when the output ends with .tar, .tar.gz or .tgz. It contains the
+		files of the reference with the same layout as the source, and a manifest (bundle.yaml) with the source of the
+		reference, the sha256 checksum of each file and the metadata hash of the reference, which is the hash printed in
+		the summary of the compare command. The signed checksums of the reference (SHA256SUMS and SHA256SUMS.sig) are
+		copied too when the source has them, so the bundle can still be verified with --verify-signature.
+	`)
+
+	pullExample = templates.Examples(`
+		# Download a reference published on a http server into a directory:
+		kubectl cluster-compare pull -r https://example.com/reference/metadata.yaml -o ./reference
+
+		# Download a reference into a tar archive:
+		kubectl cluster-compare pull -r https://example.com/reference/metadata.yaml -o ./reference.tar.gz
+
+		# Compare a cluster to the reference in the disconnected environment:
+		kubectl cluster-compare -r ./reference/metadata.yaml
+	`)
+)
+
+const (
+	bundleManifestFile = "bundle.yaml"
+
+	pullOutputRequired  = "The output of the bundle (-o) is required"
+	pullOutputNotEmpty  = "Output directory %s already exists and isn't empty"
+	pullOutsideOfSource = "the reference reads %s, which is outside of the source %s"
+)
+
+// BundleManifest describes a reference pulled into a local bundle: where it was pulled from and the files of the
+// bundle, with their checksums.
+type BundleManifest struct {
+	Source string `json:"source"`
+	// Reference is the path of the reference config in the bundle
+	Reference    string       `json:"reference"`
+	MetadataHash string       `json:"metadataHash"`
+	PulledAt     string       `json:"pulledAt"`
+	Files        []BundleFile `json:"files"`
+}
+
+// BundleFile is a file of the reference in the bundle.
+type BundleFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// PullOptions are the options of the pull subcommand.
+type PullOptions struct {
+	referenceConfig string
+	output          string
+
+	// files are the contents of the files of the bundle, by their path in the bundle
+	files    map[string][]byte
+	manifest BundleManifest
+	genericiooptions.IOStreams
+}
+
+func newPullCmd(streams genericiooptions.IOStreams) *cobra.Command {
+	options := &PullOptions{IOStreams: streams}
+	cmd := &cobra.Command{
+		Use:                   "pull -r <Reference File> -o <Directory or Archive>",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Download a reference and the files it uses into a local bundle."),
+		Long:                  pullLong,
+		Example:               pullExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			kcmdutil.CheckErr(options.Complete(cmd))
+			kcmdutil.CheckErr(options.Run())
+		},
+	}
+	options.addFlags(cmd)
+	return cmd
+}
+
+func (o *PullOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.referenceConfig, "reference", "r", "", "Path or URL of the reference config file.")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "",
+		"Path of the directory to write the bundle to, or of the tar archive when it ends with .tar, .tar.gz or .tgz")
+}
+
+// Complete reads the reference and all the files it uses from its source.
+func (o *PullOptions) Complete(cmd *cobra.Command) error {
+	if o.referenceConfig == "" {
+		return kcmdutil.UsageErrorf(cmd, noRefFileWasPassed)
+	}
+	if o.output == "" {
+		return kcmdutil.UsageErrorf(cmd, pullOutputRequired)
+	}
+	if _, err := os.Stat(o.referenceConfig); os.IsNotExist(err) && !isURL(o.referenceConfig) {
+		return errors.New(refFileNotExistsError)
+	}
+	if !isTarOutput(o.output) {
+		if entries, err := os.ReadDir(o.output); err == nil && len(entries) > 0 {
+			return kcmdutil.UsageErrorf(cmd, pullOutputNotEmpty, o.output)
+		}
+	}
+	cfs, err := GetRefFS(o.referenceConfig)
+	if err != nil {
+		return err
+	}
+	recorder := recordingFS{fsys: cfs, files: make(map[string][]byte)}
+	referenceFileName := filepath.Base(o.referenceConfig)
+	ref, err := GetReference(recorder, referenceFileName)
+	if err != nil {
+		return err
+	}
+	templates, err := ParseTemplates(ref, recorder)
+	if err != nil {
+		return err
+	}
+	// The signed checksums aren't used by the reference, they are only copied when the source has them
+	for _, name := range []string{signedChecksumsFile, signedChecksumsFile + signatureExtension} {
+		_, _ = fs.ReadFile(recorder, name)
+	}
+
+	paths, err := bundlePaths(o.referenceConfig, lo.Keys(recorder.files))
+	if err != nil {
+		return err
+	}
+	o.files = make(map[string][]byte)
+	o.manifest = BundleManifest{
+		Source:       o.referenceConfig,
+		Reference:    paths[referenceFileName],
+		MetadataHash: metadataHash(ref, templates),
+		PulledAt:     now().Format(time.RFC3339),
+	}
+	for name, content := range recorder.files {
+		o.files[paths[name]] = content
+		o.manifest.Files = append(o.manifest.Files, BundleFile{Path: paths[name], SHA256: fmt.Sprintf("%x", sha256.Sum256(content))})
+	}
+	sort.Slice(o.manifest.Files, func(i, j int) bool {
+		return o.manifest.Files[i].Path < o.manifest.Files[j].Path
+	})
+	return nil
+}
+
+// Run writes the bundle to the output directory or archive.
+func (o *PullOptions) Run() error {
+	manifest, err := yaml.Marshal(o.manifest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the bundle manifest: %w", err)
+	}
+	o.files[bundleManifestFile] = manifest
+	if isTarOutput(o.output) {
+		err = writeTarBundle(o.output, o.files)
+	} else {
+		err = writeDirBundle(o.output, o.files)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(o.Out, "Pulled %d files of the reference to %s\nReference: %s\nMetadata Hash: %s\n",
+		len(o.manifest.Files), o.output, o.manifest.Reference, o.manifest.MetadataHash)
+	return err // nolint:wrapcheck
+}
+
+func isTarOutput(output string) bool {
+	return strings.HasSuffix(output, ".tar") || strings.HasSuffix(output, ".tar.gz") || strings.HasSuffix(output, ".tgz")
+}
+
+// bundlePaths maps the paths of the files read, relative to the directory of the reference config, to their paths
+// in the bundle. The files of the references imported from the parent directories (../) keep their layout: the
+// bundle is rooted at the highest directory of the source that is read.
+func bundlePaths(referenceConfig string, names []string) (map[string]string, error) {
+	dir := filepath.ToSlash(filepath.Dir(referenceConfig))
+	if u, err := url.Parse(referenceConfig); err == nil && isURL(referenceConfig) {
+		dir = path.Dir(u.Path)
+	} else if abs, err := filepath.Abs(filepath.Dir(referenceConfig)); err == nil {
+		dir = filepath.ToSlash(abs)
+	}
+	parents := strings.Split(strings.Trim(dir, "/"), "/")
+
+	up := 0
+	for _, name := range names {
+		elems := strings.Split(path.Clean(name), "/")
+		n := 0
+		for n < len(elems) && elems[n] == ".." {
+			n++
+		}
+		if n > len(parents) || (n > 0 && parents[0] == "") {
+			return nil, fmt.Errorf(pullOutsideOfSource, name, referenceConfig)
+		}
+		up = max(up, n)
+	}
+	base := path.Join(parents[len(parents)-up:]...)
+	paths := make(map[string]string, len(names))
+	for _, name := range names {
+		paths[name] = path.Join(base, path.Clean(name))
+	}
+	return paths, nil
+}
+
+func writeDirBundle(dir string, files map[string][]byte) error {
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return fmt.Errorf("failed to create the directory of %s: %w", p, err)
+		}
+		if err := os.WriteFile(p, content, 0o644); err != nil { // nolint:gosec
+			return fmt.Errorf("failed to write %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func writeTarBundle(archive string, files map[string][]byte) (err error) {
+	f, err := os.Create(archive)
+	if err != nil {
+		return fmt.Errorf("failed to create the bundle archive: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+	var w io.Writer = f
+	if !strings.HasSuffix(archive, ".tar") {
+		gz := gzip.NewWriter(f)
+		defer func() {
+			err = errors.Join(err, gz.Close())
+		}()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	names := lo.Keys(files)
+	sort.Strings(names)
+	for _, name := range names {
+		header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), ModTime: now()}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write %s to the bundle archive: %w", name, err)
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return fmt.Errorf("failed to write %s to the bundle archive: %w", name, err)
+		}
+	}
+	return tw.Close() // nolint:wrapcheck
+}
+
+// recordingFS keeps the content of all the files of the reference that are read, to copy them into the bundle.
+type recordingFS struct {
+	fsys  fs.FS
+	files map[string][]byte
+}
+
+func (r recordingFS) Open(name string) (fs.File, error) {
+	f, err := r.fsys.Open(name)
+	if err != nil {
+		return nil, err // nolint:wrapcheck
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	if info.IsDir() {
+		return r.fsys.Open(name) // nolint:wrapcheck
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	r.files[path.Clean(name)] = content
+	return &contentFile{Reader: bytes.NewReader(content), info: info}, nil
+}
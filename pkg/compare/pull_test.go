@@ -0,0 +1,139 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"sigs.k8s.io/yaml"
+)
+
+func TestPull(t *testing.T) {
+	pull := func(t *testing.T, reference, output string) (string, error) {
+		streams, _, out, _ := genericiooptions.NewTestIOStreams()
+		o := &PullOptions{IOStreams: streams}
+		cmd := &cobra.Command{}
+		o.addFlags(cmd)
+		require.NoError(t, cmd.Flags().Set("reference", reference))
+		require.NoError(t, cmd.Flags().Set("output", output))
+		if err := o.Complete(cmd); err != nil {
+			return "", err
+		}
+		err := o.Run()
+		return out.String(), err
+	}
+	readFile := func(t *testing.T, name string) []byte {
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return content
+	}
+	readManifest := func(t *testing.T, content []byte) BundleManifest {
+		manifest := BundleManifest{}
+		require.NoError(t, yaml.Unmarshal(content, &manifest))
+		return manifest
+	}
+	bundlePathsOf := func(manifest BundleManifest) []string {
+		var paths []string
+		for _, f := range manifest.Files {
+			paths = append(paths, f.Path)
+		}
+		return paths
+	}
+
+	t.Run("Template Function Files", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "bundle")
+		out, err := pull(t, "testdata/RefWithTemplateFunctionsRendersAsExpected/reference/metadata.yaml", dir)
+		require.NoError(t, err)
+		assert.Contains(t, out, "Pulled 3 files of the reference to "+dir+"\nReference: metadata.yaml\n")
+
+		manifest := readManifest(t, readFile(t, filepath.Join(dir, bundleManifestFile)))
+		assert.Equal(t, []string{"cm.yaml", "metadata.yaml", "validate_functions"}, bundlePathsOf(manifest))
+		assert.Equal(t, "metadata.yaml", manifest.Reference)
+		assert.Equal(t,
+			readFile(t, "testdata/RefWithTemplateFunctionsRendersAsExpected/reference/validate_functions"),
+			readFile(t, filepath.Join(dir, "validate_functions")))
+
+		// The bundle is a reference with the same hash
+		cfs, err := GetRefFS(filepath.Join(dir, manifest.Reference))
+		require.NoError(t, err)
+		ref, err := GetReference(cfs, manifest.Reference)
+		require.NoError(t, err)
+		templates, err := ParseTemplates(ref, cfs)
+		require.NoError(t, err)
+		assert.Equal(t, manifest.MetadataHash, metadataHash(ref, templates))
+	})
+
+	t.Run("Imported References", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "bundle")
+		_, err := pull(t, "testdata/ReferenceImports/reference/site/metadata.yaml", dir)
+		require.NoError(t, err)
+		manifest := readManifest(t, readFile(t, filepath.Join(dir, bundleManifestFile)))
+		assert.Equal(t, "site/metadata.yaml", manifest.Reference)
+		// The Metrics component of the base is replaced by the site, its template isn't part of the reference
+		assert.Equal(t, []string{
+			"base/deploymentDashboard.yaml",
+			"base/metadata.yaml",
+			"site/configmap.yaml",
+			"site/deploymentMetrics.yaml",
+			"site/metadata.yaml",
+		}, bundlePathsOf(manifest))
+		_, err = os.Stat(filepath.Join(dir, "base", "metadata.yaml"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("HTTP To Archive", func(t *testing.T) {
+		server := httptest.NewServer(http.FileServer(http.Dir("testdata/VerifySignature/reference")))
+		defer server.Close()
+		archive := filepath.Join(t.TempDir(), "bundle.tar.gz")
+		_, err := pull(t, server.URL+"/metadata.yaml", archive)
+		require.NoError(t, err)
+
+		f, err := os.Open(archive)
+		require.NoError(t, err)
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		files := make(map[string][]byte)
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			files[header.Name], err = io.ReadAll(tr)
+			require.NoError(t, err)
+		}
+		manifest := readManifest(t, files[bundleManifestFile])
+		assert.Equal(t, server.URL+"/metadata.yaml", manifest.Source)
+		// The signed checksums are copied, the bundle can still be verified
+		assert.Equal(t, []string{
+			"SHA256SUMS", "SHA256SUMS.sig", "deploymentDashboard.yaml", "deploymentMetrics.yaml", "metadata.yaml",
+		}, bundlePathsOf(manifest))
+		assert.Equal(t, readFile(t, "testdata/VerifySignature/reference/SHA256SUMS.sig"), files["SHA256SUMS.sig"])
+	})
+
+	t.Run("Output Not Empty", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "metadata.yaml"), []byte{}, 0o600))
+		_, err := pull(t, "testdata/SomeDiffs/reference/metadata.yaml", dir)
+		assert.ErrorContains(t, err, "Output directory "+dir+" already exists and isn't empty")
+	})
+
+	t.Run("Missing Output", func(t *testing.T) {
+		_, err := pull(t, "testdata/SomeDiffs/reference/metadata.yaml", "")
+		assert.ErrorContains(t, err, pullOutputRequired)
+	})
+}
@@ -0,0 +1,40 @@
+package compare
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	k8sQuantity inlineDiffType = "k8sQuantity"
+)
+
+// QuantityInlineDiff compares the values as Kubernetes quantities, so equal amounts in different units, such as 1Gi and
+// 1024Mi or 500m and 0.5, match.
+type QuantityInlineDiff struct{}
+
+func (id QuantityInlineDiff) structured() {}
+
+func (id QuantityInlineDiff) Diff(templateValue, crValue string, sharedCapturedValues CapturedValues) (string, CapturedValues) {
+	templateQuantity, err := resource.ParseQuantity(templateValue)
+	if err != nil {
+		return templateValue, sharedCapturedValues
+	}
+	crQuantity, err := resource.ParseQuantity(crValue)
+	if err != nil || templateQuantity.Cmp(crQuantity) != 0 {
+		return templateValue, sharedCapturedValues
+	}
+	return crValue, sharedCapturedValues
+}
+
+func (id QuantityInlineDiff) Validate(templateValue string) error {
+	// The value is empty when the template is rendered without data
+	if templateValue == "" {
+		return nil
+	}
+	if _, err := resource.ParseQuantity(templateValue); err != nil {
+		return fmt.Errorf("invalid quantity passed to inline k8sQuantity diff function: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineQuantityDiff(t *testing.T) {
+	tests := []struct {
+		template string
+		input    string
+		expected string
+	}{
+		{template: "1Gi", input: "1024Mi", expected: "1024Mi"},
+		{template: "500m", input: "0.5", expected: "0.5"},
+		{template: "2", input: "2000m", expected: "2000m"},
+		{template: "1G", input: "1Gi", expected: "1G"},
+		{template: "500m", input: "1", expected: "500m"},
+		{template: "500m", input: "half", expected: "500m"},
+	}
+	for _, test := range tests {
+		t.Run(test.template+" "+test.input, func(t *testing.T) {
+			diff := QuantityInlineDiff{}
+			assert.NoError(t, diff.Validate(test.template))
+			result, _ := diff.Diff(test.template, test.input, CapturedValues{})
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestInlineQuantityValidate(t *testing.T) {
+	assert.Error(t, QuantityInlineDiff{}.Validate("1 GB"))
+}
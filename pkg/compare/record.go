@@ -0,0 +1,59 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	recordNotInLive     = "Recording the cluster CRs (--record) is only supported when comparing live clusters"
+	recordDirNotEmpty   = "Record directory %s already exists and isn't empty"
+	recordFileExtension = ".yaml"
+)
+
+// crRecorder writes the cluster CRs read from the live cluster to a directory (--record), one file per CR, so the
+// comparison can be replayed offline by comparing the directory in local mode (-f <dir>). It's called concurrently.
+type crRecorder struct {
+	dir  string
+	lock sync.Mutex
+	err  error
+}
+
+// newCRRecorder creates the record directory.
+func newCRRecorder(dir string) (*crRecorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the record directory: %w", err)
+	}
+	return &crRecorder{dir: dir}, nil
+}
+
+// record writes the CR to <dir>/<apiVersion>_<kind>_<namespace>_<name>.yaml. The errors are kept to be reported once
+// the CRs are collected, by firstError.
+func (r *crRecorder) record(clusterCR *unstructured.Unstructured) {
+	content, err := yaml.Marshal(clusterCR.Object)
+	if err == nil {
+		path := filepath.Join(r.dir, outputFileName(apiKindNamespaceName(clusterCR))+recordFileExtension)
+		err = os.WriteFile(path, content, 0o644) // nolint:gosec
+	}
+	if err != nil {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if r.err == nil {
+			r.err = fmt.Errorf("failed to record %s: %w", apiKindNamespaceName(clusterCR), err)
+		}
+	}
+}
+
+// firstError returns the first error that occurred while recording the CRs.
+func (r *crRecorder) firstError() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/openshift/kube-compare/pkg/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func TestRecordReplay(t *testing.T) {
+	recordDir := filepath.Join(t.TempDir(), "record")
+	test := defaultTest("SomeDiffs").withModes([]Mode{{Live, LocalRef}}).withRecord(recordDir)
+	tf := cmdtesting.NewTestFactory()
+	defer tf.Cleanup()
+
+	// The command continues after a fatal error when the handler doesn't exit, only the first error is kept
+	var code int
+	defer cmdutil.DefaultBehaviorOnFatal()
+	cmdutil.BehaviorOnFatal(func(_ string, c int) {
+		if code == 0 {
+			code = c
+		}
+	})
+
+	streams, _, out, _ := genericiooptions.NewTestIOStreams()
+	cmd := getCommand(t, &test, 0, tf, &streams)
+	cmd.Run(cmd, []string{})
+	require.Equal(t, 1, code)
+	live := testutils.RemoveInconsistentInfo(t, out.String(), test.fixupOpts)
+
+	entries, err := os.ReadDir(recordDir)
+	require.NoError(t, err)
+	var recorded []string
+	for _, entry := range entries {
+		recorded = append(recorded, entry.Name())
+	}
+	sort.Strings(recorded)
+	assert.Equal(t, []string{
+		"apps-v1_Deployment_kubernetes-dashboard_dashboard-metrics-scraper.yaml",
+		"apps-v1_Deployment_kubernetes-dashboard_kubernetes-dashboard.yaml",
+	}, recorded)
+
+	// Comparing the recorded CRs offline reproduces the comparison, without the information of the live cluster
+	code = 0
+	streams, _, out, _ = genericiooptions.NewTestIOStreams()
+	cmd = NewCmd(tf, streams)
+	require.NoError(t, cmd.Flags().Set("reference", path.Join(test.getTestDir(), TestRefDirName, test.referenceFileName)))
+	require.NoError(t, cmd.Flags().Set("filename", recordDir))
+	require.NoError(t, cmd.Flags().Set("recursive", "true"))
+	cmd.Run(cmd, []string{})
+	require.Equal(t, 1, code)
+	live = strings.Replace(live, "Cluster: http://localhost:8080\nKubernetes Version: v1.30.0\n", "", 1)
+	assert.Equal(t, live, testutils.RemoveInconsistentInfo(t, out.String(), test.fixupOpts))
+
+	// The CRs of a previous recording aren't mixed with the new ones
+	code = 0
+	streams, _, _, errOut := genericiooptions.NewTestIOStreams()
+	cmdutil.BehaviorOnFatal(func(msg string, c int) {
+		if code == 0 {
+			code = c
+			_, _ = errOut.WriteString(msg)
+		}
+	})
+	cmd = getCommand(t, &test, 0, tf, &streams)
+	cmd.Run(cmd, []string{})
+	assert.Equal(t, 2, code)
+	assert.Contains(t, errOut.String(), "Record directory "+recordDir+" already exists and isn't empty")
+}
@@ -3,17 +3,22 @@
 package compare
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"path"
+	"regexp"
+	"slices"
 	"strings"
 	"text/template"
 	"text/template/parse"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
 )
@@ -55,13 +60,37 @@ func (r *ReferenceV1) getTemplates() []*ReferenceTemplateV1 {
 	var templates []*ReferenceTemplateV1
 	for _, part := range r.Parts {
 		for _, comp := range part.Components {
-			templates = append(templates, comp.RequiredTemplates...)
-			templates = append(templates, comp.OptionalTemplates...)
+			for _, t := range append(slices.Clone(comp.RequiredTemplates), comp.OptionalTemplates...) {
+				t.partName, t.componentName = part.Name, comp.Name
+				templates = append(templates, t)
+			}
 		}
 	}
 	return templates
 }
 
+// selectTemplates removes the templates that aren't selected, with the components and the parts left without
+// templates.
+func (r *ReferenceV1) selectTemplates(selected func(part, component, templatePath string) bool) {
+	parts := make([]PartV1, 0, len(r.Parts))
+	for _, part := range r.Parts {
+		components := make([]ComponentV1, 0, len(part.Components))
+		for _, comp := range part.Components {
+			notSelected := func(t *ReferenceTemplateV1) bool { return !selected(part.Name, comp.Name, t.Path) }
+			comp.RequiredTemplates = slices.DeleteFunc(comp.RequiredTemplates, notSelected)
+			comp.OptionalTemplates = slices.DeleteFunc(comp.OptionalTemplates, notSelected)
+			if len(comp.RequiredTemplates)+len(comp.OptionalTemplates) > 0 {
+				components = append(components, comp)
+			}
+		}
+		part.Components = components
+		if len(components) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	r.Parts = parts
+}
+
 func (r *ReferenceV1) GetTemplates() []ReferenceTemplate {
 	var templates []ReferenceTemplate
 	// Repackage getTemplates into []ReferenceTemplate
@@ -80,6 +109,34 @@ func (r *ReferenceV1) GetTemplateFunctionFiles() []string {
 	return r.TemplateFunctionFiles
 }
 
+func (r *ReferenceV1) GetCorrelationGroups() [][][]string {
+	return nil
+}
+
+func (r *ReferenceV1) GetSharedCapturegroups() []string {
+	return nil
+}
+
+func (r *ReferenceV1) GetConsistentCapturegroups() []ConsistentCapturegroups {
+	return nil
+}
+
+func (r *ReferenceV1) GetListsAsSets() []*ListAsSetV2 {
+	return nil
+}
+
+func (r *ReferenceV1) GetClusterVersionRange() (string, string) {
+	return "", ""
+}
+
+func (r *ReferenceV1) GetAssertions() []*AssertionV2 {
+	return nil
+}
+
+func (r *ReferenceV1) GetPackage() ReferencePackage {
+	return ReferencePackage{}
+}
+
 func (c *ComponentV1) getMissingCRs(matchedTemplates map[string]int) ValidationIssue {
 	var crs []string
 	metadata := make(map[string]CRMetadata)
@@ -147,6 +204,8 @@ func getReferenceV1(fsys fs.FS, referenceFileName string) (*ReferenceV1, error)
 type FieldsToOmitV1 struct {
 	DefaultOmitRef string                       `json:"defaultOmitRef,omitempty"`
 	Items          map[string][]*ManifestPathV1 `json:"items,omitempty"`
+	// items are the Items and the curated groups the reference doesn't define
+	items map[string][]*ManifestPathV1
 }
 
 func (toOmit *FieldsToOmitV1) GetDefault() string {
@@ -154,7 +213,7 @@ func (toOmit *FieldsToOmitV1) GetDefault() string {
 }
 
 func (toOmit *FieldsToOmitV1) GetItems() map[string][]*ManifestPathV1 {
-	return toOmit.Items
+	return toOmit.items
 }
 
 const (
@@ -175,15 +234,23 @@ func (toOmit *FieldsToOmitV1) process() error {
 
 	toOmit.Items[builtInPathsKey] = builtInPathsV1
 
+	toOmit.items = make(map[string][]*ManifestPathV1, len(toOmit.Items)+len(curatedOmitGroups))
+	for key, paths := range curatedOmitGroups {
+		toOmit.items[key] = paths
+	}
+	for key, paths := range toOmit.Items {
+		toOmit.items[key] = paths
+	}
+
 	if toOmit.DefaultOmitRef == "" {
 		toOmit.DefaultOmitRef = builtInPathsKey
 	}
 
-	if _, ok := toOmit.Items[toOmit.DefaultOmitRef]; !ok {
+	if _, ok := toOmit.items[toOmit.DefaultOmitRef]; !ok {
 		return fmt.Errorf(fieldsToOmitDefaultNotFound, toOmit.DefaultOmitRef)
 	}
 	errs := make([]error, 0)
-	for _, pathsArray := range toOmit.Items {
+	for _, pathsArray := range toOmit.items {
 		for _, path := range pathsArray {
 			err := path.Process()
 			if err != nil {
@@ -207,16 +274,53 @@ func (config ReferenceTemplateConfigV1) GetInlineDiffFuncs() map[string]inlineDi
 	return map[string]inlineDiffType{}
 }
 
+func (config ReferenceTemplateConfigV1) GetIsolatedFields() map[string]bool {
+	return map[string]bool{}
+}
+
 func (config ReferenceTemplateConfigV1) GetFieldsToOmitRefs() []string {
 	return config.FieldsToOmitRefs
 }
 
+func (config ReferenceTemplateConfigV1) GetDefaults() map[string]any {
+	return nil
+}
+
+func (config ReferenceTemplateConfigV1) GetListsAsSets() []*ListAsSetV2 {
+	return nil
+}
+
+func (config ReferenceTemplateConfigV1) GetSeverity() string {
+	return ""
+}
+
+func (config ReferenceTemplateConfigV1) GetCatchAll() bool {
+	return false
+}
+
+func (config ReferenceTemplateConfigV1) GetDocsURL() string {
+	return ""
+}
+
+func (config ReferenceTemplateConfigV1) GetRemediationURL() string {
+	return ""
+}
+
 type ReferenceTemplateV1 struct {
 	*template.Template `json:"-"`
 	Path               string                    `json:"path"`
 	Description        string                    `json:"description,omitempty"`
 	Config             ReferenceTemplateConfigV1 `json:"config,omitempty"`
 	metadata           *unstructured.Unstructured
+	// withoutAssertions renders the template when there is no cluster CR to assert
+	withoutAssertions *template.Template
+	// Templates that render multiple yaml documents are split into a template per document, document is the index
+	// of the document in the rendered template.
+	multiDocument bool
+	document      int
+	// partName and componentName are the names of the part and the component the template belongs to
+	partName      string
+	componentName string
 }
 
 func (rf ReferenceTemplateV1) GetFieldsToOmit(fieldsToOmit FieldsToOmit) []*ManifestPathV1 {
@@ -252,36 +356,148 @@ func (rf ReferenceTemplateV1) ValidateFieldsToOmit(fieldsToOmit FieldsToOmit) er
 
 const noValue = "<no value>"
 
-func (rf ReferenceTemplateV1) Exec(params map[string]any) (*unstructured.Unstructured, error) {
+func (rf *ReferenceTemplateV1) setFuncs(funcs template.FuncMap) {
+	if rf.Template != nil {
+		rf.Template.Funcs(funcs)
+	}
+	if rf.withoutAssertions != nil {
+		rf.withoutAssertions.Funcs(funcs)
+	}
+}
+
+// render executes the template. The assertions of the template (required, failCompare) are only evaluated when it's
+// rendered for a cluster CR, every CR has a kind.
+func (rf ReferenceTemplateV1) render(params map[string]any) ([]byte, error) {
+	t := rf.Template
+	if _, ok := params["kind"]; !ok && rf.withoutAssertions != nil {
+		t = rf.withoutAssertions
+	}
 	var buf bytes.Buffer
-	err := rf.Template.Execute(&buf, params)
+	err := t.Execute(&buf, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to constuct template: %w", err)
 	}
+	return buf.Bytes(), nil
+}
+
+func (rf ReferenceTemplateV1) Exec(params map[string]any) (*unstructured.Unstructured, error) {
+	content, err := rf.render(params)
+	if err != nil {
+		return nil, err
+	}
+	if rf.multiDocument {
+		docs, err := splitDocuments(content)
+		if err != nil {
+			return nil, fmt.Errorf("template: %s failed to split the yaml documents after injection: %w", rf.GetIdentifier(), err)
+		}
+		if rf.document >= len(docs) {
+			return nil, fmt.Errorf("template: %s renders only %d yaml documents after injection", rf.GetIdentifier(), len(docs))
+		}
+		content = docs[rf.document]
+	}
 	data := make(map[string]any)
-	content := buf.Bytes()
-	err = yaml.Unmarshal(bytes.ReplaceAll(content, []byte(noValue), []byte("")), &data)
+	withoutNoValues := bytes.ReplaceAll(content, []byte(noValue), []byte(""))
+	err = yaml.Unmarshal(withoutNoValues, &data)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"template: %s isn't a yaml file after injection. yaml unmarshal error: %w. The Template After Execution: %s",
-			rf.GetIdentifier(), err, string(content),
-		)
+		return nil, rf.yamlError(params, content, err)
+	}
+	// The fields marked as non-authoritative are only taken from the cluster CR when the template is rendered for one
+	if _, ok := params["kind"]; ok {
+		if err := ignoreMarkedFields(withoutNoValues, data, params); err != nil {
+			return nil, fmt.Errorf("template: %s: %w", rf.GetIdentifier(), err)
+		}
 	}
 	return &unstructured.Unstructured{Object: data}, nil
 }
 
+func (rf ReferenceTemplateV1) MatchesConditions(_ *unstructured.Unstructured) (bool, error) {
+	return true, nil
+}
+
 func (rf ReferenceTemplateV1) GetPath() string {
 	return rf.Path
 }
 
 func (rf ReferenceTemplateV1) GetIdentifier() string {
+	if rf.multiDocument {
+		return fmt.Sprintf("%s#%d", rf.GetPath(), rf.document)
+	}
 	return rf.GetPath()
 }
 
+// splitDocuments splits a rendered template into its yaml documents.
+func splitDocuments(content []byte) ([][]byte, error) {
+	var docs [][]byte
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read yaml document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// documentIndexes returns the indexes of the non empty yaml documents of a rendered template.
+func documentIndexes(content []byte) ([]int, error) {
+	docs, err := splitDocuments(content)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []int
+	for i, doc := range docs {
+		data := make(map[string]any)
+		if err := yaml.Unmarshal(bytes.ReplaceAll(doc, []byte(noValue), []byte("")), &data); err != nil || len(data) > 0 {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, nil
+}
+
+// splitIntoDocuments returns a template per yaml document rendered by the template when it renders more than one
+// document, otherwise the template itself is returned.
+func (rf *ReferenceTemplateV1) splitIntoDocuments(params map[string]any) ([]*ReferenceTemplateV1, error) {
+	content, err := rf.render(params)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := documentIndexes(content)
+	if err != nil {
+		return nil, fmt.Errorf("template: %s failed to split the yaml documents: %w", rf.GetPath(), err)
+	}
+	if len(indexes) <= 1 {
+		return []*ReferenceTemplateV1{rf}, nil
+	}
+	var docs []*ReferenceTemplateV1
+	for _, i := range indexes {
+		doc := *rf
+		doc.multiDocument = true
+		doc.document = i
+		docs = append(docs, &doc)
+	}
+	return docs, nil
+}
+
 func (rf ReferenceTemplateV1) GetDescription() string {
 	return rf.Description
 }
 
+// GetPartAndComponent returns the names of the part and the component the template belongs to.
+func (rf ReferenceTemplateV1) GetPartAndComponent() (string, string) {
+	return rf.partName, rf.componentName
+}
+
+func (rf ReferenceTemplateV1) GetExpectedNamespaces() []string {
+	return nil
+}
+
+func (rf ReferenceTemplateV1) GetReportAllMatches() bool {
+	return false
+}
+
 func (rf ReferenceTemplateV1) GetMetadata() *unstructured.Unstructured {
 	return rf.metadata
 }
@@ -308,18 +524,69 @@ var builtInPathsV1 = []*ManifestPathV1{
 	{PathToKey: "status"},
 }
 
+// The curated groups of fields that controllers and deployment tools manage, that references can select with
+// fieldsToOmitRefs besides their own groups. The groups of the reference with the same names take precedence.
+const (
+	kubernetesBuiltInKey = "kubernetes-built-in"
+	openshiftBuiltInKey  = "openshift-built-in"
+	helmBuiltInKey       = "helm-built-in"
+	argocdBuiltInKey     = "argocd-built-in"
+)
+
+// curatedOmitGroups are the groups of fields to omit that are available to every reference. Unlike
+// cluster-compare-built-in they aren't added to the items of the reference, so they don't change its metadata hash.
+var curatedOmitGroups = map[string][]*ManifestPathV1{
+	kubernetesBuiltInKey: {
+		{PathToKey: `metadata.annotations."deployment.kubernetes.io/revision"`},
+		{PathToKey: `metadata.annotations."deprecated.daemonset.template.generation"`},
+		{PathToKey: `metadata.annotations."kubernetes.io/change-cause"`},
+		{PathToKey: `spec.template.metadata.annotations."kubectl.kubernetes.io/restartedAt"`},
+	},
+	openshiftBuiltInKey: {
+		{PathToKey: `metadata.annotations."openshift.io/sa.scc.mcs"`},
+		{PathToKey: `metadata.annotations."openshift.io/sa.scc.supplemental-groups"`},
+		{PathToKey: `metadata.annotations."openshift.io/sa.scc.uid-range"`},
+		{PathToKey: `metadata.annotations."operator.openshift.io/spec-hash"`},
+		{PathToKey: `metadata.annotations."operator.openshift.io/dep-*"`},
+		{PathToKey: `metadata.annotations."olm.operatorGroup"`},
+		{PathToKey: `metadata.annotations."olm.operatorNamespace"`},
+		{PathToKey: `metadata.annotations."olm.targetNamespaces"`},
+		{PathToKey: `metadata.labels."olm.operatorgroup.uid/*"`},
+	},
+	helmBuiltInKey: {
+		{PathToKey: `metadata.annotations."meta.helm.sh/release-name"`},
+		{PathToKey: `metadata.annotations."meta.helm.sh/release-namespace"`},
+		{PathToKey: `metadata.labels."app.kubernetes.io/managed-by"`},
+		{PathToKey: `metadata.labels."helm.sh/chart"`},
+	},
+	argocdBuiltInKey: {
+		{PathToKey: `metadata.annotations."argocd.argoproj.io/tracking-id"`},
+		{PathToKey: `metadata.labels."app.kubernetes.io/instance"`},
+		{PathToKey: `metadata.labels."argocd.argoproj.io/instance"`},
+	},
+}
+
+// ManifestPathV1 is a path of fields to omit. Besides dot-paths it accepts list indexes and map keys in brackets
+// (spec.containers[0], metadata.labels["app"]) and * wildcards that match any part of a map key or list index
+// (spec.containers[*].image, metadata.labels.app*).
 type ManifestPathV1 struct {
 	PathToKey string `json:"pathToKey"`
 	IsPrefix  bool   `json:"isPrefix,omitempty"`
 	parts     []string
 }
 
+// listIndexBrackets matches the list indexes and wildcards in bracket notation, a[0] and a[*]
+var listIndexBrackets = regexp.MustCompile(`\[(\*|\d+)\]`)
+
 func (p *ManifestPathV1) Process() error {
 	if len(p.parts) > 0 {
 		return nil
 	}
 	var err error
-	p.parts, err = pathToList(p.PathToKey)
+	p.parts, err = pathToList(bracketsToQuotes(listIndexBrackets.ReplaceAllString(p.PathToKey, ".$1")))
+	if err == nil && p.IsPrefix {
+		p.parts[len(p.parts)-1] += "*"
+	}
 	return err
 }
 
@@ -338,31 +605,45 @@ func ParseV1Templates(ref *ReferenceV1, fsys fs.FS) ([]ReferenceTemplate, error)
 	var errs []error
 	var result []ReferenceTemplate
 	functionTemplates := ref.TemplateFunctionFiles
-	for _, temp := range ref.getTemplates() {
-		result = append(result, temp)
-		parsedTemp, err := template.New(path.Base(temp.Path)).Funcs(FuncMap()).ParseFS(fsys, temp.Path)
+	for _, refTemp := range ref.getTemplates() {
+		parsedTemp, err := template.New(path.Base(refTemp.Path)).Funcs(FuncMap()).ParseFS(fsys, refTemp.Path)
 		if err != nil {
-			errs = append(errs, fmt.Errorf(templatesCantBeParsed, temp.Path, err))
+			result = append(result, refTemp)
+			errs = append(errs, fmt.Errorf(templatesCantBeParsed, refTemp.Path, err))
 			continue
 		}
 		if len(functionTemplates) > 0 {
 			parsedTemp, err = parsedTemp.ParseFS(fsys, functionTemplates...)
 			if err != nil {
+				result = append(result, refTemp)
 				errs = append(errs, fmt.Errorf(templatesFunctionsCantBeParsed, err))
 				continue
 			}
 		}
-		temp.Template = parsedTemp
-		temp.metadata, err = temp.Exec(map[string]any{}) // Extract Metadata
+		refTemp.Template = parsedTemp
+		refTemp.withoutAssertions, err = withoutAssertions(parsedTemp)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to parse template %s with empty data: %w", temp.Path, err))
+			result = append(result, refTemp)
+			errs = append(errs, err)
+			continue
 		}
-		err = temp.ValidateFieldsToOmit(ref.FieldsToOmit)
+		docs, err := refTemp.splitIntoDocuments(map[string]any{})
 		if err != nil {
-			errs = append(errs, err)
+			docs = []*ReferenceTemplateV1{refTemp}
 		}
-		if temp.metadata != nil && temp.metadata.GetKind() == "" {
-			errs = append(errs, fmt.Errorf("template missing kind: %s", temp.Path))
+		for _, temp := range docs {
+			result = append(result, temp)
+			temp.metadata, err = temp.Exec(map[string]any{}) // Extract Metadata
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to parse template %s with empty data: %w", temp.Path, err))
+			}
+			err = temp.ValidateFieldsToOmit(ref.FieldsToOmit)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if temp.metadata != nil && temp.metadata.GetKind() == "" {
+				errs = append(errs, fmt.Errorf("template missing kind: %s", temp.GetIdentifier()))
+			}
 		}
 	}
 	return result, errors.Join(errs...) // nolint:wrapcheck
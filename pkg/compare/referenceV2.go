@@ -3,28 +3,54 @@
 package compare
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/url"
 	"path"
 	"reflect"
 	"slices"
+	"strconv"
 	"strings"
 	"text/template"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/klog/v2"
 )
 
 const ReferenceVersionV2 string = "v2"
 
+// DefaultsKey is the key of the defaults of the template (config.defaults) in the data the template is executed with
+const DefaultsKey = "Defaults"
+
 type ReferenceV2 struct {
 	Version           string `json:"apiVersion,omitempty"`
 	normalisedVersion string
+	// Name, ReferenceVersion, Vendor and ReleaseNotesURL describe the package of the reference, they aren't imported
+	Name             string `json:"name,omitempty"`
+	ReferenceVersion string `json:"version,omitempty"`
+	Vendor           string `json:"vendor,omitempty"`
+	ReleaseNotesURL  string `json:"releaseNotesURL,omitempty"`
+	// Imports are the paths, relative to the reference, of the references it extends
+	Imports []string `json:"imports,omitempty"`
 
 	Parts                 []*PartV2       `json:"parts"`
 	TemplateFunctionFiles []string        `json:"templateFunctionFiles,omitempty"`
 	FieldsToOmit          *FieldsToOmitV2 `json:"fieldsToOmit,omitempty"`
+	CorrelationGroups     [][]string      `json:"correlationGroups,omitempty"`
+	correlationGroups     [][][]string
+	// SharedCapturegroups are the names of the capturegroups whose values are shared by all the CRs matched by the
+	// same template, the value captured for the first CR is expected from the others.
+	SharedCapturegroups []string `json:"sharedCapturegroups,omitempty"`
+	// ListsAsSets are the lists of all the templates whose order is insignificant
+	ListsAsSets []*ListAsSetV2 `json:"listsAsSets,omitempty"`
+	// MinClusterVersion and MaxClusterVersion are the range of the versions of the clusters the reference applies to
+	MinClusterVersion string `json:"minClusterVersion,omitempty"`
+	MaxClusterVersion string `json:"maxClusterVersion,omitempty"`
+	// Assertions are the relationship assertions evaluated over all the compared cluster CRs
+	Assertions []*AssertionV2 `json:"assertions,omitempty"`
 }
 
 func (r *ReferenceV2) GetAPIVersion() string {
@@ -40,6 +66,32 @@ func (r *ReferenceV2) getTemplates() []*ReferenceTemplateV2 {
 	return templates
 }
 
+// selectTemplates removes the templates that aren't selected, with the components and the parts left without
+// templates.
+func (r *ReferenceV2) selectTemplates(selected func(part, component, templatePath string) bool) {
+	parts := make([]*PartV2, 0, len(r.Parts))
+	for _, part := range r.Parts {
+		components := make([]*ComponentV2, 0, len(part.Components))
+		for _, comp := range part.Components {
+			numTemplates := 0
+			for _, g := range comp.groups() {
+				g.templates = slices.DeleteFunc(g.templates, func(t *ReferenceTemplateV2) bool {
+					return !selected(part.Name, comp.Name, t.Path)
+				})
+				numTemplates += len(g.templates)
+			}
+			if numTemplates > 0 {
+				components = append(components, comp)
+			}
+		}
+		part.Components = components
+		if len(components) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	r.Parts = parts
+}
+
 func (r *ReferenceV2) GetTemplates() []ReferenceTemplate {
 	var templates []ReferenceTemplate
 	// Repackage getTemplates into []ReferenceTemplate
@@ -58,6 +110,89 @@ func (r *ReferenceV2) GetTemplateFunctionFiles() []string {
 	return r.TemplateFunctionFiles
 }
 
+func (r *ReferenceV2) GetCorrelationGroups() [][][]string {
+	return r.correlationGroups
+}
+
+func (r *ReferenceV2) GetSharedCapturegroups() []string {
+	return r.SharedCapturegroups
+}
+
+func (r *ReferenceV2) GetListsAsSets() []*ListAsSetV2 {
+	return r.ListsAsSets
+}
+
+func (r *ReferenceV2) GetClusterVersionRange() (string, string) {
+	return r.MinClusterVersion, r.MaxClusterVersion
+}
+
+func (r *ReferenceV2) GetAssertions() []*AssertionV2 {
+	return r.Assertions
+}
+
+func (r *ReferenceV2) GetPackage() ReferencePackage {
+	return ReferencePackage{Name: r.Name, Version: r.ReferenceVersion, Vendor: r.Vendor, ReleaseNotesURL: r.ReleaseNotesURL}
+}
+
+func (r *ReferenceV2) GetConsistentCapturegroups() []ConsistentCapturegroups {
+	var res []ConsistentCapturegroups
+	for _, part := range r.Parts {
+		var partTemplates []string
+		for _, comp := range part.Components {
+			var compTemplates []string
+			for _, temp := range comp.getTemplates(part) {
+				compTemplates = append(compTemplates, temp.GetPath())
+			}
+			partTemplates = append(partTemplates, compTemplates...)
+			if len(comp.ConsistentCapturegroups) > 0 {
+				res = append(res, ConsistentCapturegroups{
+					Scope:     fmt.Sprintf("%s/%s", part.Name, comp.Name),
+					Names:     comp.ConsistentCapturegroups,
+					Templates: compTemplates,
+				})
+			}
+		}
+		if len(part.ConsistentCapturegroups) > 0 {
+			res = append(res, ConsistentCapturegroups{
+				Scope:     part.Name,
+				Names:     part.ConsistentCapturegroups,
+				Templates: partTemplates,
+			})
+		}
+	}
+	return res
+}
+
+// processCorrelationGroups parses the paths of the fields in the correlation groups.
+// Map keys can be quoted (metadata.labels."app") or use brackets (metadata.labels["app"]).
+func (r *ReferenceV2) processCorrelationGroups() error {
+	errs := make([]error, 0)
+	for i, group := range r.CorrelationGroups {
+		if len(group) == 0 {
+			errs = append(errs, fmt.Errorf("correlationGroups entry %d has no fields", i))
+			continue
+		}
+		fields := make([][]string, 0, len(group))
+		for _, field := range group {
+			listedPath, err := pathToList(bracketsToQuotes(field))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("correlationGroups entry %d has a field that is not in supported format. "+
+					"path: %s. error: %w", i, field, err))
+				continue
+			}
+			fields = append(fields, listedPath)
+		}
+		r.correlationGroups = append(r.correlationGroups, fields)
+	}
+	return errors.Join(errs...)
+}
+
+// bracketsToQuotes converts map keys in bracket notation (a["b"]) into the quoted notation used by pathToKey (a."b")
+func bracketsToQuotes(path string) string {
+	path = strings.ReplaceAll(path, `["`, `."`)
+	return strings.ReplaceAll(path, `"]`, `"`)
+}
+
 func (r *ReferenceV2) validate() error {
 	errs := make([]error, 0)
 	for _, part := range r.Parts {
@@ -68,6 +203,40 @@ func (r *ReferenceV2) validate() error {
 			}
 		}
 	}
+	for _, temp := range r.getTemplates() {
+		err := processListsAsSets(temp.Config.ListsAsSets)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reference contains template %s with invalid config: %w", temp.Path, err))
+		}
+		if err := temp.validateMatchCounts(); err != nil {
+			errs = append(errs, err)
+		}
+		if temp.Config.Severity != "" && !slices.Contains(Severities, temp.Config.Severity) {
+			errs = append(errs, fmt.Errorf("reference contains template %s with unknown severity %q, supported values: %s",
+				temp.Path, temp.Config.Severity, strings.Join(Severities, ", ")))
+		}
+		for _, link := range [][2]string{{"docsURL", temp.Config.DocsURL}, {"remediationURL", temp.Config.RemediationURL}} {
+			if link[1] == "" {
+				continue
+			}
+			if u, err := url.Parse(link[1]); err != nil || u.Scheme == "" || u.Host == "" {
+				errs = append(errs, fmt.Errorf("reference contains template %s with invalid %s %q, it must be an absolute URL",
+					temp.Path, link[0], link[1]))
+			}
+		}
+	}
+	if r.ReleaseNotesURL != "" {
+		if u, err := url.Parse(r.ReleaseNotesURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("reference has invalid releaseNotesURL %q, it must be an absolute URL", r.ReleaseNotesURL))
+		}
+	}
+	if r.normalisedVersion == ReferenceVersionV2 {
+		for _, temp := range r.getTemplates() {
+			if temp.ValuesRef != "" {
+				errs = append(errs, fmt.Errorf(valuesRefRequiresV3, temp.Path))
+			}
+		}
+	}
 	return errors.Join(errs...)
 }
 
@@ -84,9 +253,9 @@ func (r *ReferenceV2) GetValidationIssues(matchedTemplates map[string]int) (map[
 	return crs, count
 }
 
-func getbuiltInPathsV2() []*FieldsToOmitV2Entry {
+func getbuiltInPathsV2(paths []*ManifestPathV1) []*FieldsToOmitV2Entry {
 	res := make([]*FieldsToOmitV2Entry, 0)
-	for _, p := range builtInPathsV1 {
+	for _, p := range paths {
 		res = append(res, &FieldsToOmitV2Entry{ManifestPathV1: p})
 	}
 	return res
@@ -123,7 +292,7 @@ func (toOmit *FieldsToOmitV2) process() error {
 
 	errs := make([]error, 0)
 
-	toOmit.Items[builtInPathsKey] = getbuiltInPathsV2()
+	toOmit.Items[builtInPathsKey] = getbuiltInPathsV2(builtInPathsV1)
 
 	if len(errs) > 0 {
 		return errors.Join(errs...)
@@ -133,7 +302,16 @@ func (toOmit *FieldsToOmitV2) process() error {
 		toOmit.DefaultOmitRef = builtInPathsKey
 	}
 
+	keys := make([]string, 0, len(toOmit.Items)+len(curatedOmitGroups))
 	for key := range toOmit.Items {
+		keys = append(keys, key)
+	}
+	for key := range curatedOmitGroups {
+		if _, ok := toOmit.Items[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
 		paths, err := processFieldsToOmitEntries(key, toOmit, []string{})
 		if err != nil {
 			errs = append(errs, err)
@@ -153,7 +331,11 @@ func processFieldsToOmitEntries(key string, toOmit *FieldsToOmitV2, previousKeys
 
 	errs := make([]error, 0)
 	paths := make([]*ManifestPathV1, 0)
-	for _, entry := range toOmit.Items[key] {
+	entries, ok := toOmit.Items[key]
+	if !ok {
+		entries = getbuiltInPathsV2(curatedOmitGroups[key])
+	}
+	for _, entry := range entries {
 		entryPaths, err := entry.process(currentKeys, toOmit)
 		if err != nil {
 			errs = append(errs, err)
@@ -216,11 +398,85 @@ func (entry *FieldsToOmitV2Entry) process(previousKeys []string, toOmit *FieldsT
 
 type ReferenceTemplateV2 struct {
 	Config    ReferenceTemplateConfigV2 `json:"config,omitempty"`
-	part      *PartV2                   `json:"-"`
-	component *ComponentV2              `json:"-"`
+	ValuesRef string                    `json:"valuesRef,omitempty"`
+	// Min and Max are the numbers of cluster CRs the template is expected to be matched to, they are checked once the
+	// template is matched. Whether the template has to be matched at all is up to the group of its component.
+	Min       *int         `json:"min,omitempty"`
+	Max       *int         `json:"max,omitempty"`
+	part      *PartV2      `json:"-"`
+	component *ComponentV2 `json:"-"`
+	values    map[string]any
 	ReferenceTemplateV1
 }
 
+// Exec executes the template, in case the template references a values file its content will be available
+// to the template as .Values, and the defaults of its config are available as .Defaults
+func (rf ReferenceTemplateV2) Exec(params map[string]any) (*unstructured.Unstructured, error) {
+	return rf.ReferenceTemplateV1.Exec(rf.withValues(params))
+}
+
+// expectedMatches describes the numbers of cluster CRs the template is expected to be matched to: >=min, <=max, a
+// range or an exact number.
+func (rf ReferenceTemplateV2) expectedMatches() string {
+	switch {
+	case rf.Min != nil && rf.Max != nil && *rf.Min == *rf.Max:
+		return strconv.Itoa(*rf.Min)
+	case rf.Min != nil && rf.Max != nil:
+		return fmt.Sprintf("%d-%d", *rf.Min, *rf.Max)
+	case rf.Min != nil:
+		return fmt.Sprintf(">=%d", *rf.Min)
+	default:
+		return fmt.Sprintf("<=%d", *rf.Max)
+	}
+}
+
+func (rf ReferenceTemplateV2) validateMatchCounts() error {
+	if rf.Min != nil && *rf.Min < 1 {
+		return fmt.Errorf("reference contains template %s with min %d, it must be at least 1", rf.Path, *rf.Min)
+	}
+	if rf.Max != nil && *rf.Max < 1 {
+		return fmt.Errorf("reference contains template %s with max %d, it must be at least 1 (use noneOf for templates "+
+			"that must not be matched)", rf.Path, *rf.Max)
+	}
+	if rf.Min != nil && rf.Max != nil && *rf.Min > *rf.Max {
+		return fmt.Errorf("reference contains template %s with min %d greater than its max %d", rf.Path, *rf.Min, *rf.Max)
+	}
+	return nil
+}
+
+func (rf ReferenceTemplateV2) withValues(params map[string]any) map[string]any {
+	if rf.values == nil && rf.Config.Defaults == nil {
+		return params
+	}
+	paramsWithValues := make(map[string]any, len(params)+2)
+	for k, v := range params {
+		paramsWithValues[k] = v
+	}
+	if rf.values != nil {
+		paramsWithValues[valuesKey] = rf.values
+	}
+	if rf.Config.Defaults != nil {
+		paramsWithValues[DefaultsKey] = rf.Config.Defaults
+	}
+	return paramsWithValues
+}
+
+// splitIntoDocuments returns a template per yaml document rendered by the template when it renders more than one
+// document, otherwise the template itself is returned.
+func (rf *ReferenceTemplateV2) splitIntoDocuments() ([]*ReferenceTemplateV2, error) {
+	v1Docs, err := rf.ReferenceTemplateV1.splitIntoDocuments(rf.withValues(map[string]any{}))
+	if err != nil || len(v1Docs) == 1 {
+		return []*ReferenceTemplateV2{rf}, err
+	}
+	var docs []*ReferenceTemplateV2
+	for _, v1Doc := range v1Docs {
+		doc := *rf
+		doc.ReferenceTemplateV1 = *v1Doc
+		docs = append(docs, &doc)
+	}
+	return docs, nil
+}
+
 func (rf ReferenceTemplateV2) GetConfig() TemplateConfig {
 	return rf.Config
 }
@@ -237,11 +493,109 @@ func (rf ReferenceTemplateV2) GetDescription() string {
 	return ""
 }
 
+func (rf ReferenceTemplateV2) GetPartAndComponent() (string, string) {
+	var part, component string
+	if rf.part != nil {
+		part = rf.part.Name
+	}
+	if rf.component != nil {
+		component = rf.component.Name
+	}
+	return part, component
+}
+
+// GetExpectedNamespaces returns the namespaces the CRs matched to the template are expected in, set by the template or
+// by its component.
+func (rf ReferenceTemplateV2) GetExpectedNamespaces() []string {
+	if len(rf.Config.ExpectedNamespaces) > 0 || rf.component == nil {
+		return rf.Config.ExpectedNamespaces
+	}
+	return rf.component.ExpectedNamespaces
+}
+
+// GetReportAllMatches returns whether the component of the template reports all the templates a CR is correlated to
+func (rf ReferenceTemplateV2) GetReportAllMatches() bool {
+	return rf.component != nil && rf.component.ReportAllMatches
+}
+
+// GetComponentGroup returns the kind of the group of templates (allOf, oneOf...) of the component of the template
+func (rf ReferenceTemplateV2) GetComponentGroup() string {
+	if rf.component == nil {
+		return ""
+	}
+	return rf.component.GetGroup()
+}
+
 type ReferenceTemplateConfigV2 struct {
-	PerField []*PerFieldConfigV2 `json:"perField,omitempty"`
+	PerField        []*PerFieldConfigV2 `json:"perField,omitempty"`
+	MatchConditions []*MatchConditionV2 `json:"matchConditions,omitempty"`
+	// Defaults are constants of the template that are set in the reference instead of the template body, they are
+	// available to the template as .Defaults
+	Defaults map[string]any `json:"defaults,omitempty"`
+	// ListsAsSets are the lists of the template whose order is insignificant, in addition to the ones of the reference
+	ListsAsSets []*ListAsSetV2 `json:"listsAsSets,omitempty"`
+	// Severity is the severity of the diffs of the CRs matched to the template, error when it's not set. Severity rules
+	// take precedence over it.
+	Severity string `json:"severity,omitempty"`
+	// CatchAll templates are only matched to the cluster CRs that none of the other templates are matched to
+	CatchAll bool `json:"catchAll,omitempty"`
+	// ExpectedNamespaces are the namespaces the CRs matched to the template are expected in, they replace the ones of
+	// the component
+	ExpectedNamespaces []string `json:"expectedNamespaces,omitempty"`
+	// DocsURL and RemediationURL link the documentation of the template and the steps that fix its diffs, they are
+	// reported with the diffs of the CRs matched to the template
+	DocsURL        string `json:"docsURL,omitempty"`
+	RemediationURL string `json:"remediationURL,omitempty"`
 	ReferenceTemplateConfigV1
 }
 
+// MatchConditionV2 is a go template expression that is executed with the cluster CR and has to render to true or false.
+// A template is only matched to the cluster CRs that meet all of its match conditions.
+type MatchConditionV2 struct {
+	Name       string `json:"name,omitempty"`
+	Expression string `json:"expression"`
+	template   *template.Template
+}
+
+func (c *MatchConditionV2) String() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Expression
+}
+
+func (rf ReferenceTemplateV2) parseMatchConditions() error {
+	var errs []error
+	for i, condition := range rf.Config.MatchConditions {
+		t, err := template.New(fmt.Sprintf("%s-matchCondition-%d", path.Base(rf.Path), i)).Funcs(FuncMap()).Parse(condition.Expression)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reference contains template %s with match condition %s that can't be parsed: %w", rf.Path, condition, err))
+			continue
+		}
+		condition.template = t
+	}
+	return errors.Join(errs...)
+}
+
+// MatchesConditions returns if the cluster CR meets all the match conditions of the template.
+func (rf ReferenceTemplateV2) MatchesConditions(clusterCR *unstructured.Unstructured) (bool, error) {
+	for _, condition := range rf.Config.MatchConditions {
+		var buf bytes.Buffer
+		err := condition.template.Execute(&buf, clusterCR.Object)
+		if err != nil {
+			return false, fmt.Errorf("failed to execute match condition %s of template %s: %w", condition, rf.Path, err)
+		}
+		res, err := strconv.ParseBool(strings.TrimSpace(buf.String()))
+		if err != nil {
+			return false, fmt.Errorf("match condition %s of template %s rendered %q instead of true or false", condition, rf.Path, buf.String())
+		}
+		if !res {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (config ReferenceTemplateConfigV2) GetInlineDiffFuncs() map[string]inlineDiffType {
 	diffFuncs := make(map[string]inlineDiffType)
 	for _, fieldConf := range config.PerField {
@@ -250,6 +604,41 @@ func (config ReferenceTemplateConfigV2) GetInlineDiffFuncs() map[string]inlineDi
 	return diffFuncs
 }
 
+func (config ReferenceTemplateConfigV2) GetDefaults() map[string]any {
+	return config.Defaults
+}
+
+func (config ReferenceTemplateConfigV2) GetListsAsSets() []*ListAsSetV2 {
+	return config.ListsAsSets
+}
+
+func (config ReferenceTemplateConfigV2) GetSeverity() string {
+	return config.Severity
+}
+
+func (config ReferenceTemplateConfigV2) GetCatchAll() bool {
+	return config.CatchAll
+}
+
+func (config ReferenceTemplateConfigV2) GetDocsURL() string {
+	return config.DocsURL
+}
+
+func (config ReferenceTemplateConfigV2) GetRemediationURL() string {
+	return config.RemediationURL
+}
+
+// GetIsolatedFields returns the fields that opted out of sharing their capturegroups with the other fields of the CR.
+func (config ReferenceTemplateConfigV2) GetIsolatedFields() map[string]bool {
+	isolated := make(map[string]bool)
+	for _, fieldConf := range config.PerField {
+		if fieldConf.ShareCapturegroups != nil && !*fieldConf.ShareCapturegroups {
+			isolated[fieldConf.PathToKey] = true
+		}
+	}
+	return isolated
+}
+
 func (rf ReferenceTemplateV2) validateConfigPerField() error {
 	for pathToKey, inlineDiffFunc := range rf.GetConfig().GetInlineDiffFuncs() {
 		listedPath, err := pathToList(pathToKey)
@@ -257,12 +646,12 @@ func (rf ReferenceTemplateV2) validateConfigPerField() error {
 			return fmt.Errorf("reference contains template with config per field with pathToKey that is not in "+
 				"supoorted format. path: %s. error: %v", pathToKey, err)
 		}
-		diffFn, ok := InlineDiffs[inlineDiffFunc]
+		diffFn, ok := getInlineDiff(inlineDiffFunc)
 		if !ok {
 			return fmt.Errorf("reference contains template with config per field with InlineDiffFunc that does not "+
 				"exist. InlineDiffFunc: %s", inlineDiffFunc)
 		}
-		value, exist, err := NestedString(rf.metadata.Object, listedPath...)
+		value, _, exist, err := nestedInlineDiffValue(rf.metadata.Object, diffFn, listedPath...)
 		if err == nil && exist {
 			if err := diffFn.Validate(value); err != nil {
 				return fmt.Errorf("reference contains template with config per field with InlineDiffFunc that fails "+
@@ -279,13 +668,26 @@ func (rf ReferenceTemplateV2) validateConfigPerField() error {
 type PerFieldConfigV2 struct {
 	PathToKey      string         `json:"pathToKey,omitempty"`
 	InlineDiffFunc inlineDiffType `json:"inlineDiffFunc,omitempty"`
+	// ShareCapturegroups controls if the values of the capturegroups of the field are shared with the other fields of
+	// the CR, it's the default. When false the capturegroups of the field only have to be consistent within the field.
+	ShareCapturegroups *bool `json:"shareCapturegroups,omitempty"`
 }
 
 type inlineDiffType string
 
 var InlineDiffs = map[inlineDiffType]InlineDiff{
-	regex:         RegexInlineDiff{},
-	capturegroups: CapturegroupsInlineDiff{},
+	regex:           RegexInlineDiff{},
+	capturegroups:   CapturegroupsInlineDiff{},
+	semverRange:     SemverRangeInlineDiff{},
+	setCompare:      SetCompareInlineDiff{},
+	multisetCompare: SetCompareInlineDiff{Multiset: true},
+
+	base64Decoded:      Base64DecodedInlineDiff{},
+	base64DecodedRegex: Base64DecodedInlineDiff{Inner: RegexInlineDiff{}},
+	k8sQuantity:        QuantityInlineDiff{},
+	x509Cert:           X509InlineDiff{},
+	ipRange:            IPRangeInlineDiff{},
+	goDuration:         DurationInlineDiff{},
 }
 
 type InlineDiff interface {
@@ -297,6 +699,9 @@ type PartV2 struct {
 	Name        string         `json:"name"`
 	Description string         `json:"description,omitempty"`
 	Components  []*ComponentV2 `json:"components"`
+	// ConsistentCapturegroups are the names of the capturegroups that must capture the same value in all the CRs
+	// matched by the templates of the part
+	ConsistentCapturegroups []string `json:"consistentCapturegroups,omitempty"`
 }
 
 func (p *PartV2) getValidationIssues(matchedTemplates map[string]int) (map[string]ValidationIssue, int) {
@@ -321,7 +726,15 @@ type ComponentV2 struct {
 	AnyOf       `json:"anyOf,omitempty"`
 	AnyOneOf    `json:"anyOneOf,omitempty"`
 	AllOrNoneOf `json:"allOrNoneOf,omitempty"`
-	parts       []ComponentV2Group
+	// ConsistentCapturegroups are the names of the capturegroups that must capture the same value in all the CRs
+	// matched by the templates of the component
+	ConsistentCapturegroups []string `json:"consistentCapturegroups,omitempty"`
+	// ReportAllMatches reports the diffs of a cluster CR against all the templates of the component it's correlated to,
+	// when there are several, instead of only the diff against the template with the lowest score
+	ReportAllMatches bool `json:"reportAllMatches,omitempty"`
+	// ExpectedNamespaces are the namespaces the CRs matched to the templates of the component are expected in
+	ExpectedNamespaces []string `json:"expectedNamespaces,omitempty"`
+	parts              []ComponentV2Group
 }
 
 type ComponentV2Group interface {
@@ -355,16 +768,23 @@ func getFieldNameFromStructTag(c *ComponentV2, s ComponentV2Group) string {
 	return strings.Split(field.Tag.Get("json"), ",")[0]
 }
 
+// componentV2GroupUnmarshalJSON decodes the templates of the group. Unknown fields are rejected, as the strictness of
+// the decoding of the reference isn't passed on to custom unmarshallers.
 func componentV2GroupUnmarshalJSON(s ComponentV2Group, b []byte) (err error) {
 	list := make([]*ReferenceTemplateV2, 0)
-	err = json.Unmarshal(b, &list)
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&list)
 	s.SetTemplates(list)
 	return err // nolint wrapcheck
 }
 
 const (
 	MissingCRsMsg      = "Missing CRs"
+	OneOfRequiredMsg   = "One of the following is required"
 	MatchedMoreThanOne = "Should only match one but matched"
+	MatchCountMsg      = "Templates matched an unexpected number of CRs"
+	expectedMatchesMsg = "expected %s matches, found %d"
 )
 
 type OneOf struct {
@@ -387,7 +807,7 @@ func (g *OneOf) getMissingCRs(matchedTemplates map[string]int) (ValidationIssue,
 	}
 	if len(matched) == 0 {
 		return ValidationIssue{
-			Msg: "One of the following is required",
+			Msg: OneOfRequiredMsg,
 			CRs: notMatched,
 		}, 1
 	}
@@ -562,9 +982,61 @@ func (comp ComponentV2) getTemplates(component *PartV2) []*ReferenceTemplateV2 {
 	return templates
 }
 
+// GetGroup returns the kind of the group of templates of the component (allOf, oneOf...)
+func (comp *ComponentV2) GetGroup() string {
+	if len(comp.parts) == 0 {
+		return ""
+	}
+	return getFieldNameFromStructTag(comp, comp.parts[0])
+}
+
 func (comp ComponentV2) getValidationIssues(matchedTemplates map[string]int) (ValidationIssue, int) {
 	// Because of the validation in ComponentV2.validate we should ave one and only one
-	return comp.parts[0].getMissingCRs(matchedTemplates)
+	issue, count := comp.parts[0].getMissingCRs(matchedTemplates)
+	countIssue, missing := comp.getMatchCountIssues(matchedTemplates)
+	if len(countIssue.CRs) == 0 {
+		return issue, count
+	}
+	if len(issue.CRs) == 0 {
+		return countIssue, count + missing
+	}
+	// The templates matched the wrong number of times are added to the issue of the group, with their counts as reason
+	issue.CRs = append(issue.CRs, countIssue.CRs...)
+	if issue.CRMetadata == nil {
+		issue.CRMetadata = make(map[string]CRMetadata)
+	}
+	for cr, md := range countIssue.CRMetadata {
+		issue.CRMetadata[cr] = md
+	}
+	return issue, count + missing
+}
+
+// getMatchCountIssues reports the matched templates of the component that are matched to fewer cluster CRs than their
+// min or more than their max. The CRs missing to reach the min are counted as missing CRs.
+func (comp ComponentV2) getMatchCountIssues(matchedTemplates map[string]int) (ValidationIssue, int) {
+	issue := ValidationIssue{Msg: MatchCountMsg, CRMetadata: make(map[string]CRMetadata)}
+	missing := 0
+	for _, g := range comp.groups() {
+		for _, temp := range g.templates {
+			n := matchedTemplates[temp.GetPath()]
+			if n == 0 {
+				continue
+			}
+			var reason string
+			switch {
+			case temp.Min != nil && n < *temp.Min:
+				reason = fmt.Sprintf(expectedMatchesMsg, temp.expectedMatches(), n)
+				missing += *temp.Min - n
+			case temp.Max != nil && n > *temp.Max:
+				reason = fmt.Sprintf(expectedMatchesMsg, temp.expectedMatches(), n)
+			default:
+				continue
+			}
+			issue.CRs = append(issue.CRs, temp.GetPath())
+			issue.CRMetadata[temp.GetPath()] = CRMetadata{Reason: reason}
+		}
+	}
+	return issue, missing
 }
 
 func getReferenceV2(fsys fs.FS, referenceFileName string) (*ReferenceV2, error) {
@@ -573,56 +1045,103 @@ func getReferenceV2(fsys fs.FS, referenceFileName string) (*ReferenceV2, error)
 	if err != nil {
 		return result, err
 	}
-	if result.FieldsToOmit == nil {
-		result.FieldsToOmit = &FieldsToOmitV2{}
-	}
-	err = result.FieldsToOmit.process()
+	err = result.importReferences(fsys, referenceFileName, nil)
 	if err != nil {
 		return result, err
 	}
-	result.normalisedVersion = ReferenceVersionV2
+	return result, result.setup(ReferenceVersionV2)
+}
 
-	err = result.validate()
+// setup processes the parsed reference by setting defaults and validating it
+func (r *ReferenceV2) setup(version string) error {
+	if r.FieldsToOmit == nil {
+		r.FieldsToOmit = &FieldsToOmitV2{}
+	}
+	err := r.FieldsToOmit.process()
 	if err != nil {
-		return result, err
+		return err
+	}
+	r.normalisedVersion = version
+
+	err = r.processCorrelationGroups()
+	if err != nil {
+		return err
+	}
+
+	err = processListsAsSets(r.ListsAsSets)
+	if err != nil {
+		return err
+	}
+
+	err = validateClusterVersionRange(r.MinClusterVersion, r.MaxClusterVersion)
+	if err != nil {
+		return err
+	}
+
+	err = r.parseAssertions()
+	if err != nil {
+		return err
 	}
-	return result, nil
+
+	return r.validate()
 }
 
 func ParseV2Templates(ref *ReferenceV2, fsys fs.FS) ([]ReferenceTemplate, error) {
 	var errs []error
 	var result []ReferenceTemplate
 	functionTemplates := ref.TemplateFunctionFiles
-	for _, temp := range ref.getTemplates() {
-		result = append(result, temp)
-		parsedTemp, err := template.New(path.Base(temp.Path)).Funcs(FuncMap()).ParseFS(fsys, temp.Path)
+	for _, refTemp := range ref.getTemplates() {
+		parsedTemp, err := template.New(path.Base(refTemp.Path)).Funcs(FuncMap()).ParseFS(fsys, refTemp.Path)
 		if err != nil {
-			errs = append(errs, fmt.Errorf(templatesCantBeParsed, temp.Path, err))
+			result = append(result, refTemp)
+			errs = append(errs, fmt.Errorf(templatesCantBeParsed, refTemp.Path, err))
 			continue
 		}
 		if len(functionTemplates) > 0 {
 			parsedTemp, err = parsedTemp.ParseFS(fsys, functionTemplates...)
 			if err != nil {
+				result = append(result, refTemp)
 				errs = append(errs, fmt.Errorf(templatesFunctionsCantBeParsed, err))
 				continue
 			}
 		}
-		temp.Template = parsedTemp
-		temp.ReferenceTemplateV1.Config = temp.Config.ReferenceTemplateConfigV1
-		temp.metadata, err = temp.Exec(map[string]any{}) // Extract Metadata
-		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to parse template %s with empty data: %w", temp.Path, err))
-		}
-		err = temp.validateConfigPerField()
+		refTemp.Template = parsedTemp
+		refTemp.withoutAssertions, err = withoutAssertions(parsedTemp)
 		if err != nil {
+			result = append(result, refTemp)
 			errs = append(errs, err)
+			continue
 		}
-		err = temp.ValidateFieldsToOmit(ref.FieldsToOmit)
+		refTemp.ReferenceTemplateV1.Config = refTemp.Config.ReferenceTemplateConfigV1
+		docs, err := refTemp.splitIntoDocuments()
 		if err != nil {
-			errs = append(errs, err)
+			docs = []*ReferenceTemplateV2{refTemp}
+		}
+		for _, temp := range docs {
+			result = append(result, temp)
+			temp.metadata, err = temp.Exec(map[string]any{}) // Extract Metadata
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to parse template %s with empty data: %w", temp.Path, err))
+			}
+			err = temp.validateConfigPerField()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			err = temp.parseMatchConditions()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			err = temp.ValidateFieldsToOmit(ref.FieldsToOmit)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if temp.metadata != nil && temp.metadata.GetKind() == "" {
+				errs = append(errs, fmt.Errorf("template missing kind: %s", temp.GetIdentifier()))
+			}
 		}
-		if temp.metadata != nil && temp.metadata.GetKind() == "" {
-			errs = append(errs, fmt.Errorf("template missing kind: %s", temp.Path))
+		if docs[0] != refTemp {
+			// The components keep the original template, its metadata is the one of the first document
+			refTemp.metadata = docs[0].metadata
 		}
 	}
 	return result, errors.Join(errs...) // nolint:wrapcheck
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+const ReferenceVersionV3 string = "v3"
+
+const (
+	valuesKey            = "Values"
+	valuesRefRequiresV3  = "template %s has a valuesRef, valuesRef is only supported from reference apiVersion v3"
+	valuesFileNotExists  = "values file referenced by template not found. error: %w"
+	valuesFileNotInFomat = "values file referenced by template isn't in correct format. error: %w"
+)
+
+// ReferenceV3 has the same structure as ReferenceV2 but allows templates to declare a valuesRef
+// pointing to a YAML file inside the reference. The content of the file is injected into the template as .Values,
+// allowing shared constants to be defined once and used by many templates.
+type ReferenceV3 struct {
+	ReferenceV2
+}
+
+// loadValues reads the values files referenced by the templates, files shared between templates are only read once.
+func (r *ReferenceV3) loadValues(fsys fs.FS) error {
+	errs := make([]error, 0)
+	loaded := make(map[string]map[string]any)
+	for _, temp := range r.getTemplates() {
+		if temp.ValuesRef == "" {
+			continue
+		}
+		values, ok := loaded[temp.ValuesRef]
+		if !ok {
+			values = make(map[string]any)
+			err := parseYaml(fsys, temp.ValuesRef, &values, valuesFileNotExists, valuesFileNotInFomat)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to load values for template %s: %w", temp.Path, err))
+				continue
+			}
+			loaded[temp.ValuesRef] = values
+		}
+		temp.values = values
+	}
+	return errors.Join(errs...)
+}
+
+func getReferenceV3(fsys fs.FS, referenceFileName string) (*ReferenceV3, error) {
+	result := &ReferenceV3{}
+	err := parseYaml(fsys, referenceFileName, &result, refConfNotExistsError, refConfigNotInFormat)
+	if err != nil {
+		return result, err
+	}
+	err = result.importReferences(fsys, referenceFileName, nil)
+	if err != nil {
+		return result, err
+	}
+	err = result.setup(ReferenceVersionV3)
+	if err != nil {
+		return result, err
+	}
+	return result, result.loadValues(fsys)
+}
@@ -0,0 +1,207 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	referenceInfoLong = templates.LongDesc(`
+		Print the package metadata of a reference configuration (its name, version, vendor and release notes), the
+		number of templates of each of its parts and components, and its metadata hash, which is the hash printed in
+		the summary of the compare command.
+
+		The information is printed as text, or as json or yaml with -o, for the tools that inventory the references
+		used across a fleet of clusters.
+	`)
+
+	referenceInfoExample = templates.Examples(`
+		# Print the information of a reference:
+		kubectl cluster-compare reference-info -r ./reference/metadata.yaml
+
+		# Print the information of a reference published on a http server as json:
+		kubectl cluster-compare reference-info -r https://example.com/reference/metadata.yaml -o json
+	`)
+)
+
+// referenceInfoFormats are the output formats of the reference-info subcommand besides text.
+var referenceInfoFormats = []string{Json, Yaml}
+
+// ReferencePackage describes the package of a reference, it's declared at the top of its config (metadata.yaml).
+type ReferencePackage struct {
+	Name            string `json:"name,omitempty"`
+	Version         string `json:"version,omitempty"`
+	Vendor          string `json:"vendor,omitempty"`
+	ReleaseNotesURL string `json:"releaseNotesURL,omitempty"`
+}
+
+// ReferenceInfo is the output of the reference-info subcommand.
+type ReferenceInfo struct {
+	ReferencePackage
+	APIVersion   string     `json:"apiVersion"`
+	Templates    int        `json:"templates"`
+	Parts        []PartInfo `json:"parts,omitempty"`
+	MetadataHash string     `json:"metadataHash"`
+}
+
+// PartInfo counts the templates of a part of the reference, in total and by component.
+type PartInfo struct {
+	Name       string          `json:"name"`
+	Templates  int             `json:"templates"`
+	Components []ComponentInfo `json:"components,omitempty"`
+}
+
+// ComponentInfo counts the templates of a component of the reference.
+type ComponentInfo struct {
+	Name      string `json:"name"`
+	Templates int    `json:"templates"`
+}
+
+// ReferenceInfoOptions are the options of the reference-info subcommand.
+type ReferenceInfoOptions struct {
+	referenceConfig string
+	outputFormat    string
+
+	info ReferenceInfo
+	genericiooptions.IOStreams
+}
+
+func newReferenceInfoCmd(streams genericiooptions.IOStreams) *cobra.Command {
+	options := &ReferenceInfoOptions{IOStreams: streams}
+	cmd := &cobra.Command{
+		Use:                   "reference-info -r <Reference File>",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Print the package metadata, the number of templates and the metadata hash of a reference."),
+		Long:                  referenceInfoLong,
+		Example:               referenceInfoExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			kcmdutil.CheckErr(options.Complete(cmd))
+			kcmdutil.CheckErr(options.Run())
+		},
+	}
+	options.addFlags(cmd)
+	return cmd
+}
+
+func (o *ReferenceInfoOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.referenceConfig, "reference", "r", "", "Path or URL of the reference config file.")
+	cmd.Flags().StringVarP(&o.outputFormat, "output", "o", "",
+		fmt.Sprintf("Output format. One of: (%s), defaults to text", strings.Join(referenceInfoFormats, ", ")))
+}
+
+// Complete loads the reference and collects its information.
+func (o *ReferenceInfoOptions) Complete(cmd *cobra.Command) error {
+	if o.referenceConfig == "" {
+		return kcmdutil.UsageErrorf(cmd, noRefFileWasPassed)
+	}
+	if o.outputFormat != "" && !slices.Contains(referenceInfoFormats, o.outputFormat) {
+		return kcmdutil.UsageErrorf(cmd, unknownOutputFormat, o.outputFormat, strings.Join(referenceInfoFormats, ", "))
+	}
+	if _, err := os.Stat(o.referenceConfig); os.IsNotExist(err) && !isURL(o.referenceConfig) {
+		return errors.New(refFileNotExistsError)
+	}
+	cfs, err := GetRefFS(o.referenceConfig)
+	if err != nil {
+		return err
+	}
+	ref, err := GetReference(cfs, filepath.Base(o.referenceConfig))
+	if err != nil {
+		return err
+	}
+	templates, err := ParseTemplates(ref, cfs)
+	if err != nil {
+		return err
+	}
+	o.info = newReferenceInfo(ref, templates)
+	return nil
+}
+
+// newReferenceInfo returns the information of the reference, the parts and the components are in the order of the
+// reference.
+func newReferenceInfo(ref Reference, templates []ReferenceTemplate) ReferenceInfo {
+	info := ReferenceInfo{
+		ReferencePackage: ref.GetPackage(),
+		APIVersion:       ref.GetAPIVersion(),
+		Templates:        len(templates),
+		MetadataHash:     metadataHash(ref, templates),
+	}
+	for _, t := range templates {
+		partName, componentName := t.GetPartAndComponent()
+		if partName == "" {
+			continue
+		}
+		i := slices.IndexFunc(info.Parts, func(p PartInfo) bool { return p.Name == partName })
+		if i < 0 {
+			info.Parts = append(info.Parts, PartInfo{Name: partName})
+			i = len(info.Parts) - 1
+		}
+		part := &info.Parts[i]
+		part.Templates++
+		j := slices.IndexFunc(part.Components, func(c ComponentInfo) bool { return c.Name == componentName })
+		if j < 0 {
+			part.Components = append(part.Components, ComponentInfo{Name: componentName})
+			j = len(part.Components) - 1
+		}
+		part.Components[j].Templates++
+	}
+	return info
+}
+
+const referenceInfoTemplate = `
+{{- with .Name }}Name: {{ . }}
+{{ end }}
+{{- with .Version }}Version: {{ . }}
+{{ end }}
+{{- with .Vendor }}Vendor: {{ . }}
+{{ end }}
+{{- with .ReleaseNotesURL }}Release Notes: {{ . }}
+{{ end -}}
+API Version: {{ .APIVersion }}
+Templates: {{ .Templates }}
+{{- range .Parts }}
+  {{ .Name }}: {{ .Templates }}
+{{- range .Components }}
+    {{ .Name }}: {{ .Templates }}
+{{- end }}
+{{- end }}
+Metadata Hash: {{ .MetadataHash }}
+`
+
+// Run prints the information of the reference.
+func (o *ReferenceInfoOptions) Run() error {
+	var content []byte
+	var err error
+	switch o.outputFormat {
+	case Json:
+		content, err = json.Marshal(o.info)
+		content = append(content, '\n')
+	case Yaml:
+		content, err = yaml.Marshal(o.info)
+	default:
+		var sb strings.Builder
+		err = template.Must(template.New("referenceInfo").Parse(referenceInfoTemplate)).Execute(&sb, o.info)
+		content = []byte(sb.String())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal the information of the reference: %w", err)
+	}
+	if _, err := o.Out.Write(content); err != nil {
+		return fmt.Errorf("error occurred when writing output: %w", err)
+	}
+	return nil
+}
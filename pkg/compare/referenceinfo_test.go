@@ -0,0 +1,99 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+func TestReferenceInfo(t *testing.T) {
+	referenceInfo := func(t *testing.T, flags map[string]string) (string, error) {
+		streams, _, out, _ := genericiooptions.NewTestIOStreams()
+		o := &ReferenceInfoOptions{IOStreams: streams}
+		cmd := &cobra.Command{}
+		o.addFlags(cmd)
+		for name, value := range flags {
+			require.NoError(t, cmd.Flags().Set(name, value))
+		}
+		if err := o.Complete(cmd); err != nil {
+			return "", err
+		}
+		err := o.Run()
+		return out.String(), err
+	}
+	hashOf := func(t *testing.T, reference string) string {
+		cfs, err := GetRefFS(reference)
+		require.NoError(t, err)
+		ref, err := GetReference(cfs, filepath.Base(reference))
+		require.NoError(t, err)
+		templates, err := ParseTemplates(ref, cfs)
+		require.NoError(t, err)
+		return metadataHash(ref, templates)
+	}
+
+	reference := "testdata/ReferenceInfo/reference/metadata.yaml"
+
+	t.Run("Text", func(t *testing.T) {
+		out, err := referenceInfo(t, map[string]string{"reference": reference})
+		require.NoError(t, err)
+		assert.Equal(t, `Name: example-reference
+Version: 1.4.0
+Vendor: Example Corp
+Release Notes: https://example.com/reference/releases/1.4.0
+API Version: v2
+Templates: 3
+  ExamplePart: 3
+    Dashboard: 2
+    Settings: 1
+Metadata Hash: `+hashOf(t, reference)+"\n", out)
+	})
+
+	t.Run("Json", func(t *testing.T) {
+		out, err := referenceInfo(t, map[string]string{"reference": reference, "output": Json})
+		require.NoError(t, err)
+		info := ReferenceInfo{}
+		require.NoError(t, json.Unmarshal([]byte(out), &info))
+		assert.Equal(t, ReferenceInfo{
+			ReferencePackage: ReferencePackage{
+				Name:            "example-reference",
+				Version:         "1.4.0",
+				Vendor:          "Example Corp",
+				ReleaseNotesURL: "https://example.com/reference/releases/1.4.0",
+			},
+			APIVersion: ReferenceVersionV2,
+			Templates:  3,
+			Parts: []PartInfo{{Name: "ExamplePart", Templates: 3, Components: []ComponentInfo{
+				{Name: "Dashboard", Templates: 2},
+				{Name: "Settings", Templates: 1},
+			}}},
+			MetadataHash: hashOf(t, reference),
+		}, info)
+	})
+
+	t.Run("Without Package Metadata", func(t *testing.T) {
+		reference := "testdata/SomeDiffs/reference/metadata.yaml"
+		out, err := referenceInfo(t, map[string]string{"reference": reference, "output": Yaml})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(out, "apiVersion: v1\nmetadataHash: "+hashOf(t, reference)+"\n"), out)
+	})
+
+	t.Run("Invalid Release Notes URL", func(t *testing.T) {
+		_, err := referenceInfo(t, map[string]string{
+			"reference": "testdata/ReferenceInfo/reference/metadata_invalid_release_notes.yaml",
+		})
+		assert.ErrorContains(t, err, `reference has invalid releaseNotesURL "releases.md"`)
+	})
+
+	t.Run("Unknown Output Format", func(t *testing.T) {
+		_, err := referenceInfo(t, map[string]string{"reference": reference, "output": Junit})
+		assert.ErrorContains(t, err, `Unknown --output value "junit"`)
+	})
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	renderLong = templates.LongDesc(`
+		Render the templates of a reference configuration and print the resulting YAML, without comparing them to any
+		cluster CRs.
+
+		The templates are rendered with the CR (or values) passed by --input, as they would be rendered for a cluster
+		CR by the compare command, or with empty data. Templates that fail to render are reported and the other
+		templates are still printed.
+	`)
+
+	renderExample = templates.Examples(`
+		# Render all the templates of a reference with empty data:
+		kubectl cluster-compare render -r ./reference/metadata.yaml
+
+		# Render a template with the data of a cluster CR:
+		kubectl cluster-compare render -r ./reference/metadata.yaml -t deployment.yaml --input ./crs/deployment.yaml
+	`)
+)
+
+const (
+	unknownRenderTemplate = "Template %q isn't part of the reference"
+	renderSourceComment   = "# Source: %s\n"
+)
+
+// RenderOptions are the options of the render subcommand.
+type RenderOptions struct {
+	referenceConfig string
+	templatePaths   []string
+	inputPath       string
+
+	templates []ReferenceTemplate
+	params    map[string]any
+	genericiooptions.IOStreams
+}
+
+func newRenderCmd(streams genericiooptions.IOStreams) *cobra.Command {
+	options := &RenderOptions{IOStreams: streams}
+	cmd := &cobra.Command{
+		Use:                   "render -r <Reference File>",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Render the templates of a reference and print the resulting YAML."),
+		Long:                  renderLong,
+		Example:               renderExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			kcmdutil.CheckErr(options.Complete(cmd))
+			kcmdutil.CheckErr(options.Run())
+		},
+	}
+	options.addFlags(cmd)
+	return cmd
+}
+
+func (o *RenderOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.referenceConfig, "reference", "r", "", "Path to reference config file.")
+	cmd.Flags().StringSliceVarP(&o.templatePaths, "template", "t", []string{},
+		"Path of a template of the reference to render, relative to the reference config file. Defaults to all the templates")
+	cmd.Flags().StringVar(&o.inputPath, "input", "",
+		"Path to a YAML file with the CR (or values) the templates are rendered with. Defaults to empty data")
+}
+
+// Complete loads the reference, the templates to render and the data they are rendered with.
+func (o *RenderOptions) Complete(cmd *cobra.Command) error {
+	if o.referenceConfig == "" {
+		return kcmdutil.UsageErrorf(cmd, noRefFileWasPassed)
+	}
+	if _, err := os.Stat(o.referenceConfig); os.IsNotExist(err) && !isURL(o.referenceConfig) {
+		return errors.New(refFileNotExistsError)
+	}
+	cfs, err := GetRefFS(o.referenceConfig)
+	if err != nil {
+		return err
+	}
+	ref, err := GetReference(cfs, filepath.Base(o.referenceConfig))
+	if err != nil {
+		return err
+	}
+	templates, err := ParseTemplates(ref, cfs)
+	if err != nil {
+		return err
+	}
+
+	o.templates = templates
+	if len(o.templatePaths) > 0 {
+		o.templates = nil
+		for _, p := range o.templatePaths {
+			found := false
+			for _, t := range templates {
+				if t.GetPath() == p || t.GetIdentifier() == p {
+					o.templates = append(o.templates, t)
+					found = true
+				}
+			}
+			if !found {
+				return kcmdutil.UsageErrorf(cmd, unknownRenderTemplate, p)
+			}
+		}
+	}
+
+	o.params = make(map[string]any)
+	if o.inputPath != "" {
+		content, err := os.ReadFile(o.inputPath)
+		if err != nil {
+			return fmt.Errorf("failed to read the input: %w", err)
+		}
+		if err := yaml.Unmarshal(content, &o.params); err != nil {
+			return fmt.Errorf("failed to parse the input %s: %w", o.inputPath, err)
+		}
+	}
+	return nil
+}
+
+// Run renders the templates and prints them as YAML documents, each one preceded by the identifier of its template.
+func (o *RenderOptions) Run() error {
+	var errs []error
+	for _, t := range o.templates {
+		rendered, err := t.Exec(o.params)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		content, err := yaml.Marshal(rendered.Object)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to marshal the rendered template %s: %w", t.GetIdentifier(), err))
+			continue
+		}
+		if _, err := fmt.Fprintf(o.Out, "---\n"+renderSourceComment+"%s", t.GetIdentifier(), content); err != nil {
+			return fmt.Errorf("error occurred when writing output: %w", err)
+		}
+	}
+	return errors.Join(errs...)
+}
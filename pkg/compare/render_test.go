@@ -0,0 +1,66 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+func TestRender(t *testing.T) {
+	render := func(t *testing.T, flags map[string]string) (string, error) {
+		streams, _, out, _ := genericiooptions.NewTestIOStreams()
+		o := &RenderOptions{IOStreams: streams}
+		cmd := &cobra.Command{}
+		o.addFlags(cmd)
+		for name, value := range flags {
+			require.NoError(t, cmd.Flags().Set(name, value))
+		}
+		if err := o.Complete(cmd); err != nil {
+			return "", err
+		}
+		err := o.Run()
+		return out.String(), err
+	}
+
+	t.Run("All Templates", func(t *testing.T) {
+		out, err := render(t, map[string]string{"reference": "testdata/MultiDocumentTemplates/reference/metadata.yaml"})
+		require.NoError(t, err)
+		assert.Contains(t, out, "---\n# Source: operator.yaml#0\napiVersion: v1\nkind: Namespace\n")
+		assert.Contains(t, out, "# Source: operator.yaml#1\n")
+		assert.Contains(t, out, "# Source: operator.yaml#2\n")
+		assert.Contains(t, out, "# Source: config.yaml\n")
+	})
+
+	t.Run("Template With Input", func(t *testing.T) {
+		out, err := render(t, map[string]string{
+			"reference": "testdata/SomeDiffs/reference/metadata.yaml",
+			"template":  "deploymentMetrics.yaml",
+			"input":     "testdata/SomeDiffs/resources/d2.yaml",
+		})
+		require.NoError(t, err)
+		assert.Contains(t, out, "# Source: deploymentMetrics.yaml\n")
+		assert.NotContains(t, out, "deploymentDashboard.yaml")
+		assert.Contains(t, out, "image: kubernetesui/metrics-scraper")
+	})
+
+	t.Run("Unknown Template", func(t *testing.T) {
+		_, err := render(t, map[string]string{
+			"reference": "testdata/SomeDiffs/reference/metadata.yaml",
+			"template":  "missing.yaml",
+		})
+		assert.ErrorContains(t, err, `Template "missing.yaml" isn't part of the reference`)
+	})
+
+	t.Run("Failing Template", func(t *testing.T) {
+		out, err := render(t, map[string]string{
+			"reference": "testdata/RenderFailingTemplate/reference/metadata.yaml",
+			"input":     "testdata/RenderFailingTemplate/resources/input.yaml",
+		})
+		assert.ErrorContains(t, err, "the input has unexpected data")
+		assert.Contains(t, out, "# Source: good.yaml\n")
+		assert.NotContains(t, out, "# Source: bad.yaml\n")
+	})
+}
@@ -0,0 +1,186 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// renderErrorContextLines is the number of lines shown before and after the line of a yaml error in the rendered
+// template.
+const renderErrorContextLines = 3
+
+// sourceLineMarker delimits the template line numbers that are injected in the parse tree of the template to find which
+// template line renders each line of the output.
+const sourceLineMarker = "\x00"
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+):`)
+
+// yamlError returns the error of a template whose rendered content isn't valid yaml. Instead of the whole content, it
+// shows the lines of the content around the line of the yaml error, numbered, and the template line that renders it.
+func (rf ReferenceTemplateV1) yamlError(params map[string]any, content []byte, err error) error {
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	errLine := 0
+	if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+		errLine, _ = strconv.Atoi(match[1])
+	}
+	if errLine < 1 || errLine > len(lines) {
+		return fmt.Errorf(
+			"template: %s isn't a yaml file after injection. yaml unmarshal error: %w. The Template After Execution:\n%s",
+			rf.GetIdentifier(), err, numberedLines(lines, 1, len(lines), 0),
+		)
+	}
+	location := ""
+	if sourceLine := rf.sourceLineOf(params, content, errLine); sourceLine > 0 {
+		location = fmt.Sprintf(" (rendered by %s:%d)", rf.GetIdentifier(), sourceLine)
+	}
+	return fmt.Errorf(
+		"template: %s isn't a yaml file after injection. yaml unmarshal error: %w. The Template After Execution around line %d%s:\n%s",
+		rf.GetIdentifier(), err, errLine, location,
+		numberedLines(lines, errLine-renderErrorContextLines, errLine+renderErrorContextLines, errLine),
+	)
+}
+
+// numberedLines returns the lines from the first to the last (1-based, included) prefixed by their number, the marked
+// line is pointed with a '>'.
+func numberedLines(lines []string, first, last, marked int) string {
+	first = max(first, 1)
+	last = min(last, len(lines))
+	width := len(strconv.Itoa(last))
+	var b strings.Builder
+	for i := first; i <= last; i++ {
+		pointer := " "
+		if i == marked {
+			pointer = ">"
+		}
+		fmt.Fprintf(&b, "%s %*d | %s\n", pointer, width, i, lines[i-1])
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// sourceLineOf returns the line of the template that renders the line of the content, 0 when it can't be found.
+// The template is rendered again from a copy of its parse tree, where the text of every template line and every action
+// start with the number of their line. A rendered line comes from the first template line whose output is visible in
+// it, or when it's only made of the output of a multi-line action (such as toYaml) from the line of the action.
+func (rf ReferenceTemplateV1) sourceLineOf(params map[string]any, content []byte, line int) int {
+	t := rf.Template
+	if _, ok := params["kind"]; !ok && rf.withoutAssertions != nil {
+		t = rf.withoutAssertions
+	}
+	if t == nil || t.Tree == nil {
+		return 0
+	}
+	annotated, err := t.Clone()
+	if err != nil {
+		return 0
+	}
+	annotated.Tree = t.Tree.Copy()
+	annotateSourceLines(t.Tree, annotated.Tree.Root)
+	var buf bytes.Buffer
+	if err := annotated.Execute(&buf, params); err != nil {
+		return 0
+	}
+
+	var rendered strings.Builder
+	sourceLines := make([]int, 0)
+	current := 0
+	for _, renderedLine := range strings.Split(buf.String(), "\n") {
+		parts := strings.Split(renderedLine, sourceLineMarker)
+		lineSource := current
+		claimed := false
+		for i, part := range parts {
+			if i%2 == 0 {
+				rendered.WriteString(part)
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				continue
+			}
+			current = n
+			if !claimed && i+1 < len(parts) && strings.TrimSpace(parts[i+1]) != "" {
+				lineSource, claimed = n, true
+			}
+		}
+		rendered.WriteString("\n")
+		sourceLines = append(sourceLines, lineSource)
+	}
+
+	// The content can be one of the documents of the rendered template
+	offset := strings.Index(rendered.String(), string(content))
+	if offset < 0 {
+		return 0
+	}
+	index := strings.Count(rendered.String()[:offset], "\n") + line - 1
+	if index >= len(sourceLines) {
+		return 0
+	}
+	return sourceLines[index]
+}
+
+// annotateSourceLines prefixes the lines of the text nodes and the actions of the node with their line in the template.
+func annotateSourceLines(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		nodes := make([]parse.Node, 0, len(n.Nodes))
+		for _, child := range n.Nodes {
+			annotateSourceLines(tree, child)
+			switch child.(type) {
+			case *parse.ActionNode, *parse.TemplateNode:
+				if line := templateLine(tree, child); line > 0 {
+					nodes = append(nodes, &parse.TextNode{
+						NodeType: parse.NodeText, Pos: child.Position(), Text: sourceLineText(line),
+					})
+				}
+			}
+			nodes = append(nodes, child)
+		}
+		n.Nodes = nodes
+	case *parse.IfNode:
+		annotateSourceLines(tree, n.List)
+		annotateSourceLines(tree, n.ElseList)
+	case *parse.RangeNode:
+		annotateSourceLines(tree, n.List)
+		annotateSourceLines(tree, n.ElseList)
+	case *parse.WithNode:
+		annotateSourceLines(tree, n.List)
+		annotateSourceLines(tree, n.ElseList)
+	case *parse.TextNode:
+		line := templateLine(tree, n)
+		if line == 0 {
+			return
+		}
+		var text bytes.Buffer
+		for i, textLine := range bytes.SplitAfter(n.Text, []byte("\n")) {
+			if len(textLine) == 0 {
+				continue
+			}
+			text.Write(sourceLineText(line + i))
+			text.Write(textLine)
+		}
+		n.Text = text.Bytes()
+	}
+}
+
+func sourceLineText(line int) []byte {
+	return []byte(sourceLineMarker + strconv.Itoa(line) + sourceLineMarker)
+}
+
+// templateLine returns the line of the node in the template, as reported by the parse tree.
+func templateLine(tree *parse.Tree, node parse.Node) int {
+	location, _ := tree.ErrorContext(node)
+	fields := strings.Split(location, ":")
+	if len(fields) < 3 {
+		return 0
+	}
+	line, _ := strconv.Atoi(fields[len(fields)-2])
+	return line
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAMLErrorSourceLine(t *testing.T) {
+	parsed, err := template.New("pod.yaml").Funcs(FuncMap()).Parse(`apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+  {{- range .spec.containers }}
+  - name: {{ .name }}
+    env:
+      {{- .env | toYaml | nindent 6 }}
+  {{- end }}
+`)
+	require.NoError(t, err)
+	temp := ReferenceTemplateV1{Path: "pod.yaml", Template: parsed}
+
+	_, err = temp.Exec(map[string]any{
+		"kind": "Pod",
+		"spec": map[string]any{"containers": []any{
+			map[string]any{"name": "app", "env": []any{map[string]any{"name": "LEVEL", "value": "debug"}}},
+			map[string]any{"name": "proxy: v2", "env": []any{}},
+		}},
+	})
+	require.Error(t, err)
+	assert.Equal(t, `template: pod.yaml isn't a yaml file after injection. yaml unmarshal error: `+
+		`error converting YAML to JSON: yaml: line 11: mapping values are not allowed in this context. `+
+		`The Template After Execution around line 11 (rendered by pod.yaml:8):
+   8 |     env:
+   9 |       - name: LEVEL
+  10 |         value: debug
+> 11 |   - name: proxy: v2
+  12 |     env:
+  13 |       []`, err.Error())
+
+	assert.Equal(t, 10, temp.sourceLineOf(map[string]any{
+		"kind": "Pod",
+		"spec": map[string]any{"containers": []any{
+			map[string]any{"name": "app", "env": []any{map[string]any{"name": "LEVEL", "value": "debug"}}},
+		}},
+	}, nil, 10), "the lines rendered by toYaml come from the line of the action")
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/openapi3"
+)
+
+const (
+	schemaDefaultsWithoutSchemas = "--schema-defaults requires --crd-schemas when comparing local CRs (-f), in live mode " +
+		"the schemas of the cluster are used"
+
+	// maxSchemaRefs is the maximum number of $refs followed to resolve a schema, it protects against circular $refs
+	maxSchemaRefs = 10
+)
+
+// schemaOfFunc returns the OpenAPI v3 schema of the kind and the schemas its $refs point to (the components of the
+// OpenAPI document), the schema is nil when the kind has none.
+type schemaOfFunc func(gvk schema.GroupVersionKind) (map[string]any, map[string]any, error)
+
+// openAPISchemaOf returns the schemas of the kinds from the OpenAPI v3 documents of the cluster, the document of each
+// group version is only fetched once.
+func openAPISchemaOf(root openapi3.Root) schemaOfFunc {
+	var lock sync.Mutex
+	components := make(map[schema.GroupVersion]map[string]any)
+	return func(gvk schema.GroupVersionKind) (map[string]any, map[string]any, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		schemas, ok := components[gvk.GroupVersion()]
+		if !ok {
+			doc, err := root.GVSpecAsMap(gvk.GroupVersion())
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get the OpenAPI schemas of %s: %w", gvk.GroupVersion(), err)
+			}
+			schemas, _, _ = unstructured.NestedMap(doc, "components", "schemas")
+			components[gvk.GroupVersion()] = schemas
+		}
+		for _, s := range schemas {
+			if s, ok := s.(map[string]any); ok && hasGroupVersionKind(s, gvk) {
+				return s, schemas, nil
+			}
+		}
+		return nil, schemas, nil
+	}
+}
+
+// hasGroupVersionKind returns whether the OpenAPI schema is the schema of the kind.
+func hasGroupVersionKind(s map[string]any, gvk schema.GroupVersionKind) bool {
+	gvks, _, _ := unstructured.NestedSlice(s, "x-kubernetes-group-version-kind")
+	for _, v := range gvks {
+		v, ok := v.(map[string]any)
+		if ok && v["group"] == gvk.Group && v["version"] == gvk.Version && v["kind"] == gvk.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaOf returns the schema of the kind defined by the CRDs, CRD schemas don't have $refs.
+func (c *CRDSchemas) schemaOf(gvk schema.GroupVersionKind) (map[string]any, map[string]any, error) {
+	return c.schemas[gvk], nil, nil
+}
+
+// schemaDefaulter sets the defaults of the schemas of the kinds on the rendered templates (--schema-defaults), the
+// same way the API server sets them on the CRs it stores. Fields that are defaulted by the API server don't have to be
+// in the templates, or omitted with fieldsToOmit, to not be reported as diffs.
+type schemaDefaulter struct {
+	schemaOf schemaOfFunc
+}
+
+func newSchemaDefaulter(schemaOf schemaOfFunc) *schemaDefaulter {
+	return &schemaDefaulter{schemaOf: schemaOf}
+}
+
+// setDefaults sets the defaults of the schema of the kind of the object on the fields that the object doesn't set.
+// A nil defaulter doesn't change the object.
+func (d *schemaDefaulter) setDefaults(obj *unstructured.Unstructured) error {
+	if d == nil {
+		return nil
+	}
+	s, components, err := d.schemaOf(obj.GroupVersionKind())
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+	setSchemaDefaults(obj.Object, s, components)
+	return nil
+}
+
+// setSchemaDefaults sets the defaults of the properties of the schema that the value doesn't set, in the objects of
+// the value that exist, recursively.
+func setSchemaDefaults(value any, s, components map[string]any) {
+	s = resolveSchema(s, components)
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := s["properties"].(map[string]any)
+		for name, p := range properties {
+			property, ok := p.(map[string]any)
+			if !ok {
+				continue
+			}
+			if field, ok := v[name]; ok {
+				setSchemaDefaults(field, property, components)
+				continue
+			}
+			// The default is next to the $ref of the property, or in the schema it refers to
+			def, ok := property["default"]
+			if !ok {
+				def, ok = resolveSchema(property, components)["default"]
+			}
+			if ok {
+				v[name] = runtime.DeepCopyJSONValue(def)
+				setSchemaDefaults(v[name], property, components)
+			}
+		}
+		if additional, ok := s["additionalProperties"].(map[string]any); ok {
+			for name, field := range v {
+				if _, ok := properties[name]; !ok {
+					setSchemaDefaults(field, additional, components)
+				}
+			}
+		}
+	case []any:
+		if items, ok := s["items"].(map[string]any); ok {
+			for _, item := range v {
+				setSchemaDefaults(item, items, components)
+			}
+		}
+	}
+}
+
+// resolveSchema follows the $ref of the schema, either direct or wrapped in an allOf as in the OpenAPI v3 documents of
+// the API server.
+func resolveSchema(s, components map[string]any) map[string]any {
+	for i := 0; i < maxSchemaRefs; i++ {
+		if allOf, ok := s["allOf"].([]any); ok && len(allOf) == 1 {
+			if inner, ok := allOf[0].(map[string]any); ok {
+				s = inner
+			}
+		}
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			return s
+		}
+		resolved, ok := components[strings.TrimPrefix(ref, "#/components/schemas/")].(map[string]any)
+		if !ok {
+			return map[string]any{}
+		}
+		s = resolved
+	}
+	return s
+}
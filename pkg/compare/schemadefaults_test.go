@@ -0,0 +1,93 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/openapi3"
+	"k8s.io/kube-openapi/pkg/spec3"
+)
+
+// fakeOpenAPIRoot serves the same OpenAPI v3 document for all the group versions.
+type fakeOpenAPIRoot struct {
+	doc   map[string]any
+	calls int
+}
+
+var _ openapi3.Root = &fakeOpenAPIRoot{}
+
+func (r *fakeOpenAPIRoot) GroupVersions() ([]schema.GroupVersion, error) {
+	return []schema.GroupVersion{{Group: "apps", Version: "v1"}}, nil
+}
+
+func (r *fakeOpenAPIRoot) GVSpec(_ schema.GroupVersion) (*spec3.OpenAPI, error) {
+	return nil, nil
+}
+
+func (r *fakeOpenAPIRoot) GVSpecAsMap(_ schema.GroupVersion) (map[string]any, error) {
+	r.calls++
+	return r.doc, nil
+}
+
+func TestSchemaDefaults(t *testing.T) {
+	root := &fakeOpenAPIRoot{doc: map[string]any{"components": map[string]any{"schemas": map[string]any{
+		"io.k8s.api.apps.v1.Deployment": map[string]any{
+			"x-kubernetes-group-version-kind": []any{map[string]any{"group": "apps", "version": "v1", "kind": "Deployment"}},
+			"properties": map[string]any{
+				"spec": map[string]any{"allOf": []any{map[string]any{"$ref": "#/components/schemas/io.k8s.api.apps.v1.DeploymentSpec"}}},
+			},
+		},
+		"io.k8s.api.apps.v1.DeploymentSpec": map[string]any{
+			"properties": map[string]any{
+				"replicas":   map[string]any{"type": "integer", "default": int64(1)},
+				"containers": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/components/schemas/io.k8s.api.core.v1.Container"}},
+				"strategy":   map[string]any{"$ref": "#/components/schemas/io.k8s.api.apps.v1.DeploymentStrategy"},
+			},
+		},
+		"io.k8s.api.apps.v1.DeploymentStrategy": map[string]any{
+			"default":    map[string]any{"type": "RollingUpdate"},
+			"properties": map[string]any{"type": map[string]any{"type": "string"}},
+		},
+		"io.k8s.api.core.v1.Container": map[string]any{
+			"properties": map[string]any{
+				"image":           map[string]any{"type": "string"},
+				"imagePullPolicy": map[string]any{"type": "string", "default": "IfNotPresent"},
+			},
+		},
+	}}}}
+	d := newSchemaDefaulter(openAPISchemaOf(root))
+
+	deployment := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"spec": map[string]any{
+			"replicas": int64(3),
+			"containers": []any{
+				map[string]any{"image": "app"},
+				map[string]any{"image": "sidecar", "imagePullPolicy": "Always"},
+			},
+		},
+	}}
+	require.NoError(t, d.setDefaults(deployment))
+	assert.Equal(t, map[string]any{
+		"replicas": int64(3),
+		"containers": []any{
+			map[string]any{"image": "app", "imagePullPolicy": "IfNotPresent"},
+			map[string]any{"image": "sidecar", "imagePullPolicy": "Always"},
+		},
+		"strategy": map[string]any{"type": "RollingUpdate"},
+	}, deployment.Object["spec"])
+
+	unknown := &unstructured.Unstructured{Object: map[string]any{"apiVersion": "apps/v1", "kind": "StatefulSet"}}
+	require.NoError(t, d.setDefaults(unknown))
+	assert.Equal(t, map[string]any{"apiVersion": "apps/v1", "kind": "StatefulSet"}, unknown.Object)
+	assert.Equal(t, 1, root.calls)
+
+	var noDefaults *schemaDefaulter
+	assert.NoError(t, noDefaults.setDefaults(deployment))
+}
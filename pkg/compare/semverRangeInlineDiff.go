@@ -0,0 +1,34 @@
+package compare
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	semverRange inlineDiffType = "semverRange"
+)
+
+// SemverRangeInlineDiff matches the cluster value if it's a semantic version that satisfies the range of the template,
+// for example ">=4.14.0 <4.17.0".
+type SemverRangeInlineDiff struct{}
+
+func (id SemverRangeInlineDiff) Diff(constraint, crValue string, sharedCapturedValues CapturedValues) (string, CapturedValues) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return constraint, sharedCapturedValues
+	}
+	version, err := semver.NewVersion(crValue)
+	if err != nil || !c.Check(version) {
+		return constraint, sharedCapturedValues
+	}
+	return crValue, sharedCapturedValues
+}
+
+func (id SemverRangeInlineDiff) Validate(constraint string) error {
+	if _, err := semver.NewConstraint(constraint); err != nil {
+		return fmt.Errorf("invalid range passed to inline semverRange diff function: %w", err)
+	}
+	return nil
+}
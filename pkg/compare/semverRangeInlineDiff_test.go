@@ -0,0 +1,37 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInlineSemverRangeDiff(t *testing.T) {
+	tests := []struct {
+		constraint string
+		input      string
+		expected   string
+	}{
+		{constraint: ">=4.14.0 <4.17.0", input: "4.16.3", expected: "4.16.3"},
+		{constraint: ">=4.14.0 <4.17.0", input: "v4.14.0", expected: "v4.14.0"},
+		{constraint: ">=4.14.0 <4.17.0", input: "4.17.0", expected: ">=4.14.0 <4.17.0"},
+		{constraint: ">=4.14.0 <4.17.0", input: "4.13.9", expected: ">=4.14.0 <4.17.0"},
+		{constraint: "~4.16", input: "4.16.12", expected: "4.16.12"},
+		{constraint: ">=4.14.0 <4.17.0", input: "not a version", expected: ">=4.14.0 <4.17.0"},
+		{constraint: ">=4.14.0 <4.17.0", input: "4.16.0-rc.1", expected: ">=4.14.0 <4.17.0"},
+	}
+	for _, test := range tests {
+		t.Run(test.constraint+" "+test.input, func(t *testing.T) {
+			diff := SemverRangeInlineDiff{}
+			require.NoError(t, diff.Validate(test.constraint))
+			result, cg := diff.Diff(test.constraint, test.input, CapturedValues{})
+			assert.Equal(t, test.expected, result)
+			assert.Equal(t, CapturedValues{}, cg)
+		})
+	}
+}
+
+func TestInlineSemverRangeValidate(t *testing.T) {
+	assert.Error(t, SemverRangeInlineDiff{}.Validate(">=four"))
+}
@@ -0,0 +1,324 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/klog/v2"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	serveLong = templates.LongDesc(`
+		Periodically compare the live cluster to a reference configuration and serve the results over HTTP.
+
+		The comparison accepts the same flags as the cluster-compare command, it runs when the server starts and then
+		at every interval. The reference and the user overrides are loaded again by every comparison.
+
+		The server exposes the following endpoints:
+		  /healthz      200 when the last comparison succeeded, 503 before the first comparison or when it failed
+		  /metrics      The results of the last comparison in the Prometheus text format
+		  /report.json  The report of the last comparison, in the format of the JSON output (-o json)
+		  /report.html  The report of the last comparison as an HTML page
+	`)
+
+	serveExample = templates.Examples(`
+		# Compare the live cluster to a reference every 30 minutes and serve the results on port 8080:
+		kubectl cluster-compare serve -r ./reference/metadata.yaml --interval 30m --listen-address :8080
+	`)
+)
+
+const (
+	noComparisonYet      = "no comparison ran yet"
+	invalidServeInterval = "The --interval value must be positive, got %s"
+	metricsPrefix        = "cluster_compare_"
+	serveShutdownPeriod  = 5 * time.Second
+)
+
+func newServeCmd(f kcmdutil.Factory, streams genericiooptions.IOStreams) *cobra.Command {
+	options := NewOptions(streams)
+	s := &server{options: options, f: f}
+
+	cmd := &cobra.Command{
+		Use:                   "serve -r <Reference File>",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Periodically compare the live cluster to a reference and serve the results over HTTP."),
+		Long:                  serveLong,
+		Example:               serveExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			s.cmd = cmd
+			if s.interval <= 0 {
+				kcmdutil.CheckErr(kcmdutil.UsageErrorf(cmd, invalidServeInterval, s.interval))
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			kcmdutil.CheckErr(s.serve(ctx))
+		},
+	}
+	addFlags(cmd, options)
+	// The reports are served in their own formats
+	kcmdutil.CheckErr(cmd.Flags().MarkHidden("output"))
+	cmd.Flags().StringVar(&s.listenAddress, "listen-address", ":8080", "Address the server listens on")
+	cmd.Flags().DurationVar(&s.interval, "interval", time.Hour, "Time between two comparisons of the cluster")
+	return cmd
+}
+
+// server runs the comparisons on a schedule and serves the results of the last one.
+type server struct {
+	f             kcmdutil.Factory
+	cmd           *cobra.Command
+	options       *Options
+	listenAddress string
+	interval      time.Duration
+
+	lock         sync.RWMutex
+	output       *Output
+	numFailing   int
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastErr      error
+	runs         int
+	failedRuns   int
+}
+
+func (s *server) serve(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:              s.listenAddress,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		klog.Infof("Serving the comparison results on %s", s.listenAddress)
+		serverErr <- httpServer.ListenAndServe()
+	}()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	s.runComparison()
+	for {
+		select {
+		case <-ticker.C:
+			s.runComparison()
+		case err := <-serverErr:
+			return fmt.Errorf("failed to serve the comparison results: %w", err)
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownPeriod)
+			defer cancel()
+			if err := httpServer.Shutdown(shutdownCtx); err != nil {
+				return fmt.Errorf("failed to shut down the server: %w", err)
+			}
+			return nil
+		}
+	}
+}
+
+// runComparison compares the cluster to the reference with a copy of the options, so every comparison starts from
+// the flags, and records its results.
+func (s *server) runComparison() {
+	start := now()
+	o := *s.options
+	o.Out = io.Discard
+	var output Output
+	var numFailing int
+	err := o.Complete(s.f, s.cmd, nil)
+	if err == nil {
+		output, _, numFailing, err = o.compare()
+	}
+	if err == nil && o.bookmark != nil {
+		err = o.bookmark.Write(o.bookmarkPath)
+	}
+	duration := now().Sub(start)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.runs++
+	s.lastRun = start
+	s.lastDuration = duration
+	s.lastErr = err
+	if err != nil {
+		s.failedRuns++
+		klog.Errorf("Failed to compare the cluster to the reference: %s", err)
+		return
+	}
+	s.output = &output
+	s.numFailing = numFailing
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/metrics", s.metrics)
+	mux.HandleFunc("/report.json", s.reportJSON)
+	mux.HandleFunc("/report.html", s.reportHTML)
+	return mux
+}
+
+func (s *server) healthz(w http.ResponseWriter, _ *http.Request) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	switch {
+	case s.runs == 0:
+		http.Error(w, noComparisonYet, http.StatusServiceUnavailable)
+	case s.lastErr != nil:
+		http.Error(w, s.lastErr.Error(), http.StatusServiceUnavailable)
+	default:
+		fmt.Fprintln(w, "ok")
+	}
+}
+
+func (s *server) metrics(w http.ResponseWriter, _ *http.Request) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var b strings.Builder
+	writeMetric(&b, "runs_total", "counter", "Number of comparisons of the cluster to the reference.", float64(s.runs))
+	writeMetric(&b, "failed_runs_total", "counter", "Number of comparisons that failed with an error.", float64(s.failedRuns))
+	if s.runs != 0 {
+		writeMetric(&b, "last_run_timestamp_seconds", "gauge", "Time the last comparison started.", float64(s.lastRun.Unix()))
+		writeMetric(&b, "last_run_duration_seconds", "gauge", "Duration of the last comparison.", s.lastDuration.Seconds())
+	}
+	if s.output != nil && s.output.Summary != nil {
+		sum := s.output.Summary
+		writeMetric(&b, "crs", "gauge", "Number of cluster CRs compared to the reference.", float64(sum.TotalCRs))
+		writeMetric(&b, "diff_crs", "gauge", "Number of cluster CRs that differ from the reference.", float64(sum.NumDiffCRs))
+		writeMetric(&b, "failing_diff_crs", "gauge", "Number of cluster CRs with diffs with the error severity.", float64(s.numFailing))
+		writeMetric(&b, "patched_crs", "gauge", "Number of cluster CRs patched by user overrides.", float64(sum.PatchedCRs))
+		writeMetric(&b, "missing_crs", "gauge", "Number of reference CRs missing from the cluster.", float64(sum.NumMissing))
+		writeMetric(&b, "unmatched_crs", "gauge", "Number of cluster CRs not matched to any template.", float64(len(sum.UnmatchedCRS)))
+		writeValidationIssuesMetric(&b, sum.ValidationIssues)
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, b.String())
+}
+
+// writeMetric writes a metric without labels in the Prometheus text format.
+func writeMetric(w io.Writer, name, metricType, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s%s %s\n# TYPE %s%s %s\n%s%s %s\n",
+		metricsPrefix, name, help, metricsPrefix, name, metricType, metricsPrefix, name, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// writeValidationIssuesMetric writes the number of validation issues of each group.
+func writeValidationIssuesMetric(w io.Writer, issues map[string]map[string]ValidationIssue) {
+	const name = metricsPrefix + "validation_issues"
+	fmt.Fprintf(w, "# HELP %s Number of validation issues of the last comparison by group.\n# TYPE %s gauge\n", name, name)
+	groups := make([]string, 0, len(issues))
+	for group := range issues {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		fmt.Fprintf(w, "%s{group=%q} %d\n", name, group, len(issues[group]))
+	}
+}
+
+func (s *server) reportJSON(w http.ResponseWriter, _ *http.Request) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.output == nil {
+		http.Error(w, noComparisonYet, http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.output); err != nil {
+		klog.Errorf("Failed to write the JSON report: %s", err)
+	}
+}
+
+var reportHTMLTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cluster Compare Report</title>
+</head>
+<body>
+<h1>Cluster Compare Report</h1>
+<p>Compared at {{ .LastRun.Format "2006-01-02T15:04:05Z07:00" }} in {{ .LastDuration }}</p>
+{{- with .Output.Summary }}
+<h2>Summary</h2>
+<ul>
+<li>Total CRs: {{ .TotalCRs }}</li>
+<li>CRs with diffs: {{ .NumDiffCRs }}/{{ .TotalCRs }}</li>
+<li>Patched CRs: {{ .PatchedCRs }}</li>
+<li>Missing CRs: {{ .NumMissing }}</li>
+<li>Unmatched CRs: {{ len .UnmatchedCRS }}</li>
+<li>Reference hash: {{ .MetadataHash }}</li>
+</ul>
+{{- if .ValidationIssues }}
+<h2>Validation issues</h2>
+{{- range $group, $issues := .ValidationIssues }}
+<h3>{{ $group }}</h3>
+<ul>
+{{- range $name, $issue := $issues }}
+<li>{{ $name }}: {{ $issue.Msg }}
+<ul>
+{{- range $issue.CRs }}
+<li>{{ . }}</li>
+{{- end }}
+</ul>
+</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- end }}
+{{- if .UnmatchedCRS }}
+<h2>Unmatched CRs</h2>
+<ul>
+{{- range .UnmatchedCRS }}
+<li>{{ . }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- end }}
+<h2>Diffs</h2>
+{{- range .Diffs }}
+<h3>{{ .CRName }}</h3>
+<p>Reference file: {{ .CorrelatedTemplate }}{{ with .Severity }}, severity: {{ . }}{{ end }}</p>
+<pre>{{ .DiffOutput }}</pre>
+{{- else }}
+<p>No CRs differ from the reference</p>
+{{- end }}
+</body>
+</html>
+`))
+
+func (s *server) reportHTML(w http.ResponseWriter, _ *http.Request) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.output == nil {
+		http.Error(w, noComparisonYet, http.StatusServiceUnavailable)
+		return
+	}
+	data := struct {
+		Output       *Output
+		Diffs        []DiffSum
+		LastRun      time.Time
+		LastDuration time.Duration
+	}{
+		Output:       s.output,
+		Diffs:        s.output.sortedDiffs(false),
+		LastRun:      s.lastRun.UTC(),
+		LastDuration: s.lastDuration,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := reportHTMLTemplate.Execute(w, data); err != nil {
+		klog.Errorf("Failed to write the HTML report: %s", err)
+	}
+}
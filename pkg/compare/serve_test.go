@@ -0,0 +1,77 @@
+package compare
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func TestServe(t *testing.T) {
+	streams, _, _, _ := genericiooptions.NewTestIOStreams()
+	s := &server{options: NewOptions(streams), f: cmdtesting.NewTestFactory(), cmd: &cobra.Command{}}
+	addFlags(s.cmd, s.options)
+	require.NoError(t, s.cmd.Flags().Set("reference", "testdata/SomeDiffs/reference/metadata.yaml"))
+	require.NoError(t, s.cmd.Flags().Set("filename", "testdata/SomeDiffs/resources"))
+	require.NoError(t, s.cmd.Flags().Set("diff-engine", DiffEngineInternal))
+
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+	get := func(path string) (int, string) {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	for _, path := range []string{"/healthz", "/report.json", "/report.html"} {
+		code, body := get(path)
+		assert.Equal(t, http.StatusServiceUnavailable, code, path)
+		assert.Contains(t, body, noComparisonYet, path)
+	}
+
+	s.runComparison()
+	s.runComparison()
+
+	code, body := get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok\n", body)
+
+	code, body = get("/metrics")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "# TYPE cluster_compare_runs_total counter\ncluster_compare_runs_total 2\n")
+	assert.Contains(t, body, "cluster_compare_failed_runs_total 0\n")
+	assert.Contains(t, body, "cluster_compare_crs 2\n")
+	assert.Contains(t, body, "cluster_compare_diff_crs 1\n")
+	assert.Contains(t, body, "cluster_compare_failing_diff_crs 1\n")
+
+	code, body = get("/report.json")
+	assert.Equal(t, http.StatusOK, code)
+	var output Output
+	require.NoError(t, json.Unmarshal([]byte(body), &output))
+	assert.Equal(t, 1, output.Summary.NumDiffCRs)
+	assert.Len(t, *output.Diffs, 2)
+
+	code, body = get("/report.html")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "<h3>apps/v1_Deployment_kubernetes-dashboard_dashboard-metrics-scraper</h3>")
+
+	require.NoError(t, s.cmd.Flags().Set("reference", "testdata/SomeDiffs/reference/missing.yaml"))
+	s.runComparison()
+	code, _ = get("/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	_, body = get("/metrics")
+	assert.Contains(t, body, "cluster_compare_failed_runs_total 1\n")
+	// The report of the last successful comparison is still served
+	code, _ = get("/report.json")
+	assert.Equal(t, http.StatusOK, code)
+}
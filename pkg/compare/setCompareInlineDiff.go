@@ -0,0 +1,84 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	setCompare      inlineDiffType = "setCompare"
+	multisetCompare inlineDiffType = "multisetCompare"
+)
+
+// StructuredInlineDiff is an InlineDiff that also supports fields that aren't strings, such as lists. The values of
+// these fields are passed to it encoded as JSON and the result is decoded back.
+type StructuredInlineDiff interface {
+	InlineDiff
+	structured()
+}
+
+// SetCompareInlineDiff compares lists regardless of the order of their items. As a set the number of times an item
+// appears doesn't matter, as a multiset (Multiset) it has to be the same.
+type SetCompareInlineDiff struct {
+	Multiset bool
+}
+
+func (id SetCompareInlineDiff) structured() {}
+
+func (id SetCompareInlineDiff) Diff(templateValue, crValue string, sharedCapturedValues CapturedValues) (string, CapturedValues) {
+	templateItems, err := parseList(templateValue)
+	if err != nil {
+		return templateValue, sharedCapturedValues
+	}
+	crItems, err := parseList(crValue)
+	if err != nil {
+		return templateValue, sharedCapturedValues
+	}
+	if id.countItems(templateItems) != id.countItems(crItems) {
+		return templateValue, sharedCapturedValues
+	}
+	return crValue, sharedCapturedValues
+}
+
+func (id SetCompareInlineDiff) Validate(templateValue string) error {
+	if _, err := parseList(templateValue); err != nil {
+		return fmt.Errorf("invalid list passed to inline set compare diff function: %w", err)
+	}
+	return nil
+}
+
+// countItems returns the number of times each item, encoded as JSON, appears in the list. As a set it's 1 for every
+// item.
+func (id SetCompareInlineDiff) countItems(items []string) string {
+	counts := make(map[string]int)
+	for _, item := range items {
+		if id.Multiset {
+			counts[item]++
+		} else {
+			counts[item] = 1
+		}
+	}
+	// Maps are encoded with sorted keys, so equal counts have the same encoding
+	encoded, _ := json.Marshal(counts)
+	return string(encoded)
+}
+
+// parseList parses a YAML or JSON list and returns its items encoded as JSON, maps are encoded with sorted keys so
+// equal items have the same encoding.
+func parseList(value string) ([]string, error) {
+	var list []any
+	if err := yaml.Unmarshal([]byte(value), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse list: %w", err)
+	}
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode list item: %w", err)
+		}
+		items = append(items, string(encoded))
+	}
+	return items, nil
+}
@@ -0,0 +1,38 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineSetCompareDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		multiset bool
+		template string
+		input    string
+		expected string
+	}{
+		{name: "same order", template: "[a, b]", input: `["a","b"]`, expected: `["a","b"]`},
+		{name: "different order", template: "[a, b]", input: `["b","a"]`, expected: `["b","a"]`},
+		{name: "maps with different key order", template: "[{k: a, v: 1}]", input: `[{"v":1,"k":"a"}]`, expected: `[{"v":1,"k":"a"}]`},
+		{name: "missing item", template: "[a, b]", input: `["a"]`, expected: "[a, b]"},
+		{name: "duplicates as set", template: "[a, b]", input: `["a","b","a"]`, expected: `["a","b","a"]`},
+		{name: "duplicates as multiset", multiset: true, template: "[a, b]", input: `["a","b","a"]`, expected: "[a, b]"},
+		{name: "same duplicates as multiset", multiset: true, template: "[a, a, b]", input: `["a","b","a"]`, expected: `["a","b","a"]`},
+		{name: "not a list", template: "[a, b]", input: "a", expected: "[a, b]"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := SetCompareInlineDiff{Multiset: test.multiset}
+			assert.NoError(t, diff.Validate(test.template))
+			result, _ := diff.Diff(test.template, test.input, CapturedValues{})
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestInlineSetCompareValidate(t *testing.T) {
+	assert.Error(t, SetCompareInlineDiff{}.Validate("key: value"))
+}
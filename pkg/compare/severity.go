@@ -0,0 +1,190 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	SeverityError        = "error"
+	SeverityWarning      = "warning"
+	SeverityInfo         = "info"
+	SeverityAcknowledged = "acknowledged"
+
+	severityRulesDateFormat = "2006-01-02"
+	severityRuleExpired     = "Ignoring severity rule %d (%s), it expired on %s"
+)
+
+var Severities = []string{SeverityError, SeverityWarning, SeverityInfo}
+
+// severityRank orders the severities, the severity of a CR is the highest severity of its diffs.
+var severityRank = map[string]int{
+	SeverityAcknowledged: 0,
+	SeverityInfo:         1,
+	SeverityWarning:      2,
+	SeverityError:        3,
+}
+
+// SeverityRules are user defined rules that set the severity of diffs, or acknowledge them, by the template, the kind
+// and the field path of the diff. Unlike user overrides they don't change the diffs, only the way they are reported and
+// the exit code: only diffs with the error severity (the default) make the command fail.
+type SeverityRules struct {
+	Rules []*SeverityRule `json:"rules"`
+}
+
+// SeverityRule applies to the diffs of the CRs matching all of its (non-empty) selectors. FieldPath is a dot separated
+// path, the rule applies to the diffs of the field and of all the fields nested in it.
+type SeverityRule struct {
+	TemplatePath string `json:"templatePath,omitempty"`
+	Kind         string `json:"kind,omitempty"`
+	FieldPath    string `json:"fieldPath,omitempty"`
+	Severity     string `json:"severity,omitempty"`
+	Acknowledged bool   `json:"acknowledged,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	Expires      string `json:"expires,omitempty"`
+}
+
+// LoadSeverityRules reads the severity rules file, rules that expired before now are dropped with a warning.
+func LoadSeverityRules(path string, now time.Time) (*SeverityRules, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read severity rules: %w", err)
+	}
+	var rules SeverityRules
+	err = yaml.UnmarshalStrict(contents, &rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse severity rules %s: %w", path, err)
+	}
+
+	var errs []error
+	active := make([]*SeverityRule, 0, len(rules.Rules))
+	for i, rule := range rules.Rules {
+		if err := rule.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("severity rule %d (%s) is invalid: %w", i, rule, err))
+			continue
+		}
+		if rule.Expires != "" {
+			expires, err := time.Parse(severityRulesDateFormat, rule.Expires)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("severity rule %d (%s) has an invalid expiry, the format is YYYY-MM-DD: %w", i, rule, err))
+				continue
+			}
+			// The rule is valid until the end of the expiry day
+			if !now.Before(expires.AddDate(0, 0, 1)) {
+				klog.Warningf(severityRuleExpired, i, rule, rule.Expires)
+				continue
+			}
+		}
+		active = append(active, rule)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	rules.Rules = active
+	return &rules, nil
+}
+
+func (r SeverityRule) validate() error {
+	if r.Acknowledged {
+		if r.Severity != "" {
+			return errors.New("acknowledged rules can't set a severity")
+		}
+		if r.Reason == "" {
+			return errors.New("acknowledged rules require a reason")
+		}
+		return nil
+	}
+	if _, ok := severityRank[r.Severity]; !ok || r.Severity == SeverityAcknowledged {
+		return fmt.Errorf("unknown severity %q, supported values: %s", r.Severity, strings.Join(Severities, ", "))
+	}
+	return nil
+}
+
+func (r SeverityRule) String() string {
+	var selectors []string
+	for _, s := range [][2]string{{"templatePath", r.TemplatePath}, {"kind", r.Kind}, {"fieldPath", r.FieldPath}} {
+		if s[1] != "" {
+			selectors = append(selectors, s[0]+"="+s[1])
+		}
+	}
+	if len(selectors) == 0 {
+		return "all diffs"
+	}
+	return strings.Join(selectors, ", ")
+}
+
+func (r SeverityRule) matches(templatePath, kind, fieldPath string) bool {
+	return (r.TemplatePath == "" || r.TemplatePath == templatePath) &&
+		(r.Kind == "" || r.Kind == kind) &&
+		(r.FieldPath == "" || fieldPath == r.FieldPath || strings.HasPrefix(fieldPath, r.FieldPath+"."))
+}
+
+// classify returns the severity of the diffs of a CR, described by the merge patch from the rendered template to the CR,
+// and the reasons of the rules that acknowledged some of them. Each changed field gets the severity of the first rule
+// that matches it, fields without a matching rule get the default severity (the severity of the template).
+func (r *SeverityRules) classify(templatePath, kind, patch, defaultSeverity string) (string, []string, error) {
+	var data map[string]any
+	err := json.Unmarshal([]byte(patch), &data)
+	if err != nil {
+		return defaultSeverity, nil, fmt.Errorf("failed to unmarshal internal diff: %w", err)
+	}
+
+	severity := SeverityAcknowledged
+	reasons := make(map[string]bool)
+	for _, fieldPath := range leafPaths(data, "") {
+		fieldSeverity := defaultSeverity
+		for _, rule := range r.Rules {
+			if !rule.matches(templatePath, kind, fieldPath) {
+				continue
+			}
+			fieldSeverity = rule.Severity
+			if rule.Acknowledged {
+				fieldSeverity = SeverityAcknowledged
+				reasons[rule.Reason] = true
+			}
+			break
+		}
+		if severityRank[fieldSeverity] > severityRank[severity] {
+			severity = fieldSeverity
+		}
+	}
+
+	res := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		res = append(res, reason)
+	}
+	sort.Strings(res)
+	return severity, res, nil
+}
+
+// leafPaths returns the dot separated paths of the leaves of a merge patch. Lists are replaced as a whole by merge
+// patches so they are leaves too.
+func leafPaths(d any, prefix string) []string {
+	m, ok := d.(map[string]any)
+	if !ok || len(m) == 0 {
+		if prefix == "" {
+			return nil
+		}
+		return []string{prefix}
+	}
+	var paths []string
+	for k, v := range m {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		paths = append(paths, leafPaths(v, path)...)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// signedChecksumsFile lists the sha256 checksums of the files of a signed reference, in the format of sha256sum. It's
+	// signed with a detached signature in the format of cosign sign-blob, signedChecksumsFile + signatureExtension.
+	signedChecksumsFile = "SHA256SUMS"
+	signatureExtension  = ".sig"
+
+	publicKeyInvalid     = "failed to read the public key %s: %w"
+	publicKeyUnsupported = "public key %s is a %T key, only ECDSA, Ed25519 and RSA keys are supported"
+	signatureNotFound    = "failed to read the signature of the reference, the reference must contain %s and %s: %w"
+	signatureInvalid     = "the signature of the reference (%s) isn't valid for the public key %s"
+	checksumsInvalid     = "failed to parse the signed checksums of the reference (%s): line %d isn't in the sha256sum format"
+	fileNotSigned        = "%s isn't listed in the signed checksums of the reference (%s)"
+	fileChecksumMismatch = "the checksum of %s doesn't match the signed checksums of the reference, it was modified after the reference was signed"
+	imageUnsupported     = "%s is a container image, the references are read from local directories and http servers. " +
+		"Extract the reference from the image (e.g. with oc image extract) and verify it with the signature of its " +
+		"checksums (--verify-signature)"
+)
+
+// imageTransports are the prefixes of the container images, which aren't supported as references: their cosign
+// signatures can't be verified and their content can't be read.
+var imageTransports = []string{"oci://", "docker://"}
+
+// isImage returns whether the reference config is a container image.
+func isImage(refConfig string) bool {
+	return lo.SomeBy(imageTransports, func(transport string) bool {
+		return strings.HasPrefix(refConfig, transport)
+	})
+}
+
+// loadPublicKey reads a PEM encoded public key, as written by cosign generate-key-pair.
+func loadPublicKey(keyPath string) (crypto.PublicKey, error) {
+	content, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf(publicKeyInvalid, keyPath, err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf(publicKeyInvalid, keyPath, errors.New("no PEM data found"))
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf(publicKeyInvalid, keyPath, err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey, *rsa.PublicKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf(publicKeyUnsupported, keyPath, key)
+}
+
+// verifyBlob verifies a base64 encoded signature of the payload, in the format of cosign sign-blob: ECDSA (ASN.1) and
+// RSA (PKCS #1 v1.5) signatures are signatures of the sha256 digest of the payload, Ed25519 signatures of the payload.
+func verifyBlob(key crypto.PublicKey, payload, signature []byte) bool {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest[:], sig)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	}
+	return false
+}
+
+// parseChecksums parses the lines "<sha256>  <path>" of the output of sha256sum, the paths are relative to the
+// directory of the reference.
+func parseChecksums(content []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		sum, name, found := strings.Cut(text, " ")
+		if _, err := hex.DecodeString(sum); !found || len(sum) != sha256.Size*2 || err != nil {
+			return nil, fmt.Errorf(checksumsInvalid, signedChecksumsFile, line)
+		}
+		// sha256sum marks the files read in binary mode with *
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		sums[path.Clean(name)] = strings.ToLower(sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the signed checksums of the reference: %w", err)
+	}
+	return sums, nil
+}
+
+// verifiedFS is the file system of a signed reference (--verify-signature). The signature of the checksums of the
+// reference is verified when it's created, then every file of the reference that is read must match its signed
+// checksum, so neither the reference config nor the templates (or the references they import) can be modified.
+type verifiedFS struct {
+	fsys fs.FS
+	sums map[string]string
+}
+
+// newVerifiedFS verifies the signature of the checksums of the reference with the public key.
+func newVerifiedFS(fsys fs.FS, keyPath string) (fs.FS, error) {
+	key, err := loadPublicKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	checksums, err := fs.ReadFile(fsys, signedChecksumsFile)
+	if err != nil {
+		return nil, fmt.Errorf(signatureNotFound, signedChecksumsFile, signedChecksumsFile+signatureExtension, err)
+	}
+	signature, err := fs.ReadFile(fsys, signedChecksumsFile+signatureExtension)
+	if err != nil {
+		return nil, fmt.Errorf(signatureNotFound, signedChecksumsFile, signedChecksumsFile+signatureExtension, err)
+	}
+	if !verifyBlob(key, checksums, signature) {
+		return nil, fmt.Errorf(signatureInvalid, signedChecksumsFile+signatureExtension, keyPath)
+	}
+	sums, err := parseChecksums(checksums)
+	if err != nil {
+		return nil, err
+	}
+	return verifiedFS{fsys: fsys, sums: sums}, nil
+}
+
+// Open reads the whole file to verify its checksum before it's used, the directories aren't verified.
+func (v verifiedFS) Open(name string) (fs.File, error) {
+	f, err := v.fsys.Open(name)
+	if err != nil {
+		return nil, err // nolint:wrapcheck
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	if info.IsDir() {
+		return v.fsys.Open(name) // nolint:wrapcheck
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	expected, ok := v.sums[path.Clean(name)]
+	if !ok {
+		return nil, fmt.Errorf(fileNotSigned, name, signedChecksumsFile)
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != expected {
+		return nil, fmt.Errorf(fileChecksumMismatch, name)
+	}
+	return &contentFile{Reader: bytes.NewReader(content), info: info}, nil
+}
+
+// contentFile is a file of the reference that was already read, its content is kept in memory.
+type contentFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *contentFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *contentFile) Close() error {
+	return nil
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+	return keyPath
+}
+
+func checksumLine(name, content string) string {
+	return fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(content)), name)
+}
+
+func TestVerifiedFS(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ed25519Public, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sign := map[string]func(payload []byte) []byte{
+		"ecdsa": func(payload []byte) []byte {
+			digest := sha256.Sum256(payload)
+			sig, err := ecdsa.SignASN1(rand.Reader, ecdsaKey, digest[:])
+			require.NoError(t, err)
+			return sig
+		},
+		"ed25519": func(payload []byte) []byte {
+			return ed25519.Sign(ed25519Key, payload)
+		},
+	}
+	publicKeys := map[string]string{
+		"ecdsa":   writePublicKey(t, &ecdsaKey.PublicKey),
+		"ed25519": writePublicKey(t, ed25519Public),
+		"other":   writePublicKey(t, &otherKey.PublicKey),
+	}
+
+	metadata, tmpl := "apiVersion: v2\n", "kind: ConfigMap\n"
+	checksums := checksumLine("metadata.yaml", metadata) + checksumLine("templates/cm.yaml", tmpl)
+	reference := func(alg string) fstest.MapFS {
+		return fstest.MapFS{
+			"metadata.yaml":     {Data: []byte(metadata)},
+			"templates/cm.yaml": {Data: []byte(tmpl)},
+			"templates/new.yaml": {
+				Data: []byte("kind: Secret\n"),
+			},
+			signedChecksumsFile: {Data: []byte(checksums)},
+			signedChecksumsFile + signatureExtension: {
+				Data: []byte(base64.StdEncoding.EncodeToString(sign[alg]([]byte(checksums)))),
+			},
+		}
+	}
+
+	for _, alg := range []string{"ecdsa", "ed25519"} {
+		t.Run(alg, func(t *testing.T) {
+			fsys := reference(alg)
+			verified, err := newVerifiedFS(fsys, publicKeys[alg])
+			require.NoError(t, err)
+
+			content, err := fs.ReadFile(verified, "templates/cm.yaml")
+			require.NoError(t, err)
+			assert.Equal(t, tmpl, string(content))
+			matches, err := fs.Glob(verified, "templates/c*.yaml")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"templates/cm.yaml"}, matches)
+
+			_, err = fs.ReadFile(verified, "templates/new.yaml")
+			assert.EqualError(t, err, "templates/new.yaml isn't listed in the signed checksums of the reference (SHA256SUMS)")
+
+			fsys["metadata.yaml"] = &fstest.MapFile{Data: []byte("apiVersion: v1\n")}
+			_, err = fs.ReadFile(verified, "metadata.yaml")
+			assert.EqualError(t, err, "the checksum of metadata.yaml doesn't match the signed checksums of the reference, "+
+				"it was modified after the reference was signed")
+		})
+	}
+
+	_, err = newVerifiedFS(reference("ecdsa"), publicKeys["other"])
+	assert.EqualError(t, err, fmt.Sprintf("the signature of the reference (SHA256SUMS.sig) isn't valid for the public key %s",
+		publicKeys["other"]))
+
+	tampered := reference("ecdsa")
+	tampered[signedChecksumsFile] = &fstest.MapFile{Data: []byte(checksums + checksumLine("templates/new.yaml", "kind: Secret\n"))}
+	_, err = newVerifiedFS(tampered, publicKeys["ecdsa"])
+	assert.ErrorContains(t, err, "isn't valid for the public key")
+
+	unsigned := reference("ecdsa")
+	delete(unsigned, signedChecksumsFile+signatureExtension)
+	_, err = newVerifiedFS(unsigned, publicKeys["ecdsa"])
+	assert.ErrorContains(t, err, "the reference must contain SHA256SUMS and SHA256SUMS.sig")
+}
+
+func TestParseChecksums(t *testing.T) {
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte("a")))
+	sums, err := parseChecksums([]byte(sum + "  ./metadata.yaml\n\n" + sum + " *templates/cm.yaml\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"metadata.yaml": sum, "templates/cm.yaml": sum}, sums)
+
+	_, err = parseChecksums([]byte(sum + "  metadata.yaml\nnot-a-checksum templates/cm.yaml\n"))
+	assert.EqualError(t, err, "failed to parse the signed checksums of the reference (SHA256SUMS): line 2 isn't in the sha256sum format")
+}
+
+func TestIsImage(t *testing.T) {
+	assert.True(t, isImage("oci://quay.io/example/reference:v1"))
+	assert.True(t, isImage("docker://quay.io/example/reference@sha256:0123"))
+	assert.False(t, isImage("https://example.com/reference/metadata.yaml"))
+	assert.False(t, isImage("./reference/metadata.yaml"))
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	snapshotNotInLive = "--snapshot can only be used when comparing live clusters"
+	snapshotExpired   = "the snapshot of the cluster at resourceVersion %s expired while listing %s, the API server " +
+		"compacted it before all the CRs were listed. Compare again or without --snapshot"
+
+	// defaultChunkSize is the number of CRs returned by each page of the lists of the live cluster, as kubectl get
+	defaultChunkSize = 500
+)
+
+// snapshotExpiredError fails the comparison, the CRs listed before it don't form a consistent snapshot.
+type snapshotExpiredError string
+
+func (e snapshotExpiredError) Error() string {
+	return string(e)
+}
+
+// listFunc lists a page of the CRs of a resource of the cluster.
+type listFunc func(ctx context.Context, gvr schema.GroupVersionResource, opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
+
+func dynamicListFunc(client dynamic.Interface) listFunc {
+	return func(ctx context.Context, gvr schema.GroupVersionResource, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+		return client.Resource(gvr).List(ctx, opts) // nolint:wrapcheck
+	}
+}
+
+// snapshotLister lists the CRs of the live cluster as a consistent snapshot (--snapshot): the first list is done at
+// the latest resourceVersion and the lists of the other types are pinned to the same resourceVersion, so CRs that
+// change while the cluster is listed don't make the set of CRs inconsistent. The lists are paginated, the pages that
+// follow the first page of a list are read from the same snapshot through their continue token.
+type snapshotLister struct {
+	list      listFunc
+	mapper    meta.RESTMapper
+	chunkSize int64
+	retry     retryPolicy
+}
+
+// visit passes the CRs of the types (as returned by findSupportedTypes) to emit. The types whose CRs can't be listed
+// are passed to fail, their pages that were already listed are still emitted.
+func (l *snapshotLister) visit(ctx context.Context, types []string, emit func(*unstructured.Unstructured),
+	fail func(string, error)) error {
+	resourceVersion := ""
+	for _, t := range types {
+		gvk, gk := schema.ParseKindArg(t)
+		var mapping *meta.RESTMapping
+		var err error
+		if gvk != nil {
+			mapping, err = l.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		} else {
+			mapping, err = l.mapper.RESTMapping(gk)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find the resource of %s: %w", t, err)
+		}
+		listed, err := l.listAll(ctx, mapping.Resource, resourceVersion, emit)
+		var expired snapshotExpiredError
+		if errors.As(err, &expired) {
+			return err
+		}
+		if err != nil {
+			fail(t, err)
+			continue
+		}
+		if resourceVersion == "" {
+			resourceVersion = listed
+		}
+	}
+	return nil
+}
+
+// listAll lists all the pages of the CRs of the resource at the resourceVersion, or at the latest one when it's empty.
+// It returns the resourceVersion of the list.
+func (l *snapshotLister) listAll(ctx context.Context, gvr schema.GroupVersionResource, resourceVersion string,
+	emit func(*unstructured.Unstructured)) (string, error) {
+	opts := metav1.ListOptions{Limit: l.chunkSize}
+	if resourceVersion != "" {
+		opts.ResourceVersion = resourceVersion
+		opts.ResourceVersionMatch = metav1.ResourceVersionMatchExact
+	}
+	for {
+		var page *unstructured.UnstructuredList
+		err := l.retry.do(ctx, gvr.Resource, func() error {
+			var err error
+			page, err = l.list(ctx, gvr, opts)
+			return err
+		})
+		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			return "", snapshotExpiredError(fmt.Sprintf(snapshotExpired, resourceVersion, gvr.Resource))
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+		}
+		for i := range page.Items {
+			emit(&page.Items[i])
+		}
+		if resourceVersion == "" {
+			resourceVersion = page.GetResourceVersion()
+		}
+		if page.GetContinue() == "" {
+			return resourceVersion, nil
+		}
+		// The continue token already pins the snapshot, the API server rejects a resourceVersion along with it
+		opts = metav1.ListOptions{Limit: l.chunkSize, Continue: page.GetContinue()}
+	}
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSnapshotLister(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}, {Group: "apps", Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	// pages are the pages of the lists of each resource, a page continues to the next one while there is one
+	pages := map[schema.GroupVersionResource][]int{configMaps: {2, 1}, deployments: {1}}
+	var calls []string
+	list := func(_ context.Context, gvr schema.GroupVersionResource, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+		calls = append(calls, fmt.Sprintf("%s limit=%d resourceVersion=%s match=%s continue=%s",
+			gvr.Resource, opts.Limit, opts.ResourceVersion, opts.ResourceVersionMatch, opts.Continue))
+		page := 0
+		if opts.Continue != "" {
+			_, err := fmt.Sscanf(opts.Continue, "page-%d", &page)
+			require.NoError(t, err)
+		}
+		result := &unstructured.UnstructuredList{}
+		result.SetResourceVersion("42")
+		for i := 0; i < pages[gvr][page]; i++ {
+			cr := unstructured.Unstructured{}
+			cr.SetName(fmt.Sprintf("%s-%d-%d", gvr.Resource, page, i))
+			result.Items = append(result.Items, cr)
+		}
+		if page+1 < len(pages[gvr]) {
+			result.SetContinue(fmt.Sprintf("page-%d", page+1))
+		}
+		return result, nil
+	}
+
+	lister := &snapshotLister{list: list, mapper: mapper, chunkSize: 2}
+	var names []string
+	err := lister.visit(context.TODO(), []string{"ConfigMap", "Deployment.v1.apps"}, func(cr *unstructured.Unstructured) {
+		names = append(names, cr.GetName())
+	}, failTest(t))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"configmaps-0-0", "configmaps-0-1", "configmaps-1-0", "deployments-0-0"}, names)
+	assert.Equal(t, []string{
+		"configmaps limit=2 resourceVersion= match= continue=",
+		"configmaps limit=2 resourceVersion= match= continue=page-1",
+		"deployments limit=2 resourceVersion=42 match=Exact continue=",
+	}, calls)
+
+	t.Run("Expired Snapshot", func(t *testing.T) {
+		lister := &snapshotLister{mapper: mapper, chunkSize: 2, list: func(_ context.Context, gvr schema.GroupVersionResource,
+			opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+			if opts.ResourceVersion != "" {
+				return nil, apierrors.NewResourceExpired("too old resource version")
+			}
+			return list(context.TODO(), gvr, opts)
+		}}
+		err := lister.visit(context.TODO(), []string{"ConfigMap", "Deployment.v1.apps"}, func(*unstructured.Unstructured) {},
+			failTest(t))
+		assert.ErrorContains(t, err, "the snapshot of the cluster at resourceVersion 42 expired while listing deployments")
+	})
+
+	t.Run("Unknown Type", func(t *testing.T) {
+		err := lister.visit(context.TODO(), []string{"Route.v1.route.openshift.io"}, func(*unstructured.Unstructured) {},
+			failTest(t))
+		assert.ErrorContains(t, err, "failed to find the resource of Route.v1.route.openshift.io")
+	})
+
+	t.Run("Failing Lists", func(t *testing.T) {
+		// The configmaps are throttled once then listed, the deployments always fail
+		throttled := false
+		lister := &snapshotLister{mapper: mapper, chunkSize: 2, retry: retryPolicy{retries: 2, backoff: time.Millisecond},
+			list: func(_ context.Context, gvr schema.GroupVersionResource, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+				if gvr == deployments {
+					return nil, apierrors.NewInternalError(errors.New("etcd is unavailable"))
+				}
+				if !throttled {
+					throttled = true
+					return nil, apierrors.NewTooManyRequests("throttled", 1)
+				}
+				return list(context.TODO(), gvr, opts)
+			}}
+		var names, failed []string
+		err := lister.visit(context.TODO(), []string{"ConfigMap", "Deployment.v1.apps"}, func(cr *unstructured.Unstructured) {
+			names = append(names, cr.GetName())
+		}, func(kind string, err error) {
+			failed = append(failed, kind)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"configmaps-0-0", "configmaps-0-1", "configmaps-1-0"}, names)
+		assert.Equal(t, []string{"Deployment.v1.apps"}, failed)
+	})
+}
+
+// failTest fails the test when a type can't be listed.
+func failTest(t *testing.T) func(string, error) {
+	return func(kind string, err error) {
+		t.Errorf("failed to list %s: %s", kind, err)
+	}
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+const (
+	// stdinFilename is the filename (-f) of the standard input, as in kubectl
+	stdinFilename = "-"
+	// stdinStreamName is the name of the standard input in the errors of the CRs it contains
+	stdinStreamName = "STDIN"
+
+	stdinPassedTwice  = "The standard input can only be passed once (-f -)"
+	stdinWithFlapping = "Flapping detection (--detect-flapping) reads the snapshots from files, they can't be passed by the standard input (-f -)"
+)
+
+// readStdinCRs reads the CRs of the standard input when it's one of the filenames (-f -), e.g. the manifests rendered
+// by helm template. The input is read once, so the CRs can be visited again.
+func (o *Options) readStdinCRs() error {
+	count := 0
+	for _, filename := range o.CRs.Filenames {
+		if filename == stdinFilename {
+			count++
+		}
+	}
+	switch {
+	case count == 0:
+		return nil
+	case count > 1:
+		return errors.New(stdinPassedTwice)
+	case o.detectFlapping:
+		return errors.New(stdinWithFlapping)
+	}
+	content, err := io.ReadAll(o.In)
+	if err != nil {
+		return fmt.Errorf("failed to read the CRs from the standard input: %w", err)
+	}
+	o.stdinCRs = content
+	return nil
+}
+
+// withoutStdin returns the filenames without the standard input.
+func withoutStdin(filenames []string) []string {
+	return slices.DeleteFunc(slices.Clone(filenames), func(filename string) bool {
+		return filename == stdinFilename
+	})
+}
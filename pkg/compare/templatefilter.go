@@ -0,0 +1,82 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"errors"
+	"path"
+	"slices"
+)
+
+const (
+	invalidTemplateFilter = "Invalid --%s pattern %q: %s"
+	noTemplatesSelected   = "none of the templates of the reference are selected by --include-templates, " +
+		"--exclude-templates, --part and --component"
+)
+
+// templateFilter selects the templates of the reference that are compared, to restrict a comparison to an area of a
+// large reference. The patterns are globs (path.Match), the templates are matched by their path in the reference and
+// by the names of their part and component. Empty lists of patterns don't filter anything.
+type templateFilter struct {
+	include    []string
+	exclude    []string
+	parts      []string
+	components []string
+}
+
+func (f templateFilter) isEmpty() bool {
+	return len(f.include) == 0 && len(f.exclude) == 0 && len(f.parts) == 0 && len(f.components) == 0
+}
+
+// validate checks the syntax of the patterns, the error names the flag of the invalid pattern.
+func (f templateFilter) validate() (string, string, error) {
+	for _, flag := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"include-templates", f.include},
+		{"exclude-templates", f.exclude},
+		{"part", f.parts},
+		{"component", f.components},
+	} {
+		for _, pattern := range flag.patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return flag.name, pattern, err // nolint:wrapcheck
+			}
+		}
+	}
+	return "", "", nil
+}
+
+// selects reports whether the template of the part and the component is compared.
+func (f templateFilter) selects(part, component, templatePath string) bool {
+	matchesAny := func(patterns []string, value string) bool {
+		return slices.ContainsFunc(patterns, func(pattern string) bool {
+			matched, _ := path.Match(pattern, value)
+			return matched
+		})
+	}
+	if len(f.parts) > 0 && !matchesAny(f.parts, part) {
+		return false
+	}
+	if len(f.components) > 0 && !matchesAny(f.components, component) {
+		return false
+	}
+	if len(f.include) > 0 && !matchesAny(f.include, templatePath) {
+		return false
+	}
+	return !matchesAny(f.exclude, templatePath)
+}
+
+// filterReference removes the templates that aren't selected by the filter from the reference, with the components
+// and the parts left without templates. The templates that were removed aren't compared nor reported missing.
+func filterReference(ref Reference, f templateFilter) error {
+	if f.isEmpty() {
+		return nil
+	}
+	ref.selectTemplates(f.selects)
+	if len(ref.GetTemplates()) == 0 {
+		return errors.New(noTemplatesSelected)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateFilterSelects(t *testing.T) {
+	f := templateFilter{include: []string{"networking/*"}, exclude: []string{"*/sriov*"}, parts: []string{"core"}}
+	assert.True(t, f.selects("core", "network", "networking/ptp.yaml"))
+	assert.False(t, f.selects("core", "network", "networking/sriovNetwork.yaml"))
+	assert.False(t, f.selects("core", "network", "storage/lvm.yaml"))
+	assert.False(t, f.selects("extra", "network", "networking/ptp.yaml"))
+	assert.True(t, templateFilter{}.selects("any", "any", "any.yaml"))
+}
+
+func TestFilterReferenceV1(t *testing.T) {
+	ref := &ReferenceV1{Parts: []PartV1{
+		{Name: "core", Components: []ComponentV1{
+			{Name: "network", RequiredTemplates: []*ReferenceTemplateV1{{Path: "ptp.yaml"}}},
+			{Name: "storage", OptionalTemplates: []*ReferenceTemplateV1{{Path: "lvm.yaml"}}},
+		}},
+		{Name: "extra", Components: []ComponentV1{
+			{Name: "monitoring", RequiredTemplates: []*ReferenceTemplateV1{{Path: "prometheus.yaml"}}},
+		}},
+	}}
+	require.NoError(t, filterReference(ref, templateFilter{components: []string{"network"}}))
+	require.Len(t, ref.Parts, 1)
+	require.Len(t, ref.Parts[0].Components, 1)
+	assert.Equal(t, "ptp.yaml", ref.Parts[0].Components[0].RequiredTemplates[0].Path)
+
+	assert.EqualError(t, filterReference(ref, templateFilter{parts: []string{"extra"}}), noTemplatesSelected)
+}
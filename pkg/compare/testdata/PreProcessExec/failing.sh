@@ -0,0 +1,3 @@
+#!/bin/sh
+echo "the CR can't be normalized" >&2
+exit 1
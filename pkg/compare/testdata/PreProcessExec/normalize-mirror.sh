@@ -0,0 +1,3 @@
+#!/bin/sh
+# Replaces the registry mirror of the site by the registry of the reference
+sed 's/mirror\.site-a\.example\.com/registry.example.com/g'
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/utils/exec"
+)
+
+const (
+	TimeoutGroup = "Comparison timed out"
+	TimeoutIssue = "Timeout"
+	TimeoutMsg   = "The comparison didn't complete within the --timeout of %s, only the cluster CRs compared before it " +
+		"expired are reported. The templates of the CRs that weren't compared are reported missing"
+)
+
+// startDeadline sets the context of a comparison, it expires after the --timeout. The returned function releases it
+// and must be called when the comparison is done.
+func (o *Options) startDeadline() context.CancelFunc {
+	o.timedOut = false
+	if o.timeout <= 0 {
+		o.ctx = context.Background()
+		return func() {}
+	}
+	var cancel context.CancelFunc
+	o.ctx, cancel = context.WithTimeout(context.Background(), o.timeout)
+	return cancel
+}
+
+// context returns the context of the comparison, it's done when the comparison times out.
+func (o *Options) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// untilDeadline runs f and waits for it to complete, or for the comparison to time out. f isn't interrupted when the
+// comparison times out: it keeps running in the background and what it does afterward is ignored, so a hung API server
+// or a template that doesn't terminate doesn't block the command. It returns false when the comparison timed out.
+func (o *Options) untilDeadline(f func()) bool {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+		return true
+	case <-o.context().Done():
+		select {
+		case <-done:
+			return true
+		default:
+		}
+		o.timedOut = true
+		return false
+	}
+}
+
+// addTimeoutIssue reports that the comparison timed out, so partial results aren't mistaken for complete ones.
+func (s *Summary) addTimeoutIssue(timeout time.Duration) {
+	if s.ValidationIssues == nil {
+		s.ValidationIssues = make(map[string]map[string]ValidationIssue)
+	}
+	s.ValidationIssues[TimeoutGroup] = map[string]ValidationIssue{
+		TimeoutIssue: {Msg: fmt.Sprintf(TimeoutMsg, timeout)},
+	}
+}
+
+// contextExec runs the commands with a context, the diff programs still running when the comparison times out are
+// killed.
+type contextExec struct {
+	exec.Interface
+	ctx context.Context
+}
+
+func (e contextExec) Command(cmd string, args ...string) exec.Cmd {
+	return e.Interface.CommandContext(e.ctx, cmd, args...)
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUntilDeadline(t *testing.T) {
+	t.Run("completes before the timeout", func(t *testing.T) {
+		o := &Options{timeout: time.Minute}
+		cancel := o.startDeadline()
+		defer cancel()
+		ran := false
+		assert.True(t, o.untilDeadline(func() { ran = true }))
+		assert.True(t, ran)
+		assert.False(t, o.timedOut)
+	})
+	t.Run("abandons the work outliving the timeout", func(t *testing.T) {
+		o := &Options{timeout: 10 * time.Millisecond}
+		cancel := o.startDeadline()
+		defer cancel()
+		hung := make(chan struct{})
+		defer close(hung)
+		assert.False(t, o.untilDeadline(func() { <-hung }))
+		assert.True(t, o.timedOut)
+	})
+	t.Run("no timeout", func(t *testing.T) {
+		o := &Options{}
+		cancel := o.startDeadline()
+		defer cancel()
+		require.NoError(t, o.context().Err())
+		_, hasDeadline := o.context().Deadline()
+		assert.False(t, hasDeadline)
+	})
+}
+
+func TestAddTimeoutIssue(t *testing.T) {
+	sum := &Summary{}
+	sum.addTimeoutIssue(time.Minute)
+	require.Contains(t, sum.ValidationIssues, TimeoutGroup)
+	assert.Contains(t, sum.ValidationIssues[TimeoutGroup][TimeoutIssue].Msg, "--timeout of 1m0s")
+}
@@ -1,6 +1,7 @@
 package compare
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -72,6 +73,70 @@ func SetNestedString(obj any, value string, fields ...string) error {
 	return fmt.Errorf("%v accessor error: %v is of type %T, expected map[string]string", jsonPath(fields), parent, parent)
 }
 
+// nestedInlineDiffValue returns the value of a field that uses an inline diff func. Fields that aren't strings are only
+// supported by the StructuredInlineDiff funcs, their values are encoded as JSON. Returns if the value was encoded.
+func nestedInlineDiffValue(obj any, diffFn InlineDiff, fields ...string) (string, bool, bool, error) {
+	if _, ok := diffFn.(StructuredInlineDiff); !ok {
+		value, found, err := NestedString(obj, fields...)
+		return value, false, found, err
+	}
+	val, found, err := NestedField(obj, fields...)
+	if !found || err != nil {
+		return "", false, found, err
+	}
+	if s, ok := val.(string); ok {
+		return s, false, found, nil
+	}
+	encoded, err := json.Marshal(val)
+	if err != nil {
+		return "", false, found, fmt.Errorf("%v accessor error: failed to encode value: %w", jsonPath(fields), err)
+	}
+	return string(encoded), true, found, nil
+}
+
+// SetNestedValue sets the value of a nested field, the parent of the field can be a map or a slice.
+func SetNestedValue(obj, value any, fields ...string) error {
+	parent, found, err := NestedField(obj, fields[:len(fields)-1]...)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%v accessor error: Not found", jsonPath(fields))
+	}
+	leaf := fields[len(fields)-1]
+	switch settable := parent.(type) {
+	case map[string]any:
+		settable[leaf] = value
+		return nil
+	case []any:
+		index, err := strconv.Atoi(leaf)
+		if err != nil || index >= len(settable) {
+			return fmt.Errorf("%v accessor error: index %s is out of the range of the slice", jsonPath(fields), leaf)
+		}
+		settable[index] = value
+		return nil
+	}
+	return fmt.Errorf("%v accessor error: %v is of type %T, expected map[string]any or []any", jsonPath(fields), parent, parent)
+}
+
+// removeMapField removes the nested field from the obj if its parent is a map, the items of slices aren't removed.
+// Returns if the field was found and removed.
+func removeMapField(obj any, fields ...string) bool {
+	parent, found, err := NestedField(obj, fields[:len(fields)-1]...)
+	if !found || err != nil {
+		return false
+	}
+	mapping, ok := parent.(map[string]any)
+	if !ok {
+		return false
+	}
+	if _, ok := mapping[fields[len(fields)-1]]; !ok {
+		return false
+	}
+	delete(mapping, fields[len(fields)-1])
+	return true
+}
+
 // RemoveNestedField removes the nested field from the obj.
 func RemoveNestedField(obj any, fields ...string) any {
 	res, _ := removeNestedFieldBacktrackEmpty(obj, fields...)
@@ -169,3 +169,64 @@ func TestSetNestedString(t *testing.T) {
 		})
 	}
 }
+
+func TestOmitFields(t *testing.T) {
+	cases := []struct {
+		name     string
+		paths    []*ManifestPathV1
+		expected map[string]any
+	}{
+		{
+			name:  "list wildcard",
+			paths: []*ManifestPathV1{{PathToKey: "spec.containers[*].image"}},
+			expected: map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"app": "a", "app.kubernetes.io/name": "b", "tier": "c"}},
+				"spec":     map[string]any{"containers": []any{map[string]any{"name": "a"}, map[string]any{}}},
+			},
+		},
+		{
+			name:  "list index",
+			paths: []*ManifestPathV1{{PathToKey: "spec.containers[1].image"}},
+			expected: map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"app": "a", "app.kubernetes.io/name": "b", "tier": "c"}},
+				"spec":     map[string]any{"containers": []any{map[string]any{"name": "a", "image": "a:1"}, map[string]any{}}},
+			},
+		},
+		{
+			name:  "key wildcard",
+			paths: []*ManifestPathV1{{PathToKey: "metadata.labels.app*"}},
+			expected: map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"tier": "c"}},
+				"spec":     map[string]any{"containers": []any{map[string]any{"name": "a", "image": "a:1"}, map[string]any{"image": "b:1"}}},
+			},
+		},
+		{
+			name:  "quoted key wildcard",
+			paths: []*ManifestPathV1{{PathToKey: `metadata.labels["app.*/name"]`}, {PathToKey: "metadata.labels.t", IsPrefix: true}},
+			expected: map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"app": "a"}},
+				"spec":     map[string]any{"containers": []any{map[string]any{"name": "a", "image": "a:1"}, map[string]any{"image": "b:1"}}},
+			},
+		},
+		{
+			name:  "empty maps are removed",
+			paths: []*ManifestPathV1{{PathToKey: "metadata.labels.*"}},
+			expected: map[string]any{
+				"spec": map[string]any{"containers": []any{map[string]any{"name": "a", "image": "a:1"}, map[string]any{"image": "b:1"}}},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			obj := map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"app": "a", "app.kubernetes.io/name": "b", "tier": "c"}},
+				"spec":     map[string]any{"containers": []any{map[string]any{"name": "a", "image": "a:1"}, map[string]any{"image": "b:1"}}},
+			}
+			for _, p := range c.paths {
+				require.NoError(t, p.Process())
+			}
+			omitFields(obj, c.paths)
+			assert.Equal(t, c.expected, obj)
+		})
+	}
+}
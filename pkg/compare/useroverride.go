@@ -6,10 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
 	"text/template"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
 )
 
@@ -19,6 +25,11 @@ const (
 	mergePatch = "mergepatch"
 	rfc6902    = "rfc6902"
 	gotemplate = "go-template"
+
+	userOverrideDateFormat = "2006-01-02"
+	userOverrideExpired    = "user override %d (%s) expired on %s"
+	userOverrideStale      = "User override %d (%s) was generated against another version of the reference " +
+		"(metadata hash %s instead of %s), check that it still applies"
 )
 
 type UserOverride struct {
@@ -31,6 +42,18 @@ type UserOverride struct {
 	Type         patchType `json:"type"`
 	Patch        string    `json:"patch"`
 	TemplatePath string    `json:"templatePath"`
+	// CapturedValues are the values of the capturegroups of the template when the override was generated, they are
+	// informational and not used to apply the override
+	CapturedValues map[string]string `json:"capturedValues,omitempty"`
+	// Owner and TicketURL record who is responsible for the override and where it's tracked
+	Owner     string `json:"owner,omitempty"`
+	TicketURL string `json:"ticketURL,omitempty"`
+	// CreatedAt and ExpiresAt are dates in the YYYY-MM-DD format, an override is valid until the end of the day it
+	// expires on. CreatedAt is set when the override is generated.
+	CreatedAt string `json:"createdAt,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+	// ReferenceHash is the metadata hash of the reference the override was generated against
+	ReferenceHash string `json:"referenceHash,omitempty"`
 }
 
 func (o UserOverride) GetIdentifier() string {
@@ -43,6 +66,18 @@ func (o UserOverride) GetName() string {
 	return o.Name
 }
 
+// describe returns the CR and the template the override is correlated to, to tell the overrides apart in the output.
+func (o UserOverride) describe() string {
+	cr := o.ExactMatch
+	if cr == "" {
+		cr = apiKindNamespaceName(o.GetMetadata())
+	}
+	if o.TemplatePath == "" {
+		return cr
+	}
+	return fmt.Sprintf("%s (%s)", cr, o.TemplatePath)
+}
+
 func (o UserOverride) GetMetadata() *unstructured.Unstructured {
 	metadata := unstructured.Unstructured{Object: map[string]any{
 		"apiVersion": o.ApiVersion,
@@ -112,65 +147,341 @@ func (o UserOverride) Apply(rendered, live *unstructured.Unstructured) (*unstruc
 	return &unstructured.Unstructured{Object: updatedObj}, nil
 }
 
-func CreateMergePatch(temp ReferenceTemplate, obj *InfoObject, reason string) (*UserOverride, error) {
+// overrideSelector selects the cluster CRs overrides are generated for: the CRs matched to the template, or only the
+// ones with the kind, namespace and name of the selector when they are set. They can be glob patterns.
+type overrideSelector struct {
+	templatePath string
+	kind         string
+	namespace    string
+	name         string
+}
+
+// parseOverrideSelector parses a template path, optionally followed by =kind/namespace/name, or =kind/name for cluster
+// scoped CRs.
+func parseOverrideSelector(value string) (overrideSelector, error) {
+	templatePath, cr, found := strings.Cut(value, "=")
+	res := overrideSelector{templatePath: templatePath}
+	if templatePath == "" {
+		return res, errors.New("the template path is empty")
+	}
+	if !found {
+		return res, nil
+	}
+	parts := strings.Split(cr, "/")
+	switch len(parts) {
+	case 2:
+		res.kind, res.name = parts[0], parts[1]
+	case 3:
+		res.kind, res.namespace, res.name = parts[0], parts[1], parts[2]
+	default:
+		return res, fmt.Errorf("the CR selector %q isn't in the kind/namespace/name or kind/name format", cr)
+	}
+	for _, pattern := range []string{res.kind, res.namespace, res.name} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return res, fmt.Errorf("the CR selector %q has an invalid pattern %q: %w", cr, pattern, err)
+		}
+	}
+	if res.kind == "" || res.name == "" {
+		return res, fmt.Errorf("the CR selector %q requires a kind and a name", cr)
+	}
+	return res, nil
+}
+
+func (s overrideSelector) matches(temp ReferenceTemplate, clusterCR *unstructured.Unstructured) bool {
+	if s.templatePath != temp.GetPath() {
+		return false
+	}
+	if s.kind == "" {
+		return true
+	}
+	for _, p := range [][2]string{{s.kind, clusterCR.GetKind()}, {s.namespace, clusterCR.GetNamespace()}, {s.name, clusterCR.GetName()}} {
+		if ok, _ := path.Match(p[0], p[1]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var PatchTypes = []string{mergePatch, rfc6902, gotemplate}
+
+// patchDocuments returns the JSON documents of the rendered template, after the overrides and the inline diff funcs
+// were applied, and of the cluster CR.
+func patchDocuments(obj *InfoObject) ([]byte, []byte, *unstructured.Unstructured, error) {
 	localRefRuntime, err := obj.Merged()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create patch: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create patch: %w", err)
 	}
 	localRef, ok := localRefRuntime.(*unstructured.Unstructured)
 	if !ok {
-		return nil, fmt.Errorf("failed to create patch: couldn't type cast type %T to *unstructured.Unstructured", localRef)
+		return nil, nil, nil, fmt.Errorf("failed to create patch: couldn't type cast type %T to *unstructured.Unstructured", localRef)
 	}
 	localRefData, err := json.Marshal(localRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal reference CR: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to marshal reference CR: %w", err)
 	}
 	clusterCR, ok := obj.Live().(*unstructured.Unstructured)
 	if !ok {
-		return nil, fmt.Errorf("failed to create patch: couldn't type cast type %T to *unstructured.Unstructured", obj.Live())
+		return nil, nil, nil, fmt.Errorf("failed to create patch: couldn't type cast type %T to *unstructured.Unstructured", obj.Live())
 	}
 	clusterCRData, err := json.Marshal(clusterCR)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal cluster CR: %w", err)
-	}
-
-	patch, err := jsonpatch.CreateMergePatch(localRefData, clusterCRData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create patch: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to marshal cluster CR: %w", err)
 	}
+	return localRefData, clusterCRData, clusterCR, nil
+}
 
-	override := UserOverride{
+func newUserOverride(temp ReferenceTemplate, clusterCR *unstructured.Unstructured, t patchType, patch, reason string) *UserOverride {
+	return &UserOverride{
 		Name:         clusterCR.GetName(),
 		ApiVersion:   clusterCR.GetAPIVersion(),
 		Kind:         clusterCR.GetKind(),
 		Namespace:    clusterCR.GetNamespace(),
-		Type:         mergePatch,
-		Patch:        string(patch),
+		Type:         t,
+		Patch:        patch,
 		Reason:       reason,
 		TemplatePath: temp.GetPath(),
 	}
+}
 
-	return &override, nil
+func CreateMergePatch(temp ReferenceTemplate, obj *InfoObject, reason string) (*UserOverride, error) {
+	localRefData, clusterCRData, clusterCR, err := patchDocuments(obj)
+	if err != nil {
+		return nil, err
+	}
+	return mergePatchOf(temp, localRefData, clusterCRData, clusterCR, reason)
 }
 
-func LoadUserOverrides(path string) ([]*UserOverride, error) {
-	result := make([]*UserOverride, 0)
+// mergePatchOf creates the user override that patches the document of the rendered template into the document of the
+// cluster CR with a merge patch.
+func mergePatchOf(temp ReferenceTemplate, localRefData, clusterCRData []byte, clusterCR *unstructured.Unstructured, reason string) (*UserOverride, error) {
+	patch, err := jsonpatch.CreateMergePatch(localRefData, clusterCRData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create patch: %w", err)
+	}
+
+	return newUserOverride(temp, clusterCR, mergePatch, string(patch), reason), nil
+}
 
+// CreatePatch creates a user override of the given type that patches the rendered template into the cluster CR.
+// The go-template overrides render the rfc6902 patch as is, they can then be edited to use the values of the cluster
+// CR.
+func CreatePatch(temp ReferenceTemplate, obj *InfoObject, reason string, t patchType) (*UserOverride, error) {
+	if t == mergePatch {
+		return CreateMergePatch(temp, obj, reason)
+	}
+	localRefData, clusterCRData, clusterCR, err := patchDocuments(obj)
+	if err != nil {
+		return nil, err
+	}
+	var localRef, live any
+	if err := json.Unmarshal(localRefData, &localRef); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal reference CR: %w", err)
+	}
+	if err := json.Unmarshal(clusterCRData, &live); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cluster CR: %w", err)
+	}
+	ops, err := createJSONPatch(localRef, live, "")
+	if err != nil {
+		return nil, err
+	}
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	switch t {
+	case rfc6902:
+		return newUserOverride(temp, clusterCR, rfc6902, string(patch), reason), nil
+	case gotemplate:
+		rendered, err := json.Marshal(map[string]string{"type": rfc6902, "patch": string(patch)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal patch: %w", err)
+		}
+		// The patch is a template, so the delimiters in the values of the cluster CR are rendered as is
+		escaped := strings.ReplaceAll(string(rendered), "{{", `{{"{{"}}`)
+		return newUserOverride(temp, clusterCR, gotemplate, escaped, reason), nil
+	}
+	return nil, fmt.Errorf("unknown patch type: %s", t)
+}
+
+// jsonPatchOperation is an operation of a rfc6902 patch, the value is kept encoded so null values are kept and
+// removals have none.
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func newJSONPatchOperation(op, path string, value any) (jsonPatchOperation, error) {
+	res := jsonPatchOperation{Op: op, Path: path}
+	if op == "remove" {
+		return res, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return res, fmt.Errorf("failed to marshal the value of %s: %w", path, err)
+	}
+	res.Value = data
+	return res, nil
+}
+
+// createJSONPatch returns the rfc6902 operations that patch from into to. The fields of maps are patched one by one,
+// as are the items of lists, items are added to or removed from the end of lists when their lengths differ.
+func createJSONPatch(from, to any, path string) ([]jsonPatchOperation, error) {
+	var ops []jsonPatchOperation
+	add := func(op, path string, value any) error {
+		o, err := newJSONPatchOperation(op, path, value)
+		ops = append(ops, o)
+		return err
+	}
+	switch f := from.(type) {
+	case map[string]any:
+		t, ok := to.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(f)+len(t))
+		for k := range f {
+			keys = append(keys, k)
+		}
+		for k := range t {
+			if _, ok := f[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			keyPath := path + "/" + jsonPointerEscaper.Replace(k)
+			fv, inFrom := f[k]
+			tv, inTo := t[k]
+			switch {
+			case !inTo:
+				if err := add("remove", keyPath, nil); err != nil {
+					return ops, err
+				}
+			case !inFrom:
+				if err := add("add", keyPath, tv); err != nil {
+					return ops, err
+				}
+			default:
+				nested, err := createJSONPatch(fv, tv, keyPath)
+				ops = append(ops, nested...)
+				if err != nil {
+					return ops, err
+				}
+			}
+		}
+		return ops, nil
+	case []any:
+		t, ok := to.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < min(len(f), len(t)); i++ {
+			nested, err := createJSONPatch(f[i], t[i], fmt.Sprintf("%s/%d", path, i))
+			ops = append(ops, nested...)
+			if err != nil {
+				return ops, err
+			}
+		}
+		for i := len(f); i < len(t); i++ {
+			if err := add("add", fmt.Sprintf("%s/%d", path, i), t[i]); err != nil {
+				return ops, err
+			}
+		}
+		// The items are removed from the last one so the indexes of the others don't change
+		for i := len(f) - 1; i >= len(t); i-- {
+			if err := add("remove", fmt.Sprintf("%s/%d", path, i), nil); err != nil {
+				return ops, err
+			}
+		}
+		return ops, nil
+	}
+	if reflect.DeepEqual(from, to) {
+		return ops, nil
+	}
+	return ops, add("replace", path, to)
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// LoadUserOverrides reads the user overrides file. Overrides that expired before now are dropped with a warning, or
+// fail the loading when failOnExpired is set.
+func LoadUserOverrides(path string, now time.Time, failOnExpired bool) ([]*UserOverride, error) {
 	contents, err := os.ReadFile(path)
 	if err != nil {
-		return result, fmt.Errorf("failed to load user overrides: %w", err)
+		return make([]*UserOverride, 0), fmt.Errorf("failed to load user overrides: %w", err)
 	}
+	return ParseUserOverrides(contents, now, failOnExpired)
+}
+
+// ParseUserOverrides parses the content of a user overrides file, see LoadUserOverrides.
+func ParseUserOverrides(contents []byte, now time.Time, failOnExpired bool) ([]*UserOverride, error) {
+	result := make([]*UserOverride, 0)
 
-	err = yaml.Unmarshal(contents, &result)
+	err := yaml.UnmarshalStrict(contents, &result)
 	if err != nil {
-		return result, fmt.Errorf("failed to load user overrides: %w", err)
+		return result, fmt.Errorf("failed to load user overrides: %w", suggestFieldName(err, reflect.TypeOf(result)))
 	}
 
-	for _, uo := range result {
+	active := make([]*UserOverride, 0, len(result))
+	for i, uo := range result {
 		if uo.Reason == "" {
 			return result, errors.New("failed to load user overrides: missing reason")
 		}
+		if uo.CreatedAt != "" {
+			if _, err := time.Parse(userOverrideDateFormat, uo.CreatedAt); err != nil {
+				return result, fmt.Errorf("failed to load user overrides: user override %d (%s) has an invalid createdAt, the format is YYYY-MM-DD: %w", i, uo.Name, err)
+			}
+		}
+		if uo.ExpiresAt != "" {
+			expires, err := time.Parse(userOverrideDateFormat, uo.ExpiresAt)
+			if err != nil {
+				return result, fmt.Errorf("failed to load user overrides: user override %d (%s) has an invalid expiresAt, the format is YYYY-MM-DD: %w", i, uo.Name, err)
+			}
+			// The override is valid until the end of the expiry day
+			if !now.Before(expires.AddDate(0, 0, 1)) {
+				if failOnExpired {
+					return result, fmt.Errorf("failed to load user overrides: "+userOverrideExpired, i, uo.Name, uo.ExpiresAt)
+				}
+				klog.Warningf("Ignoring "+userOverrideExpired, i, uo.Name, uo.ExpiresAt)
+				continue
+			}
+		}
+		active = append(active, uo)
+	}
+
+	return active, nil
+}
+
+// warnOnReferenceChange warns about the overrides that were generated against another version of the reference, as
+// the rendered templates they patch may have changed since.
+func warnOnReferenceChange(overrides []*UserOverride, referenceHash string) {
+	for i, uo := range overrides {
+		if uo.ReferenceHash != "" && uo.ReferenceHash != referenceHash {
+			klog.Warningf(userOverrideStale, i, uo.describe(), uo.ReferenceHash, referenceHash)
+		}
+	}
+}
+
+// WriteUserOverrides writes the overrides to a user overrides file.
+func WriteUserOverrides(path string, overrides []*UserOverride) error {
+	contents, err := yaml.Marshal(overrides)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user overrides: %w", err)
+	}
+	err = os.WriteFile(path, contents, 0o644) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to write user overrides: %w", err)
 	}
+	return nil
+}
 
-	return result, nil
+// age returns the number of days since the override was created, or -1 when its creation date isn't known.
+func (o UserOverride) age(now time.Time) int {
+	created, err := time.Parse(userOverrideDateFormat, o.CreatedAt)
+	if o.CreatedAt == "" || err != nil {
+		return -1
+	}
+	return int(now.Sub(created).Hours() / 24)
 }
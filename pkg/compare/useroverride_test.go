@@ -0,0 +1,104 @@
+package compare
+
+import (
+	"encoding/json"
+	"path"
+	"testing"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserOverridesRoundTrip(t *testing.T) {
+	overrides := []*UserOverride{
+		{
+			ApiVersion:   "v1",
+			Kind:         "Namespace",
+			Name:         "openshift-storage",
+			Reason:       "For the test",
+			Type:         mergePatch,
+			Patch:        `{"metadata":{"labels":{"a":"b"}}}`,
+			TemplatePath: "namespace.yaml",
+			Owner:        "storage-team",
+			CreatedAt:    "2024-05-02",
+			ExpiresAt:    "2024-12-31",
+		},
+		{
+			ExactMatch: "v1_Namespace_openshift-other",
+			Reason:     "For the test",
+			Type:       rfc6902,
+			Patch:      `[{"op":"remove","path":"/metadata/labels"}]`,
+		},
+	}
+	file := path.Join(t.TempDir(), "overrides.yaml")
+	require.NoError(t, WriteUserOverrides(file, overrides))
+
+	loaded, err := LoadUserOverrides(file, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), true)
+	require.NoError(t, err)
+	assert.Equal(t, overrides, loaded)
+	assert.Equal(t, 30, loaded[0].age(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, -1, loaded[1].age(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestCreateJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "equal",
+			from:     `{"a":{"b":[1,2]}}`,
+			to:       `{"a":{"b":[1,2]}}`,
+			expected: `null`,
+		},
+		{
+			name:     "fields",
+			from:     `{"a":"x","b":{"c/d":1,"e~f":2}}`,
+			to:       `{"b":{"c/d":3,"e~f":2},"g":null}`,
+			expected: `[{"op":"remove","path":"/a"},{"op":"replace","path":"/b/c~1d","value":3},{"op":"add","path":"/g","value":null}]`,
+		},
+		{
+			name:     "longer list",
+			from:     `{"a":[{"b":1}]}`,
+			to:       `{"a":[{"b":2},{"b":3}]}`,
+			expected: `[{"op":"replace","path":"/a/0/b","value":2},{"op":"add","path":"/a/1","value":{"b":3}}]`,
+		},
+		{
+			name:     "shorter list",
+			from:     `{"a":[1,2,3]}`,
+			to:       `{"a":[1]}`,
+			expected: `[{"op":"remove","path":"/a/2"},{"op":"remove","path":"/a/1"}]`,
+		},
+		{
+			name:     "different types",
+			from:     `{"a":{"b":1}}`,
+			to:       `{"a":[1]}`,
+			expected: `[{"op":"replace","path":"/a","value":[1]}]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var from, to any
+			require.NoError(t, json.Unmarshal([]byte(test.from), &from))
+			require.NoError(t, json.Unmarshal([]byte(test.to), &to))
+			ops, err := createJSONPatch(from, to, "")
+			require.NoError(t, err)
+			patch, err := json.Marshal(ops)
+			require.NoError(t, err)
+			assert.JSONEq(t, test.expected, string(patch))
+
+			if ops == nil {
+				return
+			}
+			decoded, err := jsonpatch.DecodePatch(patch)
+			require.NoError(t, err)
+			patched, err := decoded.Apply([]byte(test.from))
+			require.NoError(t, err)
+			assert.JSONEq(t, test.to, string(patched))
+		})
+	}
+}
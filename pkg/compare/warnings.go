@@ -0,0 +1,58 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package compare
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// warningLog logs the warnings about the reference and the cluster CRs (duplicate correlation keys, kinds not
+// supported by the cluster, skipped invalid files) and keeps them for the summary (Summary.Warnings), so the consumers
+// of the json, yaml and junit outputs see them without reading stderr.
+type warningLog struct {
+	lock     sync.Mutex
+	warnings []string
+}
+
+// warnf logs the warning and keeps it, the warnings that were already kept aren't kept twice.
+func (w *warningLog) warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	klog.Warning(msg)
+	if w == nil {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if !slices.Contains(w.warnings, msg) {
+		w.warnings = append(w.warnings, msg)
+	}
+}
+
+// list returns the sorted warnings, they are found concurrently.
+func (w *warningLog) list() []string {
+	if w == nil {
+		return nil
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	res := slices.Clone(w.warnings)
+	slices.Sort(res)
+	return res
+}
+
+// clone returns a log with the warnings that were kept, the warnings added to either log aren't added to the other.
+func (w *warningLog) clone() *warningLog {
+	return &warningLog{warnings: w.list()}
+}
+
+// warnDuplicateKeys reports the templates of a correlator that have the same correlation keys, the CRs matching them
+// are compared to the template with the fewest diffs.
+func (o *Options) warnDuplicateKeys(warnings []string) {
+	for _, w := range warnings {
+		o.warnings.warnf("%s", w)
+	}
+}
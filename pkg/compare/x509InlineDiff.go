@@ -0,0 +1,144 @@
+package compare
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	x509Cert inlineDiffType = "x509"
+)
+
+// X509InlineDiff matches the cluster value if it's a PEM certificate, or a base64 encoded one as in the data of Secrets,
+// that satisfies the policy of the template instead of comparing their bytes. The policy is written in YAML:
+//
+//	subject: CN=*.apps.example.com
+//	issuer: CN=ingress-operator@1700000000
+//	dnsNames:
+//	- "*.apps.example.com"
+//	minValidity: 720h
+//
+// The subject and the issuer are compared in their RFC 2253 form, the dnsNames have to be SANs of the certificate, and
+// the certificate has to be valid for at least minValidity. The template can also be a PEM certificate, the cluster
+// certificate then has to have its subject, issuer and SANs. Expired certificates never match. Only the first
+// certificate of a bundle is checked. When the certificate doesn't match the diff shows the template followed by the
+// reasons, as comments.
+type X509InlineDiff struct{}
+
+type x509Policy struct {
+	Subject     string   `json:"subject,omitempty"`
+	Issuer      string   `json:"issuer,omitempty"`
+	DNSNames    []string `json:"dnsNames,omitempty"`
+	MinValidity string   `json:"minValidity,omitempty"`
+}
+
+func parseX509Policy(templateValue string) (x509Policy, time.Duration, error) {
+	policy := x509Policy{}
+	if strings.Contains(templateValue, "-----BEGIN") {
+		cert, err := parseCertificate(templateValue)
+		if err != nil {
+			return policy, 0, err
+		}
+		policy.Subject, policy.Issuer, policy.DNSNames = cert.Subject.String(), cert.Issuer.String(), cert.DNSNames
+		return policy, 0, nil
+	}
+	if err := yaml.UnmarshalStrict([]byte(templateValue), &policy); err != nil {
+		return policy, 0, fmt.Errorf("invalid policy: %w", err)
+	}
+	var minValidity time.Duration
+	if policy.MinValidity != "" {
+		var err error
+		if minValidity, err = time.ParseDuration(policy.MinValidity); err != nil {
+			return policy, 0, fmt.Errorf("invalid minValidity: %w", err)
+		}
+	}
+	return policy, minValidity, nil
+}
+
+// parseCertificate parses the first certificate of the PEM value, which can be base64 encoded.
+func parseCertificate(value string) (*x509.Certificate, error) {
+	content := []byte(value)
+	if !strings.Contains(value, "-----BEGIN") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, errors.New("the value isn't a PEM certificate")
+		}
+		content = decoded
+	}
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("the value isn't a PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("the certificate can't be parsed: %w", err)
+	}
+	return cert, nil
+}
+
+// x509Issues returns how the certificate doesn't satisfy the policy.
+func x509Issues(policy x509Policy, minValidity time.Duration, cert *x509.Certificate) []string {
+	var issues []string
+	if policy.Subject != "" && cert.Subject.String() != policy.Subject {
+		issues = append(issues, fmt.Sprintf("the subject is %s", cert.Subject))
+	}
+	if policy.Issuer != "" && cert.Issuer.String() != policy.Issuer {
+		issues = append(issues, fmt.Sprintf("the issuer is %s", cert.Issuer))
+	}
+	for _, name := range policy.DNSNames {
+		if !slices.Contains(cert.DNSNames, name) {
+			issues = append(issues, fmt.Sprintf("%s isn't a SAN of the certificate", name))
+		}
+	}
+	current := now()
+	switch {
+	case current.Before(cert.NotBefore):
+		issues = append(issues, fmt.Sprintf("the certificate isn't valid before %s", cert.NotBefore.UTC().Format(time.RFC3339)))
+	case !current.Before(cert.NotAfter):
+		issues = append(issues, fmt.Sprintf("the certificate expired on %s", cert.NotAfter.UTC().Format(time.RFC3339)))
+	case current.Add(minValidity).After(cert.NotAfter):
+		issues = append(issues, fmt.Sprintf("the certificate expires on %s, before the minimum validity of %s",
+			cert.NotAfter.UTC().Format(time.RFC3339), policy.MinValidity))
+	}
+	return issues
+}
+
+func (id X509InlineDiff) Diff(templateValue, crValue string, sharedCapturedValues CapturedValues) (string, CapturedValues) {
+	policy, minValidity, err := parseX509Policy(templateValue)
+	if err != nil {
+		return templateValue, sharedCapturedValues
+	}
+	var issues []string
+	cert, err := parseCertificate(crValue)
+	if err != nil {
+		issues = []string{err.Error()}
+	} else {
+		issues = x509Issues(policy, minValidity, cert)
+	}
+	if len(issues) == 0 {
+		return crValue, sharedCapturedValues
+	}
+	var sb strings.Builder
+	if policy := strings.TrimRight(templateValue, "\n"); policy != "" {
+		sb.WriteString(policy + "\n")
+	}
+	for _, issue := range issues {
+		sb.WriteString("# " + issue + "\n")
+	}
+	return sb.String(), sharedCapturedValues
+}
+
+func (id X509InlineDiff) Validate(templateValue string) error {
+	if _, _, err := parseX509Policy(templateValue); err != nil {
+		return fmt.Errorf("invalid policy passed to inline x509 diff function: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package compare
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCertificate(t *testing.T, subject string, notBefore, notAfter time.Time, dnsNames ...string) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: subject},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		DNSNames:     dnsNames,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestInlineX509Diff(t *testing.T) {
+	defer func(n func() time.Time) { now = n }(now)
+	now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	valid := newTestCertificate(t, "example.com", date(2024, 1, 1), date(2025, 1, 1), "example.com", "www.example.com")
+	policy := "subject: CN=example.com\nissuer: CN=example.com\ndnsNames:\n- www.example.com\nminValidity: 720h\n"
+	tests := []struct {
+		name     string
+		template string
+		input    string
+		issues   []string
+	}{
+		{name: "Valid", template: policy, input: valid},
+		{name: "Base64 Encoded", template: policy, input: base64.StdEncoding.EncodeToString([]byte(valid))},
+		{name: "Empty Policy", template: "", input: valid},
+		{name: "Certificate Template", template: valid,
+			input: newTestCertificate(t, "example.com", date(2024, 2, 1), date(2026, 1, 1), "example.com", "www.example.com")},
+		{name: "Certificate Template Other SANs", template: valid,
+			input:  newTestCertificate(t, "example.com", date(2024, 2, 1), date(2026, 1, 1), "example.com"),
+			issues: []string{"www.example.com isn't a SAN of the certificate"}},
+		{name: "Other Subject", template: policy,
+			input: newTestCertificate(t, "example.org", date(2024, 1, 1), date(2025, 1, 1), "example.org"),
+			issues: []string{"the subject is CN=example.org", "the issuer is CN=example.org",
+				"www.example.com isn't a SAN of the certificate"}},
+		{name: "Expired", template: policy,
+			input:  newTestCertificate(t, "example.com", date(2023, 1, 1), date(2024, 1, 1), "www.example.com"),
+			issues: []string{"the certificate expired on 2024-01-01T00:00:00Z"}},
+		{name: "Not Yet Valid", template: policy,
+			input:  newTestCertificate(t, "example.com", date(2024, 7, 1), date(2025, 1, 1), "www.example.com"),
+			issues: []string{"the certificate isn't valid before 2024-07-01T00:00:00Z"}},
+		{name: "Below Minimum Validity", template: policy,
+			input:  newTestCertificate(t, "example.com", date(2024, 1, 1), date(2024, 6, 15), "www.example.com"),
+			issues: []string{"the certificate expires on 2024-06-15T00:00:00Z, before the minimum validity of 720h"}},
+		{name: "Not A Certificate", template: policy, input: "example",
+			issues: []string{"the value isn't a PEM certificate"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := X509InlineDiff{}
+			require.NoError(t, diff.Validate(test.template))
+			result, _ := diff.Diff(test.template, test.input, CapturedValues{})
+			if len(test.issues) == 0 {
+				assert.Equal(t, test.input, result)
+				return
+			}
+			expected := test.template
+			for _, issue := range test.issues {
+				expected += "# " + issue + "\n"
+			}
+			assert.Equal(t, expected, result)
+		})
+	}
+}
+
+func TestInlineX509Validate(t *testing.T) {
+	assert.ErrorContains(t, X509InlineDiff{}.Validate("subjects: CN=example.com"), `unknown field "subjects"`)
+	assert.ErrorContains(t, X509InlineDiff{}.Validate("minValidity: 30d"), "invalid minValidity")
+	assert.ErrorContains(t, X509InlineDiff{}.Validate("-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n"),
+		"the certificate can't be parsed")
+}
@@ -0,0 +1,107 @@
+// Package junit is the model of the JUnit reports of the compare command (-o junit) and of the report-creator add-on.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// TestSuites is a collection of JUnit test suites.
+type TestSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr,omitempty"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []*TestSuite `xml:"testsuite"`
+}
+
+// TestSuite is a single JUnit test suite which may contain many
+// testcases.
+type TestSuite struct {
+	Name     string `xml:"name,attr"`
+	Tests    int    `xml:"tests,attr"`
+	Failures int    `xml:"failures,attr"`
+	// Time is the duration of the test cases of the suite, see Time
+	Time      string `xml:"time,attr,omitempty"`
+	Timestamp string `xml:"timestamp,attr,omitempty"`
+	// Properties identify the cluster that was compared
+	Properties *Properties `xml:"properties,omitempty"`
+	TestCases  []TestCase  `xml:"testcase"`
+	// SystemErr has the warnings of the comparison
+	SystemErr *Output `xml:"system-err,omitempty"`
+}
+
+// Properties are the properties of a test suite.
+type Properties struct {
+	Properties []Property `xml:"property"`
+}
+
+// Property represents a key/value pair used to define properties.
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// TestCase is a single test case with its result.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	Classname string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr,omitempty"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	// SystemOut reports what doesn't fail the test case, e.g. the diffs that don't have the error severity
+	SystemOut *Output `xml:"system-out,omitempty"`
+}
+
+// Output is the standard or error output of a test case or of a test suite.
+type Output struct {
+	Contents string `xml:",cdata"`
+}
+
+// Failure contains data related to a failed test.
+type Failure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",cdata"`
+}
+
+// AddTestCase appends the test case to the suite and counts it.
+func (s *TestSuite) AddTestCase(tc TestCase) {
+	s.Tests++
+	if tc.Failure != nil {
+		s.Failures++
+	}
+	s.TestCases = append(s.TestCases, tc)
+}
+
+// Marshal renders the test suites as a JUnit report, the tests and failures of the suites are added up.
+func Marshal(suites TestSuites) ([]byte, error) {
+	suites.Tests, suites.Failures = 0, 0
+	for _, suite := range suites.Suites {
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+	}
+	doc, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal junit xml: %w", err)
+	}
+	return append([]byte(xml.Header), append(doc, '\n')...), nil
+}
+
+// Write writes the test suites as a JUnit report, see Marshal.
+func Write(out io.Writer, suites TestSuites) error {
+	doc, err := Marshal(suites)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(doc); err != nil {
+		return fmt.Errorf("failed to write junit report: %w", err)
+	}
+	return nil
+}
+
+// Time formats a duration in seconds, as expected by the JUnit reports.
+func Time(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}